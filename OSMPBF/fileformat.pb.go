@@ -1,111 +1,251 @@
-// Code generated by protoc-gen-go.
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.12
+// 	protoc        (unknown)
 // source: fileformat.proto
-// DO NOT EDIT!
 
-/*
-Package OSMPBF is a generated protocol buffer package.
-
-It is generated from these files:
-	fileformat.proto
-	osmformat.proto
-
-It has these top-level messages:
-	Blob
-	BlobHeader
-*/
 package OSMPBF
 
-import proto "github.com/golang/protobuf/proto"
-import json "encoding/json"
-import math "math"
-
-// Reference proto, json, and math imports to suppress error if they are not otherwise used.
-var _ = proto.Marshal
-var _ = &json.SyntaxError{}
-var _ = math.Inf
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
 
 type Blob struct {
-	Raw     []byte `protobuf:"bytes,1,opt,name=raw" json:"raw,omitempty"`
-	RawSize *int32 `protobuf:"varint,2,opt,name=raw_size" json:"raw_size,omitempty"`
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Raw     []byte                 `protobuf:"bytes,1,opt,name=raw" json:"raw,omitempty"`                         // No compression
+	RawSize *int32                 `protobuf:"varint,2,opt,name=raw_size,json=rawSize" json:"raw_size,omitempty"` // When compressed, the uncompressed size
 	// Possible compressed versions of the data.
-	ZlibData []byte `protobuf:"bytes,3,opt,name=zlib_data" json:"zlib_data,omitempty"`
+	ZlibData []byte `protobuf:"bytes,3,opt,name=zlib_data,json=zlibData" json:"zlib_data,omitempty"`
 	// PROPOSED feature for LZMA compressed data. SUPPORT IS NOT REQUIRED.
-	LzmaData []byte `protobuf:"bytes,4,opt,name=lzma_data" json:"lzma_data,omitempty"`
+	LzmaData []byte `protobuf:"bytes,4,opt,name=lzma_data,json=lzmaData" json:"lzma_data,omitempty"`
 	// Formerly used for bzip2 compressed data. Depreciated in 2010.
-	OBSOLETEBzip2Data []byte `protobuf:"bytes,5,opt,name=OBSOLETE_bzip2_data" json:"OBSOLETE_bzip2_data,omitempty"`
-	XXX_unrecognized  []byte `json:"-"`
+	//
+	// Deprecated: Marked as deprecated in fileformat.proto.
+	OBSOLETEBzip2Data []byte `protobuf:"bytes,5,opt,name=OBSOLETE_bzip2_data,json=OBSOLETEBzip2Data" json:"OBSOLETE_bzip2_data,omitempty"` // Don't reuse this tag number.
+	// LZ4 compressed data (block format).
+	Lz4Data []byte `protobuf:"bytes,6,opt,name=lz4_data,json=lz4Data" json:"lz4_data,omitempty"`
+	// Zstandard compressed data.
+	ZstdData      []byte `protobuf:"bytes,7,opt,name=zstd_data,json=zstdData" json:"zstd_data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Blob) Reset() {
+	*x = Blob{}
+	mi := &file_fileformat_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Blob) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Blob) ProtoMessage() {}
+
+func (x *Blob) ProtoReflect() protoreflect.Message {
+	mi := &file_fileformat_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
 }
 
-func (m *Blob) Reset()         { *m = Blob{} }
-func (m *Blob) String() string { return proto.CompactTextString(m) }
-func (*Blob) ProtoMessage()    {}
+// Deprecated: Use Blob.ProtoReflect.Descriptor instead.
+func (*Blob) Descriptor() ([]byte, []int) {
+	return file_fileformat_proto_rawDescGZIP(), []int{0}
+}
 
-func (m *Blob) GetRaw() []byte {
-	if m != nil {
-		return m.Raw
+func (x *Blob) GetRaw() []byte {
+	if x != nil {
+		return x.Raw
 	}
 	return nil
 }
 
-func (m *Blob) GetRawSize() int32 {
-	if m != nil && m.RawSize != nil {
-		return *m.RawSize
+func (x *Blob) GetRawSize() int32 {
+	if x != nil && x.RawSize != nil {
+		return *x.RawSize
 	}
 	return 0
 }
 
-func (m *Blob) GetZlibData() []byte {
-	if m != nil {
-		return m.ZlibData
+func (x *Blob) GetZlibData() []byte {
+	if x != nil {
+		return x.ZlibData
 	}
 	return nil
 }
 
-func (m *Blob) GetLzmaData() []byte {
-	if m != nil {
-		return m.LzmaData
+func (x *Blob) GetLzmaData() []byte {
+	if x != nil {
+		return x.LzmaData
 	}
 	return nil
 }
 
-func (m *Blob) GetOBSOLETEBzip2Data() []byte {
-	if m != nil {
-		return m.OBSOLETEBzip2Data
+// Deprecated: Marked as deprecated in fileformat.proto.
+func (x *Blob) GetOBSOLETEBzip2Data() []byte {
+	if x != nil {
+		return x.OBSOLETEBzip2Data
+	}
+	return nil
+}
+
+func (x *Blob) GetLz4Data() []byte {
+	if x != nil {
+		return x.Lz4Data
+	}
+	return nil
+}
+
+func (x *Blob) GetZstdData() []byte {
+	if x != nil {
+		return x.ZstdData
 	}
 	return nil
 }
 
 type BlobHeader struct {
-	Type             *string `protobuf:"bytes,1,req,name=type" json:"type,omitempty"`
-	Indexdata        []byte  `protobuf:"bytes,2,opt,name=indexdata" json:"indexdata,omitempty"`
-	Datasize         *int32  `protobuf:"varint,3,req,name=datasize" json:"datasize,omitempty"`
-	XXX_unrecognized []byte  `json:"-"`
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          *string                `protobuf:"bytes,1,req,name=type" json:"type,omitempty"`
+	Indexdata     []byte                 `protobuf:"bytes,2,opt,name=indexdata" json:"indexdata,omitempty"`
+	Datasize      *int32                 `protobuf:"varint,3,req,name=datasize" json:"datasize,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (m *BlobHeader) Reset()         { *m = BlobHeader{} }
-func (m *BlobHeader) String() string { return proto.CompactTextString(m) }
-func (*BlobHeader) ProtoMessage()    {}
+func (x *BlobHeader) Reset() {
+	*x = BlobHeader{}
+	mi := &file_fileformat_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BlobHeader) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
 
-func (m *BlobHeader) GetType() string {
-	if m != nil && m.Type != nil {
-		return *m.Type
+func (*BlobHeader) ProtoMessage() {}
+
+func (x *BlobHeader) ProtoReflect() protoreflect.Message {
+	mi := &file_fileformat_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BlobHeader.ProtoReflect.Descriptor instead.
+func (*BlobHeader) Descriptor() ([]byte, []int) {
+	return file_fileformat_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *BlobHeader) GetType() string {
+	if x != nil && x.Type != nil {
+		return *x.Type
 	}
 	return ""
 }
 
-func (m *BlobHeader) GetIndexdata() []byte {
-	if m != nil {
-		return m.Indexdata
+func (x *BlobHeader) GetIndexdata() []byte {
+	if x != nil {
+		return x.Indexdata
 	}
 	return nil
 }
 
-func (m *BlobHeader) GetDatasize() int32 {
-	if m != nil && m.Datasize != nil {
-		return *m.Datasize
+func (x *BlobHeader) GetDatasize() int32 {
+	if x != nil && x.Datasize != nil {
+		return *x.Datasize
 	}
 	return 0
 }
 
-func init() {
+var File_fileformat_proto protoreflect.FileDescriptor
+
+const file_fileformat_proto_rawDesc = "" +
+	"\n" +
+	"\x10fileformat.proto\x12\x06OSMPBF\"\xd9\x01\n" +
+	"\x04Blob\x12\x10\n" +
+	"\x03raw\x18\x01 \x01(\fR\x03raw\x12\x19\n" +
+	"\braw_size\x18\x02 \x01(\x05R\arawSize\x12\x1b\n" +
+	"\tzlib_data\x18\x03 \x01(\fR\bzlibData\x12\x1b\n" +
+	"\tlzma_data\x18\x04 \x01(\fR\blzmaData\x122\n" +
+	"\x13OBSOLETE_bzip2_data\x18\x05 \x01(\fB\x02\x18\x01R\x11OBSOLETEBzip2Data\x12\x19\n" +
+	"\blz4_data\x18\x06 \x01(\fR\alz4Data\x12\x1b\n" +
+	"\tzstd_data\x18\a \x01(\fR\bzstdData\"Z\n" +
+	"\n" +
+	"BlobHeader\x12\x12\n" +
+	"\x04type\x18\x01 \x02(\tR\x04type\x12\x1c\n" +
+	"\tindexdata\x18\x02 \x01(\fR\tindexdata\x12\x1a\n" +
+	"\bdatasize\x18\x03 \x02(\x05R\bdatasizeB4\n" +
+	"\rcrosby.binaryH\x03Z!github.com/brechtbm/osmpbf/OSMPBF"
+
+var (
+	file_fileformat_proto_rawDescOnce sync.Once
+	file_fileformat_proto_rawDescData []byte
+)
+
+func file_fileformat_proto_rawDescGZIP() []byte {
+	file_fileformat_proto_rawDescOnce.Do(func() {
+		file_fileformat_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_fileformat_proto_rawDesc), len(file_fileformat_proto_rawDesc)))
+	})
+	return file_fileformat_proto_rawDescData
+}
+
+var file_fileformat_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_fileformat_proto_goTypes = []any{
+	(*Blob)(nil),       // 0: OSMPBF.Blob
+	(*BlobHeader)(nil), // 1: OSMPBF.BlobHeader
+}
+var file_fileformat_proto_depIdxs = []int32{
+	0, // [0:0] is the sub-list for method output_type
+	0, // [0:0] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_fileformat_proto_init() }
+func file_fileformat_proto_init() {
+	if File_fileformat_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_fileformat_proto_rawDesc), len(file_fileformat_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_fileformat_proto_goTypes,
+		DependencyIndexes: file_fileformat_proto_depIdxs,
+		MessageInfos:      file_fileformat_proto_msgTypes,
+	}.Build()
+	File_fileformat_proto = out.File
+	file_fileformat_proto_goTypes = nil
+	file_fileformat_proto_depIdxs = nil
 }
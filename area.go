@@ -0,0 +1,345 @@
+package osmpbf
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+)
+
+// Area is a (multi)polygon assembled from multipolygon or boundary relation.
+type Area struct {
+	Relation *Relation
+
+	// Polygons are outer rings, each followed by rings of its holes. Rings are closed: the first
+	// and the last locations are the same. Outer rings are counterclockwise and inner rings are
+	// clockwise, as required by GeoJSON.
+	Polygons [][][]LatLon
+}
+
+// Polygon returns polygon of area, for example for spatial filtering.
+func (a *Area) Polygon() *Polygon {
+	var rings [][]LatLon
+	for _, polygon := range a.Polygons {
+		rings = append(rings, polygon...)
+	}
+	return NewPolygon(rings...)
+}
+
+// AreaError describes relation which is not assembled into area because of invalid geometry.
+type AreaError struct {
+	RelationID int64
+	Err        error
+}
+
+func (e *AreaError) Error() string {
+	return fmt.Sprintf("relation %d: %v", e.RelationID, e.Err)
+}
+
+func (e *AreaError) Unwrap() error {
+	return e.Err
+}
+
+// IsAreaRelation returns true for multipolygon and boundary relations.
+func IsAreaRelation(r *Relation) bool {
+	typ, ok := r.Tags["type"]
+	if !ok {
+		typ = r.TagList.Value("type")
+	}
+	return typ == "multipolygon" || typ == "boundary"
+}
+
+// An AreaAssembler assembles areas from multipolygon and boundary relations, like osmium area
+// assembler. Member ways are joined into rings, and rings are assigned outer and inner roles by
+// their nesting, roles of members are not used. Relations are collected in the first pass over
+// input stream, and their member ways with node locations in the second one.
+type AreaAssembler struct {
+	locations NodeLocationStore
+	relations []*Relation
+	ways      map[int64]*areaWay // member ways by ID, nil until way is added
+	invalid   []*AreaError
+}
+
+// Geometry of member way.
+type areaWay struct {
+	nodeIDs   []int64
+	locations []LatLon // nil if some locations are unknown
+}
+
+// NewAreaAssembler returns a new assembler taking locations of nodes of member ways from s,
+// which may be nil if ways have NodeLocations.
+func NewAreaAssembler(s NodeLocationStore) *AreaAssembler {
+	return &AreaAssembler{locations: s, ways: make(map[int64]*areaWay)}
+}
+
+// AddRelation keeps relation, if it is multipolygon or boundary relation, and returns true then.
+// Kept relations must not be released. Relations must be added before their member ways.
+func (a *AreaAssembler) AddRelation(r *Relation) bool {
+	if !IsAreaRelation(r) {
+		return false
+	}
+	a.relations = append(a.relations, r)
+	for _, m := range r.Members {
+		if m.Type == WayType {
+			a.ways[m.ID] = nil
+		}
+	}
+	return true
+}
+
+// AddWay keeps geometry of way, if it is a member of kept relation. Way.NodeLocations are used if
+// present, otherwise locations of its nodes are taken from the store, so they must be stored
+// before. Way is not referenced after AddWay returns.
+func (a *AreaAssembler) AddWay(w *Way) {
+	if _, ok := a.ways[w.ID]; !ok {
+		return
+	}
+	aw := &areaWay{nodeIDs: append([]int64(nil), w.NodeIDs...)}
+	if len(w.NodeLocations) == len(w.NodeIDs) {
+		aw.locations = append([]LatLon(nil), w.NodeLocations...)
+	} else if a.locations != nil {
+		aw.locations = make([]LatLon, len(w.NodeIDs))
+		for i, id := range w.NodeIDs {
+			l, ok := a.locations.Location(id)
+			if !ok {
+				aw.locations = nil
+				break
+			}
+			aw.locations[i] = l
+		}
+	}
+	a.ways[w.ID] = aw
+}
+
+// ReadRelations reads objects from started decoder until the end of input stream and adds
+// relations by AddRelation. Other objects are released, so decoder should skip to relations,
+// see Decoder.SkipToRelations.
+func (a *AreaAssembler) ReadRelations(dec *Decoder) error {
+	return readAll(dec, func(o Object) {
+		if r, ok := o.(*Relation); !ok || !a.AddRelation(r) {
+			o.Release()
+		}
+	})
+}
+
+// ReadWays reads objects from started decoder until the end of input stream: locations of nodes
+// are put into the store, if it is set, and ways are added by AddWay. All objects are released.
+func (a *AreaAssembler) ReadWays(dec *Decoder) error {
+	var err error
+	readErr := readAll(dec, func(o Object) {
+		switch o := o.(type) {
+		case *Node:
+			if a.locations != nil && err == nil {
+				err = a.locations.Set(o.ID, LatLon{o.Lat, o.Lon})
+			}
+		case *Way:
+			a.AddWay(o)
+		}
+		o.Release()
+	})
+	if readErr != nil {
+		return readErr
+	}
+	return err
+}
+
+func readAll(dec *Decoder, fn func(o Object)) error {
+	for {
+		v, err := dec.Decode()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		fn(v.(Object))
+	}
+}
+
+// Assemble calls fn with area of each kept relation, in the order they were added. Relations
+// which cannot be assembled are skipped, see Invalid.
+func (a *AreaAssembler) Assemble(fn func(area *Area) error) error {
+	for _, r := range a.relations {
+		polygons, err := a.assemble(r)
+		if err != nil {
+			a.invalid = append(a.invalid, &AreaError{r.ID, err})
+			continue
+		}
+		if err := fn(&Area{r, polygons}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Invalid returns errors of relations skipped by Assemble: relations with missing member ways or
+// node locations, like relations crossing extract borders, rings which are not closed or have less
+// than four nodes, and no member ways. Self-intersections are not checked.
+func (a *AreaAssembler) Invalid() []*AreaError {
+	return append([]*AreaError(nil), a.invalid...)
+}
+
+// Ring joined from member ways.
+type areaRing struct {
+	nodeIDs   []int64
+	locations []LatLon
+	area      float64 // signed, positive for counterclockwise ring
+	depth     int     // number of rings containing it, even for outer rings
+	parent    int     // index of the smallest ring containing it, -1 if none
+}
+
+func (a *AreaAssembler) assemble(r *Relation) ([][][]LatLon, error) {
+	var ways []*areaWay
+	seen := make(map[int64]bool)
+	for _, m := range r.Members {
+		if m.Type != WayType || seen[m.ID] {
+			continue
+		}
+		seen[m.ID] = true
+		w := a.ways[m.ID]
+		if w == nil {
+			return nil, fmt.Errorf("missing way %d", m.ID)
+		}
+		if w.locations == nil {
+			return nil, fmt.Errorf("way %d has nodes of unknown location", m.ID)
+		}
+		if len(w.nodeIDs) < 2 {
+			return nil, fmt.Errorf("way %d has less than two nodes", m.ID)
+		}
+		ways = append(ways, w)
+	}
+	if len(ways) == 0 {
+		return nil, errors.New("no member ways")
+	}
+
+	rings, err := joinRings(ways)
+	if err != nil {
+		return nil, err
+	}
+
+	// larger rings first, so containing rings precede contained ones
+	sort.SliceStable(rings, func(i, j int) bool {
+		return math.Abs(rings[i].area) > math.Abs(rings[j].area)
+	})
+	for i, ring := range rings {
+		ring.parent = -1
+		// midpoint of the first segment, rings touching at nodes do not contain it
+		p, q := ring.locations[0], ring.locations[1]
+		lat, lon := (p.Lat+q.Lat)/2, (p.Lon+q.Lon)/2
+		for j := i - 1; j >= 0; j-- {
+			if ringContains(rings[j].locations, lat, lon) {
+				ring.parent = j
+				ring.depth = rings[j].depth + 1
+				break
+			}
+		}
+	}
+
+	var polygons [][][]LatLon
+	polygonIndex := make(map[int]int) // by index of outer ring
+	for i, ring := range rings {
+		if ring.depth%2 == 0 {
+			polygonIndex[i] = len(polygons)
+			polygons = append(polygons, [][]LatLon{orient(ring, true)})
+		} else {
+			pi := polygonIndex[ring.parent]
+			polygons[pi] = append(polygons[pi], orient(ring, false))
+		}
+	}
+	return polygons, nil
+}
+
+// Joins ways into closed rings by their end nodes.
+func joinRings(ways []*areaWay) ([]*areaRing, error) {
+	// unused ways by their end nodes
+	ends := make(map[int64][]int)
+	for i, w := range ways {
+		ends[w.nodeIDs[0]] = append(ends[w.nodeIDs[0]], i)
+		ends[w.nodeIDs[len(w.nodeIDs)-1]] = append(ends[w.nodeIDs[len(w.nodeIDs)-1]], i)
+	}
+	used := make([]bool, len(ways))
+	take := func(node int64) int {
+		for _, i := range ends[node] {
+			if !used[i] {
+				used[i] = true
+				return i
+			}
+		}
+		return -1
+	}
+
+	var rings []*areaRing
+	for start := range ways {
+		if used[start] {
+			continue
+		}
+		used[start] = true
+		w := ways[start]
+		ring := &areaRing{
+			nodeIDs:   append([]int64(nil), w.nodeIDs...),
+			locations: append([]LatLon(nil), w.locations...),
+		}
+		for ring.nodeIDs[0] != ring.nodeIDs[len(ring.nodeIDs)-1] {
+			end := ring.nodeIDs[len(ring.nodeIDs)-1]
+			i := take(end)
+			if i < 0 {
+				return nil, fmt.Errorf("ring is not closed at node %d", end)
+			}
+			next := ways[i]
+			ids, locations := next.nodeIDs, next.locations
+			if ids[0] != end {
+				ids, locations = reversed(ids, locations)
+			}
+			ring.nodeIDs = append(ring.nodeIDs, ids[1:]...)
+			ring.locations = append(ring.locations, locations[1:]...)
+		}
+		if len(ring.nodeIDs) < 4 {
+			return nil, fmt.Errorf("ring at node %d has less than four nodes", ring.nodeIDs[0])
+		}
+		ring.area = signedArea(ring.locations)
+		rings = append(rings, ring)
+	}
+	return rings, nil
+}
+
+func reversed(ids []int64, locations []LatLon) ([]int64, []LatLon) {
+	rids := make([]int64, len(ids))
+	rlocations := make([]LatLon, len(locations))
+	for i := range ids {
+		rids[len(ids)-1-i] = ids[i]
+		rlocations[len(ids)-1-i] = locations[i]
+	}
+	return rids, rlocations
+}
+
+// Returns twice the signed area of closed ring in degrees, positive for counterclockwise ring.
+func signedArea(ring []LatLon) float64 {
+	var area float64
+	for i := 0; i < len(ring)-1; i++ {
+		area += ring[i].Lon*ring[i+1].Lat - ring[i+1].Lon*ring[i].Lat
+	}
+	return area
+}
+
+// Returns true if point is inside closed ring, by the same rule as Polygon.Contains.
+func ringContains(ring []LatLon, lat, lon float64) bool {
+	var inside bool
+	for i := 0; i < len(ring)-1; i++ {
+		a, b := ring[i], ring[i+1]
+		if (a.Lat > lat) != (b.Lat > lat) {
+			if lon < a.Lon+(lat-a.Lat)*(b.Lon-a.Lon)/(b.Lat-a.Lat) {
+				inside = !inside
+			}
+		}
+	}
+	return inside
+}
+
+// Returns locations of ring, counterclockwise if ccw is set, otherwise clockwise.
+func orient(ring *areaRing, ccw bool) []LatLon {
+	if (ring.area > 0) == ccw {
+		return ring.locations
+	}
+	_, locations := reversed(ring.nodeIDs, ring.locations)
+	return locations
+}
@@ -0,0 +1,144 @@
+package osmpbf
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAreaAssembler(t *testing.T) {
+	// outer square of two ways, clockwise, with a square hole
+	nodes := []*Node{
+		{ID: 1, Lat: 0, Lon: 0}, {ID: 2, Lat: 10, Lon: 0}, {ID: 3, Lat: 10, Lon: 10}, {ID: 4, Lat: 0, Lon: 10},
+		{ID: 5, Lat: 2, Lon: 2}, {ID: 6, Lat: 2, Lon: 4}, {ID: 7, Lat: 4, Lon: 4}, {ID: 8, Lat: 4, Lon: 2},
+	}
+	ways := []*Way{
+		{ID: 1, NodeIDs: []int64{1, 2, 3}},
+		{ID: 2, NodeIDs: []int64{1, 4, 3}}, // reversed direction
+		{ID: 3, NodeIDs: []int64{5, 6, 7, 8, 5}},
+		{ID: 4, NodeIDs: []int64{1, 2}},
+	}
+	multipolygon := func(id int64, ways ...int64) *Relation {
+		r := &Relation{ID: id, Tags: map[string]string{"type": "multipolygon"}}
+		for _, w := range ways {
+			// roles are not used
+			r.Members = append(r.Members, Member{ID: w, Type: WayType, Role: "outer"})
+		}
+		return r
+	}
+	relations := []*Relation{
+		multipolygon(1, 3, 1, 2),
+		multipolygon(2, 4),
+		multipolygon(3, 1, 2, 5),
+		{ID: 4, Tags: map[string]string{"type": "route"}, Members: []Member{{ID: 1, Type: WayType}}},
+	}
+
+	var objects []Object
+	for _, n := range nodes {
+		objects = append(objects, n)
+	}
+	for _, w := range ways {
+		objects = append(objects, w)
+	}
+	for _, r := range relations {
+		objects = append(objects, r)
+	}
+	data := encodeObjects(t, objects...).Bytes()
+
+	a := NewAreaAssembler(NewDenseLocationStore())
+	d := NewDecoder(bytes.NewReader(data))
+	d.SkipToRelations()
+	if err := d.Start(2); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.ReadRelations(d); err != nil {
+		t.Fatal(err)
+	}
+	d.Close()
+	d = NewDecoder(bytes.NewReader(data))
+	if err := d.Start(2); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.ReadWays(d); err != nil {
+		t.Fatal(err)
+	}
+	d.Close()
+
+	var areas []*Area
+	if err := a.Assemble(func(area *Area) error {
+		areas = append(areas, area)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(areas) != 1 || areas[0].Relation.ID != 1 {
+		t.Fatalf("expected area of relation 1, got %v", areas)
+	}
+	expected := [][][]LatLon{{
+		{{0, 0}, {0, 10}, {10, 10}, {10, 0}, {0, 0}},
+		{{2, 2}, {4, 2}, {4, 4}, {2, 4}, {2, 2}},
+	}}
+	if !reflect.DeepEqual(expected, areas[0].Polygons) {
+		t.Errorf("\nExpected: %v\nActual:   %v", expected, areas[0].Polygons)
+	}
+	p := areas[0].Polygon()
+	if !p.Contains(1, 1) || p.Contains(3, 3) {
+		t.Error("unexpected polygon of area")
+	}
+
+	invalid := a.Invalid()
+	if len(invalid) != 2 {
+		t.Fatalf("expected 2 invalid relations, got %v", invalid)
+	}
+	for i, c := range []struct {
+		id       int64
+		expected string
+	}{
+		{2, "ring is not closed at node 2"},
+		{3, "missing way 5"},
+	} {
+		var err *AreaError
+		if !errors.As(invalid[i], &err) || err.RelationID != c.id || !strings.Contains(err.Error(), c.expected) {
+			t.Errorf("expected error of relation %d containing %q, got %v", c.id, c.expected, invalid[i])
+		}
+	}
+}
+
+func TestAreaNesting(t *testing.T) {
+	square := func(id int64, min, max float64) *Way {
+		return &Way{
+			ID:            id,
+			NodeIDs:       []int64{id*10 + 1, id*10 + 2, id*10 + 3, id*10 + 4, id*10 + 1},
+			NodeLocations: []LatLon{{min, min}, {min, max}, {max, max}, {max, min}, {min, min}},
+		}
+	}
+	a := NewAreaAssembler(nil)
+	// island in a hole, and a separate outer ring; polygons are ordered by size of outer rings
+	r := &Relation{ID: 1, TagList: TagList{{"type", "boundary"}}, Members: []Member{
+		{ID: 1, Type: WayType}, {ID: 2, Type: WayType}, {ID: 3, Type: WayType}, {ID: 4, Type: WayType},
+	}}
+	if !a.AddRelation(r) {
+		t.Fatal("expected boundary relation to be kept")
+	}
+	for _, w := range []*Way{square(3, 4, 6), square(1, 0, 10), square(2, 2, 8), square(4, 20, 30)} {
+		a.AddWay(w)
+	}
+
+	var polygons [][][]LatLon
+	a.Assemble(func(area *Area) error {
+		polygons = area.Polygons
+		return nil
+	})
+	if len(polygons) != 3 || len(polygons[0]) != 2 || len(polygons[1]) != 1 || len(polygons[2]) != 1 {
+		t.Fatalf("expected polygons with 1, 0 and 0 holes, got %v", polygons)
+	}
+	if polygons[0][0][1] != (LatLon{0, 10}) || polygons[2][0][1] != (LatLon{4, 6}) {
+		t.Errorf("unexpected polygons %v", polygons)
+	}
+	if len(a.Invalid()) != 0 {
+		t.Errorf("unexpected invalid relations %v", a.Invalid())
+	}
+}
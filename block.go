@@ -0,0 +1,99 @@
+package osmpbf
+
+import (
+	"io"
+	"sync/atomic"
+
+	"github.com/brechtbm/osmpbf/OSMPBF"
+	"google.golang.org/protobuf/proto"
+)
+
+// NextPrimitiveBlock reads the next OSMData fileblock of the input stream and returns its raw
+// PrimitiveBlock: string table, granularity, offsets and primitive groups, without decoding objects.
+// It is a lower-level alternative to Start and Decode, which must not be used together with it.
+// The first call reads OSMHeader. The end of the input stream is reported by an io.EOF error.
+func (dec *Decoder) NextPrimitiveBlock() (*OSMPBF.PrimitiveBlock, error) {
+	if !dec.headerRead {
+		if err := dec.readOSMHeader(); err != nil {
+			return nil, err
+		}
+	}
+
+	offset := dec.offset
+	blobHeader, blob, err := dec.readFileBlock()
+	for err == nil && blobHeader.GetType() == "OSMHeader" {
+		// input stream is concatenation of several streams, each starting with OSMHeader
+		var h *Header
+		if h, err = decodeOSMHeader(blob); err == nil {
+			dec.addHeader(h)
+			offset = dec.offset
+			blobHeader, blob, err = dec.readFileBlock()
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if blobHeader.GetType() != "OSMData" {
+		return nil, &UnexpectedBlockTypeError{blobHeader.GetType(), "OSMData"}
+	}
+
+	// blob is already unmarshalled, so buffer can be reused for uncompressed data
+	data, err := getData(blob, dec.buf)
+	if err != nil {
+		return nil, err
+	}
+	pb := new(OSMPBF.PrimitiveBlock)
+	if err := proto.Unmarshal(data, pb); err != nil {
+		return nil, err
+	}
+
+	atomic.StoreInt64(&dec.decodedOffset, offset)
+	atomic.StoreInt64(&dec.progress.Bytes, dec.offset)
+	atomic.AddInt64(&dec.progress.Blobs, 1)
+	return pb, nil
+}
+
+// BlobReader reads fileblocks of the input stream without decoding them, for building custom
+// schedulers or remote workers on top of the file format. Blobs can be decoded with DecodeBlob
+// and DecodeHeaderBlob.
+type BlobReader struct {
+	dec *Decoder
+}
+
+// NewBlobReader returns a new blob reader that reads from r.
+func NewBlobReader(r io.Reader) *BlobReader {
+	return &BlobReader{dec: NewDecoder(r)}
+}
+
+// Next returns the next fileblock: its BlobHeader, Blob and offset in the input stream.
+// The end of the input stream is reported by an io.EOF error.
+func (br *BlobReader) Next() (*OSMPBF.BlobHeader, *OSMPBF.Blob, int64, error) {
+	offset := br.dec.offset
+	blobHeader, blob, err := br.dec.readFileBlock()
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	return blobHeader, blob, offset, nil
+}
+
+// DecodeBlob decodes objects from Blob of OSMData fileblock. It is safe for concurrent use.
+func DecodeBlob(blob *OSMPBF.Blob) ([]Object, error) {
+	q, err := new(dataDecoder).Decode(blob)
+	if err != nil {
+		return nil, err
+	}
+
+	objects := make([]Object, len(*q))
+	for i, o := range *q {
+		objects[i] = o.(Object)
+		(*q)[i] = nil
+	}
+	putObjects(q)
+	return objects, nil
+}
+
+// DecodeHeaderBlob decodes metadata from Blob of OSMHeader fileblock. It returns an error if
+// required features are not supported.
+func DecodeHeaderBlob(blob *OSMPBF.Blob) (*Header, error) {
+	return decodeOSMHeader(blob)
+}
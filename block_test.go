@@ -0,0 +1,81 @@
+package osmpbf
+
+import (
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestNextPrimitiveBlock(t *testing.T) {
+	d := NewDecoder(encodeObjects(t, en, ew, er))
+
+	var groups int
+	var st []string
+	for {
+		pb, err := d.NextPrimitiveBlock()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		groups += len(pb.GetPrimitivegroup())
+		st = append(st, pb.GetStringtable().GetS()...)
+		if pb.GetGranularity() != encodeGranularity {
+			t.Errorf("expected granularity %d, got %d", encodeGranularity, pb.GetGranularity())
+		}
+	}
+
+	if d.Header() == nil {
+		t.Error("expected header")
+	}
+	if groups != 3 {
+		t.Errorf("expected 3 primitive groups, got %d", groups)
+	}
+	var found bool
+	for _, s := range st {
+		found = found || s == ew.Tags["name"]
+	}
+	if !found {
+		t.Errorf("expected %q in string tables", ew.Tags["name"])
+	}
+}
+
+func TestBlobReader(t *testing.T) {
+	buf := encodeObjects(t, en, ew, er)
+	size := int64(buf.Len())
+	br := NewBlobReader(buf)
+
+	var decoded []interface{}
+	var offset int64
+	for {
+		blobHeader, blob, o, err := br.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		if o < offset || o >= size {
+			t.Errorf("unexpected offset %d", o)
+		}
+		offset = o
+
+		switch blobHeader.GetType() {
+		case "OSMHeader":
+			if _, err := DecodeHeaderBlob(blob); err != nil {
+				t.Fatal(err)
+			}
+		case "OSMData":
+			objects, err := DecodeBlob(blob)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, o := range objects {
+				decoded = append(decoded, o)
+			}
+		}
+	}
+
+	if !reflect.DeepEqual(decoded, []interface{}{en, ew, er}) {
+		t.Errorf("\nExpected: %#v\nActual:   %#v", []interface{}{en, ew, er}, decoded)
+	}
+}
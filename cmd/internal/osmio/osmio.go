@@ -0,0 +1,158 @@
+// Package osmio opens input and output files of commands in formats supported by package osmpbf
+// and its osmxml and o5m subpackages, so commands can read and write any of them.
+package osmio
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/brechtbm/osmpbf"
+	"github.com/brechtbm/osmpbf/o5m"
+	"github.com/brechtbm/osmpbf/osmxml"
+)
+
+// Format is a file format.
+type Format int
+
+const (
+	// PBF is OpenStreetMap PBF format.
+	PBF Format = iota
+
+	// XML is OSM XML format.
+	XML
+
+	// O5M is o5m format.
+	O5M
+)
+
+func (f Format) String() string {
+	switch f {
+	case PBF:
+		return "pbf"
+	case XML:
+		return "osm"
+	case O5M:
+		return "o5m"
+	default:
+		return fmt.Sprintf("Format(%d)", int(f))
+	}
+}
+
+// ParseFormat returns format of name: pbf, osm or xml, o5m.
+func ParseFormat(name string) (Format, error) {
+	switch strings.ToLower(name) {
+	case "pbf":
+		return PBF, nil
+	case "osm", "xml":
+		return XML, nil
+	case "o5m":
+		return O5M, nil
+	}
+	return 0, fmt.Errorf("unknown format %q", name)
+}
+
+// FormatOf returns format of file by its extension, like .osm.pbf.
+func FormatOf(path string) (Format, error) {
+	ext := filepath.Ext(path)
+	if ext == "" {
+		return 0, fmt.Errorf("unknown format of %s, it must be given explicitly", path)
+	}
+	return ParseFormat(ext[1:])
+}
+
+// A Decoder reads objects, it is implemented by decoders of all formats.
+type Decoder interface {
+	Start(n int) error
+	Header() *osmpbf.Header
+	Decode() (interface{}, error)
+	Close() error
+}
+
+// An Encoder writes objects, it is implemented by encoders of all formats.
+type Encoder interface {
+	SetHeader(h *osmpbf.Header)
+	Encode(v interface{}) error
+	Close() error
+}
+
+// NewDecoder returns a new decoder of format f reading r.
+func NewDecoder(r io.Reader, f Format) Decoder {
+	switch f {
+	case XML:
+		return osmxml.NewDecoder(r)
+	case O5M:
+		return o5m.NewDecoder(r)
+	default:
+		return osmpbf.NewDecoder(r)
+	}
+}
+
+// NewEncoder returns a new encoder of format f writing to w.
+func NewEncoder(w io.Writer, f Format) Encoder {
+	switch f {
+	case XML:
+		return osmxml.NewEncoder(w)
+	case O5M:
+		return o5m.NewEncoder(w)
+	default:
+		return osmpbf.NewEncoder(w)
+	}
+}
+
+// Open opens file at path for reading, or returns standard input if path is "-". Format is
+// taken from extension if name is empty.
+func Open(path, name string) (*os.File, Format, error) {
+	f, err := format(path, name)
+	if err != nil {
+		return nil, 0, err
+	}
+	if path == "-" {
+		return os.Stdin, f, nil
+	}
+	file, err := os.Open(path)
+	return file, f, err
+}
+
+// Create creates file at path for writing, or returns standard output if path is "-". Format is
+// taken from extension if name is empty.
+func Create(path, name string) (*os.File, Format, error) {
+	f, err := format(path, name)
+	if err != nil {
+		return nil, 0, err
+	}
+	if path == "-" {
+		return os.Stdout, f, nil
+	}
+	file, err := os.Create(path)
+	return file, f, err
+}
+
+func format(path, name string) (Format, error) {
+	if name != "" {
+		return ParseFormat(name)
+	}
+	if path == "-" {
+		return 0, fmt.Errorf("format of standard input or output must be given explicitly")
+	}
+	return FormatOf(path)
+}
+
+// ParseBBox returns bounding box of left,bottom,right,top string, as in osmium.
+func ParseBBox(s string) (osmpbf.BBox, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 4 {
+		return osmpbf.BBox{}, fmt.Errorf("invalid bounding box %q", s)
+	}
+	var v [4]float64
+	for i, p := range parts {
+		var err error
+		if v[i], err = strconv.ParseFloat(strings.TrimSpace(p), 64); err != nil {
+			return osmpbf.BBox{}, fmt.Errorf("invalid bounding box %q", s)
+		}
+	}
+	return osmpbf.BBox{Left: v[0], Bottom: v[1], Right: v[2], Top: v[3]}, nil
+}
@@ -0,0 +1,78 @@
+package osmio
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/brechtbm/osmpbf"
+)
+
+func TestFormatOf(t *testing.T) {
+	for path, expected := range map[string]Format{
+		"a.osm.pbf": PBF,
+		"a.osm":     XML,
+		"a.xml":     XML,
+		"a.O5M":     O5M,
+	} {
+		if f, err := FormatOf(path); err != nil || f != expected {
+			t.Errorf("%s: expected %v, got %v, %v", path, expected, f, err)
+		}
+	}
+	for _, path := range []string{"a", "a.txt"} {
+		if _, err := FormatOf(path); err == nil {
+			t.Errorf("%s: expected error", path)
+		}
+	}
+}
+
+func TestEncodeDecode(t *testing.T) {
+	objects := []interface{}{
+		&osmpbf.Node{ID: 1, Lat: 1.5, Lon: 2.5, Tags: map[string]string{"name": "a"}, Info: osmpbf.Info{Visible: true}},
+		&osmpbf.Way{ID: 10, NodeIDs: []int64{1, 2}, Tags: map[string]string{}, Info: osmpbf.Info{Visible: true}},
+	}
+	for _, f := range []Format{PBF, XML, O5M} {
+		var buf bytes.Buffer
+		enc := NewEncoder(&buf, f)
+		for _, o := range objects {
+			if err := enc.Encode(o); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := enc.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		dec := NewDecoder(&buf, f)
+		if err := dec.Start(1); err != nil {
+			t.Fatal(err)
+		}
+		var decoded []interface{}
+		for {
+			v, err := dec.Decode()
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			decoded = append(decoded, v)
+		}
+		dec.Close()
+		if len(decoded) != len(objects) || !reflect.DeepEqual(decoded[0].(*osmpbf.Node).Tags, objects[0].(*osmpbf.Node).Tags) {
+			t.Errorf("%v: unexpected objects %v", f, decoded)
+		}
+	}
+}
+
+func TestParseBBox(t *testing.T) {
+	bbox, err := ParseBBox("1.5, -2,3,4")
+	if err != nil || bbox != (osmpbf.BBox{Left: 1.5, Bottom: -2, Right: 3, Top: 4}) {
+		t.Errorf("unexpected bounding box %+v, %v", bbox, err)
+	}
+	for _, s := range []string{"", "1,2,3", "1,2,3,x"} {
+		if _, err := ParseBBox(s); err == nil {
+			t.Errorf("%q: expected error", s)
+		}
+	}
+}
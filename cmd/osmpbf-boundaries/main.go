@@ -0,0 +1,72 @@
+// Command osmpbf-boundaries writes administrative boundaries of OpenStreetMap PBF file: multipolygons
+// of boundary=administrative relations with their admin_level and names, as GeoJSON
+// FeatureCollection or tab-separated lines with hex encoded WKB, see package osmadmin.
+//
+// Usage:
+//
+//	osmpbf-boundaries [-f geojson|wkb] [-o output] input.osm.pbf
+//
+// Input is read twice and must be sorted by type. Relations which cannot be assembled, for example
+// because they cross extract borders, are reported on standard error.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/brechtbm/osmpbf"
+	"github.com/brechtbm/osmpbf/osmadmin"
+)
+
+func main() {
+	format := flag.String("f", "geojson", "output `format`: geojson or wkb")
+	output := flag.String("o", "-", "output `file`")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "usage: osmpbf-boundaries [-f geojson|wkb] [-o output] input.osm.pbf")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	invalid, err := run(flag.Arg(0), *output, *format)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "osmpbf-boundaries:", err)
+		os.Exit(1)
+	}
+	for _, e := range invalid {
+		fmt.Fprintln(os.Stderr, "osmpbf-boundaries: skipped", e)
+	}
+}
+
+// Writes boundaries of input to output, returns errors of relations which are not assembled.
+func run(input, output, format string) ([]*osmpbf.AreaError, error) {
+	f, err := osmadmin.ParseFormat(format)
+	if err != nil {
+		return nil, err
+	}
+
+	var w io.Writer = os.Stdout
+	if output != "-" {
+		file, err := os.Create(output)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		w = file
+	}
+
+	enc := osmadmin.NewEncoder(w, f)
+	open := func() (*osmpbf.Decoder, error) {
+		return osmpbf.OpenMmapDecoder(input)
+	}
+	invalid, err := osmadmin.Extract(open, osmpbf.NewSparseLocationStore(), enc.Encode)
+	if err != nil {
+		return nil, err
+	}
+	return invalid, enc.Close()
+}
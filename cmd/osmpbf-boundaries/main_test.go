@@ -0,0 +1,56 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/brechtbm/osmpbf"
+)
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "test.osm.pbf")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc := osmpbf.NewEncoder(f)
+	for _, o := range []osmpbf.Object{
+		&osmpbf.Node{ID: 1, Lat: 0, Lon: 0}, &osmpbf.Node{ID: 2, Lat: 0, Lon: 1}, &osmpbf.Node{ID: 3, Lat: 1, Lon: 1},
+		&osmpbf.Way{ID: 10, NodeIDs: []int64{1, 2, 3, 1}},
+		&osmpbf.Relation{ID: 20, Tags: map[string]string{"type": "boundary", "boundary": "administrative", "admin_level": "2", "name": "Country"},
+			Members: []osmpbf.Member{{ID: 10, Type: osmpbf.WayType, Role: "outer"}}},
+		&osmpbf.Relation{ID: 21, Tags: map[string]string{"type": "boundary", "boundary": "administrative", "admin_level": "4"},
+			Members: []osmpbf.Member{{ID: 11, Type: osmpbf.WayType, Role: "outer"}}},
+	} {
+		if err := enc.Encode(o); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	output := filepath.Join(dir, "boundaries.tsv")
+	invalid, err := run(path, output, "wkb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(invalid) != 1 || invalid[0].RelationID != 21 {
+		t.Errorf("unexpected invalid relations %v", invalid)
+	}
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), "20\t2\tCountry\t0106000000") || strings.Count(string(data), "\n") != 1 {
+		t.Errorf("unexpected output %q", data)
+	}
+
+	if _, err := run(path, output, "shp"); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
@@ -0,0 +1,156 @@
+// Command osmpbf-cat converts OpenStreetMap files between PBF, XML and o5m formats, like osmium
+// cat. Several input files are concatenated, with header metadata of the first one.
+//
+// Usage:
+//
+//	osmpbf-cat [flags] input... -o output
+//
+// Formats are taken from file extensions (.pbf, .osm or .xml, .o5m) unless given by -F and -f
+// flags, which are required for standard input and output given as "-".
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/brechtbm/osmpbf"
+	"github.com/brechtbm/osmpbf/cmd/internal/osmio"
+)
+
+// Options of conversion.
+type options struct {
+	inputFormat  string
+	outputFormat string
+	output       string
+	compression  string
+	level        int
+	blockSize    int
+	noMetadata   bool
+}
+
+func main() {
+	var opts options
+	flag.StringVar(&opts.inputFormat, "F", "", "input `format`: pbf, osm or o5m")
+	flag.StringVar(&opts.outputFormat, "f", "", "output `format`: pbf, osm or o5m")
+	flag.StringVar(&opts.output, "o", "-", "output `file`")
+	flag.StringVar(&opts.compression, "compression", "zlib", "PBF blob compression: zlib, zstd or none")
+	flag.IntVar(&opts.level, "level", 0, "PBF compression level, 0 for default")
+	flag.IntVar(&opts.blockSize, "block-size", 8000, "maximum number of objects per PBF block")
+	flag.BoolVar(&opts.noMetadata, "no-metadata", false, "drop versions, timestamps, changesets and users")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "usage: osmpbf-cat [flags] input... -o output")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := run(flag.Args(), &opts); err != nil {
+		fmt.Fprintln(os.Stderr, "osmpbf-cat:", err)
+		os.Exit(1)
+	}
+}
+
+// Converts inputs to output.
+func run(inputs []string, opts *options) error {
+	compression, err := parseCompression(opts.compression)
+	if err != nil {
+		return err
+	}
+
+	out, format, err := osmio.Create(opts.output, opts.outputFormat)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	w := bufio.NewWriter(out)
+	enc := osmio.NewEncoder(w, format)
+	if e, ok := enc.(*osmpbf.Encoder); ok {
+		e.SetCompression(compression)
+		e.SetCompressionLevel(opts.level)
+		if opts.blockSize > 0 {
+			e.SetBlockSize(opts.blockSize)
+		}
+	}
+
+	for i, path := range inputs {
+		if err := copyObjects(enc, path, opts, i == 0); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return out.Close()
+}
+
+// Encodes objects of file at path, and its header if first is set.
+func copyObjects(enc osmio.Encoder, path string, opts *options, first bool) error {
+	in, format, err := osmio.Open(path, opts.inputFormat)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	dec := osmio.NewDecoder(bufio.NewReader(in), format)
+	if err := dec.Start(1); err != nil {
+		return err
+	}
+	defer dec.Close()
+	if first {
+		h := *dec.Header()
+		h.WritingProgram = ""
+		if opts.noMetadata {
+			h.RequiredFeatures = nil
+		}
+		enc.SetHeader(&h)
+	}
+
+	for {
+		v, err := dec.Decode()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if opts.noMetadata {
+			dropMetadata(v)
+		}
+		if err := enc.Encode(v); err != nil {
+			return err
+		}
+	}
+}
+
+// Clears Info of object, except visible flag.
+func dropMetadata(v interface{}) {
+	var info *osmpbf.Info
+	switch o := v.(type) {
+	case *osmpbf.Node:
+		info = &o.Info
+	case *osmpbf.Way:
+		info = &o.Info
+	case *osmpbf.Relation:
+		info = &o.Info
+	default:
+		return
+	}
+	*info = osmpbf.Info{Visible: info.Visible}
+}
+
+func parseCompression(name string) (osmpbf.Compression, error) {
+	for _, c := range []osmpbf.Compression{osmpbf.Zlib, osmpbf.Zstd, osmpbf.Uncompressed} {
+		if c.String() == name {
+			return c, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown compression %q", name)
+}
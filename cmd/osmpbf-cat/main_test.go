@@ -0,0 +1,107 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/brechtbm/osmpbf"
+)
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "test.osm.pbf")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := osmpbf.Info{Version: 2, Timestamp: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC), Changeset: 3, Uid: 4, User: "a", Visible: true}
+	objects := []osmpbf.Object{
+		&osmpbf.Node{ID: 1, Lat: 1.5, Lon: 2.5, Tags: map[string]string{"name": "a"}, Info: info},
+		&osmpbf.Node{ID: 2, Lat: -1, Lon: 3, Tags: map[string]string{}, Info: info},
+		&osmpbf.Way{ID: 10, NodeIDs: []int64{1, 2}, Tags: map[string]string{"highway": "primary"}, Info: info},
+		&osmpbf.Relation{ID: 20, Members: []osmpbf.Member{{ID: 10, Type: osmpbf.WayType, Role: "outer"}}, Tags: map[string]string{}, Info: info},
+	}
+	enc := osmpbf.NewEncoder(f)
+	enc.SetHeader(&osmpbf.Header{ReplicationSequenceNumber: 42})
+	for _, o := range objects {
+		if err := enc.Encode(o); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	xmlPath := filepath.Join(dir, "test.osm")
+	if err := run([]string{path}, &options{output: xmlPath, compression: "zlib"}); err != nil {
+		t.Fatal(err)
+	}
+	o5mPath := filepath.Join(dir, "test.o5m")
+	if err := run([]string{xmlPath}, &options{output: o5mPath, compression: "zlib", noMetadata: true}); err != nil {
+		t.Fatal(err)
+	}
+	pbfPath := filepath.Join(dir, "out.pbf")
+	if err := run([]string{o5mPath}, &options{output: pbfPath, compression: "zstd", level: 3, blockSize: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := os.Open(pbfPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+	idx, err := osmpbf.BuildIndex(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(idx.Entries) != 5 || idx.Entries[1].Compression != osmpbf.Zstd {
+		t.Errorf("expected header and 4 zstd blocks, got %+v", idx.Entries)
+	}
+
+	out.Seek(0, 0)
+	dec := osmpbf.NewDecoder(out)
+	if err := dec.Start(1); err != nil {
+		t.Fatal(err)
+	}
+	defer dec.Close()
+	for _, o := range objects {
+		v, err := dec.Decode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		dropMetadata(o)
+		if !reflect.DeepEqual(o, v) {
+			t.Errorf("\nExpected: %#v\nActual:   %#v", o, v)
+		}
+	}
+
+	// header is kept by PBF
+	copyPath := filepath.Join(dir, "copy.pbf")
+	if err := run([]string{path}, &options{output: copyPath, compression: "none"}); err != nil {
+		t.Fatal(err)
+	}
+	c, err := os.Open(copyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	dec = osmpbf.NewDecoder(c)
+	if err := dec.Start(1); err != nil {
+		t.Fatal(err)
+	}
+	defer dec.Close()
+	if h := dec.Header(); h.ReplicationSequenceNumber != 42 {
+		t.Errorf("expected replication header, got %+v", h)
+	}
+
+	if err := run([]string{path}, &options{output: "-", compression: "zlib"}); err == nil {
+		t.Error("expected error for standard output without format")
+	}
+	if err := run([]string{path}, &options{output: pbfPath, compression: "gzip"}); err == nil {
+		t.Error("expected error for unknown compression")
+	}
+}
@@ -0,0 +1,177 @@
+// Command osmpbf-extract writes extracts of regions of OpenStreetMap PBF file, like osmium extract.
+// Each extract is given as region=output, where region is .poly file, GeoJSON file with Polygon or
+// MultiPolygon, or bounding box left,bottom,right,top, and output is PBF file.
+//
+// Usage:
+//
+//	osmpbf-extract [-s strategy] input.osm.pbf region=output.osm.pbf...
+//
+// Strategies are simple, complete_ways and smart, see osmpbf.ExtractMode; smart keeps complete
+// relations. Several extracts are made in one pass over input with simple strategy, other
+// strategies read input several times for each extract. Input must be sorted by type.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/brechtbm/osmpbf"
+	"github.com/brechtbm/osmpbf/cmd/internal/osmio"
+)
+
+var strategies = map[string]osmpbf.ExtractMode{
+	"simple":        osmpbf.SimpleExtract,
+	"complete_ways": osmpbf.CompleteWays,
+	"smart":         osmpbf.CompleteRelations,
+}
+
+func main() {
+	strategy := flag.String("s", "complete_ways", "`strategy`: simple, complete_ways or smart")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "usage: osmpbf-extract [-s strategy] input.osm.pbf region=output.osm.pbf...")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() < 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := run(flag.Arg(0), flag.Args()[1:], *strategy); err != nil {
+		fmt.Fprintln(os.Stderr, "osmpbf-extract:", err)
+		os.Exit(1)
+	}
+}
+
+// Extract of one region.
+type extract struct {
+	region osmpbf.Region
+	output string
+}
+
+// Writes extracts of input.
+func run(input string, args []string, strategy string) error {
+	mode, ok := strategies[strategy]
+	if !ok {
+		return fmt.Errorf("unknown strategy %q", strategy)
+	}
+	var extracts []extract
+	for _, arg := range args {
+		i := strings.LastIndexByte(arg, '=')
+		if i < 0 {
+			return fmt.Errorf("invalid extract %q, expected region=output", arg)
+		}
+		region, err := readRegion(arg[:i])
+		if err != nil {
+			return err
+		}
+		extracts = append(extracts, extract{region, arg[i+1:]})
+	}
+
+	if mode == osmpbf.SimpleExtract {
+		return multiExtract(input, extracts)
+	}
+	open := func() (*osmpbf.Decoder, error) {
+		return osmpbf.OpenMmapDecoder(input)
+	}
+	for _, e := range extracts {
+		err := create(e.output, func(w *bufio.Writer) error {
+			return osmpbf.Extract(w, open, e.region, mode)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Writes extracts in one pass over input.
+func multiExtract(input string, extracts []extract) error {
+	var files []*os.File
+	defer func() {
+		for _, f := range files {
+			f.Close()
+		}
+	}()
+	targets := make([]osmpbf.ExtractTarget, len(extracts))
+	writers := make([]*bufio.Writer, len(extracts))
+	for i, e := range extracts {
+		f, err := os.Create(e.output)
+		if err != nil {
+			return err
+		}
+		files = append(files, f)
+		writers[i] = bufio.NewWriter(f)
+		targets[i] = osmpbf.ExtractTarget{Region: e.region, W: writers[i]}
+	}
+
+	dec, err := osmpbf.OpenMmapDecoder(input)
+	if err != nil {
+		return err
+	}
+	defer dec.Close()
+	if err := dec.Start(0); err != nil {
+		return err
+	}
+	if err := osmpbf.MultiExtract(dec, targets); err != nil {
+		return err
+	}
+	for i, w := range writers {
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		if err := files[i].Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Creates file at path and calls write with buffered writer of it.
+func create(path string, write func(w *bufio.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+	if err := write(w); err != nil {
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// Returns region of .poly or GeoJSON file, or of bounding box.
+func readRegion(s string) (osmpbf.Region, error) {
+	ext := strings.ToLower(filepath.Ext(s))
+	if ext != ".poly" && ext != ".json" && ext != ".geojson" {
+		bbox, err := osmio.ParseBBox(s)
+		if err != nil {
+			return nil, fmt.Errorf("region %q is not .poly or GeoJSON file, or bounding box", s)
+		}
+		return bbox, nil
+	}
+
+	f, err := os.Open(s)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var p *osmpbf.Polygon
+	if ext == ".poly" {
+		p, err = osmpbf.ParsePoly(f)
+	} else {
+		p, err = osmpbf.ParseGeoJSONPolygon(f)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", s, err)
+	}
+	return p, nil
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/brechtbm/osmpbf"
+)
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "test.osm.pbf")
+	f, err := os.Create(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc := osmpbf.NewEncoder(f)
+	for _, o := range []osmpbf.Object{
+		&osmpbf.Node{ID: 1, Lat: 1, Lon: 1},
+		&osmpbf.Node{ID: 2, Lat: 5, Lon: 5},
+		&osmpbf.Node{ID: 3, Lat: 6, Lon: 6},
+		&osmpbf.Way{ID: 10, NodeIDs: []int64{1, 2}},
+		&osmpbf.Way{ID: 11, NodeIDs: []int64{2, 3}},
+	} {
+		if err := enc.Encode(o); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	poly := filepath.Join(dir, "region.poly")
+	err = os.WriteFile(poly, []byte("region\n1\n 0 0\n 2 0\n 2 2\n 0 2\nEND\nEND\n"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		strategy string
+		expected osmpbf.Counts
+	}{
+		{"simple", osmpbf.Counts{Nodes: 1, Ways: 1}},
+		{"complete_ways", osmpbf.Counts{Nodes: 2, Ways: 1}},
+	} {
+		polyOutput := filepath.Join(dir, "poly.osm.pbf")
+		bboxOutput := filepath.Join(dir, "bbox.osm.pbf")
+		err := run(input, []string{poly + "=" + polyOutput, "4.5,4.5,5.5,5.5=" + bboxOutput}, test.strategy)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if c := count(t, polyOutput); c != test.expected {
+			t.Errorf("%s: expected %+v in polygon extract, got %+v", test.strategy, test.expected, c)
+		}
+		if c := count(t, bboxOutput); c.Nodes < 1 || c.Ways != 2 {
+			t.Errorf("%s: unexpected bounding box extract %+v", test.strategy, c)
+		}
+	}
+
+	if err := run(input, []string{poly}, "simple"); err == nil {
+		t.Error("expected error for missing output")
+	}
+	if err := run(input, []string{poly + "=x.pbf"}, "fast"); err == nil {
+		t.Error("expected error for unknown strategy")
+	}
+}
+
+func count(t *testing.T, path string) osmpbf.Counts {
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	c, err := osmpbf.Count(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
@@ -0,0 +1,159 @@
+// Command osmpbf-filter writes objects of OpenStreetMap PBF file matching tag expressions, like
+// osmium tags-filter. Expression is optionally prefixed by object kinds, like nwr/amenity=restaurant
+// or w/highway, followed by tag expression of osmpbf.Tags.Match; objects matching any expression
+// are written. Referenced objects, like nodes of matched ways, are not added.
+//
+// Usage:
+//
+//	osmpbf-filter [flags] input.osm.pbf expression... -o output
+//
+// Output format is taken from file extension (.pbf, .osm or .xml, .o5m) unless given by -f flag.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/brechtbm/osmpbf"
+	"github.com/brechtbm/osmpbf/cmd/internal/osmio"
+)
+
+// Options of filtering.
+type options struct {
+	outputFormat string
+	output       string
+	bbox         string
+}
+
+func main() {
+	var opts options
+	flag.StringVar(&opts.outputFormat, "f", "", "output `format`: pbf, osm or o5m")
+	flag.StringVar(&opts.output, "o", "-", "output `file`")
+	flag.StringVar(&opts.bbox, "bbox", "", "keep only objects inside `left,bottom,right,top`")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "usage: osmpbf-filter [flags] input.osm.pbf expression... -o output")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() < 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := run(flag.Arg(0), flag.Args()[1:], &opts); err != nil {
+		fmt.Fprintln(os.Stderr, "osmpbf-filter:", err)
+		os.Exit(1)
+	}
+}
+
+// Writes objects of input matching expressions to output.
+func run(input string, expressions []string, opts *options) error {
+	var filters []filter
+	for _, expr := range expressions {
+		f, err := parseFilter(expr)
+		if err != nil {
+			return err
+		}
+		filters = append(filters, f)
+	}
+
+	in, err := os.Open(input)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	dec := osmpbf.NewDecoder(bufio.NewReader(in))
+	if opts.bbox != "" {
+		bbox, err := osmio.ParseBBox(opts.bbox)
+		if err != nil {
+			return err
+		}
+		dec.SetBBox(bbox, osmpbf.SpatialRelations)
+	}
+	if err := dec.Start(0); err != nil {
+		return err
+	}
+	defer dec.Close()
+
+	out, format, err := osmio.Create(opts.output, opts.outputFormat)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	w := bufio.NewWriter(out)
+	enc := osmio.NewEncoder(w, format)
+	h := *dec.Header()
+	h.WritingProgram = ""
+	enc.SetHeader(&h)
+
+	for {
+		v, err := dec.Decode()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		o := v.(osmpbf.Object)
+		if !match(filters, o) {
+			o.Release()
+			continue
+		}
+		if err := enc.Encode(o); err != nil {
+			return err
+		}
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return out.Close()
+}
+
+// Filter of objects by kind and tags.
+type filter struct {
+	kinds [3]bool // by Kind
+	expr  string
+}
+
+// Returns filter of expression like nwr/amenity=restaurant.
+func parseFilter(expr string) (filter, error) {
+	f := filter{kinds: [3]bool{true, true, true}, expr: expr}
+	if i := strings.IndexByte(expr, '/'); i >= 0 && strings.Trim(expr[:i], "nwr") == "" {
+		f.kinds = [3]bool{}
+		for _, c := range expr[:i] {
+			f.kinds[strings.IndexRune("nwr", c)] = true
+		}
+		f.expr = expr[i+1:]
+	}
+	if f.expr == "" || f.expr == "!" {
+		return filter{}, fmt.Errorf("invalid expression %q", expr)
+	}
+	return f, nil
+}
+
+// Returns true if object matches any filter.
+func match(filters []filter, o osmpbf.Object) bool {
+	var tags osmpbf.Tags
+	switch o := o.(type) {
+	case *osmpbf.Node:
+		tags = o.Tags
+	case *osmpbf.Way:
+		tags = o.Tags
+	case *osmpbf.Relation:
+		tags = o.Tags
+	default:
+		return false
+	}
+	for _, f := range filters {
+		if f.kinds[o.Kind()] && tags.Match(f.expr) {
+			return true
+		}
+	}
+	return false
+}
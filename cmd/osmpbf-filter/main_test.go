@@ -0,0 +1,80 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/brechtbm/osmpbf"
+)
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "test.osm.pbf")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc := osmpbf.NewEncoder(f)
+	for _, o := range []osmpbf.Object{
+		&osmpbf.Node{ID: 1, Lat: 1, Lon: 1, Tags: map[string]string{"amenity": "restaurant"}},
+		&osmpbf.Node{ID: 2, Lat: 5, Lon: 5, Tags: map[string]string{"amenity": "restaurant"}},
+		&osmpbf.Node{ID: 3, Lat: 1, Lon: 1, Tags: map[string]string{"amenity": "bar"}},
+		&osmpbf.Way{ID: 10, NodeIDs: []int64{1, 3}, Tags: map[string]string{"highway": "primary"}},
+		&osmpbf.Way{ID: 11, NodeIDs: []int64{1, 3}, Tags: map[string]string{"amenity": "restaurant"}},
+		&osmpbf.Relation{ID: 20, Tags: map[string]string{"highway": "pedestrian"}},
+	} {
+		if err := enc.Encode(o); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	output := filepath.Join(dir, "out.osm")
+	if err := run(path, []string{"n/amenity=restaurant", "w/highway"}, &options{output: output}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{`<node id="1"`, `<node id="2"`, `<way id="10"`} {
+		if !strings.Contains(string(data), s) {
+			t.Errorf("expected %s in output:\n%s", s, data)
+		}
+	}
+	if n := strings.Count(string(data), " id="); n != 3 {
+		t.Errorf("expected 3 objects, got %d:\n%s", n, data)
+	}
+
+	if err := run(path, []string{"amenity=restaurant"}, &options{output: output, bbox: "0,0,2,2"}); err != nil {
+		t.Fatal(err)
+	}
+	data, _ = os.ReadFile(output)
+	if !strings.Contains(string(data), `<node id="1"`) || !strings.Contains(string(data), `<way id="11"`) ||
+		strings.Count(string(data), " id=") != 2 {
+		t.Errorf("unexpected output:\n%s", data)
+	}
+
+	if err := run(path, []string{"nw/"}, &options{output: output}); err == nil {
+		t.Error("expected error for invalid expression")
+	}
+	if err := run(path, []string{"highway"}, &options{output: output, bbox: "0,0,2"}); err == nil {
+		t.Error("expected error for invalid bounding box")
+	}
+}
+
+func TestParseFilter(t *testing.T) {
+	f, err := parseFilter("wr/highway=primary")
+	if err != nil || f.kinds != [3]bool{false, true, true} || f.expr != "highway=primary" {
+		t.Errorf("unexpected filter %+v, %v", f, err)
+	}
+	f, err = parseFilter("type/x")
+	if err != nil || f.kinds != [3]bool{true, true, true} || f.expr != "type/x" {
+		t.Errorf("unexpected filter %+v, %v", f, err)
+	}
+}
@@ -0,0 +1,184 @@
+// Command osmpbf-info prints information about OpenStreetMap PBF file, like osmium fileinfo: header
+// metadata, numbers of blobs by type and compression, and numbers, ID ranges and bounding box of
+// objects. Objects are not decoded, so it is fast even for planet files.
+//
+// Usage:
+//
+//	osmpbf-info file.osm.pbf
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/brechtbm/osmpbf"
+)
+
+func main() {
+	if len(os.Args) != 2 {
+		fmt.Fprintln(os.Stderr, "usage: osmpbf-info file.osm.pbf")
+		os.Exit(2)
+	}
+	if err := run(os.Stdout, os.Args[1]); err != nil {
+		fmt.Fprintln(os.Stderr, "osmpbf-info:", err)
+		os.Exit(1)
+	}
+}
+
+// Writes information about file at path to w.
+func run(w io.Writer, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	d := osmpbf.NewDecoder(f)
+	if err := d.Start(1); err != nil {
+		return err
+	}
+	header := d.Header()
+	d.Close()
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	idx, err := osmpbf.BuildIndex(f)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(w, "File:")
+	fmt.Fprintf(w, "  Name: %s\n", path)
+	var size int64
+	for _, e := range idx.Entries {
+		size += e.Size
+	}
+	fmt.Fprintf(w, "  Size: %d\n", size)
+
+	writeHeader(w, header)
+	writeBlobs(w, idx)
+	writeData(w, idx)
+	return nil
+}
+
+func writeHeader(w io.Writer, h *osmpbf.Header) {
+	fmt.Fprintln(w, "Header:")
+	if h.BBox != nil {
+		fmt.Fprintf(w, "  Bounding box: %s\n", formatBBox(*h.BBox))
+	}
+	fmt.Fprintf(w, "  Required features: %s\n", strings.Join(h.RequiredFeatures, ", "))
+	fmt.Fprintf(w, "  Optional features: %s\n", strings.Join(h.OptionalFeatures, ", "))
+	fmt.Fprintf(w, "  Writing program: %s\n", h.WritingProgram)
+	if h.Source != "" {
+		fmt.Fprintf(w, "  Source: %s\n", h.Source)
+	}
+	if h.HasReplication() {
+		if !h.ReplicationTimestamp.IsZero() {
+			fmt.Fprintf(w, "  Replication timestamp: %s\n", h.ReplicationTimestamp.Format(time.RFC3339))
+		}
+		fmt.Fprintf(w, "  Replication sequence number: %d\n", h.ReplicationSequenceNumber)
+		if h.ReplicationBaseURL != "" {
+			fmt.Fprintf(w, "  Replication base URL: %s\n", h.ReplicationBaseURL)
+		}
+	}
+}
+
+// Compression statistics of blobs.
+type compressionStats struct {
+	blobs, size, rawSize int64
+}
+
+func writeBlobs(w io.Writer, idx *osmpbf.Index) {
+	types := make(map[string]int)
+	compressions := make(map[osmpbf.Compression]*compressionStats)
+	for _, e := range idx.Entries {
+		types[e.Type]++
+		s := compressions[e.Compression]
+		if s == nil {
+			s = new(compressionStats)
+			compressions[e.Compression] = s
+		}
+		s.blobs++
+		s.size += e.Size
+		s.rawSize += e.RawSize
+	}
+
+	fmt.Fprintln(w, "Blobs:")
+	var names []string
+	for t := range types {
+		names = append(names, t)
+	}
+	sort.Strings(names)
+	for _, t := range names {
+		fmt.Fprintf(w, "  %s: %d\n", t, types[t])
+	}
+
+	fmt.Fprintln(w, "Compression:")
+	var cs []osmpbf.Compression
+	for c := range compressions {
+		cs = append(cs, c)
+	}
+	sort.Slice(cs, func(i, j int) bool { return cs[i] < cs[j] })
+	for _, c := range cs {
+		s := compressions[c]
+		ratio := 0.0
+		if s.rawSize > 0 {
+			ratio = 100 * float64(s.size) / float64(s.rawSize)
+		}
+		fmt.Fprintf(w, "  %s: %d blobs, %d bytes, %d uncompressed (%.1f%%)\n", c, s.blobs, s.size, s.rawSize, ratio)
+	}
+}
+
+func writeData(w io.Writer, idx *osmpbf.Index) {
+	fmt.Fprintln(w, "Data:")
+	var bbox *osmpbf.BBox
+	for _, e := range idx.Entries {
+		if e.BBox == nil {
+			continue
+		}
+		if bbox == nil {
+			b := *e.BBox
+			bbox = &b
+			continue
+		}
+		bbox.Left = math.Min(bbox.Left, e.BBox.Left)
+		bbox.Bottom = math.Min(bbox.Bottom, e.BBox.Bottom)
+		bbox.Right = math.Max(bbox.Right, e.BBox.Right)
+		bbox.Top = math.Max(bbox.Top, e.BBox.Top)
+	}
+	if bbox != nil {
+		fmt.Fprintf(w, "  Bounding box: %s\n", formatBBox(*bbox))
+	}
+
+	for _, k := range []osmpbf.Kind{osmpbf.NodeKind, osmpbf.WayKind, osmpbf.RelationKind} {
+		var r osmpbf.IDRange
+		for _, e := range idx.Entries {
+			er := e.Range(k)
+			if er.Count == 0 {
+				continue
+			}
+			if r.Count == 0 || er.Min < r.Min {
+				r.Min = er.Min
+			}
+			if r.Count == 0 || er.Max > r.Max {
+				r.Max = er.Max
+			}
+			r.Count += er.Count
+		}
+		fmt.Fprintf(w, "  %ss: %d", strings.ToUpper(k.String()[:1])+k.String()[1:], r.Count)
+		if r.Count > 0 {
+			fmt.Fprintf(w, " (IDs %d - %d)", r.Min, r.Max)
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+func formatBBox(b osmpbf.BBox) string {
+	return fmt.Sprintf("(%.7f, %.7f, %.7f, %.7f)", b.Left, b.Bottom, b.Right, b.Top)
+}
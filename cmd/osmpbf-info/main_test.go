@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/brechtbm/osmpbf"
+)
+
+func TestRun(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.osm.pbf")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc := osmpbf.NewEncoder(f)
+	enc.SetHeader(&osmpbf.Header{WritingProgram: "test", ReplicationSequenceNumber: 42})
+	for _, o := range []interface{}{
+		&osmpbf.Node{ID: 1, Lat: 1, Lon: 2},
+		&osmpbf.Node{ID: 5, Lat: -1, Lon: 3},
+		&osmpbf.Way{ID: 10, NodeIDs: []int64{1, 5}},
+	} {
+		if err := enc.Encode(o); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	var buf bytes.Buffer
+	if err := run(&buf, path); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{
+		"Writing program: test\n",
+		"Replication sequence number: 42\n",
+		"OSMData: 2\n",
+		"OSMHeader: 1\n",
+		"zlib: 3 blobs",
+		"Bounding box: (2.0000000, -1.0000000, 3.0000000, 1.0000000)\n",
+		"Nodes: 2 (IDs 1 - 5)\n",
+		"Ways: 1 (IDs 10 - 10)\n",
+		"Relations: 0\n",
+	} {
+		if !strings.Contains(buf.String(), s) {
+			t.Errorf("expected %q in output:\n%s", s, buf.String())
+		}
+	}
+
+	if err := run(&buf, filepath.Join(t.TempDir(), "missing.osm.pbf")); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
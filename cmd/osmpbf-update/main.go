@@ -0,0 +1,134 @@
+// Command osmpbf-update keeps OpenStreetMap PBF file up to date with replication diffs, like
+// pyosmium-up-to-date. Replication base URL and initial state are read from header of the file,
+// then state is tracked in state file. Updated file replaces the original one, or, with -osc flag,
+// downloaded changes are written to osmChange file instead and the PBF file is not changed.
+//
+// Usage:
+//
+//	osmpbf-update [flags] file.osm.pbf
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/brechtbm/osmpbf"
+	"github.com/brechtbm/osmpbf/replication"
+)
+
+// Options of update.
+type options struct {
+	statePath string
+	baseURL   string
+	osc       string
+}
+
+func main() {
+	var opts options
+	flag.StringVar(&opts.statePath, "state", "", "state `file`, default is file.osm.pbf.state.txt")
+	flag.StringVar(&opts.baseURL, "url", "", "replication base `URL`, default is taken from header")
+	flag.StringVar(&opts.osc, "osc", "", "write changes to osmChange `file`, gzipped if it ends with .gz")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "usage: osmpbf-update [flags] file.osm.pbf")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	state, err := run(context.Background(), flag.Arg(0), &opts)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "osmpbf-update:", err)
+		os.Exit(1)
+	}
+	fmt.Printf("sequence number %d, timestamp %s\n", state.SequenceNumber, state.Timestamp.Format(time.RFC3339))
+}
+
+// Updates file at path, or writes changes to osmChange file, and returns the new state.
+func run(ctx context.Context, path string, opts *options) (*replication.State, error) {
+	statePath := opts.statePath
+	if statePath == "" {
+		statePath = path + ".state.txt"
+	}
+	header, err := readHeader(path)
+	if err != nil {
+		return nil, err
+	}
+	if opts.baseURL != "" {
+		header.ReplicationBaseURL = opts.baseURL
+	}
+	c, err := replication.HeaderClient(header, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.osc == "" {
+		return c.UpdateFile(ctx, path, statePath)
+	}
+
+	state, err := replication.LoadState(statePath)
+	if errors.Is(err, os.ErrNotExist) {
+		state, err = replication.HeaderState(header)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if state, err = writeChanges(ctx, c, opts.osc, state); err != nil {
+		return nil, err
+	}
+	return state, replication.SaveState(statePath, state)
+}
+
+// Returns header of PBF file at path.
+func readHeader(path string) (*osmpbf.Header, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	d := osmpbf.NewDecoder(f)
+	if err := d.Start(1); err != nil {
+		return nil, err
+	}
+	defer d.Close()
+	return d.Header(), nil
+}
+
+// Writes changes after state to osmChange file at path.
+func writeChanges(ctx context.Context, c *replication.Client, path string, state *replication.State) (*replication.State, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	bw := bufio.NewWriter(f)
+	var w io.Writer = bw
+	var zw *gzip.Writer
+	if strings.HasSuffix(path, ".gz") {
+		zw = gzip.NewWriter(bw)
+		w = zw
+	}
+
+	if state, err = c.WriteChanges(ctx, w, state); err != nil {
+		return nil, err
+	}
+	if zw != nil {
+		if err := zw.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		return nil, err
+	}
+	return state, f.Close()
+}
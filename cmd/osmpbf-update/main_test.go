@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/brechtbm/osmpbf"
+	"github.com/brechtbm/osmpbf/replication"
+)
+
+func gzipData(s string) []byte {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	w.Write([]byte(s))
+	w.Close()
+	return buf.Bytes()
+}
+
+func TestRun(t *testing.T) {
+	files := map[string][]byte{
+		"/state.txt": []byte("sequenceNumber=11\ntimestamp=2022-10-15T10\\:02\\:00Z\n"),
+		"/000/000/011.osc.gz": gzipData(`<osmChange version="0.6"><create>` +
+			`<node id="3" version="1" lat="1" lon="1"/></create></osmChange>`),
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(data)
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "extract.osm.pbf")
+	var buf bytes.Buffer
+	e := osmpbf.NewEncoder(&buf)
+	e.SetHeader(&osmpbf.Header{ReplicationBaseURL: srv.URL, ReplicationSequenceNumber: 10})
+	e.Encode(&osmpbf.Node{ID: 1})
+	if err := e.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// changes only
+	osc := filepath.Join(dir, "changes.osc.gz")
+	state, err := run(context.Background(), path, &options{osc: osc})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.SequenceNumber != 11 {
+		t.Errorf("expected state 11, got %v", state)
+	}
+	f, err := os.Open(osc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(zr)
+	if err != nil || !strings.Contains(string(data), `<node id="3"`) {
+		t.Errorf("unexpected changes %s, %v", data, err)
+	}
+	if s, err := replication.LoadState(path + ".state.txt"); err != nil || s.SequenceNumber != 11 {
+		t.Errorf("expected saved state 11, got %v, %v", s, err)
+	}
+
+	// update of file with separate state file
+	statePath := filepath.Join(dir, "state.txt")
+	state, err = run(context.Background(), path, &options{statePath: statePath})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.SequenceNumber != 11 {
+		t.Errorf("expected state 11, got %v", state)
+	}
+	f2, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f2.Close()
+	c, err := osmpbf.Count(f2)
+	if err != nil || c.Nodes != 2 {
+		t.Errorf("expected 2 nodes in updated file, got %+v, %v", c, err)
+	}
+
+	if _, err := run(context.Background(), path, &options{statePath: statePath, baseURL: srv.URL + "/missing"}); err == nil {
+		t.Error("expected error for missing replication directory")
+	}
+}
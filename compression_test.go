@@ -0,0 +1,325 @@
+package osmpbf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/brechtbm/osmpbf/OSMPBF"
+	kzlib "github.com/klauspost/compress/zlib"
+	"github.com/pierrec/lz4/v4"
+	"github.com/ulikunitz/xz/lzma"
+	"google.golang.org/protobuf/proto"
+)
+
+// Rewrites all blobs of PBF stream using compress function.
+func recompress(t *testing.T, r io.Reader, compress func(data []byte) *OSMPBF.Blob) *bytes.Buffer {
+	var buf bytes.Buffer
+	d := NewDecoder(r)
+	e := NewEncoder(&buf)
+	for {
+		blobHeader, blob, err := d.readFileBlock()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+
+		data, err := getData(blob, new(bytes.Buffer))
+		if err != nil {
+			t.Fatal(err)
+		}
+		blob = compress(append([]byte{}, data...))
+		blob.RawSize = proto.Int32(int32(len(data)))
+		if err = e.writeBlob(blobHeader.GetType(), blob); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return &buf
+}
+
+func testCompression(t *testing.T, compress func(data []byte) *OSMPBF.Blob) {
+	objects := []interface{}{ew, er}
+	buf := recompress(t, encodeObjects(t, ew, er), compress)
+	if decoded := decodeAll(t, NewDecoder(buf)); !reflect.DeepEqual(objects, decoded) {
+		t.Errorf("\nExpected: %#v\nActual:   %#v", objects, decoded)
+	}
+}
+
+func TestDecodeRaw(t *testing.T) {
+	testCompression(t, func(data []byte) *OSMPBF.Blob {
+		return &OSMPBF.Blob{Raw: data}
+	})
+}
+
+func TestDecodeLZ4(t *testing.T) {
+	testCompression(t, func(data []byte) *OSMPBF.Blob {
+		compressed := make([]byte, lz4.CompressBlockBound(len(data)))
+		n, err := lz4.CompressBlock(data, compressed, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &OSMPBF.Blob{Lz4Data: compressed[:n]}
+	})
+}
+
+func TestDecodeLZMA(t *testing.T) {
+	testCompression(t, func(data []byte) *OSMPBF.Blob {
+		var buf bytes.Buffer
+		w, err := lzma.NewWriter(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = w.Write(data); err != nil {
+			t.Fatal(err)
+		}
+		if err = w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		return &OSMPBF.Blob{LzmaData: buf.Bytes()}
+	})
+}
+
+func TestInvalidRawSize(t *testing.T) {
+	lz4Blob := func(data []byte) *OSMPBF.Blob {
+		compressed := make([]byte, lz4.CompressBlockBound(len(data)))
+		n, err := lz4.CompressBlock(data, compressed, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &OSMPBF.Blob{Lz4Data: compressed[:n]}
+	}
+	zlibBlob := func(data []byte) *OSMPBF.Blob {
+		var buf bytes.Buffer
+		w := zlib.NewWriter(&buf)
+		w.Write(data)
+		w.Close()
+		return &OSMPBF.Blob{ZlibData: buf.Bytes()}
+	}
+
+	for _, size := range []int32{-1, -1 << 30, MaxBlobSize + 1} {
+		for _, compress := range []func(data []byte) *OSMPBF.Blob{zlibBlob, lz4Blob} {
+			// raw size of blob of the way is invalid
+			var out bytes.Buffer
+			d := NewDecoder(encodeObjects(t, en, ew, er))
+			e := NewEncoder(&out)
+			for i := 0; ; i++ {
+				blobHeader, blob, err := d.readFileBlock()
+				if err == io.EOF {
+					break
+				} else if err != nil {
+					t.Fatal(err)
+				}
+				data, err := getData(blob, new(bytes.Buffer))
+				if err != nil {
+					t.Fatal(err)
+				}
+				blob = compress(append([]byte{}, data...))
+				blob.RawSize = proto.Int32(int32(len(data)))
+				if i == 2 {
+					blob.RawSize = proto.Int32(size)
+				}
+				if err = e.writeBlob(blobHeader.GetType(), blob); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			d = NewDecoder(bytes.NewReader(out.Bytes()))
+			if err := d.Start(2); err != nil {
+				t.Fatal(err)
+			}
+			var err error
+			for err == nil {
+				_, err = d.Decode()
+			}
+			if !errors.Is(err, ErrBlobTooLarge) {
+				t.Errorf("raw size %d: expected ErrBlobTooLarge, got %v", size, err)
+			}
+			d.Close()
+
+			objects := []interface{}{en, er}
+			decoded := decodeAll(t, NewDecoder(bytes.NewReader(out.Bytes()), WithSkipCorrupt(true)))
+			if !reflect.DeepEqual(objects, decoded) {
+				t.Errorf("raw size %d:\nExpected: %#v\nActual:   %#v", size, objects, decoded)
+			}
+		}
+	}
+}
+
+func TestZlibReader(t *testing.T) {
+	objects := decodeAll(t, NewDecoder(encodeNodesWays(t, maxBlockEntities*2, 10)))
+	var calls int32
+	newReader := func(r io.Reader) (io.ReadCloser, error) {
+		atomic.AddInt32(&calls, 1)
+		return kzlib.NewReader(r)
+	}
+	d := NewDecoder(encodeNodesWays(t, maxBlockEntities*2, 10), WithZlibReader(newReader))
+	if decoded := decodeAll(t, d); !reflect.DeepEqual(objects, decoded) {
+		t.Errorf("expected %d objects, got %d different ones", len(objects), len(decoded))
+	}
+	// one reader per data decoder goroutine
+	if calls == 0 || calls > 2 {
+		t.Errorf("expected 1 or 2 zlib readers, got %d", calls)
+	}
+
+	var inf inflater
+	for i := 0; i < 2; i++ {
+		var buf bytes.Buffer
+		w := zlib.NewWriter(&buf)
+		w.Write([]byte("data"))
+		w.Close()
+		data, err := inf.data(&OSMPBF.Blob{ZlibData: buf.Bytes(), RawSize: proto.Int32(4)}, new(bytes.Buffer))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != "data" {
+			t.Errorf("expected data, got %q", data)
+		}
+		zr := inf.zlib
+		if _, err = inf.data(&OSMPBF.Blob{ZlibData: []byte("corrupt")}, new(bytes.Buffer)); err == nil {
+			t.Error("expected error")
+		}
+		if inf.zlib != zr {
+			t.Error("expected zlib reader to be reused")
+		}
+	}
+}
+
+// Decompressor of zlib data using klauspost/compress, counting blobs.
+type countingDecompressor struct {
+	blobs *int32
+	size  int // added to returned data size if not zero
+}
+
+func (d countingDecompressor) Decompress(dst, src []byte, size int) ([]byte, error) {
+	atomic.AddInt32(d.blobs, 1)
+	r, err := kzlib.NewReader(bytes.NewReader(src))
+	if err != nil {
+		return nil, err
+	}
+	buf := bytes.NewBuffer(dst)
+	if _, err = buf.ReadFrom(r); err != nil {
+		return nil, err
+	}
+	return append(buf.Bytes(), make([]byte, d.size)...), nil
+}
+
+func TestDecompressor(t *testing.T) {
+	objects := []interface{}{ew, er}
+	var blobs int32
+	d := NewDecoder(encodeObjects(t, ew, er), WithDecompressor(Zlib, func() Decompressor {
+		return countingDecompressor{blobs: &blobs}
+	}))
+	if decoded := decodeAll(t, d); !reflect.DeepEqual(objects, decoded) {
+		t.Errorf("\nExpected: %#v\nActual:   %#v", objects, decoded)
+	}
+	if blobs != 2 {
+		t.Errorf("expected 2 blobs decompressed by custom decompressor, got %d", blobs)
+	}
+
+	// other compression methods use built-in decompression
+	var buf bytes.Buffer
+	e := NewEncoder(&buf)
+	e.SetCompression(Zstd)
+	if err := e.Encode(ew); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Close(); err != nil {
+		t.Fatal(err)
+	}
+	d = NewDecoder(&buf, WithDecompressor(Zlib, func() Decompressor {
+		return countingDecompressor{blobs: &blobs}
+	}))
+	if decoded := decodeAll(t, d); !reflect.DeepEqual([]interface{}{ew}, decoded) {
+		t.Errorf("\nExpected: %#v\nActual:   %#v", []interface{}{ew}, decoded)
+	}
+
+	d = NewDecoder(encodeObjects(t, ew), WithDecompressor(Zlib, func() Decompressor {
+		return countingDecompressor{blobs: &blobs, size: 1}
+	}))
+	if err := d.Start(1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.Decode(); err == nil || !strings.Contains(err.Error(), "raw blob data size") {
+		t.Errorf("expected size error, got %v", err)
+	}
+	d.Close()
+}
+
+func TestEncodeCompression(t *testing.T) {
+	objects := []interface{}{en}
+	for i := 0; i < 1000; i++ {
+		objects = append(objects, &Way{ID: int64(i), NodeIDs: []int64{1, 2, 3}, Tags: map[string]string{"highway": "residential"}, Info: ew.Info})
+	}
+	objects = append(objects, er)
+	sizes := make(map[Compression]int)
+	for _, c := range []Compression{Zlib, Zstd, Uncompressed} {
+		var buf bytes.Buffer
+		e := NewEncoder(&buf)
+		e.SetCompression(c)
+		for _, o := range objects {
+			if err := e.Encode(o); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := e.Close(); err != nil {
+			t.Fatal(err)
+		}
+		sizes[c] = buf.Len()
+
+		decoded := decodeAll(t, NewDecoder(&buf))
+		if !reflect.DeepEqual(objects[1:], decoded[1:]) {
+			t.Errorf("compression %d:\nExpected: %#v\nActual:   %#v", c, objects[1:], decoded[1:])
+		}
+	}
+
+	if sizes[Zstd] >= sizes[Uncompressed] {
+		t.Errorf("expected compressed output, got sizes %v", sizes)
+	}
+}
+
+func TestEncodeCompressionLevel(t *testing.T) {
+	var ways []Object
+	for i := 0; i < 1000; i++ {
+		ways = append(ways, &Way{ID: int64(i), NodeIDs: []int64{1, 2, 3}, Tags: map[string]string{"highway": "residential"}})
+	}
+	for _, c := range []Compression{Zlib, Zstd} {
+		for _, level := range []int{1, 9} {
+			var buf bytes.Buffer
+			e := NewEncoder(&buf)
+			e.SetCompression(c)
+			e.SetCompressionLevel(level)
+			e.SetBlockSize(100)
+			for _, o := range ways {
+				if err := e.Encode(o); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := e.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			idx, err := BuildIndex(bytes.NewReader(buf.Bytes()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(idx.Entries) != 11 {
+				t.Errorf("expected header and 10 blocks, got %d entries", len(idx.Entries))
+			}
+			if decoded := decodeAll(t, NewDecoder(&buf)); len(decoded) != len(ways) {
+				t.Errorf("compression %v, level %d: expected %d objects, got %d", c, level, len(ways), len(decoded))
+			}
+		}
+	}
+
+	e := NewEncoder(new(bytes.Buffer))
+	e.SetCompressionLevel(10)
+	if err := e.Close(); err == nil {
+		t.Error("expected error for invalid zlib level")
+	}
+}
@@ -0,0 +1,69 @@
+package osmpbf
+
+import (
+	"context"
+	"io"
+	"testing"
+)
+
+func TestStartWithContext(t *testing.T) {
+	objects := make([]Object, maxBlockEntities*3)
+	for i := range objects {
+		objects[i] = &Node{ID: int64(i + 1)}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	d := NewDecoder(encodeObjects(t, objects...))
+	if err := d.StartWithContext(ctx, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := d.Decode(); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+
+	// wait for all goroutines to exit
+	d.wg.Wait()
+
+	if v, err := d.Decode(); err != context.Canceled {
+		t.Errorf("expected %v, got %#v, %v", context.Canceled, v, err)
+	}
+}
+
+func TestClose(t *testing.T) {
+	objects := make([]Object, maxBlockEntities*3)
+	for i := range objects {
+		objects[i] = &Node{ID: int64(i + 1)}
+	}
+
+	d := NewDecoder(encodeObjects(t, objects...))
+	if err := d.Start(2); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.Decode(); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := d.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if v, err := d.Decode(); err != io.EOF {
+			t.Errorf("expected io.EOF, got %#v, %v", v, err)
+		}
+	}
+}
+
+func TestCloseBeforeStart(t *testing.T) {
+	d := NewDecoder(encodeObjects(t, en))
+	if err := d.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Start(1); err == nil {
+		t.Error("expected error")
+	}
+	if v, err := d.Decode(); err != io.EOF {
+		t.Errorf("expected io.EOF, got %#v, %v", v, err)
+	}
+}
@@ -0,0 +1,117 @@
+package osmpbf
+
+import (
+	"bytes"
+	"io"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Counts are numbers of objects by kind.
+type Counts struct {
+	Nodes     int64
+	Ways      int64
+	Relations int64
+}
+
+// Count returns numbers of objects in the input stream read from r. Blobs are uncompressed, but
+// objects are not decoded: PrimitiveGroups are only scanned for message fields, and dense nodes are
+// counted by their packed IDs, so it is much faster than decoding for sanity checks of big files.
+func Count(r io.Reader) (Counts, error) {
+	d := NewDecoder(r)
+	var c Counts
+	var inf inflater
+	var buf bytes.Buffer
+	for {
+		offset := d.offset
+		blobHeader, blob, err := d.readFileBlock()
+		if err == io.EOF && d.offset == offset {
+			return c, nil
+		} else if err == io.EOF {
+			return c, io.ErrUnexpectedEOF
+		} else if err != nil {
+			return c, err
+		}
+		if blobHeader.GetType() != "OSMData" {
+			continue
+		}
+
+		data, err := inf.data(blob, &buf)
+		if err != nil {
+			return c, err
+		}
+		if err := c.addBlock(data); err != nil {
+			return c, err
+		}
+	}
+}
+
+// Adds objects of PrimitiveBlock wire data.
+func (c *Counts) addBlock(data []byte) error {
+	return rangeFields(data, func(num protowire.Number, typ protowire.Type, b []byte) error {
+		if num == 2 && typ == protowire.BytesType {
+			return c.addGroup(b)
+		}
+		return nil
+	})
+}
+
+// Adds objects of PrimitiveGroup wire data.
+func (c *Counts) addGroup(data []byte) error {
+	return rangeFields(data, func(num protowire.Number, typ protowire.Type, b []byte) error {
+		if typ != protowire.BytesType {
+			return nil
+		}
+		switch num {
+		case 1:
+			c.Nodes++
+		case 2:
+			return rangeFields(b, func(num protowire.Number, typ protowire.Type, b []byte) error {
+				if num == 1 {
+					// IDs are varints, each ends with a byte without continuation bit
+					for _, v := range b {
+						if v < 0x80 {
+							c.Nodes++
+						}
+					}
+				}
+				return nil
+			})
+		case 3:
+			c.Ways++
+		case 4:
+			c.Relations++
+		}
+		return nil
+	})
+}
+
+// Calls fn with number, type and data of each field of message wire data. Data of bytes field is
+// its content without length, data of other fields is their encoded value.
+func rangeFields(data []byte, fn func(num protowire.Number, typ protowire.Type, b []byte) error) error {
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return errBlockFormat
+		}
+		data = data[n:]
+
+		var b []byte
+		if typ == protowire.BytesType {
+			b, n = protowire.ConsumeBytes(data)
+		} else {
+			n = protowire.ConsumeFieldValue(num, typ, data)
+			if n >= 0 {
+				b = data[:n]
+			}
+		}
+		if n < 0 {
+			return errBlockFormat
+		}
+		if err := fn(num, typ, b); err != nil {
+			return err
+		}
+		data = data[n:]
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package osmpbf
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestCount(t *testing.T) {
+	n := maxBlockEntities*2 + 1
+	data := encodeNodesWays(t, n, 10).Bytes()
+	c, err := Count(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c != (Counts{Nodes: int64(n), Ways: 10}) {
+		t.Errorf("unexpected counts %+v", c)
+	}
+
+	c, err = Count(encodeObjects(t,
+		&Node{ID: 1}, &Node{ID: 300, Lat: -1, Lon: 2},
+		&Relation{ID: 1}, &Relation{ID: 2},
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c != (Counts{Nodes: 2, Relations: 2}) {
+		t.Errorf("unexpected counts %+v", c)
+	}
+
+	if _, err := Count(bytes.NewReader(data[:len(data)-10])); err != io.ErrUnexpectedEOF {
+		t.Errorf("expected unexpected EOF, got %v", err)
+	}
+}
@@ -0,0 +1,188 @@
+package osmpbf
+
+import (
+	"bytes"
+	"io"
+	"math"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// DataBBox returns bounding box of all nodes in the input stream read from r, computed from their
+// locations, as bounding box of OSMHeader is often missing or wrong. False is returned if there are
+// no nodes. Like Count, it scans wire data of PrimitiveBlocks without decoding objects.
+// Bounding boxes of single blobs are set by BuildIndex.
+func DataBBox(r io.Reader) (BBox, bool, error) {
+	d := NewDecoder(r)
+	var bbox BBox
+	found := false
+	var inf inflater
+	var buf bytes.Buffer
+	for {
+		offset := d.offset
+		blobHeader, blob, err := d.readFileBlock()
+		if err == io.EOF && d.offset == offset {
+			return bbox, found, nil
+		} else if err == io.EOF {
+			return BBox{}, false, io.ErrUnexpectedEOF
+		} else if err != nil {
+			return BBox{}, false, err
+		}
+		if blobHeader.GetType() != "OSMData" {
+			continue
+		}
+
+		data, err := inf.data(blob, &buf)
+		if err != nil {
+			return BBox{}, false, err
+		}
+		b, ok, err := blockBBox(data)
+		if err != nil {
+			return BBox{}, false, err
+		}
+		if ok && found {
+			bbox = unionBBox(bbox, b)
+		} else if ok {
+			bbox, found = b, true
+		}
+	}
+}
+
+// Returns bounding box containing both a and b.
+func unionBBox(a, b BBox) BBox {
+	return BBox{
+		Left:   math.Min(a.Left, b.Left),
+		Right:  math.Max(a.Right, b.Right),
+		Top:    math.Max(a.Top, b.Top),
+		Bottom: math.Min(a.Bottom, b.Bottom),
+	}
+}
+
+// Range of raw coordinates of nodes in PrimitiveBlock, in granularity units.
+type rawBounds struct {
+	minLat, maxLat int64
+	minLon, maxLon int64
+	n              int
+}
+
+func (rb *rawBounds) add(lat, lon int64) {
+	if rb.n == 0 || lat < rb.minLat {
+		rb.minLat = lat
+	}
+	if rb.n == 0 || lat > rb.maxLat {
+		rb.maxLat = lat
+	}
+	if rb.n == 0 || lon < rb.minLon {
+		rb.minLon = lon
+	}
+	if rb.n == 0 || lon > rb.maxLon {
+		rb.maxLon = lon
+	}
+	rb.n++
+}
+
+// Returns bounding box of nodes of PrimitiveBlock wire data, false if it has no nodes.
+func blockBBox(data []byte) (BBox, bool, error) {
+	var raw rawBounds
+	granularity, latOffset, lonOffset := int64(100), int64(0), int64(0)
+	err := rangeFields(data, func(num protowire.Number, typ protowire.Type, b []byte) error {
+		switch {
+		case num == 2 && typ == protowire.BytesType:
+			return raw.addGroup(b)
+		case num == 17 || num == 19 || num == 20:
+			v, n := protowire.ConsumeVarint(b)
+			if n < 0 || typ != protowire.VarintType {
+				return errBlockFormat
+			}
+			switch num {
+			case 17:
+				granularity = int64(int32(v))
+			case 19:
+				latOffset = int64(v)
+			case 20:
+				lonOffset = int64(v)
+			}
+		}
+		return nil
+	})
+	if err != nil || raw.n == 0 {
+		return BBox{}, false, err
+	}
+
+	// granularity is positive, so raw bounds are bounds of locations
+	return BBox{
+		Left:   1e-9 * float64(lonOffset+granularity*raw.minLon),
+		Right:  1e-9 * float64(lonOffset+granularity*raw.maxLon),
+		Bottom: 1e-9 * float64(latOffset+granularity*raw.minLat),
+		Top:    1e-9 * float64(latOffset+granularity*raw.maxLat),
+	}, true, nil
+}
+
+// Adds locations of nodes of PrimitiveGroup wire data.
+func (rb *rawBounds) addGroup(data []byte) error {
+	return rangeFields(data, func(num protowire.Number, typ protowire.Type, b []byte) error {
+		if typ != protowire.BytesType {
+			return nil
+		}
+		switch num {
+		case 1:
+			return rb.addNode(b)
+		case 2:
+			return rb.addDense(b)
+		}
+		return nil
+	})
+}
+
+// Adds location of Node wire data.
+func (rb *rawBounds) addNode(data []byte) error {
+	var lat, lon int64
+	err := rangeFields(data, func(num protowire.Number, typ protowire.Type, b []byte) error {
+		if (num == 8 || num == 9) && typ == protowire.VarintType {
+			v, _ := protowire.ConsumeVarint(b)
+			if num == 8 {
+				lat = protowire.DecodeZigZag(v)
+			} else {
+				lon = protowire.DecodeZigZag(v)
+			}
+		}
+		return nil
+	})
+	if err == nil {
+		rb.add(lat, lon)
+	}
+	return err
+}
+
+// Adds locations of DenseNodes wire data, which are delta coded.
+func (rb *rawBounds) addDense(data []byte) error {
+	var lats, lons []int64
+	err := rangeFields(data, func(num protowire.Number, typ protowire.Type, b []byte) error {
+		if num != 8 && num != 9 {
+			return nil
+		}
+		if typ != protowire.BytesType && typ != protowire.VarintType {
+			return errBlockFormat
+		}
+		var err error
+		if num == 8 {
+			lats, err = appendSint64s(lats, b)
+		} else {
+			lons, err = appendSint64s(lons, b)
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if len(lats) != len(lons) {
+		return errBlockFormat
+	}
+	var lat, lon int64
+	for i := range lats {
+		lat += lats[i]
+		lon += lons[i]
+		rb.add(lat, lon)
+	}
+	return nil
+}
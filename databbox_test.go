@@ -0,0 +1,45 @@
+package osmpbf
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"testing"
+)
+
+func TestDataBBox(t *testing.T) {
+	n := maxBlockEntities*2 + 1
+	data := encodeNodesWays(t, n, 10).Bytes()
+	bbox, ok, err := DataBBox(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := BBox{Left: -float64(n) / 1e3, Right: -1e-3, Top: float64(n) / 1e3, Bottom: 1e-3}
+	if !ok || !bboxEqual(expected, bbox) {
+		t.Errorf("\nExpected: %v\nActual:   %v", expected, bbox)
+	}
+
+	bbox, ok, err = DataBBox(encodeObjects(t,
+		&Node{ID: 1, Lat: 50, Lon: -3},
+		&Node{ID: 2, Lat: -20, Lon: 170.5},
+		&Way{ID: 1, NodeIDs: []int64{1, 2}},
+	))
+	expected = BBox{Left: -3, Right: 170.5, Top: 50, Bottom: -20}
+	if err != nil || !ok || !bboxEqual(expected, bbox) {
+		t.Errorf("\nExpected: %v\nActual:   %v, %v", expected, bbox, err)
+	}
+
+	if _, ok, err := DataBBox(encodeObjects(t, &Way{ID: 1})); ok || err != nil {
+		t.Errorf("expected no bounding box, got %v", err)
+	}
+	if _, _, err := DataBBox(bytes.NewReader(data[:len(data)-10])); err != io.ErrUnexpectedEOF {
+		t.Errorf("expected unexpected EOF, got %v", err)
+	}
+}
+
+// Returns true if a and b differ less than precision of encoded coordinates.
+func bboxEqual(a, b BBox) bool {
+	const e = 1e-7
+	return math.Abs(a.Left-b.Left) < e && math.Abs(a.Right-b.Right) < e &&
+		math.Abs(a.Top-b.Top) < e && math.Abs(a.Bottom-b.Bottom) < e
+}
@@ -2,37 +2,90 @@
 // Use this package by creating a NewDecoder and passing it a PBF file.
 // Use Start to start decoding process.
 // Use Decode to return Node, Way and Relation structs.
+// Use NewEncoder to write them back to PBF file.
+//
+// Full-history files (.osh.pbf, with HistoricalInformation required feature) are supported:
+// each version of an object is returned as a separate struct, in file order, which is
+// by type, then by ID, then by version. Deleted versions have Info.Visible set to false.
 package osmpbf
 
 import (
 	"bytes"
-	"compress/zlib"
+	"context"
 	"encoding/binary"
 	"errors"
 	"fmt"
 	"github.com/brechtbm/osmpbf/OSMPBF"
-	"github.com/gogo/protobuf/proto"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
 	"io"
 	"runtime"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
 const (
-	maxBlobHeaderSize = 64 * 1024
+	// MaxBlobHeaderSize is default maximum BlobHeader size.
+	MaxBlobHeaderSize = 64 * 1024
 
 	initialBlobBufSize = 1 * 1024 * 1024
 
-	// MaxBlobSize is maximum supported blob size.
+	// MaxBlobSize is default maximum Blob size.
 	MaxBlobSize = 32 * 1024 * 1024
 )
 
 var (
 	parseCapabilities = map[string]bool{
-		"OsmSchema-V0.6": true,
-		"DenseNodes":     true,
+		"OsmSchema-V0.6":        true,
+		"DenseNodes":            true,
+		"HistoricalInformation": true,
+		"LocationsOnWays":       true,
 	}
 )
 
+// Kind is the type of OSM object.
+type Kind int
+
+const (
+	NodeKind Kind = iota
+	WayKind
+	RelationKind
+
+	// BoundKind is kind of Bound, returned only if WithBound is set.
+	BoundKind
+)
+
+func (k Kind) String() string {
+	switch k {
+	case NodeKind:
+		return "node"
+	case WayKind:
+		return "way"
+	case RelationKind:
+		return "relation"
+	case BoundKind:
+		return "bound"
+	default:
+		return fmt.Sprintf("Kind(%d)", int(k))
+	}
+}
+
+// Object is implemented by pointers to Node, Way and Relation structs, and to Bound.
+// Use Kind method for dispatching without type switch.
+type Object interface {
+	Kind() Kind
+
+	// Release returns object to pool, see Decoder.Recycle.
+	Release()
+
+	// unexported method prevents implementations outside of this package
+	object()
+}
+
+// Info is metadata of object. It is kept by value, so objects need no separate allocation for it.
+// Fields other than Visible are zero if object has no metadata, all of them if it is skipped by
+// WithSkipInfo.
 type Info struct {
 	Version   int16
 	Timestamp time.Time
@@ -43,27 +96,67 @@ type Info struct {
 }
 
 type Node struct {
-	ID   int64
-	Lat  float64
-	Lon  float64
+	ID  int64
+	Lat float64
+	Lon float64
+
 	Tags map[string]string
+
+	// TagList is set instead of Tags if enabled by Decoder.SetTagList.
+	TagList TagList
+
 	Info Info
+
+	// Raw is location as stored in PBF data, nil unless enabled by Decoder.SetRawLocations.
+	Raw *RawLocation
 }
 
 type Way struct {
 	ID      int64
 	Tags    map[string]string
+	TagList TagList // see Node.TagList
 	NodeIDs []int64
-	Info    Info
+
+	// NodeLocations are locations of NodeIDs, only present in files with LocationsOnWays feature.
+	NodeLocations []LatLon
+
+	Info Info
 }
 
 type Relation struct {
 	ID      int64
 	Tags    map[string]string
+	TagList TagList // see Node.TagList
 	Members []Member
 	Info    Info
 }
 
+// Bound is bounding box of input stream from OSMHeader. If WithBound is set, it is returned
+// by Decode before objects of the input stream.
+type Bound struct {
+	BBox
+}
+
+// Kind returns NodeKind.
+func (*Node) Kind() Kind { return NodeKind }
+
+// Kind returns WayKind.
+func (*Way) Kind() Kind { return WayKind }
+
+// Kind returns RelationKind.
+func (*Relation) Kind() Kind { return RelationKind }
+
+// Kind returns BoundKind.
+func (*Bound) Kind() Kind { return BoundKind }
+
+// Release does nothing, bounds are not pooled.
+func (*Bound) Release() {}
+
+func (*Node) object()     {}
+func (*Way) object()      {}
+func (*Relation) object() {}
+func (*Bound) object()    {}
+
 type MemberType int
 
 const (
@@ -79,109 +172,505 @@ type Member struct {
 }
 
 type pair struct {
-	i interface{}
-	e error
+	i      interface{}
+	e      error
+	offset int64 // of fileblock value comes from
+}
+
+// Options for data decoders, copied to each of them by Start.
+type decodeOptions struct {
+	skipNodes     bool
+	skipWays      bool
+	skipRelations bool
+	skipInfo      bool
+	internSize    int
+	tagList       bool
+	rawLocations  bool
+
+	// check data for violations of specification
+	strict bool
+
+	// return Bound from OSMHeader before objects
+	bound bool
+
+	// constructor of zlib readers, zlib.NewReader if nil
+	zlibReader func(r io.Reader) (io.ReadCloser, error)
+
+	// constructors of custom decompressors by compression method
+	decompressors map[Compression]func() Decompressor
+
+	filter     func(kind Kind, tags map[string]string) bool
+	infoFilter *InfoFilter
+	region     Region
+
+	// nil if statistics are not collected
+	stats *Stats
 }
 
 // A Decoder reads and decodes OpenStreetMap PBF data from an input stream.
 type Decoder struct {
+	// accessed atomically, kept first for alignment
+	decodedOffset int64 // of fileblock of the last object returned by Decode
+	progress      Progress
+
 	r          io.Reader
 	serializer chan *pair
 
-	buf *bytes.Buffer
+	// set in ReaderAt mode, r is section reader over ra then
+	ra io.ReaderAt
+	sr *io.SectionReader
+
+	// closed by Close, if set by constructor
+	closer io.Closer
+
+	buf    *bytes.Buffer
+	header *Header
+
+	// OSMHeaders of input streams concatenated to the first one
+	headersMu sync.Mutex
+	headers   []*Header
+
+	// position in input stream, updated by reader goroutine
+	offset      int64
+	startOffset int64
+	skipTo      Kind // NodeKind if not skipping
+	handler     Handler
+	unordered   bool
+
+	// corrupt fileblocks are skipped and collected if skipCorrupt is set
+	skipCorrupt bool
+	skippedMu   sync.Mutex
+	skipped     []*CorruptBlockError
+
+	// number and queue size of data decoders, maximum number of blobs being decoded
+	workers     int
+	queueSize   int
+	maxInFlight int
+	headerRead  bool
+
+	maxBlobHeaderSize uint32
+	maxBlobSize       int32
+
+	opts        decodeOptions
+	spatialMode SpatialMode
+	locations   NodeLocationStore
+	versions    *versionFilter // settings of version filter, nil if all versions are returned
+	skipBlobs   map[int64]bool // offsets of fileblocks skipped by WithIndexBBox
+	transforms  []Transform    // applied by serializer goroutine
 
 	// for data decoders
-	inputs  []chan<- *pair
-	outputs []<-chan *pair
+	inputs       []chan<- *pair
+	outputs      []<-chan *pair
+	dataDecoders []*dataDecoder
+
+	// closed to stop all decoding goroutines, err is returned by Decode after that
+	done     chan struct{}
+	stopOnce sync.Once
+	err      error
+
+	// closed when all decoded objects are sent to serializer
+	finished chan struct{}
+
+	// for reader, data decoders and serializer goroutines
+	wg      sync.WaitGroup
+	started bool
 }
 
-// NewDecoder returns a new decoder that reads from r.
-func NewDecoder(r io.Reader) *Decoder {
+// NewDecoder returns a new decoder that reads from r, configured by options.
+func NewDecoder(r io.Reader, opts ...Option) *Decoder {
 	d := &Decoder{
 		r:          r,
 		serializer: make(chan *pair, 8000), // typical PrimitiveBlock contains 8k OSM entities
+		done:       make(chan struct{}),
+		finished:   make(chan struct{}),
+
+		maxBlobHeaderSize: MaxBlobHeaderSize,
+		maxBlobSize:       MaxBlobSize,
+		queueSize:         -1, // chosen by Start
 	}
 	d.SetBufferSize(initialBlobBufSize)
+	for _, opt := range opts {
+		opt(d)
+	}
 	return d
 }
 
-// SetBufferSize sets initial size of decoding buffer. Default value is 1MB, you can set higher value
-// (for example, MaxBlobSize) for (probably) faster decoding, or lower value for reduced memory consumption.
-// Any value will produce valid results; buffer will grow automatically if required.
+// NewReaderAtDecoder returns a new decoder that reads size bytes from r. Only BlobHeaders are read
+// sequentially, Blobs are read and decoded by data decoder goroutines independently, so reading
+// is not a bottleneck on fast storage. r must be safe for concurrent ReadAt calls, as *os.File is.
+func NewReaderAtDecoder(r io.ReaderAt, size int64, opts ...Option) *Decoder {
+	sr := io.NewSectionReader(r, 0, size)
+	d := NewDecoder(sr, opts...)
+	d.ra = r
+	d.sr = sr
+	return d
+}
+
+// SetBufferSize is the same as WithBufferSize option. Must be called before Start.
 func (dec *Decoder) SetBufferSize(n int) {
-	dec.buf = bytes.NewBuffer(make([]byte, 0, n))
+	WithBufferSize(n)(dec)
+}
+
+// SetMaxBlobHeaderSize is the same as WithMaxBlobHeaderSize option. Must be called before Start.
+func (dec *Decoder) SetMaxBlobHeaderSize(n int) {
+	WithMaxBlobHeaderSize(n)(dec)
 }
 
-// Start decoding process using n goroutines.
+// SetMaxBlobSize is the same as WithMaxBlobSize option. Must be called before Start.
+func (dec *Decoder) SetMaxBlobSize(n int) {
+	WithMaxBlobSize(n)(dec)
+}
+
+// SetStartOffset is the same as WithStartOffset option. Must be called before Start.
+func (dec *Decoder) SetStartOffset(offset int64) {
+	WithStartOffset(offset)(dec)
+}
+
+// Offset returns offset of fileblock containing the object last returned by Decode, or start offset
+// if none were returned yet. Decoding can be resumed from it with SetStartOffset, repeating objects
+// of that fileblock which were already returned.
+func (dec *Decoder) Offset() int64 {
+	return atomic.LoadInt64(&dec.decodedOffset)
+}
+
+// SkipToWays makes decoder skip fileblocks until the first one containing ways, and skip nodes
+// in it. Skipped fileblocks are uncompressed to check their contents, but objects are not decoded.
+// If Index is available, SetStartOffset with Index.First is faster. Must be called before Start.
+func (dec *Decoder) SkipToWays() {
+	dec.skipTo = WayKind
+	dec.opts.skipNodes = true
+}
+
+// SkipToRelations makes decoder skip fileblocks until the first one containing relations,
+// see SkipToWays.
+func (dec *Decoder) SkipToRelations() {
+	dec.skipTo = RelationKind
+	dec.opts.skipNodes = true
+	dec.opts.skipWays = true
+}
+
+// SetUnordered is the same as WithUnordered option. Must be called before Start.
+func (dec *Decoder) SetUnordered(unordered bool) {
+	WithUnordered(unordered)(dec)
+}
+
+// SetStrict is the same as WithStrict option. Must be called before Start.
+func (dec *Decoder) SetStrict(strict bool) {
+	WithStrict(strict)(dec)
+}
+
+// SetBound is the same as WithBound option. Must be called before Start.
+func (dec *Decoder) SetBound(bound bool) {
+	WithBound(bound)(dec)
+}
+
+// SetZlibReader is the same as WithZlibReader option. Must be called before Start.
+func (dec *Decoder) SetZlibReader(newReader func(r io.Reader) (io.ReadCloser, error)) {
+	WithZlibReader(newReader)(dec)
+}
+
+// SetDecompressor is the same as WithDecompressor option. Must be called before Start.
+func (dec *Decoder) SetDecompressor(c Compression, newDecompressor func() Decompressor) {
+	WithDecompressor(c, newDecompressor)(dec)
+}
+
+// SetSkipCorrupt is the same as WithSkipCorrupt option. Must be called before Start.
+func (dec *Decoder) SetSkipCorrupt(skip bool) {
+	WithSkipCorrupt(skip)(dec)
+}
+
+// SetOutputBuffer is the same as WithOutputBuffer option. Must be called before Start.
+func (dec *Decoder) SetOutputBuffer(n int) {
+	WithOutputBuffer(n)(dec)
+}
+
+// SetQueueSize is the same as WithQueueSize option. Must be called before Start.
+func (dec *Decoder) SetQueueSize(n int) {
+	WithQueueSize(n)(dec)
+}
+
+// SetMaxInFlight is the same as WithMaxInFlight option. Must be called before Start.
+func (dec *Decoder) SetMaxInFlight(n int) {
+	WithMaxInFlight(n)(dec)
+}
+
+// SetSkipNodes is the same as WithSkipNodes option. Must be called before Start.
+func (dec *Decoder) SetSkipNodes(skip bool) {
+	WithSkipNodes(skip)(dec)
+}
+
+// SetSkipWays is the same as WithSkipWays option. Must be called before Start.
+func (dec *Decoder) SetSkipWays(skip bool) {
+	WithSkipWays(skip)(dec)
+}
+
+// SetSkipRelations is the same as WithSkipRelations option. Must be called before Start.
+func (dec *Decoder) SetSkipRelations(skip bool) {
+	WithSkipRelations(skip)(dec)
+}
+
+// SetSkipInfo is the same as WithSkipInfo option. Must be called before Start.
+func (dec *Decoder) SetSkipInfo(skip bool) {
+	WithSkipInfo(skip)(dec)
+}
+
+// SetInternStrings is the same as WithInternStrings option. Must be called before Start.
+func (dec *Decoder) SetInternStrings(n int) {
+	WithInternStrings(n)(dec)
+}
+
+// SetFilter is the same as WithFilter option. Must be called before Start.
+func (dec *Decoder) SetFilter(filter func(kind Kind, tags map[string]string) bool) {
+	WithFilter(filter)(dec)
+}
+
+// Header returns metadata from OSMHeader block of the input stream, or nil if decoding was not started.
+// Header is read by Start, so it is available as soon as Start returns.
+func (dec *Decoder) Header() *Header {
+	return dec.header
+}
+
+// Headers returns metadata from all OSMHeader blocks read so far: the first one is returned by Header,
+// following ones start input streams concatenated to the first one. It is safe to call concurrently
+// with Decode.
+func (dec *Decoder) Headers() []*Header {
+	if dec.header == nil {
+		return nil
+	}
+	dec.headersMu.Lock()
+	defer dec.headersMu.Unlock()
+	return append([]*Header{dec.header}, dec.headers...)
+}
+
+func (dec *Decoder) addHeader(h *Header) {
+	dec.headersMu.Lock()
+	dec.headers = append(dec.headers, h)
+	dec.headersMu.Unlock()
+}
+
+// Start decoding process using n goroutines. If n <= 0, number set by WithWorkers is used, or
+// runtime.GOMAXPROCS(0) goroutines with automatically chosen queue size, unless it is set by WithQueueSize.
 func (dec *Decoder) Start(n int) error {
+	return dec.StartWithContext(context.Background(), n)
+}
+
+// StartWithContext starts decoding process using n goroutines. Decoding stops when ctx is cancelled:
+// all goroutines exit and Decode returns ctx.Err(). See Start for meaning of n.
+func (dec *Decoder) StartWithContext(ctx context.Context, n int) error {
+	queueSize := dec.queueSize
 	if n < 1 {
-		n = 1
+		n = dec.workers
 	}
-
-	// read OSMHeader
-	blobHeader, blob, err := dec.readFileBlock()
-	if err == nil {
-		if blobHeader.GetType() == "OSMHeader" {
-			err = decodeOSMHeader(blob)
-		} else {
-			err = fmt.Errorf("unexpected first fileblock of type %s", blobHeader.GetType())
+	if n < 1 {
+		n = runtime.GOMAXPROCS(0)
+		if queueSize < 0 {
+			// read one blob ahead for each goroutine
+			queueSize = 1
 		}
 	}
-	if err != nil {
+	if queueSize < 0 {
+		queueSize = 0
+	}
+
+	select {
+	case <-dec.done:
+		return errors.New("decoder is closed")
+	default:
+	}
+
+	if dec.unordered && dec.opts.region != nil && dec.spatialMode > SpatialNodes {
+		return errors.New("spatial filtering of ways and relations requires ordered decoding")
+	}
+	if dec.opts.infoFilter != nil && dec.opts.skipInfo {
+		return errors.New("info filter requires decoding of Info")
+	}
+	if dec.unordered && dec.locations != nil {
+		return errors.New("node locations require ordered decoding")
+	}
+	if dec.unordered && dec.versions != nil {
+		return errors.New("version filter requires ordered decoding")
+	}
+
+	if err := dec.readOSMHeader(); err != nil {
 		return err
 	}
 
-	// Memory probblem, force GC every 3 seconds while decoding
-	// Better solution needed...
-	go func() {
-		for {
-			select {
-			case <-time.After(3 * time.Second):
-				runtime.GC()
-			}
-		}
-	}()
+	var blobHeader *OSMPBF.BlobHeader
+	var err error
+
+	dec.started = true
+	dec.wg.Add(n + 2)
+
+	// acquired by reader for each sent blob, released by serializer
+	var inFlight chan struct{}
+	if dec.maxInFlight > 0 {
+		inFlight = make(chan struct{}, dec.maxInFlight)
+	}
+
+	// in unordered mode all data decoders send to one output, the last one closes it
+	var shared chan *pair
+	remaining := int32(n)
+	if dec.unordered {
+		shared = make(chan *pair, queueSize)
+		dec.outputs = append(dec.outputs, shared)
+	}
 
 	// start data decoders
 	for i := 0; i < n; i++ {
-		input := make(chan *pair)
-		output := make(chan *pair)
+		input := make(chan *pair, queueSize)
+		output := make(chan *pair, queueSize)
+		if dec.unordered {
+			output = shared
+		}
+		// data decoders and their buffers are kept for reuse after Reset
+		if i == len(dec.dataDecoders) {
+			dec.dataDecoders = append(dec.dataDecoders, new(dataDecoder))
+		}
+		dd := dec.dataDecoders[i]
+		dd.opts = dec.opts
+		dd.r = dec.ra
+		dd.inflater.newZlib = dec.opts.zlibReader
+		dd.inflater.newDecompressors = dec.opts.decompressors
+		dd.inflater.maxRawSize = dec.maxBlobSize
 		go func() {
-			dd := new(dataDecoder)
+			defer dec.wg.Done()
+
 			for p := range input {
-				if p.e == nil {
-					// send decoded objects or decoding error
-					objects, err := dd.Decode(p.i.(*OSMPBF.Blob))
-					output <- &pair{objects, err}
-				} else {
-					// send input error as is
-					output <- &pair{nil, p.e}
+				// OSMHeader of concatenated input stream is sent to serializer as is
+				if _, ok := p.i.(*Header); p.e == nil && !ok {
+					// send decoded objects or decoding error, input error is sent as is
+					var objects *[]interface{}
+					blob, err := dd.blob(p.i)
+					if err == nil {
+						objects, err = dd.Decode(blob)
+					}
+					// decoded objects do not reference Blob data
+					releaseBlob(p.i)
+					if ve, ok := err.(*ValidationError); ok {
+						ve.Offset = p.offset
+					}
+					var v interface{}
+					if err != nil && dec.skipCorrupt {
+						// nothing is sent for corrupt blob, except offset
+						dec.addSkipped(p.offset, err)
+						err = nil
+					} else if err == nil {
+						atomic.AddInt64(&dec.progress.Blobs, 1)
+						if dec.handler != nil {
+							// objects are passed to handler here, only errors are sent
+							dec.handle(objects)
+						} else {
+							v = objects
+						}
+					}
+					p = &pair{v, err, p.offset}
+				}
+				select {
+				case output <- p:
+				case <-dec.done:
 				}
 			}
-			close(output)
+			if !dec.unordered || atomic.AddInt32(&remaining, -1) == 0 {
+				close(output)
+			}
 		}()
 
 		dec.inputs = append(dec.inputs, input)
-		dec.outputs = append(dec.outputs, output)
+		if !dec.unordered {
+			dec.outputs = append(dec.outputs, output)
+		}
 	}
 
 	// start reading OSMData
 	go func() {
+		defer dec.wg.Done()
+
+		stats := dec.opts.stats
+		skipTo := dec.skipTo
 		var inputIndex int
 		for {
-			input := dec.inputs[inputIndex]
-			inputIndex = (inputIndex + 1) % n
-
-			blobHeader, blob, err = dec.readFileBlock()
-			if err == nil && blobHeader.GetType() != "OSMData" {
-				err = fmt.Errorf("unexpected fileblock of type %s", blobHeader.GetType())
+			var start time.Time
+			if stats != nil {
+				start = time.Now()
 			}
-			if err == nil {
-				// send blob for decoding
-				input <- &pair{blob, nil}
+
+			// in ReaderAt mode data decoder reads Blob itself, unless reader checks its contents
+			offset := dec.offset
+			var v interface{}
+			if dec.ra != nil && skipTo == NodeKind {
+				blobHeader, v, err = dec.readFileBlockRef()
 			} else {
+				blobHeader, v, err = dec.readFileBlockPooled()
+			}
+			if stats != nil && err == nil {
+				addSince(&stats.ReadTime, start)
+				atomic.AddInt64(&stats.Blobs, 1)
+			}
+			if err == nil && dec.skipBlobs[offset] {
+				// outside of bounding box of WithIndexBBox
+				releaseBlob(v)
+				select {
+				case <-dec.done:
+					err = io.EOF
+				default:
+					continue
+				}
+			}
+			if err == nil && blobHeader.GetType() == "OSMHeader" {
+				// input stream is concatenation of several streams, each starting with OSMHeader
+				var h *Header
+				h, err = dec.readNextOSMHeader(v)
+				releaseBlob(v)
+				v = h
+			} else if err == nil && blobHeader.GetType() != "OSMData" {
+				err = &UnexpectedBlockTypeError{blobHeader.GetType(), "OSMData"}
+			}
+			if err == nil && skipTo != NodeKind && blobHeader.GetType() == "OSMData" {
+				// fast-forward to the first fileblock containing objects of interest
+				var found bool
+				found, err = blobContains(v.(*pooledBlob).blob, skipTo, dec.buf)
+				if err == nil && !found {
+					releaseBlob(v)
+					select {
+					case <-dec.done:
+						err = io.EOF
+					default:
+						continue
+					}
+				}
+				if err == nil {
+					skipTo = NodeKind
+				}
+			}
+			if err != nil && blobHeader != nil && dec.skipCorrupt {
+				// BlobHeader is read, so the next fileblock can be read after this one
+				dec.addSkipped(offset, err)
+				releaseBlob(v)
+				continue
+			}
+			atomic.StoreInt64(&dec.progress.Bytes, dec.offset)
+			p := &pair{v, err, offset}
+			if err != nil {
 				// send input error as is
-				input <- &pair{nil, err}
+				releaseBlob(v)
+				p.i = nil
+			}
+
+			if inFlight != nil {
+				select {
+				case inFlight <- struct{}{}:
+				case <-dec.done:
+				}
+			}
+
+			input := dec.inputs[inputIndex]
+			inputIndex = (inputIndex + 1) % n
+			select {
+			case input <- p:
+			case <-dec.done:
+				err = io.EOF
+			}
+			if err != nil {
 				for _, input := range dec.inputs {
 					close(input)
 				}
@@ -190,45 +679,341 @@ func (dec *Decoder) Start(n int) error {
 		}
 	}()
 
+	var spatial *spatialFilter
+	if dec.opts.region != nil {
+		spatial = newSpatialFilter(dec.spatialMode)
+	}
+	var versions *versionFilter
+	if dec.versions != nil {
+		versions = dec.versions.clone()
+	}
+
 	go func() {
+		defer dec.wg.Done()
+		defer close(dec.finished)
+		defer close(dec.serializer)
+
+		// in strict mode sort order is checked across fileblocks, data decoders check it within fileblock
+		newOrder := func(h *Header) *sortOrder {
+			if dec.opts.strict && isSorted(h) && !dec.unordered {
+				return new(sortOrder)
+			}
+			return nil
+		}
+		order := newOrder(dec.header)
+
+		// sends Bound of input stream with header h, returns false if stopped
+		sendBound := func(h *Header, offset int64) bool {
+			if !dec.opts.bound || h == nil || h.BBox == nil {
+				return true
+			}
+			select {
+			case dec.serializer <- &pair{&Bound{*h.BBox}, nil, offset}:
+				return true
+			case <-dec.done:
+				return false
+			}
+		}
+		if !sendBound(dec.header, dec.startOffset) {
+			return
+		}
+
+		// sends the last version kept by version filter, returns false if stopped
+		flushVersions := func() bool {
+			if versions == nil {
+				return true
+			}
+			o, offset := versions.flush()
+			if o == nil {
+				return true
+			}
+			if spatial != nil && !spatial.keep(o) {
+				o.Release()
+				return true
+			}
+			if o = applyTransforms(dec.transforms, o, true); o == nil {
+				return true
+			}
+			select {
+			case dec.serializer <- &pair{o, nil, offset}:
+				return true
+			case <-dec.done:
+				return false
+			}
+		}
+
 		var outputIndex int
 		for {
 			output := dec.outputs[outputIndex]
-			outputIndex = (outputIndex + 1) % n
+			outputIndex = (outputIndex + 1) % len(dec.outputs)
 
-			p := <-output
+			var p *pair
+			select {
+			case p = <-output:
+			case <-dec.done:
+			}
+			if p == nil {
+				// stopped
+				return
+			}
+
+			if h, ok := p.i.(*Header); ok {
+				// sort order starts over in concatenated input stream
+				if !flushVersions() {
+					return
+				}
+				dec.addHeader(h)
+				order = newOrder(h)
+				p.i = nil
+				if p.e == nil && !sendBound(h, p.offset) {
+					return
+				}
+			}
 			if p.i != nil {
 				// send decoded objects one by one
-				for _, o := range p.i.([]interface{}) {
-					dec.serializer <- &pair{o, nil}
+				q := p.i.(*[]interface{})
+				objects := *q
+				for i, o := range objects {
+					if order != nil && p.e == nil {
+						if err := order.next(kindID(o.(Object))); err != nil {
+							// objects from the first unsorted one are dropped, error is sent after them
+							p.e = &ValidationError{p.offset, err}
+						}
+					}
+					if dec.locations != nil && p.e == nil {
+						if err := resolveLocations(dec.locations, o.(Object)); err != nil {
+							p.e = err
+						}
+					}
+					offset := p.offset
+					if versions != nil && p.e == nil {
+						// previous object is sent when its last version is known
+						objects[i] = nil
+						var prev Object
+						if prev, offset = versions.next(o.(Object), offset); prev == nil {
+							continue
+						}
+						o = prev
+					}
+					if p.e != nil || spatial != nil && !spatial.keep(o) {
+						o.(Object).Release()
+						objects[i] = nil
+						continue
+					}
+					if o = applyTransforms(dec.transforms, o.(Object), true); o == nil {
+						objects[i] = nil
+						continue
+					}
+
+					select {
+					case dec.serializer <- &pair{o, nil, offset}:
+						objects[i] = nil
+					case <-dec.done:
+						return
+					}
 				}
+				putObjects(q)
+			}
+			if inFlight != nil {
+				<-inFlight
+			}
+			if p.e == io.EOF && dec.unordered {
+				// other data decoders may be still working, output is closed after them
+				continue
+			}
+			if p.e == io.EOF && !flushVersions() {
+				return
 			}
 			if p.e != nil {
 				// send input or decoding error
-				dec.serializer <- &pair{nil, p.e}
-				close(dec.serializer)
+				select {
+				case dec.serializer <- &pair{nil, p.e, p.offset}:
+				case <-dec.done:
+				}
 				return
 			}
 		}
 	}()
 
+	if ctx.Done() != nil {
+		// waited for by Close and Reset, so it does not stop decoding of the next input stream
+		dec.wg.Add(1)
+		go func() {
+			defer dec.wg.Done()
+			select {
+			case <-ctx.Done():
+				dec.stop(ctx.Err())
+			case <-dec.finished:
+			}
+		}()
+	}
+
 	return nil
 }
 
+// Reads OSMHeader, or positions input at start offset if it is set.
+func (dec *Decoder) readOSMHeader() error {
+	dec.headerRead = true
+	dec.decodedOffset = dec.startOffset
+	if dec.startOffset > 0 {
+		// resume decoding without OSMHeader
+		if dec.sr != nil {
+			if _, err := dec.sr.Seek(dec.startOffset, io.SeekStart); err != nil {
+				return err
+			}
+		}
+		dec.offset = dec.startOffset
+		return nil
+	}
+
+	blobHeader, blob, err := dec.readFileBlock()
+	if err != nil {
+		return err
+	}
+	if blobHeader.GetType() != "OSMHeader" {
+		return &UnexpectedBlockTypeError{blobHeader.GetType(), "OSMHeader"}
+	}
+	dec.header, err = decodeOSMHeader(blob)
+	return err
+}
+
+// Decodes OSMHeader found after OSMData, which starts concatenated input stream. Its required
+// features are checked in the same way as for the first OSMHeader.
+func (dec *Decoder) readNextOSMHeader(v interface{}) (*Header, error) {
+	// in ReaderAt mode Blob is not read yet
+	blob, err := (&dataDecoder{r: dec.ra}).blob(v)
+	if err != nil {
+		return nil, err
+	}
+	return decodeOSMHeader(blob)
+}
+
 // Decode reads the next object from the input stream and returns either a
 // pointer to Node, Way or Relation struct representing the underlying OpenStreetMap PBF
 // data, or error encountered. The end of the input stream is reported by an io.EOF error.
+// Returned value always implements Object interface.
 //
 // Decode is safe for parallel execution. Only first error encountered will be returned,
 // subsequent invocations will return io.EOF.
+//
+// If decoding was stopped (for example, context passed to StartWithContext was cancelled),
+// Decode returns the reason without returning remaining objects.
 func (dec *Decoder) Decode() (interface{}, error) {
+	select {
+	case <-dec.done:
+		return nil, dec.err
+	default:
+	}
+
 	p, ok := <-dec.serializer
 	if !ok {
-		return nil, io.EOF
+		select {
+		case <-dec.done:
+			return nil, dec.err
+		default:
+			return nil, io.EOF
+		}
+	}
+	if p.e == nil {
+		atomic.StoreInt64(&dec.decodedOffset, p.offset)
+		atomic.AddInt64(&dec.progress.Objects, 1)
 	}
 	return p.i, p.e
 }
 
+// Close stops decoding and releases resources: waits for all goroutines to exit, drains
+// decoded objects and frees buffers. If the goroutine reading input stream is blocked in
+// a read call, Close waits for it to return.
+//
+// Close is safe to call at any point, including before Start and more than once.
+// Subsequent Decode calls return io.EOF.
+func (dec *Decoder) Close() error {
+	dec.stop(io.EOF)
+	dec.wg.Wait()
+
+	if dec.started {
+		for range dec.serializer {
+		}
+	}
+	dec.inputs = nil
+	dec.outputs = nil
+	dec.dataDecoders = nil
+	dec.buf = nil
+
+	if dec.closer != nil {
+		err := dec.closer.Close()
+		dec.closer = nil
+		return err
+	}
+	return nil
+}
+
+// Reset stops decoding of the current input stream, like Close, and prepares decoder for decoding
+// of r from its beginning, with the same options and handler. Buffers and state of data decoders
+// (decompressors, parsing buffers, interned strings) are reused, so decoding many small files by one
+// decoder allocates less than creating a new decoder for each of them. Start must be called again.
+// Progress, statistics, headers and skipped fileblocks start over; start offset and fileblocks
+// skipped by WithIndexBBox are cleared, as they are specific to input stream. Decoder reads r in streaming mode, even if it was created in ReaderAt
+// mode; closer of the previous input stream, like memory-mapped file, is closed and its error
+// is returned.
+func (dec *Decoder) Reset(r io.Reader) error {
+	dec.stop(io.EOF)
+	dec.wg.Wait()
+	if dec.started {
+		for range dec.serializer {
+		}
+	}
+
+	var err error
+	if dec.closer != nil {
+		err = dec.closer.Close()
+	}
+
+	buf := dec.buf
+	if buf == nil {
+		// closed
+		buf = bytes.NewBuffer(make([]byte, 0, initialBlobBufSize))
+	}
+	buf.Reset()
+	opts := dec.opts
+	if opts.stats != nil {
+		opts.stats = new(Stats)
+	}
+
+	*dec = Decoder{
+		r:          r,
+		serializer: make(chan *pair, cap(dec.serializer)),
+		done:       make(chan struct{}),
+		finished:   make(chan struct{}),
+		buf:        buf,
+
+		skipTo:            dec.skipTo,
+		handler:           dec.handler,
+		unordered:         dec.unordered,
+		skipCorrupt:       dec.skipCorrupt,
+		workers:           dec.workers,
+		queueSize:         dec.queueSize,
+		maxInFlight:       dec.maxInFlight,
+		maxBlobHeaderSize: dec.maxBlobHeaderSize,
+		maxBlobSize:       dec.maxBlobSize,
+		opts:              opts,
+		spatialMode:       dec.spatialMode,
+		locations:         dec.locations,
+		versions:          dec.versions,
+		transforms:        dec.transforms,
+		dataDecoders:      dec.dataDecoders,
+	}
+	return err
+}
+
+// stop signals all decoding goroutines to exit. Subsequent Decode calls will return err.
+func (dec *Decoder) stop(err error) {
+	dec.stopOnce.Do(func() {
+		dec.err = err
+		close(dec.done)
+	})
+}
+
 func (dec *Decoder) readFileBlock() (*OSMPBF.BlobHeader, *OSMPBF.Blob, error) {
 	blobHeaderSize, err := dec.readBlobHeaderSize()
 	if err != nil {
@@ -240,31 +1025,125 @@ func (dec *Decoder) readFileBlock() (*OSMPBF.BlobHeader, *OSMPBF.Blob, error) {
 		return nil, nil, err
 	}
 
+	// BlobHeader is returned with Blob error, so corrupt Blob can be skipped
 	blob, err := dec.readBlob(blobHeader)
 	if err != nil {
-		return nil, nil, err
+		return blobHeader, nil, err
 	}
 
 	return blobHeader, blob, err
 }
 
+// Reads BlobHeader and Blob, see readFileBlock. Blob is unmarshalled in place from its own buffer
+// taken from blobBufPool, so it can be handed to data decoder, which returns buffer to pool.
+func (dec *Decoder) readFileBlockPooled() (*OSMPBF.BlobHeader, *pooledBlob, error) {
+	blobHeaderSize, err := dec.readBlobHeaderSize()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	blobHeader, err := dec.readBlobHeader(blobHeaderSize)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	size := int(blobHeader.GetDatasize())
+	buf := blobBufPool.Get().(*[]byte)
+	if cap(*buf) < size {
+		*buf = make([]byte, size)
+	}
+	data := (*buf)[:size]
+	n, err := io.ReadFull(dec.r, data)
+	dec.offset += int64(n)
+	if err == io.ErrUnexpectedEOF {
+		// truncated input stream ends like in readBlob
+		err = io.EOF
+	}
+	if err != nil {
+		blobBufPool.Put(buf)
+		return blobHeader, nil, err
+	}
+
+	blob, err := unmarshalBlobInPlace(data)
+	if err != nil {
+		blobBufPool.Put(buf)
+		return blobHeader, nil, err
+	}
+	return blobHeader, &pooledBlob{blob, buf}, nil
+}
+
+// Reads BlobHeader and skips Blob, returning reference to it.
+func (dec *Decoder) readFileBlockRef() (*OSMPBF.BlobHeader, blobRef, error) {
+	blobHeaderSize, err := dec.readBlobHeaderSize()
+	if err != nil {
+		return nil, blobRef{}, err
+	}
+
+	blobHeader, err := dec.readBlobHeader(blobHeaderSize)
+	if err != nil {
+		return nil, blobRef{}, err
+	}
+
+	size := blobHeader.GetDatasize()
+	if _, err := dec.sr.Seek(int64(size), io.SeekCurrent); err != nil {
+		return nil, blobRef{}, err
+	}
+	ref := blobRef{dec.offset, size}
+	dec.offset += int64(size)
+	return blobHeader, ref, nil
+}
+
+// Returns true if OSMData blob contains objects of kind k or following kinds. Only fields of
+// PrimitiveGroups in wire data are checked, like by Count, objects are not decoded.
+func blobContains(blob *OSMPBF.Blob, k Kind, buf *bytes.Buffer) (bool, error) {
+	data, err := getData(blob, buf)
+	if err != nil {
+		return false, err
+	}
+	found := false
+	err = rangeFields(data, func(num protowire.Number, typ protowire.Type, b []byte) error {
+		if num != 2 || typ != protowire.BytesType || found {
+			return nil
+		}
+		return rangeFields(b, func(num protowire.Number, typ protowire.Type, b []byte) error {
+			if typ != protowire.BytesType {
+				return nil
+			}
+			switch num {
+			case 1, 2:
+				found = found || k <= NodeKind
+			case 3:
+				found = found || k <= WayKind
+			case 4:
+				found = found || k <= RelationKind
+			}
+			return nil
+		})
+	})
+	return found, err
+}
+
 func (dec *Decoder) readBlobHeaderSize() (uint32, error) {
 	dec.buf.Reset()
-	if _, err := io.CopyN(dec.buf, dec.r, 4); err != nil {
+	n, err := io.CopyN(dec.buf, dec.r, 4)
+	dec.offset += n
+	if err != nil {
 		return 0, err
 	}
 
 	size := binary.BigEndian.Uint32(dec.buf.Bytes())
 
-	if size >= maxBlobHeaderSize {
-		return 0, errors.New("BlobHeader size >= 64Kb")
+	if size >= dec.maxBlobHeaderSize {
+		return 0, fmt.Errorf("%w: %d >= %d", ErrBlobHeaderTooLarge, size, dec.maxBlobHeaderSize)
 	}
 	return size, nil
 }
 
 func (dec *Decoder) readBlobHeader(size uint32) (*OSMPBF.BlobHeader, error) {
 	dec.buf.Reset()
-	if _, err := io.CopyN(dec.buf, dec.r, int64(size)); err != nil {
+	n, err := io.CopyN(dec.buf, dec.r, int64(size))
+	dec.offset += n
+	if err != nil {
 		return nil, err
 	}
 
@@ -273,15 +1152,20 @@ func (dec *Decoder) readBlobHeader(size uint32) (*OSMPBF.BlobHeader, error) {
 		return nil, err
 	}
 
-	if blobHeader.GetDatasize() >= MaxBlobSize {
-		return nil, errors.New("Blob size >= 32Mb")
+	if blobHeader.GetDatasize() < 0 {
+		return nil, fmt.Errorf("negative Blob size %d", blobHeader.GetDatasize())
+	}
+	if blobHeader.GetDatasize() >= dec.maxBlobSize {
+		return nil, fmt.Errorf("%w: %d >= %d", ErrBlobTooLarge, blobHeader.GetDatasize(), dec.maxBlobSize)
 	}
 	return blobHeader, nil
 }
 
 func (dec *Decoder) readBlob(blobHeader *OSMPBF.BlobHeader) (*OSMPBF.Blob, error) {
 	dec.buf.Reset()
-	if _, err := io.CopyN(dec.buf, dec.r, int64(blobHeader.GetDatasize())); err != nil {
+	n, err := io.CopyN(dec.buf, dec.r, int64(blobHeader.GetDatasize()))
+	dec.offset += n
+	if err != nil {
 		return nil, err
 	}
 
@@ -292,50 +1176,24 @@ func (dec *Decoder) readBlob(blobHeader *OSMPBF.BlobHeader) (*OSMPBF.Blob, error
 	return blob, nil
 }
 
-func getData(blob *OSMPBF.Blob) ([]byte, error) {
-	switch {
-	case blob.Raw != nil:
-		return blob.GetRaw(), nil
-
-	case blob.ZlibData != nil:
-		r, err := zlib.NewReader(bytes.NewReader(blob.GetZlibData()))
-		if err != nil {
-			return nil, err
-		}
-		buf := bytes.NewBuffer(make([]byte, 0, blob.GetRawSize()+bytes.MinRead))
-		_, err = buf.ReadFrom(r)
-		if err != nil {
-			return nil, err
-		}
-		if buf.Len() != int(blob.GetRawSize()) {
-			err = fmt.Errorf("raw blob data size %d but expected %d", buf.Len(), blob.GetRawSize())
-			return nil, err
-		}
-		return buf.Bytes(), nil
-
-	default:
-		return nil, errors.New("unknown blob data")
-	}
-}
-
-func decodeOSMHeader(blob *OSMPBF.Blob) error {
-	data, err := getData(blob)
+func decodeOSMHeader(blob *OSMPBF.Blob) (*Header, error) {
+	data, err := getData(blob, new(bytes.Buffer))
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	headerBlock := new(OSMPBF.HeaderBlock)
 	if err := proto.Unmarshal(data, headerBlock); err != nil {
-		return err
+		return nil, err
 	}
 
 	// Check we have the parse capabilities
 	requiredFeatures := headerBlock.GetRequiredFeatures()
 	for _, feature := range requiredFeatures {
 		if !parseCapabilities[feature] {
-			return fmt.Errorf("parser does not have %s capability", feature)
+			return nil, &UnsupportedFeatureError{feature}
 		}
 	}
 
-	return nil
+	return newHeader(headerBlock), nil
 }
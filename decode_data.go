@@ -1,32 +1,210 @@
 package osmpbf
 
 import (
+	"bytes"
+	"fmt"
+	"io"
+	"runtime"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/brechtbm/osmpbf/OSMPBF"
-	"github.com/gogo/protobuf/proto"
 )
 
+// Pointers to slices of decoded objects, returned by serializer after sending objects to Decode.
+var objectsPool = sync.Pool{
+	New: func() interface{} {
+		q := make([]interface{}, 0, 8000) // typical PrimitiveBlock contains 8k OSM entities
+		return &q
+	},
+}
+
+// Returns slice of objects to pool, its elements must be already cleared.
+func putObjects(q *[]interface{}) {
+	*q = (*q)[:0]
+	objectsPool.Put(q)
+}
+
 // Decoder for Blob with OSMData (PrimitiveBlock)
 type dataDecoder struct {
-	q []interface{}
+	q    []interface{}
+	opts decodeOptions
+
+	// reused for uncompressed data, which is not referenced after unmarshalling
+	buf bytes.Buffer
+
+	// input stream in ReaderAt mode, Blob data is read into blobBuf
+	r       io.ReaderAt
+	blobBuf []byte
+
+	// interned strings, up to opts.internSize
+	strings map[string]string
+
+	// reused for each PrimitiveBlock
+	parser blockParser
+
+	// reused for each compressed Blob
+	inflater inflater
+
+	// raw locations of nodes, replaced instead of reused when full as nodes point to its elements
+	raws []RawLocation
+}
+
+// Reference to Blob in input stream, sent to data decoders in ReaderAt mode.
+type blobRef struct {
+	offset int64
+	size   int32
 }
 
-func (dec *dataDecoder) Decode(blob *OSMPBF.Blob) ([]interface{}, error) {
-	dec.q = make([]interface{}, 0, 8000) // typical PrimitiveBlock contains 8k OSM entities
+// Buffers of Blobs read by reader goroutine, each Blob is read into its own buffer.
+var blobBufPool = sync.Pool{New: func() interface{} { return new([]byte) }}
 
-	data, err := getData(blob)
+// Blob read by reader goroutine, referencing buf from blobBufPool. Data decoder returns buf
+// to pool after decoding Blob.
+type pooledBlob struct {
+	blob *OSMPBF.Blob
+	buf  *[]byte
+}
+
+// Returns buffer of v to blobBufPool if v is *pooledBlob, Blob must not be used after that.
+func releaseBlob(v interface{}) {
+	if pb, ok := v.(*pooledBlob); ok && pb != nil {
+		blobBufPool.Put(pb.buf)
+		pb.blob, pb.buf = nil, nil
+	}
+}
+
+// Returns Blob sent by reader goroutine, reading it from input stream if v is blobRef.
+func (dec *dataDecoder) blob(v interface{}) (*OSMPBF.Blob, error) {
+	switch v := v.(type) {
+	case *pooledBlob:
+		return v.blob, nil
+	case *OSMPBF.Blob:
+		return v, nil
+	}
+	ref := v.(blobRef)
+
+	if m, ok := dec.r.(*mmapReaderAt); ok {
+		// mapped memory is not copied, Blob references it
+		data, err := m.slice(ref.offset, int(ref.size))
+		if err != nil {
+			return nil, err
+		}
+		return unmarshalBlobInPlace(data)
+	}
+
+	if cap(dec.blobBuf) < int(ref.size) {
+		dec.blobBuf = make([]byte, ref.size)
+	}
+	var start time.Time
+	if dec.opts.stats != nil {
+		start = time.Now()
+	}
+	data := dec.blobBuf[:ref.size]
+	n, err := dec.r.ReadAt(data, ref.offset)
+	if n < len(data) {
+		if err == nil || err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	if dec.opts.stats != nil {
+		addSince(&dec.opts.stats.ReadTime, start)
+	}
+
+	// blobBuf is reused for the next Blob only after this one is decoded
+	return unmarshalBlobInPlace(data)
+}
+
+func (dec *dataDecoder) Decode(blob *OSMPBF.Blob) (*[]interface{}, error) {
+	stats := dec.opts.stats
+	var t time.Time
+	if stats != nil {
+		t = time.Now()
+	}
+
+	data, err := dec.inflater.data(blob, &dec.buf)
 	if err != nil {
 		return nil, err
 	}
+	if stats != nil {
+		t = addSince(&stats.InflateTime, t)
+		atomic.AddInt64(&stats.UncompressedBytes, int64(len(data)))
+	}
+	if dec.opts.strict {
+		if err := validateBlob(blob, data); err != nil {
+			return nil, &ValidationError{Err: err}
+		}
+	}
 
-	primitiveBlock := &OSMPBF.PrimitiveBlock{}
-	if err := proto.Unmarshal(data, primitiveBlock); err != nil {
+	primitiveBlock, err := dec.parser.parse(data)
+	if err != nil {
 		return nil, err
 	}
+	if stats != nil {
+		t = addSince(&stats.UnmarshalTime, t)
+	}
+	if dec.opts.strict {
+		if err := validateBlock(primitiveBlock); err != nil {
+			return nil, &ValidationError{Err: err}
+		}
+	}
+	if dec.opts.internSize > 0 {
+		dec.intern(primitiveBlock.GetStringtable().GetS())
+	}
+
+	q := objectsPool.Get().(*[]interface{})
+	dec.q = *q
+
+	err = dec.parseObjects(primitiveBlock)
+	*q = dec.q
+	dec.q = nil
+	if err != nil {
+		putObjects(q)
+		return nil, err
+	}
+	if stats != nil {
+		addSince(&stats.BuildTime, t)
+		stats.count(*q)
+	}
+	return q, nil
+}
+
+// Replaces strings of string table with previously seen identical ones.
+func (dec *dataDecoder) intern(st []string) {
+	if dec.strings == nil {
+		dec.strings = make(map[string]string)
+	}
+	for i, s := range st {
+		if interned, ok := dec.strings[s]; ok {
+			st[i] = interned
+		} else if len(dec.strings) < dec.opts.internSize {
+			dec.strings[s] = s
+		}
+	}
+}
 
-	dec.parsePrimitiveBlock(primitiveBlock)
-	return dec.q, nil
+// Parses objects of block into dec.q. Runtime errors caused by invalid string table or array
+// indexes in block are returned as errors and objects parsed before them are released, so
+// corrupt blocks do not crash data decoder goroutines.
+func (dec *dataDecoder) parseObjects(pb *OSMPBF.PrimitiveBlock) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			re, ok := r.(runtime.Error)
+			if !ok {
+				panic(r)
+			}
+			for i, o := range dec.q {
+				o.(Object).Release()
+				dec.q[i] = nil
+			}
+			dec.q = dec.q[:0]
+			err = fmt.Errorf("invalid PrimitiveBlock: %w", re)
+		}
+	}()
+	dec.parsePrimitiveBlock(pb)
+	return nil
 }
 
 func (dec *dataDecoder) parsePrimitiveBlock(pb *OSMPBF.PrimitiveBlock) {
@@ -36,10 +214,16 @@ func (dec *dataDecoder) parsePrimitiveBlock(pb *OSMPBF.PrimitiveBlock) {
 }
 
 func (dec *dataDecoder) parsePrimitiveGroup(pb *OSMPBF.PrimitiveBlock, pg *OSMPBF.PrimitiveGroup) {
-	dec.parseNodes(pb, pg.GetNodes())
-	dec.parseDenseNodes(pb, pg.GetDense())
-	dec.parseWays(pb, pg.GetWays())
-	dec.parseRelations(pb, pg.GetRelations())
+	if !dec.opts.skipNodes {
+		dec.parseNodes(pb, pg.GetNodes())
+		dec.parseDenseNodes(pb, pg.GetDense())
+	}
+	if !dec.opts.skipWays {
+		dec.parseWays(pb, pg.GetWays())
+	}
+	if !dec.opts.skipRelations {
+		dec.parseRelations(pb, pg.GetRelations())
+	}
 }
 
 func (dec *dataDecoder) parseNodes(pb *OSMPBF.PrimitiveBlock, nodes []*OSMPBF.Node) {
@@ -57,11 +241,25 @@ func (dec *dataDecoder) parseNodes(pb *OSMPBF.PrimitiveBlock, nodes []*OSMPBF.No
 
 		latitude := 1e-9 * float64((latOffset + (granularity * lat)))
 		longitude := 1e-9 * float64((lonOffset + (granularity * lon)))
+		if !dec.inside(latitude, longitude) {
+			continue
+		}
+		info := dec.extractInfo(st, node.GetInfo(), dateGranularity)
+		if !dec.matchInfo(&info) {
+			continue
+		}
 
-		tags := extractTags(st, node.GetKeys(), node.GetVals())
-		info := extractInfo(st, node.GetInfo(), dateGranularity)
+		n := nodePool.Get().(*Node)
+		tags, tagList := dec.extractTags(st, node.GetKeys(), node.GetVals(), n.Tags, n.TagList)
+		if !dec.keep(NodeKind, tags, tagList) {
+			n.Tags, n.TagList = tags, tagList
+			n.Release()
+			continue
+		}
 
-		dec.q = append(dec.q, &Node{id, latitude, longitude, tags, info})
+		*n = Node{ID: id, Lat: latitude, Lon: longitude, Tags: tags, TagList: tagList, Info: info}
+		dec.setRawLocation(n, RawLocation{lat, lon, int32(granularity), latOffset, lonOffset})
+		dec.q = append(dec.q, n)
 
 		panic("Please test this first")
 	}
@@ -78,6 +276,7 @@ func (dec *dataDecoder) parseDenseNodes(pb *OSMPBF.PrimitiveBlock, dn *OSMPBF.De
 	lats := dn.GetLat()
 	lons := dn.GetLon()
 	di := dn.GetDenseinfo()
+	skipInfo := dec.opts.skipInfo
 
 	tu := tagUnpacker{st, dn.GetKeysVals(), 0}
 	var id, lat, lon int64
@@ -88,43 +287,100 @@ func (dec *dataDecoder) parseDenseNodes(pb *OSMPBF.PrimitiveBlock, dn *OSMPBF.De
 		lon = lons[index] + lon
 		latitude := 1e-9 * float64((latOffset + (granularity * lat)))
 		longitude := 1e-9 * float64((lonOffset + (granularity * lon)))
-		tags := tu.next()
-		info := extractDenseInfo(st, &state, di, index, dateGranularity)
-		dec.q = append(dec.q, &Node{id, latitude, longitude, tags, info})
+		if !dec.inside(latitude, longitude) {
+			tu.skip()
+			if !skipInfo {
+				extractDenseInfo(st, &state, di, index, dateGranularity) // advance delta state
+			}
+			continue
+		}
+		var info Info
+		if !skipInfo {
+			info = extractDenseInfo(st, &state, di, index, dateGranularity) // always advances delta state
+		}
+		if !dec.matchInfo(&info) {
+			tu.skip()
+			continue
+		}
+
+		n := nodePool.Get().(*Node)
+		var tags map[string]string
+		var tagList TagList
+		if dec.opts.tagList {
+			tagList = tu.nextList(n.TagList)
+		} else {
+			tags = tu.next(n.Tags)
+		}
+		if dec.keep(NodeKind, tags, tagList) {
+			*n = Node{ID: id, Lat: latitude, Lon: longitude, Tags: tags, TagList: tagList, Info: info}
+			dec.setRawLocation(n, RawLocation{lat, lon, int32(granularity), latOffset, lonOffset})
+			dec.q = append(dec.q, n)
+		} else {
+			n.Tags, n.TagList = tags, tagList
+			n.Release()
+		}
 	}
 }
 
 func (dec *dataDecoder) parseWays(pb *OSMPBF.PrimitiveBlock, ways []*OSMPBF.Way) {
 	st := pb.GetStringtable().GetS()
+	granularity := int64(pb.GetGranularity())
+	latOffset := pb.GetLatOffset()
+	lonOffset := pb.GetLonOffset()
 	dateGranularity := int64(pb.GetDateGranularity())
 
 	for _, way := range ways {
 		id := way.GetId()
+		info := dec.extractInfo(st, way.GetInfo(), dateGranularity)
+		if !dec.matchInfo(&info) {
+			continue
+		}
 
-		tags := extractTags(st, way.GetKeys(), way.GetVals())
+		w := wayPool.Get().(*Way)
+		tags, tagList := dec.extractTags(st, way.GetKeys(), way.GetVals(), w.Tags, w.TagList)
+		if !dec.keep(WayKind, tags, tagList) {
+			w.Tags, w.TagList = tags, tagList
+			w.Release()
+			continue
+		}
 
 		refs := way.GetRefs()
 		var nodeID int64
-		nodeIDs := make([]int64, len(refs))
+		nodeIDs := resizeIDs(w.NodeIDs, len(refs))
 		for index := range refs {
 			nodeID = refs[index] + nodeID // delta encoding
 			nodeIDs[index] = nodeID
 		}
 
-		info := extractInfo(st, way.GetInfo(), dateGranularity)
+		// LocationsOnWays
+		var locations []LatLon
+		if lats, lons := way.GetLat(), way.GetLon(); len(lats) > 0 && len(lats) == len(refs) && len(lons) == len(refs) {
+			var lat, lon int64
+			locations = resizeLocations(w.NodeLocations, len(refs))
+			for index := range refs {
+				lat = lats[index] + lat // delta encoding
+				lon = lons[index] + lon
+				locations[index] = LatLon{
+					Lat: 1e-9 * float64((latOffset + (granularity * lat))),
+					Lon: 1e-9 * float64((lonOffset + (granularity * lon))),
+				}
+			}
+		}
 
-		dec.q = append(dec.q, &Way{id, tags, nodeIDs, info})
+		*w = Way{id, tags, tagList, nodeIDs, locations, info}
+		dec.q = append(dec.q, w)
 	}
 }
 
 // Make relation members from stringtable and three parallel arrays of IDs.
-func extractMembers(stringTable []string, rel *OSMPBF.Relation) []Member {
+// Capacity of members is reused if possible.
+func extractMembers(stringTable []string, rel *OSMPBF.Relation, members []Member) []Member {
 	memIDs := rel.GetMemids()
 	types := rel.GetTypes()
 	roleIDs := rel.GetRolesSid()
 
 	var memID int64
-	members := make([]Member, len(memIDs))
+	members = resizeMembers(members, len(memIDs))
 	for index := range memIDs {
 		memID = memIDs[index] + memID // delta encoding
 
@@ -152,12 +408,74 @@ func (dec *dataDecoder) parseRelations(pb *OSMPBF.PrimitiveBlock, relations []*O
 
 	for _, rel := range relations {
 		id := rel.GetId()
-		tags := extractTags(st, rel.GetKeys(), rel.GetVals())
-		members := extractMembers(st, rel)
-		info := extractInfo(st, rel.GetInfo(), dateGranularity)
+		info := dec.extractInfo(st, rel.GetInfo(), dateGranularity)
+		if !dec.matchInfo(&info) {
+			continue
+		}
+
+		r := relationPool.Get().(*Relation)
+		tags, tagList := dec.extractTags(st, rel.GetKeys(), rel.GetVals(), r.Tags, r.TagList)
+		if !dec.keep(RelationKind, tags, tagList) {
+			r.Tags, r.TagList = tags, tagList
+			r.Release()
+			continue
+		}
+
+		members := extractMembers(st, rel, r.Members)
+
+		*r = Relation{id, tags, tagList, members, info}
+		dec.q = append(dec.q, r)
+	}
+}
+
+// Sets Raw location of node, if it is enabled. Locations are allocated in chunks, not one by one.
+func (dec *dataDecoder) setRawLocation(n *Node, raw RawLocation) {
+	if !dec.opts.rawLocations {
+		return
+	}
+	if len(dec.raws) == cap(dec.raws) {
+		dec.raws = make([]RawLocation, 0, rawLocationsChunk)
+	}
+	dec.raws = append(dec.raws, raw)
+	n.Raw = &dec.raws[len(dec.raws)-1]
+}
+
+// Returns false if node should be dropped by spatial filter.
+func (dec *dataDecoder) inside(lat, lon float64) bool {
+	return dec.opts.region == nil || dec.opts.region.Contains(lat, lon)
+}
+
+// Returns false if object should be dropped by info filter.
+func (dec *dataDecoder) matchInfo(info *Info) bool {
+	return dec.opts.infoFilter == nil || dec.opts.infoFilter.match(info)
+}
+
+// Returns false if object should be dropped by filter.
+func (dec *dataDecoder) keep(kind Kind, tags map[string]string, tagList TagList) bool {
+	if dec.opts.filter == nil {
+		return true
+	}
+	if tags == nil {
+		// filter takes map, it is made only for filter in TagList mode
+		tags = tagList.Map()
+	}
+	return dec.opts.filter(kind, tags)
+}
+
+// Makes tags map, or TagList if it is enabled, reusing given ones.
+func (dec *dataDecoder) extractTags(stringTable []string, keyIDs, valueIDs []uint32, tags map[string]string, tagList TagList) (map[string]string, TagList) {
+	if dec.opts.tagList {
+		return nil, extractTagList(stringTable, keyIDs, valueIDs, tagList)
+	}
+	return extractTags(stringTable, keyIDs, valueIDs, tags), nil
+}
 
-		dec.q = append(dec.q, &Relation{id, tags, members, info})
+// Returns zero Info if it is skipped.
+func (dec *dataDecoder) extractInfo(stringTable []string, i *OSMPBF.Info, dateGranularity int64) Info {
+	if dec.opts.skipInfo {
+		return Info{}
 	}
+	return extractInfo(stringTable, i, dateGranularity)
 }
 
 func extractInfo(stringTable []string, i *OSMPBF.Info, dateGranularity int64) Info {
@@ -1,8 +1,11 @@
 package osmpbf
 
 // Make tags map from stringtable and two parallel arrays of IDs.
-func extractTags(stringTable []string, keyIDs, valueIDs []uint32) map[string]string {
-	tags := make(map[string]string, len(keyIDs))
+// Empty tags map is reused if not nil.
+func extractTags(stringTable []string, keyIDs, valueIDs []uint32, tags map[string]string) map[string]string {
+	if tags == nil {
+		tags = make(map[string]string, len(keyIDs))
+	}
 	for index, keyID := range keyIDs {
 		key := stringTable[keyID]
 		val := stringTable[valueIDs[index]]
@@ -11,6 +14,16 @@ func extractTags(stringTable []string, keyIDs, valueIDs []uint32) map[string]str
 	return tags
 }
 
+// Make TagList from stringtable and two parallel arrays of IDs.
+// Capacity of tagList is reused.
+func extractTagList(stringTable []string, keyIDs, valueIDs []uint32, tagList TagList) TagList {
+	tagList = tagList[:0]
+	for index, keyID := range keyIDs {
+		tagList = append(tagList, Tag{stringTable[keyID], stringTable[valueIDs[index]]})
+	}
+	return tagList
+}
+
 type tagUnpacker struct {
 	stringTable []string
 	keysVals    []int32
@@ -18,8 +31,11 @@ type tagUnpacker struct {
 }
 
 // Make tags map from stringtable and array of IDs (used in DenseNodes encoding).
-func (tu *tagUnpacker) next() map[string]string {
-	tags := make(map[string]string)
+// Empty tags map is reused if not nil.
+func (tu *tagUnpacker) next(tags map[string]string) map[string]string {
+	if tags == nil {
+		tags = make(map[string]string)
+	}
 	for tu.index < len(tu.keysVals) {
 		keyID := tu.keysVals[tu.index]
 		tu.index++
@@ -36,3 +52,33 @@ func (tu *tagUnpacker) next() map[string]string {
 	}
 	return tags
 }
+
+// Make TagList from stringtable and array of IDs, see next. Capacity of tagList is reused.
+func (tu *tagUnpacker) nextList(tagList TagList) TagList {
+	tagList = tagList[:0]
+	for tu.index < len(tu.keysVals) {
+		keyID := tu.keysVals[tu.index]
+		tu.index++
+		if keyID == 0 {
+			break
+		}
+
+		valID := tu.keysVals[tu.index]
+		tu.index++
+
+		tagList = append(tagList, Tag{tu.stringTable[keyID], tu.stringTable[valID]})
+	}
+	return tagList
+}
+
+// Skip tags of one node without making map.
+func (tu *tagUnpacker) skip() {
+	for tu.index < len(tu.keysVals) {
+		keyID := tu.keysVals[tu.index]
+		tu.index++
+		if keyID == 0 {
+			break
+		}
+		tu.index++
+	}
+}
@@ -418,3 +418,19 @@ func BenchmarkDecodeConcurrent(b *testing.B) {
 			time.Now().Sub(start).Seconds(), nc, wc, rc)
 	}
 }
+
+func TestObjectKind(t *testing.T) {
+	for _, c := range []struct {
+		o    Object
+		kind Kind
+		s    string
+	}{
+		{en, NodeKind, "node"},
+		{ew, WayKind, "way"},
+		{er, RelationKind, "relation"},
+	} {
+		if c.o.Kind() != c.kind || c.kind.String() != c.s {
+			t.Errorf("%T: expected %s, got %s", c.o, c.s, c.o.Kind())
+		}
+	}
+}
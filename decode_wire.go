@@ -0,0 +1,578 @@
+package osmpbf
+
+import (
+	"errors"
+
+	"github.com/brechtbm/osmpbf/OSMPBF"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+var errBlockFormat = errors.New("invalid PrimitiveBlock")
+
+// Parser of PrimitiveBlock wire format, used by data decoders instead of generated code.
+// Parsed messages and their slices are reused by the next parse, so returned PrimitiveBlock
+// is valid until then. Packed fields are read directly into reused slices.
+type blockParser struct {
+	pb OSMPBF.PrimitiveBlock
+	st OSMPBF.StringTable
+
+	// values of optional fields, referenced by pb
+	granularity     int32
+	dateGranularity int32
+	latOffset       int64
+	lonOffset       int64
+
+	groups []*groupBuf
+}
+
+// Reused PrimitiveGroup with its messages, only first n of each kind are used.
+type groupBuf struct {
+	pg        OSMPBF.PrimitiveGroup
+	dense     OSMPBF.DenseNodes
+	denseInfo OSMPBF.DenseInfo
+	nodes     []*nodeBuf
+	ways      []*wayBuf
+	relations []*relationBuf
+}
+
+type nodeBuf struct {
+	msg          OSMPBF.Node
+	id, lat, lon int64
+	info         infoBuf
+}
+
+type wayBuf struct {
+	msg  OSMPBF.Way
+	id   int64
+	info infoBuf
+}
+
+type relationBuf struct {
+	msg  OSMPBF.Relation
+	id   int64
+	info infoBuf
+}
+
+type infoBuf struct {
+	msg       OSMPBF.Info
+	version   int32
+	timestamp int64
+	changeset int64
+	uid       int32
+	userSid   uint32
+	visible   bool
+}
+
+func (p *blockParser) parse(data []byte) (*OSMPBF.PrimitiveBlock, error) {
+	pb := &p.pb
+	pb.Stringtable = &p.st
+	p.st.S = p.st.S[:0]
+	pb.Primitivegroup = pb.Primitivegroup[:0]
+	pb.Granularity, pb.DateGranularity, pb.LatOffset, pb.LonOffset = nil, nil, nil, nil
+
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return nil, errBlockFormat
+		}
+		data = data[n:]
+
+		var b []byte
+		var v uint64
+		var err error
+		switch {
+		case num == 1 && typ == protowire.BytesType:
+			b, n = protowire.ConsumeBytes(data)
+			p.st.S, err = appendStrings(p.st.S, b)
+		case num == 2 && typ == protowire.BytesType:
+			b, n = protowire.ConsumeBytes(data)
+			if len(p.groups) == len(pb.Primitivegroup) {
+				p.groups = append(p.groups, new(groupBuf))
+			}
+			g := p.groups[len(pb.Primitivegroup)]
+			err = g.parse(b)
+			pb.Primitivegroup = append(pb.Primitivegroup, &g.pg)
+		case num >= 17 && num <= 20 && typ == protowire.VarintType:
+			v, n = protowire.ConsumeVarint(data)
+			switch num {
+			case 17:
+				p.granularity = int32(v)
+				pb.Granularity = &p.granularity
+			case 18:
+				p.dateGranularity = int32(v)
+				pb.DateGranularity = &p.dateGranularity
+			case 19:
+				p.latOffset = int64(v)
+				pb.LatOffset = &p.latOffset
+			case 20:
+				p.lonOffset = int64(v)
+				pb.LonOffset = &p.lonOffset
+			}
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, data)
+		}
+		if n < 0 || err != nil {
+			return nil, errBlockFormat
+		}
+		data = data[n:]
+	}
+	return pb, nil
+}
+
+// Appends strings of StringTable message to s.
+func appendStrings(s []string, data []byte) ([]string, error) {
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return s, errBlockFormat
+		}
+		data = data[n:]
+
+		if num == 1 && typ == protowire.BytesType {
+			var b []byte
+			b, n = protowire.ConsumeBytes(data)
+			s = append(s, string(b))
+		} else {
+			n = protowire.ConsumeFieldValue(num, typ, data)
+		}
+		if n < 0 {
+			return s, errBlockFormat
+		}
+		data = data[n:]
+	}
+	return s, nil
+}
+
+func (g *groupBuf) parse(data []byte) error {
+	pg := &g.pg
+	pg.Nodes, pg.Ways, pg.Relations, pg.Dense = pg.Nodes[:0], pg.Ways[:0], pg.Relations[:0], nil
+
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return errBlockFormat
+		}
+		data = data[n:]
+		if typ != protowire.BytesType || num < 1 || num > 4 {
+			// changesets are not decoded
+			if n = protowire.ConsumeFieldValue(num, typ, data); n < 0 {
+				return errBlockFormat
+			}
+			data = data[n:]
+			continue
+		}
+
+		b, n := protowire.ConsumeBytes(data)
+		if n < 0 {
+			return errBlockFormat
+		}
+		data = data[n:]
+
+		var err error
+		switch num {
+		case 1:
+			if len(g.nodes) == len(pg.Nodes) {
+				g.nodes = append(g.nodes, new(nodeBuf))
+			}
+			nb := g.nodes[len(pg.Nodes)]
+			err = nb.parse(b)
+			pg.Nodes = append(pg.Nodes, &nb.msg)
+		case 2:
+			if pg.Dense == nil {
+				// following DenseNodes messages of the group are merged into the first one
+				g.resetDense()
+				pg.Dense = &g.dense
+			}
+			err = g.parseDense(b)
+		case 3:
+			if len(g.ways) == len(pg.Ways) {
+				g.ways = append(g.ways, new(wayBuf))
+			}
+			wb := g.ways[len(pg.Ways)]
+			err = wb.parse(b)
+			pg.Ways = append(pg.Ways, &wb.msg)
+		case 4:
+			if len(g.relations) == len(pg.Relations) {
+				g.relations = append(g.relations, new(relationBuf))
+			}
+			rb := g.relations[len(pg.Relations)]
+			err = rb.parse(b)
+			pg.Relations = append(pg.Relations, &rb.msg)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (g *groupBuf) resetDense() {
+	dn := &g.dense
+	dn.Id, dn.Lat, dn.Lon, dn.KeysVals, dn.Denseinfo = dn.Id[:0], dn.Lat[:0], dn.Lon[:0], dn.KeysVals[:0], nil
+	di := &g.denseInfo
+	di.Version, di.Timestamp, di.Changeset = di.Version[:0], di.Timestamp[:0], di.Changeset[:0]
+	di.Uid, di.UserSid, di.Visible = di.Uid[:0], di.UserSid[:0], di.Visible[:0]
+}
+
+func (g *groupBuf) parseDense(data []byte) error {
+	dn := &g.dense
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return errBlockFormat
+		}
+		data = data[n:]
+
+		var b []byte
+		var err error
+		switch num {
+		case 1:
+			b, n = repeatedVarints(typ, data)
+			dn.Id, err = appendSint64s(dn.Id, b)
+		case 5:
+			if typ != protowire.BytesType {
+				return errBlockFormat
+			}
+			b, n = protowire.ConsumeBytes(data)
+			dn.Denseinfo = &g.denseInfo
+			err = parseDenseInfo(b, &g.denseInfo)
+		case 8:
+			b, n = repeatedVarints(typ, data)
+			dn.Lat, err = appendSint64s(dn.Lat, b)
+		case 9:
+			b, n = repeatedVarints(typ, data)
+			dn.Lon, err = appendSint64s(dn.Lon, b)
+		case 10:
+			b, n = repeatedVarints(typ, data)
+			dn.KeysVals, err = appendInt32s(dn.KeysVals, b)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, data)
+		}
+		if n < 0 || err != nil {
+			return errBlockFormat
+		}
+		data = data[n:]
+	}
+	return nil
+}
+
+func parseDenseInfo(data []byte, di *OSMPBF.DenseInfo) error {
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return errBlockFormat
+		}
+		data = data[n:]
+
+		var b []byte
+		var err error
+		switch num {
+		case 1:
+			b, n = repeatedVarints(typ, data)
+			di.Version, err = appendInt32s(di.Version, b)
+		case 2:
+			b, n = repeatedVarints(typ, data)
+			di.Timestamp, err = appendSint64s(di.Timestamp, b)
+		case 3:
+			b, n = repeatedVarints(typ, data)
+			di.Changeset, err = appendSint64s(di.Changeset, b)
+		case 4:
+			b, n = repeatedVarints(typ, data)
+			di.Uid, err = appendSint32s(di.Uid, b)
+		case 5:
+			b, n = repeatedVarints(typ, data)
+			di.UserSid, err = appendSint32s(di.UserSid, b)
+		case 6:
+			b, n = repeatedVarints(typ, data)
+			di.Visible, err = appendBools(di.Visible, b)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, data)
+		}
+		if n < 0 || err != nil {
+			return errBlockFormat
+		}
+		data = data[n:]
+	}
+	return nil
+}
+
+func (nb *nodeBuf) parse(data []byte) error {
+	msg := &nb.msg
+	msg.Id, msg.Lat, msg.Lon, msg.Info = nil, nil, nil, nil
+	msg.Keys, msg.Vals = msg.Keys[:0], msg.Vals[:0]
+
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return errBlockFormat
+		}
+		data = data[n:]
+
+		var b []byte
+		var v uint64
+		var err error
+		switch {
+		case (num == 1 || num == 8 || num == 9) && typ == protowire.VarintType:
+			v, n = protowire.ConsumeVarint(data)
+			switch num {
+			case 1:
+				nb.id = protowire.DecodeZigZag(v)
+				msg.Id = &nb.id
+			case 8:
+				nb.lat = protowire.DecodeZigZag(v)
+				msg.Lat = &nb.lat
+			case 9:
+				nb.lon = protowire.DecodeZigZag(v)
+				msg.Lon = &nb.lon
+			}
+		case num == 2:
+			b, n = repeatedVarints(typ, data)
+			msg.Keys, err = appendUint32s(msg.Keys, b)
+		case num == 3:
+			b, n = repeatedVarints(typ, data)
+			msg.Vals, err = appendUint32s(msg.Vals, b)
+		case num == 4 && typ == protowire.BytesType:
+			b, n = protowire.ConsumeBytes(data)
+			msg.Info, err = nb.info.parse(b, msg.Info)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, data)
+		}
+		if n < 0 || err != nil {
+			return errBlockFormat
+		}
+		data = data[n:]
+	}
+	return nil
+}
+
+func (wb *wayBuf) parse(data []byte) error {
+	msg := &wb.msg
+	msg.Id, msg.Info = nil, nil
+	msg.Keys, msg.Vals, msg.Refs, msg.Lat, msg.Lon = msg.Keys[:0], msg.Vals[:0], msg.Refs[:0], msg.Lat[:0], msg.Lon[:0]
+
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return errBlockFormat
+		}
+		data = data[n:]
+
+		var b []byte
+		var v uint64
+		var err error
+		switch {
+		case num == 1 && typ == protowire.VarintType:
+			v, n = protowire.ConsumeVarint(data)
+			wb.id = int64(v)
+			msg.Id = &wb.id
+		case num == 2:
+			b, n = repeatedVarints(typ, data)
+			msg.Keys, err = appendUint32s(msg.Keys, b)
+		case num == 3:
+			b, n = repeatedVarints(typ, data)
+			msg.Vals, err = appendUint32s(msg.Vals, b)
+		case num == 4 && typ == protowire.BytesType:
+			b, n = protowire.ConsumeBytes(data)
+			msg.Info, err = wb.info.parse(b, msg.Info)
+		case num == 8:
+			b, n = repeatedVarints(typ, data)
+			msg.Refs, err = appendSint64s(msg.Refs, b)
+		case num == 9:
+			b, n = repeatedVarints(typ, data)
+			msg.Lat, err = appendSint64s(msg.Lat, b)
+		case num == 10:
+			b, n = repeatedVarints(typ, data)
+			msg.Lon, err = appendSint64s(msg.Lon, b)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, data)
+		}
+		if n < 0 || err != nil {
+			return errBlockFormat
+		}
+		data = data[n:]
+	}
+	return nil
+}
+
+func (rb *relationBuf) parse(data []byte) error {
+	msg := &rb.msg
+	msg.Id, msg.Info = nil, nil
+	msg.Keys, msg.Vals, msg.RolesSid, msg.Memids, msg.Types = msg.Keys[:0], msg.Vals[:0], msg.RolesSid[:0], msg.Memids[:0], msg.Types[:0]
+
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return errBlockFormat
+		}
+		data = data[n:]
+
+		var b []byte
+		var v uint64
+		var err error
+		switch {
+		case num == 1 && typ == protowire.VarintType:
+			v, n = protowire.ConsumeVarint(data)
+			rb.id = int64(v)
+			msg.Id = &rb.id
+		case num == 2:
+			b, n = repeatedVarints(typ, data)
+			msg.Keys, err = appendUint32s(msg.Keys, b)
+		case num == 3:
+			b, n = repeatedVarints(typ, data)
+			msg.Vals, err = appendUint32s(msg.Vals, b)
+		case num == 4 && typ == protowire.BytesType:
+			b, n = protowire.ConsumeBytes(data)
+			msg.Info, err = rb.info.parse(b, msg.Info)
+		case num == 8:
+			b, n = repeatedVarints(typ, data)
+			msg.RolesSid, err = appendInt32s(msg.RolesSid, b)
+		case num == 9:
+			b, n = repeatedVarints(typ, data)
+			msg.Memids, err = appendSint64s(msg.Memids, b)
+		case num == 10:
+			b, n = repeatedVarints(typ, data)
+			for len(b) > 0 && err == nil {
+				var m int
+				if v, m = protowire.ConsumeVarint(b); m < 0 {
+					err = errBlockFormat
+				} else {
+					msg.Types = append(msg.Types, OSMPBF.Relation_MemberType(v))
+					b = b[m:]
+				}
+			}
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, data)
+		}
+		if n < 0 || err != nil {
+			return errBlockFormat
+		}
+		data = data[n:]
+	}
+	return nil
+}
+
+// Parses Info message, merging it into info if it is not nil (repeated message is merged).
+func (ib *infoBuf) parse(data []byte, info *OSMPBF.Info) (*OSMPBF.Info, error) {
+	msg := &ib.msg
+	if info == nil {
+		msg.Version, msg.Timestamp, msg.Changeset, msg.Uid, msg.UserSid, msg.Visible = nil, nil, nil, nil, nil, nil
+	}
+
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return nil, errBlockFormat
+		}
+		data = data[n:]
+		if typ != protowire.VarintType {
+			if n = protowire.ConsumeFieldValue(num, typ, data); n < 0 {
+				return nil, errBlockFormat
+			}
+			data = data[n:]
+			continue
+		}
+
+		v, n := protowire.ConsumeVarint(data)
+		if n < 0 {
+			return nil, errBlockFormat
+		}
+		data = data[n:]
+		switch num {
+		case 1:
+			ib.version = int32(v)
+			msg.Version = &ib.version
+		case 2:
+			ib.timestamp = int64(v)
+			msg.Timestamp = &ib.timestamp
+		case 3:
+			ib.changeset = int64(v)
+			msg.Changeset = &ib.changeset
+		case 4:
+			ib.uid = int32(v)
+			msg.Uid = &ib.uid
+		case 5:
+			ib.userSid = uint32(v)
+			msg.UserSid = &ib.userSid
+		case 6:
+			ib.visible = v != 0
+			msg.Visible = &ib.visible
+		}
+	}
+	return msg, nil
+}
+
+// Returns encoded varints of repeated field and length of field data, or -1 if data is invalid.
+// Both packed and unpacked encoding is accepted.
+func repeatedVarints(typ protowire.Type, data []byte) ([]byte, int) {
+	switch typ {
+	case protowire.BytesType:
+		return protowire.ConsumeBytes(data)
+	case protowire.VarintType:
+		_, n := protowire.ConsumeVarint(data)
+		if n < 0 {
+			return nil, n
+		}
+		return data[:n], n
+	}
+	return nil, -1
+}
+
+func appendSint64s(s []int64, b []byte) ([]int64, error) {
+	for len(b) > 0 {
+		v, n := protowire.ConsumeVarint(b)
+		if n < 0 {
+			return s, errBlockFormat
+		}
+		s = append(s, protowire.DecodeZigZag(v))
+		b = b[n:]
+	}
+	return s, nil
+}
+
+func appendSint32s(s []int32, b []byte) ([]int32, error) {
+	for len(b) > 0 {
+		v, n := protowire.ConsumeVarint(b)
+		if n < 0 {
+			return s, errBlockFormat
+		}
+		s = append(s, int32(protowire.DecodeZigZag(v&0xffffffff)))
+		b = b[n:]
+	}
+	return s, nil
+}
+
+func appendInt32s(s []int32, b []byte) ([]int32, error) {
+	for len(b) > 0 {
+		v, n := protowire.ConsumeVarint(b)
+		if n < 0 {
+			return s, errBlockFormat
+		}
+		s = append(s, int32(v))
+		b = b[n:]
+	}
+	return s, nil
+}
+
+func appendUint32s(s []uint32, b []byte) ([]uint32, error) {
+	for len(b) > 0 {
+		v, n := protowire.ConsumeVarint(b)
+		if n < 0 {
+			return s, errBlockFormat
+		}
+		s = append(s, uint32(v))
+		b = b[n:]
+	}
+	return s, nil
+}
+
+func appendBools(s []bool, b []byte) ([]bool, error) {
+	for len(b) > 0 {
+		v, n := protowire.ConsumeVarint(b)
+		if n < 0 {
+			return s, errBlockFormat
+		}
+		s = append(s, v != 0)
+		b = b[n:]
+	}
+	return s, nil
+}
@@ -0,0 +1,106 @@
+package osmpbf
+
+import (
+	"testing"
+
+	"github.com/brechtbm/osmpbf/OSMPBF"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestBlockParser(t *testing.T) {
+	nodes := []interface{}{en, &Node{ID: 1, Lat: -1, Lon: 1}, &Node{ID: 2, Info: Info{Version: 1, Visible: false}}}
+	way := &Way{ID: 1, NodeIDs: []int64{1, 2}, NodeLocations: []LatLon{{1, 2}, {3, 4}}}
+
+	var p blockParser
+	for _, q := range [][]interface{}{{ew, way}, nodes, {er}, nodes} {
+		expected := new(dataEncoder).Encode(q)
+		// legacy nodes are not written by Encoder
+		if _, ok := q[0].(*Node); ok {
+			expected.Primitivegroup = append(expected.Primitivegroup, &OSMPBF.PrimitiveGroup{
+				Nodes: []*OSMPBF.Node{{Id: proto.Int64(3), Lat: proto.Int64(-5), Lon: proto.Int64(5), Keys: []uint32{1}, Vals: []uint32{2}}},
+			})
+		}
+		data, err := proto.Marshal(expected)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		pb, err := p.parse(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !proto.Equal(expected, pb) {
+			t.Errorf("\nExpected: %v\nActual:   %v", expected, pb)
+		}
+		if _, err := p.parse(data[:len(data)-1]); err == nil {
+			t.Error("expected error for truncated block")
+		}
+	}
+}
+
+func TestBlockParserUnpacked(t *testing.T) {
+	var dense []byte
+	for _, id := range []int64{5, 1} {
+		dense = protowire.AppendTag(dense, 1, protowire.VarintType)
+		dense = protowire.AppendVarint(dense, protowire.EncodeZigZag(id))
+	}
+	dense = protowire.AppendTag(dense, 8, protowire.BytesType)
+	dense = protowire.AppendBytes(dense, []byte{0, 0})
+	dense = protowire.AppendTag(dense, 9, protowire.BytesType)
+	dense = protowire.AppendBytes(dense, []byte{0, 0})
+
+	var group []byte
+	group = protowire.AppendTag(group, 2, protowire.BytesType)
+	group = protowire.AppendBytes(group, dense)
+
+	var data []byte
+	data = protowire.AppendTag(data, 1, protowire.BytesType)
+	data = protowire.AppendBytes(data, nil)
+	data = protowire.AppendTag(data, 2, protowire.BytesType)
+	data = protowire.AppendBytes(data, group)
+
+	expected := new(OSMPBF.PrimitiveBlock)
+	if err := proto.Unmarshal(data, expected); err != nil {
+		t.Fatal(err)
+	}
+	pb, err := new(blockParser).parse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !proto.Equal(expected, pb) {
+		t.Errorf("\nExpected: %v\nActual:   %v", expected, pb)
+	}
+	if ids := pb.GetPrimitivegroup()[0].GetDense().GetId(); len(ids) != 2 || ids[0] != 5 || ids[1] != 1 {
+		t.Errorf("unexpected IDs %v", ids)
+	}
+}
+
+func BenchmarkBlockParser(b *testing.B) {
+	q := make([]interface{}, maxBlockEntities)
+	for i := range q {
+		q[i] = &Node{ID: int64(i + 1), Lat: float64(i) / 1e3, Lon: -float64(i) / 1e3, Tags: map[string]string{"k": "v"}}
+	}
+	data, err := proto.Marshal(new(dataEncoder).Encode(q))
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.Run("generated", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if err := proto.Unmarshal(data, new(OSMPBF.PrimitiveBlock)); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("parser", func(b *testing.B) {
+		b.ReportAllocs()
+		var p blockParser
+		for i := 0; i < b.N; i++ {
+			if _, err := p.parse(data); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
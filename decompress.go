@@ -0,0 +1,210 @@
+package osmpbf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/brechtbm/osmpbf/OSMPBF"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+	"github.com/ulikunitz/xz/lzma"
+)
+
+// getData returns uncompressed blob data. Compressed data is inflated into buf,
+// so returned slice is valid until next use of buf.
+func getData(blob *OSMPBF.Blob, buf *bytes.Buffer) ([]byte, error) {
+	return new(inflater).data(blob, buf)
+}
+
+// A Decompressor uncompresses blob data compressed by one method, see WithDecompressor.
+type Decompressor interface {
+	// Decompress returns uncompressed data of src, size is raw size declared by blob. Returned
+	// slice may use dst as backing array, its capacity is at least size.
+	Decompress(dst, src []byte, size int) ([]byte, error)
+}
+
+// Returns compression method of blob and its compressed data.
+func blobCompression(blob *OSMPBF.Blob) (Compression, []byte, error) {
+	switch {
+	case blob.Raw != nil:
+		return Uncompressed, blob.GetRaw(), nil
+	case blob.ZlibData != nil:
+		return Zlib, blob.GetZlibData(), nil
+	case blob.LzmaData != nil:
+		return Lzma, blob.GetLzmaData(), nil
+	case blob.Lz4Data != nil:
+		return Lz4, blob.GetLz4Data(), nil
+	case blob.ZstdData != nil:
+		return Zstd, blob.GetZstdData(), nil
+	default:
+		return 0, nil, errors.New("unknown blob data")
+	}
+}
+
+// Inflates blob data, reusing zlib reader between blobs. Not safe for concurrent use.
+type inflater struct {
+	// constructor of zlib readers, zlib.NewReader if nil
+	newZlib func(r io.Reader) (io.ReadCloser, error)
+
+	// constructors of custom decompressors, built-in decompression is used for other methods
+	newDecompressors map[Compression]func() Decompressor
+	decompressors    map[Compression]Decompressor
+
+	// maximum raw size of data if it is larger than MaxBlobSize, see WithMaxBlobSize
+	maxRawSize int32
+
+	zlib io.ReadCloser
+	src  bytes.Reader
+}
+
+// Returns custom decompressor of compression method c, creating it on first use; nil if not set.
+func (inf *inflater) decompressor(c Compression) Decompressor {
+	newDecompressor := inf.newDecompressors[c]
+	if newDecompressor == nil {
+		return nil
+	}
+	d, ok := inf.decompressors[c]
+	if !ok {
+		d = newDecompressor()
+		if inf.decompressors == nil {
+			inf.decompressors = make(map[Compression]Decompressor)
+		}
+		inf.decompressors[c] = d
+	}
+	return d
+}
+
+// Returns zlib reader of r, reset to it if possible.
+func (inf *inflater) zlibReader(r io.Reader) (io.Reader, error) {
+	if rs, ok := inf.zlib.(zlib.Resetter); ok {
+		if err := rs.Reset(r, nil); err != nil {
+			return nil, err
+		}
+		return inf.zlib, nil
+	}
+
+	newZlib := inf.newZlib
+	if newZlib == nil {
+		newZlib = zlib.NewReader
+	}
+	zr, err := newZlib(r)
+	if err != nil {
+		return nil, err
+	}
+	inf.zlib = zr
+	return zr, nil
+}
+
+// Returns uncompressed blob data, see getData.
+func (inf *inflater) data(blob *OSMPBF.Blob, buf *bytes.Buffer) ([]byte, error) {
+	// raw size of compressed data is checked before buffers are allocated
+	maxSize := int32(MaxBlobSize)
+	if inf.maxRawSize > maxSize {
+		maxSize = inf.maxRawSize
+	}
+	if size := blob.GetRawSize(); blob.Raw == nil && (size < 0 || size > maxSize) {
+		return nil, fmt.Errorf("%w: raw size %d", ErrBlobTooLarge, size)
+	}
+	if c, src, err := blobCompression(blob); err == nil && c != Uncompressed {
+		if d := inf.decompressor(c); d != nil {
+			size := int(blob.GetRawSize())
+			buf.Reset()
+			buf.Grow(size)
+			data, err := d.Decompress(buf.Bytes()[:0], src, size)
+			if err != nil {
+				return nil, err
+			}
+			if len(data) != size {
+				return nil, fmt.Errorf("raw blob data size %d but expected %d", len(data), size)
+			}
+			return data, nil
+		}
+	}
+
+	switch {
+	case blob.Raw != nil:
+		return blob.GetRaw(), nil
+
+	case blob.ZlibData != nil:
+		inf.src.Reset(blob.GetZlibData())
+		r, err := inf.zlibReader(&inf.src)
+		if err != nil {
+			return nil, err
+		}
+		return readData(r, int(blob.GetRawSize()), buf)
+
+	case blob.LzmaData != nil:
+		// classic LZMA format with header, as written by LZMA SDK
+		r, err := lzma.NewReader(bytes.NewReader(blob.GetLzmaData()))
+		if err != nil {
+			return nil, err
+		}
+		return readData(r, int(blob.GetRawSize()), buf)
+
+	case blob.Lz4Data != nil:
+		size := int(blob.GetRawSize())
+		buf.Reset()
+		buf.Grow(size)
+		data := buf.Bytes()[:size]
+		n, err := lz4.UncompressBlock(blob.GetLz4Data(), data)
+		if err != nil {
+			return nil, err
+		}
+		if n != size {
+			err = fmt.Errorf("raw blob data size %d but expected %d", n, size)
+			return nil, err
+		}
+		return data, nil
+
+	case blob.ZstdData != nil:
+		zd, err := getZstdDecoder()
+		if err != nil {
+			return nil, err
+		}
+		buf.Reset()
+		buf.Grow(int(blob.GetRawSize()))
+		data, err := zd.DecodeAll(blob.GetZstdData(), buf.Bytes())
+		if err != nil {
+			return nil, err
+		}
+		if len(data) != int(blob.GetRawSize()) {
+			err = fmt.Errorf("raw blob data size %d but expected %d", len(data), blob.GetRawSize())
+			return nil, err
+		}
+		return data, nil
+
+	default:
+		return nil, errors.New("unknown blob data")
+	}
+}
+
+// Reads uncompressed data of given size from r into buf.
+func readData(r io.Reader, size int, buf *bytes.Buffer) ([]byte, error) {
+	buf.Reset()
+	buf.Grow(size + bytes.MinRead)
+	if _, err := buf.ReadFrom(r); err != nil {
+		return nil, err
+	}
+	if buf.Len() != size {
+		return nil, fmt.Errorf("raw blob data size %d but expected %d", buf.Len(), size)
+	}
+	return buf.Bytes(), nil
+}
+
+var (
+	zstdDecoder     *zstd.Decoder
+	zstdDecoderErr  error
+	zstdDecoderOnce sync.Once
+)
+
+// Returns zstd decoder shared by all data decoders, DecodeAll is safe for concurrent use.
+func getZstdDecoder() (*zstd.Decoder, error) {
+	zstdDecoderOnce.Do(func() {
+		zstdDecoder, zstdDecoderErr = zstd.NewReader(nil)
+	})
+	return zstdDecoder, zstdDecoderErr
+}
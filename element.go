@@ -0,0 +1,97 @@
+package osmpbf
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ElementID is kind and ID of OSM object in one comparable value, for example a map key of objects
+// of all kinds. IDs must fit into 62 bits, which holds for all OSM IDs, including negative ones.
+type ElementID int64
+
+// NewElementID returns ElementID of object of kind k with given ID. Kind must be NodeKind, WayKind
+// or RelationKind.
+func NewElementID(k Kind, id int64) ElementID {
+	return ElementID(id<<2 | int64(k&3))
+}
+
+// Kind returns kind of object.
+func (e ElementID) Kind() Kind {
+	return Kind(e & 3)
+}
+
+// ID returns ID of object.
+func (e ElementID) ID() int64 {
+	return int64(e) >> 2
+}
+
+// String returns kind and ID like "way/123", as used by OSM website and API.
+func (e ElementID) String() string {
+	return e.Kind().String() + "/" + strconv.FormatInt(e.ID(), 10)
+}
+
+// MarshalText returns String, so ElementID is written as string in JSON.
+func (e ElementID) MarshalText() ([]byte, error) {
+	return []byte(e.String()), nil
+}
+
+// UnmarshalText parses ElementID like ParseElementID.
+func (e *ElementID) UnmarshalText(text []byte) error {
+	id, err := ParseElementID(string(text))
+	if err == nil {
+		*e = id
+	}
+	return err
+}
+
+// ParseElementID parses ElementID in long format "way/123" returned by String, or in short
+// format "w123" used by osmium.
+func ParseElementID(s string) (ElementID, error) {
+	kind, id := s, ""
+	if i := strings.IndexByte(s, '/'); i >= 0 {
+		kind, id = s[:i], s[i+1:]
+	} else if len(s) > 0 {
+		kind, id = s[:1], s[1:]
+	}
+
+	var k Kind
+	switch kind {
+	case "node", "n":
+		k = NodeKind
+	case "way", "w":
+		k = WayKind
+	case "relation", "r":
+		k = RelationKind
+	default:
+		return 0, fmt.Errorf("invalid element ID %q", s)
+	}
+	n, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid element ID %q", s)
+	}
+	return NewElementID(k, n), nil
+}
+
+// ElementID returns kind and ID of node. Node can not have ID method, as it has ID field.
+func (n *Node) ElementID() ElementID { return NewElementID(NodeKind, n.ID) }
+
+// ElementID returns kind and ID of way.
+func (w *Way) ElementID() ElementID { return NewElementID(WayKind, w.ID) }
+
+// ElementID returns kind and ID of relation.
+func (r *Relation) ElementID() ElementID { return NewElementID(RelationKind, r.ID) }
+
+// ElementID returns kind and ID of member object.
+func (m Member) ElementID() ElementID { return NewElementID(m.Type.Kind(), m.ID) }
+
+// Kind returns kind of objects of member type.
+func (t MemberType) Kind() Kind {
+	switch t {
+	case WayType:
+		return WayKind
+	case RelationType:
+		return RelationKind
+	}
+	return NodeKind
+}
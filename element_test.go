@@ -0,0 +1,54 @@
+package osmpbf
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestElementID(t *testing.T) {
+	for _, c := range []struct {
+		kind Kind
+		id   int64
+		s    string
+	}{
+		{NodeKind, 1, "node/1"},
+		{WayKind, 123, "way/123"},
+		{RelationKind, -5, "relation/-5"},
+		{NodeKind, 1 << 60, "node/1152921504606846976"},
+	} {
+		e := NewElementID(c.kind, c.id)
+		if e.Kind() != c.kind || e.ID() != c.id || e.String() != c.s {
+			t.Errorf("expected %s, got %v %d %s", c.s, e.Kind(), e.ID(), e)
+		}
+		if parsed, err := ParseElementID(c.s); err != nil || parsed != e {
+			t.Errorf("%s: unexpected parsed %v, %v", c.s, parsed, err)
+		}
+	}
+
+	if e, err := ParseElementID("w42"); err != nil || e != NewElementID(WayKind, 42) {
+		t.Errorf("unexpected parsed %v, %v", e, err)
+	}
+	for _, s := range []string{"", "x1", "way/", "w", "node/1a"} {
+		if _, err := ParseElementID(s); err == nil {
+			t.Errorf("expected error for %q", s)
+		}
+	}
+
+	r := &Relation{ID: 7, Members: []Member{{1, NodeType, ""}, {2, WayType, ""}, {3, RelationType, ""}}}
+	ids := map[ElementID]bool{(&Node{ID: 1}).ElementID(): true, (&Way{ID: 2}).ElementID(): true, r.ElementID(): true}
+	for _, m := range r.Members[:2] {
+		if !ids[m.ElementID()] {
+			t.Errorf("expected member %v in map", m.ElementID())
+		}
+	}
+	if ids[r.Members[2].ElementID()] {
+		t.Error("unexpected relation 3 in map")
+	}
+
+	var decoded []ElementID
+	if err := json.Unmarshal([]byte(`["node/1","w2"]`), &decoded); err != nil ||
+		!reflect.DeepEqual(decoded, []ElementID{NewElementID(NodeKind, 1), NewElementID(WayKind, 2)}) {
+		t.Errorf("unexpected unmarshalled %v, %v", decoded, err)
+	}
+}
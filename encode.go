@@ -0,0 +1,308 @@
+package osmpbf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/brechtbm/osmpbf/OSMPBF"
+	"github.com/klauspost/compress/zstd"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	historicalFeature = "HistoricalInformation"
+
+	// maxBlockEntities is the number of OSM entities written per PrimitiveBlock.
+	maxBlockEntities = 8000
+
+	writingProgram = "osmpbf"
+)
+
+var (
+	writeFeatures = []string{"OsmSchema-V0.6", "DenseNodes"}
+)
+
+// Compression is a blob compression method, used by Encoder and by Decoder to select Decompressor.
+type Compression int
+
+const (
+	// Zlib is the default compression, supported by all PBF readers.
+	Zlib Compression = iota
+
+	// Zstd is faster and produces smaller files than Zlib, but is not supported by some readers.
+	Zstd
+
+	// Uncompressed blobs are written as is.
+	Uncompressed
+
+	// Lzma and Lz4 compressions are supported by Decoder only.
+	Lzma
+	Lz4
+)
+
+func (c Compression) String() string {
+	switch c {
+	case Zlib:
+		return "zlib"
+	case Zstd:
+		return "zstd"
+	case Uncompressed:
+		return "none"
+	case Lzma:
+		return "lzma"
+	case Lz4:
+		return "lz4"
+	default:
+		return fmt.Sprintf("Compression(%d)", int(c))
+	}
+}
+
+// An Encoder writes OpenStreetMap PBF data to an output stream.
+type Encoder struct {
+	w   io.Writer
+	buf *bytes.Buffer
+
+	header        Header
+	headerWritten bool
+
+	compression Compression
+	level       int // zero for default
+	zstdEncoder *zstd.Encoder
+	blockSize   int
+	transforms  []Transform
+
+	// pending objects of the same type, written as one PrimitiveBlock
+	q  []interface{}
+	de *dataEncoder
+}
+
+// NewEncoder returns a new encoder that writes to w.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{
+		w:      w,
+		buf:    bytes.NewBuffer(make([]byte, 0, initialBlobBufSize)),
+		header: Header{WritingProgram: writingProgram},
+		q:      make([]interface{}, 0, maxBlockEntities),
+		de:     new(dataEncoder),
+
+		blockSize: maxBlockEntities,
+	}
+}
+
+// SetCompression sets compression method for written blobs. Default is Zlib.
+func (enc *Encoder) SetCompression(c Compression) {
+	enc.compression = c
+}
+
+// SetCompressionLevel sets compression level of Zlib, from 1 to 9, or of Zstd, from 1 to 22 as in
+// zstd command. Zero selects default level. Must be called before Encode.
+func (enc *Encoder) SetCompressionLevel(level int) {
+	enc.level = level
+}
+
+// SetBlockSize sets maximum number of objects written per PrimitiveBlock, default is 8000.
+func (enc *Encoder) SetBlockSize(n int) {
+	enc.blockSize = n
+}
+
+// SetHeader sets metadata written to OSMHeader block. Required features are determined by Encoder:
+// from RequiredFeatures field only HistoricalInformation is used, it makes Encoder write visible flag
+// for all objects. Empty WritingProgram is replaced with default value. Must be called before Encode.
+func (enc *Encoder) SetHeader(h *Header) {
+	enc.header = *h
+	if enc.header.WritingProgram == "" {
+		enc.header.WritingProgram = writingProgram
+	}
+
+	enc.de.historical = false
+	for _, feature := range h.RequiredFeatures {
+		if feature == historicalFeature {
+			enc.de.historical = true
+		}
+	}
+}
+
+// Encode writes a pointer to Node, Way or Relation struct to the output stream. Bound sets
+// bounding box of OSMHeader, so it must be passed before objects.
+// Objects are batched into PrimitiveBlocks, so they are not written immediately;
+// Close must be called to write remaining objects.
+//
+// Objects should be passed in the usual OSM order: nodes, then ways, then relations.
+// Each change of object type starts a new PrimitiveBlock.
+//
+// Way.NodeLocations are written if present for all way nodes; in that case LocationsOnWays
+// should be added to Header.OptionalFeatures.
+func (enc *Encoder) Encode(v interface{}) error {
+	o, ok := v.(Object)
+	if !ok {
+		return fmt.Errorf("unknown type %T", v)
+	}
+	if b, ok := o.(*Bound); ok {
+		// bounding box of OSMHeader, which is written before the first PrimitiveBlock
+		if enc.headerWritten || len(enc.q) > 0 {
+			return errors.New("bound after objects")
+		}
+		bbox := b.BBox
+		enc.header.BBox = &bbox
+		return nil
+	}
+	if o = applyTransforms(enc.transforms, o, false); o == nil {
+		return nil
+	}
+
+	if len(enc.q) > 0 {
+		last := enc.q[len(enc.q)-1].(Object)
+		if last.Kind() != o.Kind() || len(enc.q) >= enc.blockSize {
+			if err := enc.flush(); err != nil {
+				return err
+			}
+		}
+	}
+
+	enc.q = append(enc.q, o)
+	return nil
+}
+
+// Close writes all pending objects to the output stream. It does not close the underlying writer.
+// If no objects were encoded, only OSMHeader is written.
+func (enc *Encoder) Close() error {
+	if err := enc.flush(); err != nil {
+		return err
+	}
+	return enc.writeOSMHeader()
+}
+
+func (enc *Encoder) flush() error {
+	if len(enc.q) == 0 {
+		return nil
+	}
+
+	if err := enc.writeOSMHeader(); err != nil {
+		return err
+	}
+
+	data, err := proto.Marshal(enc.de.Encode(enc.q))
+	if err != nil {
+		return err
+	}
+
+	// release references to written objects
+	for i := range enc.q {
+		enc.q[i] = nil
+	}
+	enc.q = enc.q[:0]
+
+	return enc.writeFileBlock("OSMData", data)
+}
+
+func (enc *Encoder) writeOSMHeader() error {
+	if enc.headerWritten {
+		return nil
+	}
+
+	requiredFeatures := append([]string{}, writeFeatures...)
+	if enc.de.historical {
+		requiredFeatures = append(requiredFeatures, historicalFeature)
+	}
+	data, err := proto.Marshal(newHeaderBlock(&enc.header, requiredFeatures))
+	if err != nil {
+		return err
+	}
+
+	enc.headerWritten = true
+	return enc.writeFileBlock("OSMHeader", data)
+}
+
+func (enc *Encoder) writeFileBlock(blobType string, data []byte) error {
+	blob, err := enc.newBlob(data)
+	if err != nil {
+		return err
+	}
+	return enc.writeBlob(blobType, blob)
+}
+
+func (enc *Encoder) writeBlob(blobType string, blob *OSMPBF.Blob) error {
+	blobData, err := proto.Marshal(blob)
+	if err != nil {
+		return err
+	}
+	if len(blobData) >= MaxBlobSize {
+		return fmt.Errorf("%w: %d >= %d", ErrBlobTooLarge, len(blobData), MaxBlobSize)
+	}
+
+	blobHeader := &OSMPBF.BlobHeader{
+		Type:     proto.String(blobType),
+		Datasize: proto.Int32(int32(len(blobData))),
+	}
+	blobHeaderData, err := proto.Marshal(blobHeader)
+	if err != nil {
+		return err
+	}
+	if len(blobHeaderData) >= MaxBlobHeaderSize {
+		return fmt.Errorf("%w: %d >= %d", ErrBlobHeaderTooLarge, len(blobHeaderData), MaxBlobHeaderSize)
+	}
+
+	enc.buf.Reset()
+	var size [4]byte
+	binary.BigEndian.PutUint32(size[:], uint32(len(blobHeaderData)))
+	enc.buf.Write(size[:])
+	enc.buf.Write(blobHeaderData)
+	enc.buf.Write(blobData)
+	_, err = enc.buf.WriteTo(enc.w)
+	return err
+}
+
+func (enc *Encoder) newBlob(data []byte) (*OSMPBF.Blob, error) {
+	switch enc.compression {
+	case Zlib:
+		level := zlib.DefaultCompression
+		if enc.level != 0 {
+			level = enc.level
+		}
+		var buf bytes.Buffer
+		w, err := zlib.NewWriterLevel(&buf, level)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(data); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+
+		return &OSMPBF.Blob{
+			RawSize:  proto.Int32(int32(len(data))),
+			ZlibData: buf.Bytes(),
+		}, nil
+
+	case Zstd:
+		if enc.zstdEncoder == nil {
+			opts := []zstd.EOption{zstd.WithEncoderConcurrency(1)}
+			if enc.level != 0 {
+				opts = append(opts, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(enc.level)))
+			}
+			var err error
+			if enc.zstdEncoder, err = zstd.NewWriter(nil, opts...); err != nil {
+				return nil, err
+			}
+		}
+
+		return &OSMPBF.Blob{
+			RawSize:  proto.Int32(int32(len(data))),
+			ZstdData: enc.zstdEncoder.EncodeAll(data, nil),
+		}, nil
+
+	case Uncompressed:
+		return &OSMPBF.Blob{
+			Raw: data,
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("unknown compression %d", enc.compression)
+	}
+}
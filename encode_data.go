@@ -0,0 +1,294 @@
+package osmpbf
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/brechtbm/osmpbf/OSMPBF"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// granularity and date granularity used for writing, same as protobuf defaults
+	encodeGranularity     = 100
+	encodeDateGranularity = 1000
+)
+
+// Encoder for Blob with OSMData (PrimitiveBlock)
+type dataEncoder struct {
+	st   []string
+	sids map[string]uint32
+
+	// write visible flag, it is written only with HistoricalInformation feature
+	historical bool
+}
+
+// Encode makes PrimitiveBlock with single PrimitiveGroup from objects of the same type.
+func (enc *dataEncoder) Encode(q []interface{}) *OSMPBF.PrimitiveBlock {
+	enc.st = []string{""} // index 0 is reserved as delimiter
+	enc.sids = make(map[string]uint32)
+
+	pg := new(OSMPBF.PrimitiveGroup)
+	switch q[0].(type) {
+	case *Node:
+		pg.Dense = enc.encodeDenseNodes(q)
+	case *Way:
+		pg.Ways = enc.encodeWays(q)
+	case *Relation:
+		pg.Relations = enc.encodeRelations(q)
+	}
+
+	return &OSMPBF.PrimitiveBlock{
+		Stringtable:     &OSMPBF.StringTable{S: enc.st},
+		Primitivegroup:  []*OSMPBF.PrimitiveGroup{pg},
+		Granularity:     proto.Int32(encodeGranularity),
+		DateGranularity: proto.Int32(encodeDateGranularity),
+	}
+}
+
+// Returns string table index of s, adding it to the table if required.
+func (enc *dataEncoder) sid(s string) uint32 {
+	id, ok := enc.sids[s]
+	if !ok {
+		id = uint32(len(enc.st))
+		enc.st = append(enc.st, s)
+		enc.sids[s] = id
+	}
+	return id
+}
+
+func (enc *dataEncoder) encodeDenseNodes(q []interface{}) *OSMPBF.DenseNodes {
+	dn := &OSMPBF.DenseNodes{
+		Id:  make([]int64, len(q)),
+		Lat: make([]int64, len(q)),
+		Lon: make([]int64, len(q)),
+	}
+
+	var hasTags, hasInfo bool
+	for _, o := range q {
+		node := o.(*Node)
+		hasTags = hasTags || len(node.Tags) > 0 || len(node.TagList) > 0
+		hasInfo = hasInfo || !isZeroInfo(node.Info)
+	}
+
+	var di *OSMPBF.DenseInfo
+	if hasInfo {
+		di = &OSMPBF.DenseInfo{
+			Version:   make([]int32, len(q)),
+			Timestamp: make([]int64, len(q)),
+			Changeset: make([]int64, len(q)),
+			Uid:       make([]int32, len(q)),
+			UserSid:   make([]int32, len(q)),
+		}
+		if enc.historical {
+			di.Visible = make([]bool, len(q))
+		}
+		dn.Denseinfo = di
+	}
+
+	var id, lat, lon int64
+	var state denseInfoState
+	for index, o := range q {
+		node := o.(*Node)
+
+		// delta encoding
+		nodeLat, nodeLon := toCoordinate(node.Lat), toCoordinate(node.Lon)
+		dn.Id[index] = node.ID - id
+		dn.Lat[index] = nodeLat - lat
+		dn.Lon[index] = nodeLon - lon
+		id, lat, lon = node.ID, nodeLat, nodeLon
+
+		if hasTags {
+			for _, tag := range sortedTags(node.Tags, node.TagList) {
+				dn.KeysVals = append(dn.KeysVals, int32(enc.sid(tag.Key)), int32(enc.sid(tag.Value)))
+			}
+			dn.KeysVals = append(dn.KeysVals, 0)
+		}
+
+		if di != nil {
+			enc.encodeDenseInfo(&state, di, index, node.Info)
+		}
+	}
+
+	return dn
+}
+
+func (enc *dataEncoder) encodeWays(q []interface{}) []*OSMPBF.Way {
+	ways := make([]*OSMPBF.Way, len(q))
+	for index, o := range q {
+		way := o.(*Way)
+
+		keys, vals := enc.encodeTags(sortedTags(way.Tags, way.TagList))
+
+		var nodeID int64
+		refs := make([]int64, len(way.NodeIDs))
+		for i, id := range way.NodeIDs {
+			refs[i] = id - nodeID // delta encoding
+			nodeID = id
+		}
+
+		ways[index] = &OSMPBF.Way{
+			Id:   proto.Int64(way.ID),
+			Keys: keys,
+			Vals: vals,
+			Info: enc.encodeInfo(way.Info),
+			Refs: refs,
+		}
+
+		// LocationsOnWays
+		if len(way.NodeLocations) > 0 && len(way.NodeLocations) == len(way.NodeIDs) {
+			var lat, lon int64
+			lats := make([]int64, len(way.NodeLocations))
+			lons := make([]int64, len(way.NodeLocations))
+			for i, l := range way.NodeLocations {
+				nodeLat, nodeLon := toCoordinate(l.Lat), toCoordinate(l.Lon)
+				lats[i] = nodeLat - lat // delta encoding
+				lons[i] = nodeLon - lon
+				lat, lon = nodeLat, nodeLon
+			}
+			ways[index].Lat = lats
+			ways[index].Lon = lons
+		}
+	}
+	return ways
+}
+
+func (enc *dataEncoder) encodeRelations(q []interface{}) []*OSMPBF.Relation {
+	relations := make([]*OSMPBF.Relation, len(q))
+	for index, o := range q {
+		rel := o.(*Relation)
+
+		keys, vals := enc.encodeTags(sortedTags(rel.Tags, rel.TagList))
+
+		var memID int64
+		memIDs := make([]int64, len(rel.Members))
+		types := make([]OSMPBF.Relation_MemberType, len(rel.Members))
+		roleIDs := make([]int32, len(rel.Members))
+		for i, m := range rel.Members {
+			memIDs[i] = m.ID - memID // delta encoding
+			memID = m.ID
+
+			switch m.Type {
+			case NodeType:
+				types[i] = OSMPBF.Relation_NODE
+			case WayType:
+				types[i] = OSMPBF.Relation_WAY
+			case RelationType:
+				types[i] = OSMPBF.Relation_RELATION
+			}
+
+			roleIDs[i] = int32(enc.sid(m.Role))
+		}
+
+		relations[index] = &OSMPBF.Relation{
+			Id:       proto.Int64(rel.ID),
+			Keys:     keys,
+			Vals:     vals,
+			Info:     enc.encodeInfo(rel.Info),
+			RolesSid: roleIDs,
+			Memids:   memIDs,
+			Types:    types,
+		}
+	}
+	return relations
+}
+
+// Make two parallel arrays of string IDs from tags.
+func (enc *dataEncoder) encodeTags(tags TagList) (keyIDs, valueIDs []uint32) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+
+	keyIDs = make([]uint32, 0, len(tags))
+	valueIDs = make([]uint32, 0, len(tags))
+	for _, tag := range tags {
+		keyIDs = append(keyIDs, enc.sid(tag.Key))
+		valueIDs = append(valueIDs, enc.sid(tag.Value))
+	}
+	return keyIDs, valueIDs
+}
+
+// Returns tags from map sorted by key, so output does not depend on map iteration order.
+// If map is empty, list is returned as is.
+func sortedTags(tags map[string]string, list TagList) TagList {
+	if len(tags) == 0 {
+		return list
+	}
+
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	l := make(TagList, len(keys))
+	for i, key := range keys {
+		l[i] = Tag{key, tags[key]}
+	}
+	return l
+}
+
+func (enc *dataEncoder) encodeInfo(info Info) *OSMPBF.Info {
+	if isZeroInfo(info) {
+		return nil
+	}
+
+	i := &OSMPBF.Info{
+		Version:   proto.Int32(int32(info.Version)),
+		Timestamp: proto.Int64(toTimestamp(info.Timestamp)),
+		Changeset: proto.Int64(int64(info.Changeset)),
+		Uid:       proto.Int32(info.Uid),
+		UserSid:   proto.Uint32(enc.sid(info.User)),
+	}
+	if enc.historical {
+		i.Visible = proto.Bool(info.Visible)
+	}
+	return i
+}
+
+func (enc *dataEncoder) encodeDenseInfo(state *denseInfoState, di *OSMPBF.DenseInfo, index int, info Info) {
+	di.Version[index] = int32(info.Version)
+
+	timestamp := toTimestamp(info.Timestamp)
+	di.Timestamp[index] = timestamp - state.timestamp
+	state.timestamp = timestamp
+
+	di.Changeset[index] = int64(info.Changeset - state.changeset)
+	state.changeset = info.Changeset
+
+	di.Uid[index] = info.Uid - state.uid
+	state.uid = info.Uid
+
+	userSid := int32(enc.sid(info.User))
+	di.UserSid[index] = userSid - state.userSid
+	state.userSid = userSid
+
+	if di.Visible != nil {
+		di.Visible[index] = info.Visible
+	}
+}
+
+// Info without any metadata is not written; Visible alone is not considered metadata,
+// since zero value of Info has it unset.
+func isZeroInfo(info Info) bool {
+	return info.Version == 0 && info.Timestamp.IsZero() && info.Changeset == 0 && info.Uid == 0 && info.User == ""
+}
+
+// Converts degrees to units of encodeGranularity nanodegrees.
+func toCoordinate(degrees float64) int64 {
+	return int64(math.Floor(degrees*1e9/encodeGranularity + 0.5))
+}
+
+// Converts degrees to nanodegrees.
+func toNanodegrees(degrees float64) int64 {
+	return int64(math.Floor(degrees*1e9 + 0.5))
+}
+
+// Converts time to units of encodeDateGranularity milliseconds.
+func toTimestamp(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano() / int64(time.Millisecond) / encodeDateGranularity
+}
@@ -0,0 +1,141 @@
+package osmpbf
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func decodeAll(t *testing.T, d *Decoder) []interface{} {
+	if err := d.Start(2); err != nil {
+		t.Fatal(err)
+	}
+
+	var objects []interface{}
+	for {
+		v, err := d.Decode()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		objects = append(objects, v)
+	}
+	return objects
+}
+
+func encodeObjects(t *testing.T, objects ...Object) *bytes.Buffer {
+	var buf bytes.Buffer
+	e := NewEncoder(&buf)
+	for _, o := range objects {
+		if err := e.Encode(o); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := e.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return &buf
+}
+
+func TestEncodeDecode(t *testing.T) {
+	untagged := &Node{ID: 18088579, Lat: -33.8688197, Lon: 151.2092955, Tags: map[string]string{}, Info: en.Info}
+	objects := []interface{}{en, untagged, ew, er}
+
+	var buf bytes.Buffer
+	e := NewEncoder(&buf)
+	for _, o := range objects {
+		if err := e.Encode(o); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := e.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	decoded := decodeAll(t, NewDecoder(&buf))
+	if len(decoded) != len(objects) {
+		t.Fatalf("expected %d objects, got %d", len(objects), len(decoded))
+	}
+
+	for i, v := range decoded {
+		expected := objects[i]
+		if n, ok := v.(*Node); ok {
+			en := expected.(*Node)
+			if math.Abs(n.Lat-en.Lat) > 1e-7 || math.Abs(n.Lon-en.Lon) > 1e-7 {
+				t.Errorf("\nExpected: %v, %v\nActual:   %v, %v", en.Lat, en.Lon, n.Lat, n.Lon)
+			}
+			c := *n
+			c.Lat, c.Lon = en.Lat, en.Lon
+			v = &c
+		}
+		if !reflect.DeepEqual(expected, v) {
+			t.Errorf("\nExpected: %#v\nActual:   %#v", expected, v)
+		}
+	}
+}
+
+func TestEncodeUserInfo(t *testing.T) {
+	// Info built by user has Visible unset, objects are not deleted without HistoricalInformation
+	info := Info{Version: 3, Timestamp: parseTime("2020-01-02T03:04:05Z"), Changeset: 42, Uid: 7, User: "user"}
+	objects := []Object{
+		&Node{ID: 1, Lat: 1, Lon: 2, Info: info},
+		&Way{ID: 10, NodeIDs: []int64{1, 2}, Info: info},
+	}
+
+	for _, v := range decodeAll(t, NewDecoder(encodeObjects(t, objects...))) {
+		decoded := *objectInfo(v.(Object))
+		expected := info
+		expected.Visible = true
+		if decoded != expected {
+			t.Errorf("\nExpected: %#v\nActual:   %#v", expected, decoded)
+		}
+	}
+}
+
+func TestEncodeBlocks(t *testing.T) {
+	var buf bytes.Buffer
+	e := NewEncoder(&buf)
+	n := maxBlockEntities*2 + 1
+	for i := 1; i <= n; i++ {
+		if err := e.Encode(&Node{ID: int64(i), Lat: float64(i) / 1e3, Lon: -float64(i) / 1e3}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := e.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	decoded := decodeAll(t, NewDecoder(&buf))
+	if len(decoded) != n {
+		t.Fatalf("expected %d nodes, got %d", n, len(decoded))
+	}
+	for i, v := range decoded {
+		node := v.(*Node)
+		if node.ID != int64(i+1) {
+			t.Fatalf("expected node %d, got %d", i+1, node.ID)
+		}
+		if node.Info.Version != 0 || !node.Info.Timestamp.IsZero() {
+			t.Fatalf("expected empty Info, got %#v", node.Info)
+		}
+	}
+}
+
+func TestEncodeEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	if err := NewEncoder(&buf).Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if decoded := decodeAll(t, NewDecoder(&buf)); len(decoded) != 0 {
+		t.Errorf("expected no objects, got %d", len(decoded))
+	}
+}
+
+func TestEncodeUnknownType(t *testing.T) {
+	if err := NewEncoder(new(bytes.Buffer)).Encode(42); err == nil {
+		t.Error("expected error")
+	}
+}
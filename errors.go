@@ -0,0 +1,77 @@
+package osmpbf
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrBlobHeaderTooLarge is returned for BlobHeader larger than maximum size, see WithMaxBlobHeaderSize.
+	ErrBlobHeaderTooLarge = errors.New("BlobHeader is too large")
+
+	// ErrBlobTooLarge is returned for Blob larger than maximum size, see WithMaxBlobSize.
+	ErrBlobTooLarge = errors.New("Blob is too large")
+)
+
+// UnexpectedBlockTypeError is returned for fileblock of unexpected type, for example
+// if input stream does not start with OSMHeader.
+type UnexpectedBlockTypeError struct {
+	Type     string
+	Expected string
+}
+
+func (e *UnexpectedBlockTypeError) Error() string {
+	return fmt.Sprintf("unexpected fileblock of type %s, expected %s", e.Type, e.Expected)
+}
+
+// UnsupportedFeatureError is returned for OSMHeader with required feature not supported by decoder.
+type UnsupportedFeatureError struct {
+	Feature string
+}
+
+func (e *UnsupportedFeatureError) Error() string {
+	return fmt.Sprintf("parser does not have %s capability", e.Feature)
+}
+
+// CorruptBlockError describes fileblock skipped because of error, see WithSkipCorrupt.
+type CorruptBlockError struct {
+	Offset int64 // of fileblock start
+	Err    error
+}
+
+func (e *CorruptBlockError) Error() string {
+	return fmt.Sprintf("fileblock at offset %d: %v", e.Offset, e.Err)
+}
+
+func (e *CorruptBlockError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationError is returned for violation of PBF format specification found in strict mode,
+// see WithStrict.
+type ValidationError struct {
+	Offset int64 // of fileblock start
+	Err    error
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid fileblock at offset %d: %v", e.Offset, e.Err)
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// Skipped returns errors of fileblocks skipped so far, see WithSkipCorrupt. After Decode returns
+// io.EOF it is a summary of all skipped fileblocks. It is safe to call concurrently with Decode.
+func (dec *Decoder) Skipped() []*CorruptBlockError {
+	dec.skippedMu.Lock()
+	defer dec.skippedMu.Unlock()
+	return append([]*CorruptBlockError(nil), dec.skipped...)
+}
+
+func (dec *Decoder) addSkipped(offset int64, err error) {
+	dec.skippedMu.Lock()
+	dec.skipped = append(dec.skipped, &CorruptBlockError{offset, err})
+	dec.skippedMu.Unlock()
+}
@@ -0,0 +1,84 @@
+package osmpbf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/brechtbm/osmpbf/OSMPBF"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestErrors(t *testing.T) {
+	data := encodeObjects(t, en, ew, er).Bytes()
+
+	d := NewDecoder(bytes.NewReader(data), WithMaxBlobSize(10))
+	if err := d.Start(1); !errors.Is(err, ErrBlobTooLarge) {
+		t.Errorf("expected ErrBlobTooLarge, got %v", err)
+	}
+
+	d = NewDecoder(bytes.NewReader(data), WithMaxBlobHeaderSize(4))
+	if err := d.Start(1); !errors.Is(err, ErrBlobHeaderTooLarge) {
+		t.Errorf("expected ErrBlobHeaderTooLarge, got %v", err)
+	}
+
+	// no OSMHeader
+	idx, err := BuildIndex(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	d = NewDecoder(bytes.NewReader(data[idx.Entries[1].Offset:]))
+	var typeErr *UnexpectedBlockTypeError
+	if err := d.Start(1); !errors.As(err, &typeErr) || typeErr.Type != "OSMData" || typeErr.Expected != "OSMHeader" {
+		t.Errorf("expected UnexpectedBlockTypeError, got %v", err)
+	}
+
+	hb, err := proto.Marshal(newHeaderBlock(&Header{}, []string{"OsmSchema-V0.6", "Unknown"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var featureErr *UnsupportedFeatureError
+	if _, err := DecodeHeaderBlob(&OSMPBF.Blob{Raw: hb}); !errors.As(err, &featureErr) || featureErr.Feature != "Unknown" {
+		t.Errorf("expected UnsupportedFeatureError, got %v", err)
+	}
+}
+
+// Returns input stream with objects and fileblock with negative Blob size after them.
+func negativeBlobSizeStream(t *testing.T) []byte {
+	data := encodeObjects(t, en, ew).Bytes()
+	bh, err := proto.Marshal(&OSMPBF.BlobHeader{Type: proto.String("OSMData"), Datasize: proto.Int32(-100)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var size [4]byte
+	binary.BigEndian.PutUint32(size[:], uint32(len(bh)))
+	data = append(append(data, size[:]...), bh...)
+	return append(data, make([]byte, 200)...)
+}
+
+// Decodes all objects, returns their number and the error ending decoding.
+func decodeUntilError(t *testing.T, d *Decoder) (int, error) {
+	defer d.Close()
+	if err := d.Start(2); err != nil {
+		return 0, err
+	}
+	n := 0
+	for {
+		if _, err := d.Decode(); err != nil {
+			return n, err
+		}
+		n++
+	}
+}
+
+func TestNegativeBlobSize(t *testing.T) {
+	data := negativeBlobSizeStream(t)
+	for _, skipCorrupt := range []bool{false, true} {
+		n, err := decodeUntilError(t, NewDecoder(bytes.NewReader(data), WithSkipCorrupt(skipCorrupt)))
+		if n != 2 || err == nil || err == io.EOF {
+			t.Errorf("expected 2 objects and Blob size error, got %d and %v", n, err)
+		}
+	}
+}
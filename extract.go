@@ -0,0 +1,311 @@
+package osmpbf
+
+import (
+	"io"
+)
+
+// ExtractMode controls which objects referenced by objects inside region are added to extract.
+type ExtractMode int
+
+const (
+	// SimpleExtract keeps nodes inside region, ways with at least one of them and relations with
+	// at least one kept member, where member relations are kept only if they precede the relation.
+	// Ways crossing region border reference nodes missing in extract. It needs one pass over input.
+	SimpleExtract ExtractMode = iota
+
+	// CompleteWays additionally keeps all nodes of kept ways, like osmosis completeWays option.
+	// It needs two passes over input.
+	CompleteWays
+
+	// CompleteRelations additionally keeps all members of kept relations, with all nodes of member
+	// ways, like osmosis completeRelations option. Members of member relations are not added.
+	// It needs up to three passes over input.
+	CompleteRelations
+)
+
+// Decides which objects are kept in extract of one region.
+type extractor struct {
+	region Region
+	mode   ExtractMode
+
+	inside    *IDTracker // nodes inside region
+	nodes     *IDTracker // nodes referenced by kept ways, and members of kept relations
+	ways      *IDTracker
+	relations *IDTracker
+
+	// ways added as members of kept relations, their nodes are added by the next pass
+	memberWays *IDTracker
+
+	// relation members of relations not kept yet, or all members of kept relations and relations
+	// with member relations in CompleteRelations mode, by relation ID
+	members map[int64][]Member
+}
+
+func newExtractor(region Region, mode ExtractMode) *extractor {
+	return &extractor{
+		region:     region,
+		mode:       mode,
+		inside:     NewIDTracker(),
+		nodes:      NewIDTracker(),
+		ways:       NewIDTracker(),
+		relations:  NewIDTracker(),
+		memberWays: NewIDTracker(),
+		members:    make(map[int64][]Member),
+	}
+}
+
+// Checks object in the first pass over input, objects must be passed in input stream order.
+func (e *extractor) scan(o Object) {
+	switch o := o.(type) {
+	case *Node:
+		if e.region.Contains(o.Lat, o.Lon) {
+			e.inside.Add(o.ID)
+		}
+
+	case *Way:
+		for _, id := range o.NodeIDs {
+			if e.inside.Contains(id) {
+				e.ways.Add(o.ID)
+				if e.mode >= CompleteWays {
+					for _, ref := range o.NodeIDs {
+						e.nodes.Add(ref)
+					}
+				}
+				break
+			}
+		}
+
+	case *Relation:
+		var relationMembers []Member
+		keep := false
+		for _, m := range o.Members {
+			switch m.Type {
+			case NodeType:
+				keep = keep || e.inside.Contains(m.ID)
+			case WayType:
+				keep = keep || e.ways.Contains(m.ID)
+			case RelationType:
+				keep = keep || e.relations.Contains(m.ID)
+				relationMembers = append(relationMembers, m)
+			}
+		}
+		if keep {
+			e.relations.Add(o.ID)
+		}
+		if e.mode >= CompleteRelations && (keep || len(relationMembers) > 0) {
+			e.members[o.ID] = append([]Member(nil), o.Members...)
+		} else if !keep && len(relationMembers) > 0 {
+			// relation may be kept later by its member relation kept after it
+			e.members[o.ID] = relationMembers
+		}
+	}
+}
+
+// Finishes the first pass: keeps relations with kept member relations and adds members of kept
+// relations. Returns true if nodes of added member ways must be read by another pass.
+func (e *extractor) finish() bool {
+	for changed := true; changed; {
+		changed = false
+		for id, members := range e.members {
+			if e.relations.Contains(id) {
+				continue
+			}
+			for _, m := range members {
+				if m.Type == RelationType && e.relations.Contains(m.ID) {
+					e.relations.Add(id)
+					changed = true
+					break
+				}
+			}
+		}
+	}
+
+	if e.mode >= CompleteRelations {
+		for id, members := range e.members {
+			if !e.relations.Contains(id) {
+				continue
+			}
+			for _, m := range members {
+				switch m.Type {
+				case NodeType:
+					e.nodes.Add(m.ID)
+				case WayType:
+					if !e.ways.Contains(m.ID) {
+						e.ways.Add(m.ID)
+						e.memberWays.Add(m.ID)
+					}
+				case RelationType:
+					e.relations.Add(m.ID)
+				}
+			}
+		}
+	}
+	e.members = nil
+	return e.memberWays.Len() > 0
+}
+
+// Adds nodes of member ways in the second pass.
+func (e *extractor) scanWay(w *Way) {
+	if e.memberWays.Contains(w.ID) {
+		for _, id := range w.NodeIDs {
+			e.nodes.Add(id)
+		}
+	}
+}
+
+// Returns true if object is kept in extract, in the last pass.
+func (e *extractor) keep(o Object) bool {
+	switch o := o.(type) {
+	case *Node:
+		return e.inside.Contains(o.ID) || e.nodes.Contains(o.ID)
+	case *Way:
+		return e.ways.Contains(o.ID)
+	case *Relation:
+		return e.relations.Contains(o.ID)
+	}
+	return false
+}
+
+// Extract writes extract of region from input to w as PBF data: objects inside region and, depending
+// on mode, objects they reference. Input must be sorted by type, so nodes precede ways and ways
+// precede relations. Input is read by several passes, each with a new decoder returned by open,
+// which is started by Extract; for example, open may call OpenMmapDecoder. OSMHeader of input is
+// written to w, with bounding box of region if it is BBox or Polygon.
+func Extract(w io.Writer, open func() (*Decoder, error), region Region, mode ExtractMode) error {
+	e := newExtractor(region, mode)
+	if mode >= CompleteWays {
+		if err := readPass(open, false, func(o Object) {
+			e.scan(o)
+			o.Release()
+		}); err != nil {
+			return err
+		}
+		if e.finish() {
+			// nodes of member ways added by relations
+			if err := readPass(open, true, func(o Object) {
+				if w, ok := o.(*Way); ok {
+					e.scanWay(w)
+				}
+				o.Release()
+			}); err != nil {
+				return err
+			}
+		}
+	}
+
+	dec, err := open()
+	if err != nil {
+		return err
+	}
+	if err := dec.Start(0); err != nil {
+		dec.Close()
+		return err
+	}
+	defer dec.Close()
+
+	enc := NewEncoder(w)
+	enc.SetHeader(extractHeader(dec.Header(), region))
+	var encodeErr error
+	err = readAll(dec, func(o Object) {
+		if mode == SimpleExtract {
+			// decided by objects read so far
+			e.scan(o)
+		}
+		if e.keep(o) && encodeErr == nil {
+			encodeErr = enc.Encode(o)
+		} else {
+			o.Release()
+		}
+	})
+	if err != nil {
+		return err
+	}
+	if encodeErr != nil {
+		return encodeErr
+	}
+	return enc.Close()
+}
+
+// Reads all objects of a new decoder returned by open, skipping to ways if waysOnly is set.
+func readPass(open func() (*Decoder, error), waysOnly bool, fn func(o Object)) error {
+	dec, err := open()
+	if err != nil {
+		return err
+	}
+	defer dec.Close()
+	if waysOnly {
+		dec.SkipToWays()
+	}
+	if err := dec.Start(0); err != nil {
+		return err
+	}
+	return readAll(dec, fn)
+}
+
+// Returns header of extract of input with header h.
+func extractHeader(h *Header, region Region) *Header {
+	var eh Header
+	if h != nil {
+		eh = *h
+	}
+	eh.BBox = nil
+	switch r := region.(type) {
+	case BBox:
+		eh.BBox = &r
+	case *BBox:
+		bbox := *r
+		eh.BBox = &bbox
+	case *Polygon:
+		bbox := r.BBox()
+		eh.BBox = &bbox
+	}
+	return &eh
+}
+
+// ExtractTarget is a region and output of its extract, see MultiExtract.
+type ExtractTarget struct {
+	Region Region
+	W      io.Writer
+}
+
+// MultiExtract writes extracts of several regions in one pass over input, like osmium extract with
+// config file: each object is written as PBF data to outputs of all extracts it belongs to. Extracts
+// are made in SimpleExtract mode, which needs only one pass. Objects are read from started decoder
+// until the end of input stream, which must be sorted by type. OSMHeader of input is written to all
+// outputs, with bounding boxes of regions, see Extract.
+func MultiExtract(dec *Decoder, targets []ExtractTarget) error {
+	extractors := make([]*extractor, len(targets))
+	encoders := make([]*Encoder, len(targets))
+	for i, t := range targets {
+		extractors[i] = newExtractor(t.Region, SimpleExtract)
+		encoders[i] = NewEncoder(t.W)
+		encoders[i].SetHeader(extractHeader(dec.Header(), t.Region))
+	}
+
+	var encodeErr error
+	err := readAll(dec, func(o Object) {
+		kept := false
+		for i, e := range extractors {
+			e.scan(o)
+			if e.keep(o) && encodeErr == nil {
+				encodeErr = encoders[i].Encode(o)
+				kept = true
+			}
+		}
+		if !kept {
+			o.Release()
+		}
+	})
+	if err != nil {
+		return err
+	}
+	if encodeErr != nil {
+		return encodeErr
+	}
+	for _, enc := range encoders {
+		if err := enc.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package osmpbf
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// Returns kind and ID of objects, like "n1".
+func objectIDs(objects []interface{}) []string {
+	ids := make([]string, len(objects))
+	for i, o := range objects {
+		kind, id := kindID(o.(Object))
+		ids[i] = fmt.Sprintf("%c%d", kind.String()[0], id)
+	}
+	return ids
+}
+
+func TestExtract(t *testing.T) {
+	data := encodeObjects(t,
+		&Node{ID: 1, Lat: 1, Lon: 1},
+		&Node{ID: 2, Lat: 5, Lon: 5},
+		&Node{ID: 3, Lat: 6, Lon: 6},
+		&Node{ID: 4, Lat: 7, Lon: 7},
+		&Node{ID: 5, Lat: 8, Lon: 8},
+		&Way{ID: 10, NodeIDs: []int64{1, 2}},
+		&Way{ID: 11, NodeIDs: []int64{3, 4}},
+		&Relation{ID: 19, Members: []Member{{21, RelationType, ""}}},
+		&Relation{ID: 20, Members: []Member{{10, WayType, ""}}},
+		&Relation{ID: 21, Members: []Member{{11, WayType, ""}, {5, NodeType, ""}, {20, RelationType, ""}}},
+		&Relation{ID: 22, Members: []Member{{11, WayType, ""}}},
+	).Bytes()
+	open := func() (*Decoder, error) {
+		return NewDecoder(bytes.NewReader(data)), nil
+	}
+	region := BBox{Left: 0, Right: 2, Top: 2, Bottom: 0}
+
+	for _, c := range []struct {
+		mode     ExtractMode
+		expected []string
+	}{
+		{SimpleExtract, []string{"n1", "w10", "r20", "r21"}},
+		{CompleteWays, []string{"n1", "n2", "w10", "r19", "r20", "r21"}},
+		{CompleteRelations, []string{"n1", "n2", "n3", "n4", "n5", "w10", "w11", "r19", "r20", "r21"}},
+	} {
+		var buf bytes.Buffer
+		if err := Extract(&buf, open, region, c.mode); err != nil {
+			t.Fatal(err)
+		}
+		d := NewDecoder(&buf)
+		if ids := objectIDs(decodeAll(t, d)); !reflect.DeepEqual(c.expected, ids) {
+			t.Errorf("mode %d:\nExpected: %v\nActual:   %v", c.mode, c.expected, ids)
+		}
+		if bbox := d.Header().BBox; bbox == nil || *bbox != region {
+			t.Errorf("mode %d: expected bounding box of region, got %v", c.mode, bbox)
+		}
+	}
+}
+
+func TestMultiExtract(t *testing.T) {
+	data := encodeObjects(t,
+		&Node{ID: 1, Lat: 1, Lon: 1},
+		&Node{ID: 2, Lat: 5, Lon: 5},
+		&Node{ID: 3, Lat: 9, Lon: 9},
+		&Way{ID: 10, NodeIDs: []int64{1, 2}},
+		&Way{ID: 11, NodeIDs: []int64{2, 3}},
+		&Relation{ID: 20, Members: []Member{{10, WayType, ""}, {11, WayType, ""}}},
+	)
+	d := NewDecoder(data)
+	if err := d.Start(2); err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+
+	outputs := make([]bytes.Buffer, 3)
+	targets := []ExtractTarget{
+		{BBox{Left: 0, Right: 2, Top: 2, Bottom: 0}, &outputs[0]},
+		{NewPolygon([]LatLon{{4, 4}, {4, 10}, {10, 10}, {10, 4}}), &outputs[1]},
+		{BBox{Left: 20, Right: 30, Top: 30, Bottom: 20}, &outputs[2]},
+	}
+	if err := MultiExtract(d, targets); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, expected := range [][]string{
+		{"n1", "w10", "r20"},
+		{"n2", "n3", "w10", "w11", "r20"},
+		{},
+	} {
+		if ids := objectIDs(decodeAll(t, NewDecoder(&outputs[i]))); !reflect.DeepEqual(expected, ids) {
+			t.Errorf("extract %d:\nExpected: %v\nActual:   %v", i, expected, ids)
+		}
+	}
+}
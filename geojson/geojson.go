@@ -0,0 +1,199 @@
+// Package geojson converts nodes and ways decoded by package osmpbf to GeoJSON features, and writes
+// them as FeatureCollection or as newline-delimited GeoJSON.
+//
+// GeoJSON format is described in RFC 7946.
+package geojson
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/brechtbm/osmpbf"
+)
+
+// Locations is a source of node locations, used to build geometry of ways. It is implemented by
+// osmpbf.NodeLocationStore.
+type Locations interface {
+	// Location returns location of node with given ID, or false if it is unknown.
+	Location(id int64) (osmpbf.LatLon, bool)
+}
+
+// Feature is a GeoJSON feature. ID is type and ID of object, like "node/1", as in osmtogeojson
+// output.
+type Feature struct {
+	Type       string            `json:"type"`
+	ID         string            `json:"id"`
+	Geometry   *Geometry         `json:"geometry"`
+	Properties map[string]string `json:"properties"`
+}
+
+// Geometry is a GeoJSON geometry: Point, LineString or Polygon. Coordinates are []float64,
+// [][]float64 or [][][]float64, with longitude before latitude.
+type Geometry struct {
+	Type        string      `json:"type"`
+	Coordinates interface{} `json:"coordinates"`
+}
+
+// NewFeature returns feature of node or way with its tags as properties, or nil if object has
+// no geometry: relations, and ways with less than two known node locations. Way locations are
+// taken from Way.NodeLocations if present, otherwise from locations, which may be nil; nodes with
+// unknown locations are skipped. Closed ways with all locations known are Polygons if IsArea
+// returns true for their tags, otherwise ways are LineStrings.
+func NewFeature(o osmpbf.Object, locations Locations) *Feature {
+	switch o := o.(type) {
+	case *osmpbf.Node:
+		return &Feature{
+			Type:       "Feature",
+			ID:         o.ElementID().String(),
+			Geometry:   &Geometry{"Point", []float64{o.Lon, o.Lat}},
+			Properties: properties(o.Tags, o.TagList),
+		}
+
+	case *osmpbf.Way:
+		coordinates := make([][]float64, 0, len(o.NodeIDs))
+		for i := range o.NodeIDs {
+			if l, ok := wayNode(o, i, locations); ok {
+				coordinates = append(coordinates, []float64{l.Lon, l.Lat})
+			}
+		}
+		if len(coordinates) < 2 {
+			return nil
+		}
+
+		tags := properties(o.Tags, o.TagList)
+		geometry := &Geometry{"LineString", coordinates}
+		if closed(o.NodeIDs) && len(coordinates) == len(o.NodeIDs) && IsArea(tags) {
+			geometry = &Geometry{"Polygon", [][][]float64{coordinates}}
+		}
+		return &Feature{
+			Type:       "Feature",
+			ID:         o.ElementID().String(),
+			Geometry:   geometry,
+			Properties: tags,
+		}
+	}
+	return nil
+}
+
+// Returns location of i-th node of way.
+func wayNode(w *osmpbf.Way, i int, locations Locations) (osmpbf.LatLon, bool) {
+	if len(w.NodeLocations) == len(w.NodeIDs) {
+		return w.NodeLocations[i], true
+	}
+	if locations == nil {
+		return osmpbf.LatLon{}, false
+	}
+	return locations.Location(w.NodeIDs[i])
+}
+
+// IsArea returns true if closed way with given tags is an area, see osmpbf.Tags.IsArea.
+func IsArea(tags map[string]string) bool {
+	return osmpbf.Tags(tags).IsArea()
+}
+
+func closed(ids []int64) bool {
+	return len(ids) >= 4 && ids[0] == ids[len(ids)-1]
+}
+
+func properties(tags map[string]string, list osmpbf.TagList) map[string]string {
+	if len(tags) > 0 || len(list) == 0 {
+		if tags == nil {
+			return map[string]string{}
+		}
+		return tags
+	}
+	p := make(map[string]string, len(list))
+	for _, tag := range list {
+		p[tag.Key] = tag.Value
+	}
+	return p
+}
+
+// An Encoder writes GeoJSON features of nodes and ways to an output stream.
+type Encoder struct {
+	w         *bufio.Writer
+	locations Locations
+	lines     bool // newline-delimited GeoJSON
+
+	bbox    *osmpbf.BBox
+	started bool
+}
+
+// NewEncoder returns a new encoder that writes FeatureCollection to w, with way geometry built
+// from locations, see NewFeature.
+func NewEncoder(w io.Writer, locations Locations) *Encoder {
+	return &Encoder{w: bufio.NewWriter(w), locations: locations}
+}
+
+// NewLineEncoder returns a new encoder that writes newline-delimited GeoJSON to w: one feature
+// per line, without FeatureCollection.
+func NewLineEncoder(w io.Writer, locations Locations) *Encoder {
+	return &Encoder{w: bufio.NewWriter(w), locations: locations, lines: true}
+}
+
+// Encode writes feature of a pointer to Node or Way struct to the output stream. Objects without
+// geometry, like relations, are skipped. Bound sets bbox of FeatureCollection, so it must be passed
+// before objects. Output is buffered, so Close must be called to write remaining data.
+func (enc *Encoder) Encode(v interface{}) error {
+	if b, ok := v.(*osmpbf.Bound); ok {
+		if enc.started {
+			return errors.New("bound after objects")
+		}
+		bbox := b.BBox
+		enc.bbox = &bbox
+		return nil
+	}
+
+	o, ok := v.(osmpbf.Object)
+	if !ok {
+		return fmt.Errorf("unknown type %T", v)
+	}
+	f := NewFeature(o, enc.locations)
+	if f == nil {
+		return nil
+	}
+	data, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case enc.lines:
+		enc.started = true
+		data = append(data, '\n')
+	case enc.started:
+		enc.w.WriteString(",\n")
+	default:
+		enc.writeStart()
+	}
+	_, err = enc.w.Write(data)
+	return err
+}
+
+// Close writes the end of FeatureCollection and flushes buffered data. It does not close the
+// underlying writer.
+func (enc *Encoder) Close() error {
+	if !enc.lines {
+		if !enc.started {
+			enc.writeStart()
+		}
+		enc.w.WriteString("\n]}\n")
+	}
+	return enc.w.Flush()
+}
+
+// Writes FeatureCollection up to its features.
+func (enc *Encoder) writeStart() {
+	enc.started = true
+	enc.w.WriteString(`{"type":"FeatureCollection",`)
+	if b := enc.bbox; b != nil {
+		bbox, _ := json.Marshal([]float64{b.Left, b.Bottom, b.Right, b.Top})
+		enc.w.WriteString(`"bbox":`)
+		enc.w.Write(bbox)
+		enc.w.WriteByte(',')
+	}
+	enc.w.WriteString(`"features":[` + "\n")
+}
@@ -0,0 +1,134 @@
+package geojson
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/brechtbm/osmpbf"
+)
+
+type mapLocations map[int64]osmpbf.LatLon
+
+func (m mapLocations) Location(id int64) (osmpbf.LatLon, bool) {
+	l, ok := m[id]
+	return l, ok
+}
+
+var testLocations = mapLocations{
+	1: {Lat: 0, Lon: 0},
+	2: {Lat: 0, Lon: 1},
+	3: {Lat: 1, Lon: 1},
+}
+
+func TestNewFeature(t *testing.T) {
+	for _, c := range []struct {
+		name     string
+		object   osmpbf.Object
+		expected *Feature
+	}{
+		{"node", &osmpbf.Node{ID: 1, Lat: 1.5, Lon: 2.5, TagList: osmpbf.TagList{{Key: "amenity", Value: "pub"}}},
+			&Feature{"Feature", "node/1", &Geometry{"Point", []float64{2.5, 1.5}},
+				map[string]string{"amenity": "pub"}}},
+		{"line", &osmpbf.Way{ID: 2, NodeIDs: []int64{1, 4, 2}, Tags: map[string]string{"highway": "path"}},
+			&Feature{"Feature", "way/2", &Geometry{"LineString", [][]float64{{0, 0}, {1, 0}}},
+				map[string]string{"highway": "path"}}},
+		{"closed line", &osmpbf.Way{ID: 3, NodeIDs: []int64{1, 2, 3, 1}},
+			&Feature{"Feature", "way/3", &Geometry{"LineString", [][]float64{{0, 0}, {1, 0}, {1, 1}, {0, 0}}},
+				map[string]string{}}},
+		{"polygon", &osmpbf.Way{ID: 4, NodeIDs: []int64{1, 2, 3, 1}, Tags: map[string]string{"building": "yes"}},
+			&Feature{"Feature", "way/4", &Geometry{"Polygon", [][][]float64{{{0, 0}, {1, 0}, {1, 1}, {0, 0}}}},
+				map[string]string{"building": "yes"}}},
+		{"way locations", &osmpbf.Way{ID: 5, NodeIDs: []int64{7, 8},
+			NodeLocations: []osmpbf.LatLon{{Lat: 5, Lon: 6}, {Lat: 7, Lon: 8}}},
+			&Feature{"Feature", "way/5", &Geometry{"LineString", [][]float64{{6, 5}, {8, 7}}},
+				map[string]string{}}},
+		{"unknown locations", &osmpbf.Way{ID: 6, NodeIDs: []int64{1, 5}}, nil},
+		{"relation", &osmpbf.Relation{ID: 7}, nil},
+	} {
+		if f := NewFeature(c.object, testLocations); !reflect.DeepEqual(c.expected, f) {
+			t.Errorf("%s:\nExpected: %#v\nActual:   %#v", c.name, c.expected, f)
+		}
+	}
+}
+
+func TestIsArea(t *testing.T) {
+	for _, c := range []struct {
+		tags     map[string]string
+		expected bool
+	}{
+		{map[string]string{"building": "yes"}, true},
+		{map[string]string{"building": "yes", "area": "no"}, false},
+		{map[string]string{"highway": "pedestrian", "area": "yes"}, true},
+		{map[string]string{"highway": "pedestrian"}, false},
+		{map[string]string{"natural": "coastline"}, false},
+		{map[string]string{"natural": "wood"}, true},
+	} {
+		if IsArea(c.tags) != c.expected {
+			t.Errorf("%v: expected %v", c.tags, c.expected)
+		}
+	}
+}
+
+func TestEncoder(t *testing.T) {
+	objects := []interface{}{
+		&osmpbf.Bound{BBox: osmpbf.BBox{Left: -1, Right: 2, Top: 2, Bottom: -1}},
+		&osmpbf.Node{ID: 1},
+		&osmpbf.Way{ID: 2, NodeIDs: []int64{1, 2}},
+		&osmpbf.Relation{ID: 3},
+	}
+
+	var buf bytes.Buffer
+	e := NewEncoder(&buf, testLocations)
+	for _, o := range objects {
+		if err := e.Encode(o); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := e.Encode(objects[0]); err == nil {
+		t.Error("expected error for bound after objects")
+	}
+	if err := e.Close(); err != nil {
+		t.Fatal(err)
+	}
+	var collection struct {
+		Type     string
+		BBox     []float64
+		Features []Feature
+	}
+	if err := json.Unmarshal(buf.Bytes(), &collection); err != nil {
+		t.Fatal(err)
+	}
+	if collection.Type != "FeatureCollection" || !reflect.DeepEqual(collection.BBox, []float64{-1, -1, 2, 2}) ||
+		len(collection.Features) != 2 || collection.Features[1].ID != "way/2" {
+		t.Errorf("unexpected FeatureCollection %s", buf.String())
+	}
+
+	buf.Reset()
+	e = NewLineEncoder(&buf, testLocations)
+	for _, o := range objects {
+		if err := e.Encode(o); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := e.Close(); err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"type":"Feature","id":"node/1","geometry":{"type":"Point","coordinates":[0,0]},"properties":{}}
+{"type":"Feature","id":"way/2","geometry":{"type":"LineString","coordinates":[[0,0],[1,0]]},"properties":{}}
+`
+	if buf.String() != expected {
+		t.Errorf("\nExpected: %s\nActual:   %s", expected, buf.String())
+	}
+
+	// empty collection is valid JSON
+	buf.Reset()
+	e = NewEncoder(&buf, nil)
+	if err := e.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !json.Valid(buf.Bytes()) {
+		t.Errorf("invalid empty FeatureCollection %s", buf.String())
+	}
+}
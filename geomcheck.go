@@ -0,0 +1,222 @@
+package osmpbf
+
+import "io"
+
+// GeometryIssueType is type of geometry problem found by GeometryChecker.
+type GeometryIssueType string
+
+const (
+	// DuplicateNode is a node with the same location as another node.
+	DuplicateNode GeometryIssueType = "duplicate_node"
+
+	// DegenerateWay is a way with less than two distinct nodes.
+	DegenerateWay GeometryIssueType = "degenerate_way"
+
+	// DuplicateRef is a way with the same node twice in a row.
+	DuplicateRef GeometryIssueType = "duplicate_ref"
+
+	// SelfIntersection is a closed way with crossing or touching segments.
+	SelfIntersection GeometryIssueType = "self_intersection"
+)
+
+// GeometryIssue is a geometry problem of one object. Issues are written to JSON with their field
+// names and element IDs like "way/123", so a report can be processed by other tools.
+type GeometryIssue struct {
+	Type    GeometryIssueType
+	Element ElementID
+
+	// Other is the other node with the same location for DuplicateNode, or the duplicated node
+	// for DuplicateRef.
+	Other ElementID `json:",omitempty"`
+
+	// Location of duplicate node or of intersection, nil for other issues.
+	Location *LatLon `json:",omitempty"`
+}
+
+// A GeometryChecker finds duplicate and degenerate geometry, a subset of validation of JOSM or
+// Osmose. Objects must be added in input stream order, so nodes are added before ways. Not safe
+// for concurrent use.
+type GeometryChecker struct {
+	locations NodeLocationStore
+	nodes     map[uint64]int64 // first node by packed location
+	issues    []GeometryIssue
+}
+
+// NewGeometryChecker returns a new checker. Locations of added nodes are put into store s, so
+// closed ways can be checked for self intersections; if s is nil, only ways with NodeLocations
+// are checked.
+func NewGeometryChecker(s NodeLocationStore) *GeometryChecker {
+	return &GeometryChecker{locations: s, nodes: make(map[uint64]int64)}
+}
+
+// Add checks node or way, other objects are ignored.
+func (c *GeometryChecker) Add(o Object) error {
+	switch o := o.(type) {
+	case *Node:
+		return c.addNode(o)
+	case *Way:
+		c.addWay(o)
+	}
+	return nil
+}
+
+// Issues returns issues found so far, in order of objects.
+func (c *GeometryChecker) Issues() []GeometryIssue {
+	return c.issues
+}
+
+func (c *GeometryChecker) addNode(n *Node) error {
+	l := LatLon{n.Lat, n.Lon}
+	key := packLocation(l)
+	if id, ok := c.nodes[key]; ok {
+		c.issues = append(c.issues, GeometryIssue{
+			Type:     DuplicateNode,
+			Element:  n.ElementID(),
+			Other:    NewElementID(NodeKind, id),
+			Location: &l,
+		})
+	} else {
+		c.nodes[key] = n.ID
+	}
+	if c.locations != nil {
+		return c.locations.Set(n.ID, l)
+	}
+	return nil
+}
+
+func (c *GeometryChecker) addWay(w *Way) {
+	distinct := 0
+	for i, id := range w.NodeIDs {
+		if i > 0 && id == w.NodeIDs[i-1] {
+			c.issues = append(c.issues, GeometryIssue{
+				Type:    DuplicateRef,
+				Element: w.ElementID(),
+				Other:   NewElementID(NodeKind, id),
+			})
+			continue
+		}
+		if distinct < 2 && (distinct == 0 || id != w.NodeIDs[0]) {
+			distinct++
+		}
+	}
+	if distinct < 2 {
+		c.issues = append(c.issues, GeometryIssue{Type: DegenerateWay, Element: w.ElementID()})
+		return
+	}
+
+	n := len(w.NodeIDs)
+	if n < 4 || w.NodeIDs[0] != w.NodeIDs[n-1] {
+		return
+	}
+	ring := w.NodeLocations
+	if len(ring) != n {
+		if ring = c.wayLocations(w); ring == nil {
+			return
+		}
+	}
+	if l, ok := selfIntersection(ring); ok {
+		c.issues = append(c.issues, GeometryIssue{Type: SelfIntersection, Element: w.ElementID(), Location: &l})
+	}
+}
+
+// Returns locations of way nodes from the store, or nil if some are unknown.
+func (c *GeometryChecker) wayLocations(w *Way) []LatLon {
+	if c.locations == nil {
+		return nil
+	}
+	ring := make([]LatLon, len(w.NodeIDs))
+	for i, id := range w.NodeIDs {
+		l, ok := c.locations.Location(id)
+		if !ok {
+			return nil
+		}
+		ring[i] = l
+	}
+	return ring
+}
+
+// Returns location of the first crossing or touching of non-adjacent segments of closed ring.
+// Segments of zero length are skipped, they are reported as duplicate refs.
+func selfIntersection(ring []LatLon) (LatLon, bool) {
+	var segments [][2]LatLon
+	for i := 1; i < len(ring); i++ {
+		if ring[i] != ring[i-1] {
+			segments = append(segments, [2]LatLon{ring[i-1], ring[i]})
+		}
+	}
+	n := len(segments)
+	for i := 0; i < n; i++ {
+		for j := i + 2; j < n; j++ {
+			if i == 0 && j == n-1 {
+				// adjacent by closing node
+				continue
+			}
+			if l, ok := intersection(segments[i], segments[j]); ok {
+				return l, true
+			}
+		}
+	}
+	return LatLon{}, false
+}
+
+// Returns a common point of segments a and b, if they cross or touch.
+func intersection(a, b [2]LatLon) (LatLon, bool) {
+	d1 := orientation(b[0], b[1], a[0])
+	d2 := orientation(b[0], b[1], a[1])
+	d3 := orientation(a[0], a[1], b[0])
+	d4 := orientation(a[0], a[1], b[1])
+
+	if (d1 > 0 && d2 < 0 || d1 < 0 && d2 > 0) && (d3 > 0 && d4 < 0 || d3 < 0 && d4 > 0) {
+		t := d1 / (d1 - d2)
+		return LatLon{
+			Lat: a[0].Lat + t*(a[1].Lat-a[0].Lat),
+			Lon: a[0].Lon + t*(a[1].Lon-a[0].Lon),
+		}, true
+	}
+	switch {
+	case d1 == 0 && onSegment(b, a[0]):
+		return a[0], true
+	case d2 == 0 && onSegment(b, a[1]):
+		return a[1], true
+	case d3 == 0 && onSegment(a, b[0]):
+		return b[0], true
+	case d4 == 0 && onSegment(a, b[1]):
+		return b[1], true
+	}
+	return LatLon{}, false
+}
+
+// Returns positive value if c is left of line from a to b, negative if right and zero if on it.
+func orientation(a, b, c LatLon) float64 {
+	return (b.Lon-a.Lon)*(c.Lat-a.Lat) - (b.Lat-a.Lat)*(c.Lon-a.Lon)
+}
+
+// Returns true if point p, collinear with segment s, lies on it.
+func onSegment(s [2]LatLon, p LatLon) bool {
+	return (p.Lat-s[0].Lat)*(p.Lat-s[1].Lat) <= 0 && (p.Lon-s[0].Lon)*(p.Lon-s[1].Lon) <= 0
+}
+
+// CheckGeometry reads objects from started decoder until the end of input stream and returns
+// issues found by GeometryChecker, using store s for node locations, which may be nil to use
+// NewSparseLocationStore.
+func CheckGeometry(dec *Decoder, s NodeLocationStore) ([]GeometryIssue, error) {
+	if s == nil {
+		s = NewSparseLocationStore()
+		defer s.Close()
+	}
+	c := NewGeometryChecker(s)
+	for {
+		v, err := dec.Decode()
+		if err == io.EOF {
+			return c.Issues(), nil
+		} else if err != nil {
+			return nil, err
+		}
+		o := v.(Object)
+		err = c.Add(o)
+		o.Release()
+		if err != nil {
+			return nil, err
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package osmpbf
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestCheckGeometry(t *testing.T) {
+	d := NewDecoder(encodeObjects(t,
+		&Node{ID: 1, Lat: 0, Lon: 0},
+		&Node{ID: 2, Lat: 0, Lon: 1},
+		&Node{ID: 3, Lat: 1, Lon: 1},
+		&Node{ID: 4, Lat: 1, Lon: 0},
+		&Node{ID: 5, Lat: 1, Lon: 1},
+		&Way{ID: 10, NodeIDs: []int64{1, 2, 3, 4, 1}},    // valid square
+		&Way{ID: 11, NodeIDs: []int64{1, 3, 2, 4, 1}},    // bow tie
+		&Way{ID: 12, NodeIDs: []int64{1, 2, 2, 3}},       // duplicate ref
+		&Way{ID: 13, NodeIDs: []int64{1, 1}},             // degenerate
+		&Way{ID: 14, NodeIDs: []int64{1, 2, 3, 1, 4, 1}}, // touches itself at node 1
+	))
+	if err := d.Start(2); err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	issues, err := CheckGeometry(d, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []GeometryIssue{
+		{Type: DuplicateNode, Element: NewElementID(NodeKind, 5), Other: NewElementID(NodeKind, 3), Location: &LatLon{1, 1}},
+		{Type: SelfIntersection, Element: NewElementID(WayKind, 11), Location: &LatLon{0.5, 0.5}},
+		{Type: DuplicateRef, Element: NewElementID(WayKind, 12), Other: NewElementID(NodeKind, 2)},
+		{Type: DuplicateRef, Element: NewElementID(WayKind, 13), Other: NewElementID(NodeKind, 1)},
+		{Type: DegenerateWay, Element: NewElementID(WayKind, 13)},
+		{Type: SelfIntersection, Element: NewElementID(WayKind, 14), Location: &LatLon{0, 0}},
+	}
+	if !reflect.DeepEqual(expected, issues) {
+		t.Errorf("\nExpected: %+v\nActual:   %+v", expected, issues)
+	}
+
+	data, err := json.Marshal(issues[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := `{"Type":"duplicate_ref","Element":"way/12","Other":"node/2"}`; string(data) != s {
+		t.Errorf("expected %s, got %s", s, data)
+	}
+}
@@ -0,0 +1,121 @@
+package osmpbf
+
+import "io"
+
+// MissingNodesMode controls geometry of ways with nodes of unknown location, usually nodes outside
+// of extract borders.
+type MissingNodesMode int
+
+const (
+	// SkipMissingNodes leaves missing nodes out of geometry, known nodes are joined directly.
+	SkipMissingNodes MissingNodesMode = iota
+
+	// SplitAtMissingNodes splits geometry into parts of consecutive known nodes.
+	SplitAtMissingNodes
+
+	// DropIncompleteWays drops ways with any missing node.
+	DropIncompleteWays
+)
+
+// WayGeometry is geometry of a way resolved from node locations.
+type WayGeometry struct {
+	Way *Way
+
+	// Parts are coordinate sequences of way, each of at least two locations. There is one part,
+	// unless way is split at missing nodes.
+	Parts [][]LatLon
+
+	// Missing is number of nodes of way with unknown location.
+	Missing int
+
+	// Closed is true if way is a closed ring: its first and last nodes are the same, it has
+	// at least four nodes and locations of all of them are known.
+	Closed bool
+}
+
+// A GeometryBuilder builds geometry of ways from locations of their nodes.
+type GeometryBuilder struct {
+	locations NodeLocationStore
+	mode      MissingNodesMode
+}
+
+// NewGeometryBuilder returns a new builder using locations from s, which may be nil if all ways
+// have NodeLocations, and mode for ways with missing nodes.
+func NewGeometryBuilder(s NodeLocationStore, mode MissingNodesMode) *GeometryBuilder {
+	return &GeometryBuilder{locations: s, mode: mode}
+}
+
+// Build returns geometry of way, or nil if it is dropped by mode or has no part of at least two
+// known locations. Way.NodeLocations are used if present, otherwise locations come from the store.
+func (b *GeometryBuilder) Build(w *Way) *WayGeometry {
+	g := &WayGeometry{Way: w}
+	var part []LatLon
+	for i, id := range w.NodeIDs {
+		var l LatLon
+		ok := true
+		if len(w.NodeLocations) == len(w.NodeIDs) {
+			l = w.NodeLocations[i]
+		} else if b.locations != nil {
+			l, ok = b.locations.Location(id)
+		} else {
+			ok = false
+		}
+
+		if !ok {
+			g.Missing++
+			if b.mode == DropIncompleteWays {
+				return nil
+			}
+			if b.mode == SplitAtMissingNodes {
+				g.addPart(part)
+				part = nil
+			}
+			continue
+		}
+		part = append(part, l)
+	}
+	g.addPart(part)
+
+	if len(g.Parts) == 0 {
+		return nil
+	}
+	n := len(w.NodeIDs)
+	g.Closed = g.Missing == 0 && n >= 4 && w.NodeIDs[0] == w.NodeIDs[n-1]
+	return g
+}
+
+func (g *WayGeometry) addPart(part []LatLon) {
+	if len(part) >= 2 {
+		g.Parts = append(g.Parts, part)
+	}
+}
+
+// Ways reads objects from started decoder until the end of input stream: locations of nodes are put
+// into the store and fn is called with geometry of each way built by Build; ways without geometry
+// and relations are skipped. Objects are not released. Input must be sorted by type, so nodes are
+// read before ways, unless the store already contains their locations.
+func (b *GeometryBuilder) Ways(dec *Decoder, fn func(g *WayGeometry) error) error {
+	for {
+		v, err := dec.Decode()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		switch o := v.(type) {
+		case *Node:
+			if b.locations != nil {
+				if err := b.locations.Set(o.ID, LatLon{o.Lat, o.Lon}); err != nil {
+					return err
+				}
+			}
+		case *Way:
+			if g := b.Build(o); g != nil {
+				if err := fn(g); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
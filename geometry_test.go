@@ -0,0 +1,61 @@
+package osmpbf
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGeometryBuilder(t *testing.T) {
+	s := NewSparseLocationStore()
+	for id := int64(1); id <= 4; id++ {
+		s.Set(id, LatLon{float64(id), float64(-id)})
+	}
+	ring := &Way{ID: 1, NodeIDs: []int64{1, 2, 3, 1}}
+	border := &Way{ID: 2, NodeIDs: []int64{1, 2, 5, 3, 4}}
+
+	for _, c := range []struct {
+		name     string
+		mode     MissingNodesMode
+		way      *Way
+		expected *WayGeometry
+	}{
+		{"ring", SkipMissingNodes, ring,
+			&WayGeometry{ring, [][]LatLon{{{1, -1}, {2, -2}, {3, -3}, {1, -1}}}, 0, true}},
+		{"skip", SkipMissingNodes, border,
+			&WayGeometry{border, [][]LatLon{{{1, -1}, {2, -2}, {3, -3}, {4, -4}}}, 1, false}},
+		{"split", SplitAtMissingNodes, border,
+			&WayGeometry{border, [][]LatLon{{{1, -1}, {2, -2}}, {{3, -3}, {4, -4}}}, 1, false}},
+		{"drop", DropIncompleteWays, border, nil},
+		{"single part node", SplitAtMissingNodes, &Way{NodeIDs: []int64{1, 5, 2}}, nil},
+		{"way locations", DropIncompleteWays, &Way{ID: 3, NodeIDs: []int64{7, 8}, NodeLocations: []LatLon{{5, 6}, {7, 8}}},
+			&WayGeometry{&Way{ID: 3, NodeIDs: []int64{7, 8}, NodeLocations: []LatLon{{5, 6}, {7, 8}}},
+				[][]LatLon{{{5, 6}, {7, 8}}}, 0, false}},
+	} {
+		if g := NewGeometryBuilder(s, c.mode).Build(c.way); !reflect.DeepEqual(c.expected, g) {
+			t.Errorf("%s:\nExpected: %+v\nActual:   %+v", c.name, c.expected, g)
+		}
+	}
+}
+
+func TestGeometryBuilderWays(t *testing.T) {
+	d := NewDecoder(encodeNodesWays(t, 10, 5))
+	if err := d.Start(2); err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+
+	var ids []int64
+	err := NewGeometryBuilder(NewDenseLocationStore(), DropIncompleteWays).Ways(d, func(g *WayGeometry) error {
+		ids = append(ids, g.Way.ID)
+		if len(g.Parts) != 1 || len(g.Parts[0]) != 2 || g.Parts[0][0].Lat != float64(g.Way.ID)/1e3 {
+			t.Errorf("unexpected geometry %v of way %d", g.Parts, g.Way.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual([]int64{1, 2, 3, 4, 5}, ids) {
+		t.Errorf("expected ways [1 2 3 4 5], got %v", ids)
+	}
+}
@@ -0,0 +1,62 @@
+package osmpbf
+
+import (
+	"io"
+	"sync/atomic"
+)
+
+// Handler processes decoded objects, see Decoder.Handle.
+// Methods are called from several goroutines concurrently and must be safe for that.
+type Handler interface {
+	HandleNode(n *Node)
+	HandleWay(w *Way)
+	HandleRelation(r *Relation)
+}
+
+// Handle decodes the input stream using n goroutines and passes objects to h. It is an alternative
+// to Start and Decode for processing which does not depend on objects order: handler methods are
+// called by data decoder goroutines directly, avoiding serialization of all objects through Decode.
+// Objects of one PrimitiveBlock are handled by one goroutine in the input stream order, but blocks are
+// handled concurrently, as with SetUnordered. Spatial filtering of ways and relations is not supported.
+//
+// Handle returns after all objects are handled, or the first error encountered.
+func (dec *Decoder) Handle(h Handler, n int) error {
+	dec.handler = h
+	dec.unordered = true
+	if err := dec.Start(n); err != nil {
+		return err
+	}
+
+	for {
+		// objects are not returned, only errors
+		if _, err := dec.Decode(); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			dec.Close()
+			return err
+		}
+	}
+}
+
+// Passes objects not dropped by transforms to handler and returns slice to pool.
+func (dec *Decoder) handle(q *[]interface{}) {
+	handled := 0
+	for i, o := range *q {
+		(*q)[i] = nil
+		o := applyTransforms(dec.transforms, o.(Object), true)
+		switch o := o.(type) {
+		case *Node:
+			dec.handler.HandleNode(o)
+		case *Way:
+			dec.handler.HandleWay(o)
+		case *Relation:
+			dec.handler.HandleRelation(o)
+		default:
+			continue
+		}
+		handled++
+	}
+	atomic.AddInt64(&dec.progress.Objects, int64(handled))
+	putObjects(q)
+}
@@ -0,0 +1,88 @@
+package osmpbf
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+)
+
+type testHandler struct {
+	mu    sync.Mutex
+	nodes map[int64]bool
+	ways  int
+	rels  int
+}
+
+func (h *testHandler) HandleNode(n *Node) {
+	h.mu.Lock()
+	h.nodes[n.ID] = true
+	h.mu.Unlock()
+}
+
+func (h *testHandler) HandleWay(w *Way) {
+	h.mu.Lock()
+	h.ways++
+	h.mu.Unlock()
+}
+
+func (h *testHandler) HandleRelation(r *Relation) {
+	h.mu.Lock()
+	h.rels++
+	h.mu.Unlock()
+}
+
+func TestHandle(t *testing.T) {
+	n := maxBlockEntities*3 + 1
+	d := NewDecoder(encodeNodesWays(t, n, 10))
+	h := &testHandler{nodes: make(map[int64]bool)}
+	if err := d.Handle(h, 4); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(h.nodes) != n || h.ways != 10 || h.rels != 0 {
+		t.Errorf("expected %d nodes and 10 ways, got %d, %d, %d", n, len(h.nodes), h.ways, h.rels)
+	}
+	if p := d.Progress(); p.Objects != int64(n+10) {
+		t.Errorf("expected %d objects, got %d", n+10, p.Objects)
+	}
+}
+
+func TestHandleTransforms(t *testing.T) {
+	n := maxBlockEntities + 1
+	d := NewDecoder(encodeNodesWays(t, n, 10), WithTransforms(func(o Object) Object {
+		switch o := o.(type) {
+		case *Node:
+			if o.ID%2 == 0 {
+				return nil
+			}
+			o.ID = -o.ID
+		case *Way:
+			return nil
+		}
+		return o
+	}))
+	h := &testHandler{nodes: make(map[int64]bool)}
+	if err := d.Handle(h, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(h.nodes) != (n+1)/2 || h.ways != 0 {
+		t.Errorf("expected %d nodes and no ways, got %d, %d", (n+1)/2, len(h.nodes), h.ways)
+	}
+	for id := range h.nodes {
+		if id >= 0 || -id%2 == 0 {
+			t.Errorf("unexpected node %d", id)
+		}
+	}
+	if p := d.Progress(); p.Objects != int64(len(h.nodes)) {
+		t.Errorf("expected %d objects, got %d", len(h.nodes), p.Objects)
+	}
+}
+
+func TestHandleError(t *testing.T) {
+	data := encodeNodesWays(t, maxBlockEntities, 10).Bytes()
+	d := NewReaderAtDecoder(bytes.NewReader(data[:len(data)-10]), int64(len(data)-10))
+	if err := d.Handle(&testHandler{nodes: make(map[int64]bool)}, 2); err == nil {
+		t.Error("expected error")
+	}
+}
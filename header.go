@@ -0,0 +1,87 @@
+package osmpbf
+
+import (
+	"time"
+
+	"github.com/brechtbm/osmpbf/OSMPBF"
+	"google.golang.org/protobuf/proto"
+)
+
+// Header contains metadata from OSMHeader block.
+type Header struct {
+	// BBox is nil if header does not contain bounding box.
+	BBox *BBox
+
+	RequiredFeatures []string
+	OptionalFeatures []string
+	WritingProgram   string
+	Source           string
+
+	// Osmosis replication state, allows to continue applying updates to the file.
+	// Zero values if absent.
+	ReplicationTimestamp      time.Time
+	ReplicationSequenceNumber int64
+	ReplicationBaseURL        string
+}
+
+// HasReplication returns true if header contains replication state.
+func (h *Header) HasReplication() bool {
+	return !h.ReplicationTimestamp.IsZero() || h.ReplicationSequenceNumber != 0 || h.ReplicationBaseURL != ""
+}
+
+func newHeader(hb *OSMPBF.HeaderBlock) *Header {
+	h := &Header{
+		RequiredFeatures:          hb.GetRequiredFeatures(),
+		OptionalFeatures:          hb.GetOptionalFeatures(),
+		WritingProgram:            hb.GetWritingprogram(),
+		Source:                    hb.GetSource(),
+		ReplicationSequenceNumber: hb.GetOsmosisReplicationSequenceNumber(),
+		ReplicationBaseURL:        hb.GetOsmosisReplicationBaseUrl(),
+	}
+
+	if hb.OsmosisReplicationTimestamp != nil {
+		h.ReplicationTimestamp = time.Unix(hb.GetOsmosisReplicationTimestamp(), 0).UTC()
+	}
+
+	if bbox := hb.GetBbox(); bbox != nil {
+		// units are always nanodegrees, not affected by granularity
+		h.BBox = &BBox{
+			Left:   float64(bbox.GetLeft()) / 1e9,
+			Right:  float64(bbox.GetRight()) / 1e9,
+			Top:    float64(bbox.GetTop()) / 1e9,
+			Bottom: float64(bbox.GetBottom()) / 1e9,
+		}
+	}
+	return h
+}
+
+// Makes HeaderBlock from h, with given required features.
+func newHeaderBlock(h *Header, requiredFeatures []string) *OSMPBF.HeaderBlock {
+	hb := &OSMPBF.HeaderBlock{
+		RequiredFeatures: requiredFeatures,
+		OptionalFeatures: h.OptionalFeatures,
+		Writingprogram:   proto.String(h.WritingProgram),
+	}
+	if h.Source != "" {
+		hb.Source = proto.String(h.Source)
+	}
+	if !h.ReplicationTimestamp.IsZero() {
+		hb.OsmosisReplicationTimestamp = proto.Int64(h.ReplicationTimestamp.Unix())
+	}
+	if h.ReplicationSequenceNumber != 0 {
+		hb.OsmosisReplicationSequenceNumber = proto.Int64(h.ReplicationSequenceNumber)
+	}
+	if h.ReplicationBaseURL != "" {
+		hb.OsmosisReplicationBaseUrl = proto.String(h.ReplicationBaseURL)
+	}
+
+	if h.BBox != nil {
+		hb.Bbox = &OSMPBF.HeaderBBox{
+			Left:   proto.Int64(toNanodegrees(h.BBox.Left)),
+			Right:  proto.Int64(toNanodegrees(h.BBox.Right)),
+			Top:    proto.Int64(toNanodegrees(h.BBox.Top)),
+			Bottom: proto.Int64(toNanodegrees(h.BBox.Bottom)),
+		}
+	}
+	return hb
+}
@@ -0,0 +1,163 @@
+package osmpbf
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestHeader(t *testing.T) {
+	expected := &Header{
+		BBox:                      &BBox{Left: -0.5103751, Right: 0.3340155, Top: 51.6918741, Bottom: 51.2867602},
+		RequiredFeatures:          []string{"OsmSchema-V0.6", "DenseNodes"},
+		OptionalFeatures:          []string{"Sort.Type_then_ID"},
+		WritingProgram:            "test",
+		Source:                    "http://www.openstreetmap.org/api/0.6",
+		ReplicationTimestamp:      parseTime("2014-03-24T00:00:00Z"),
+		ReplicationSequenceNumber: 1234,
+		ReplicationBaseURL:        "http://download.geofabrik.de/europe/great-britain/england/greater-london-updates",
+	}
+
+	var buf bytes.Buffer
+	e := NewEncoder(&buf)
+	e.SetHeader(expected)
+	if err := e.Encode(en); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	d := NewDecoder(&buf)
+	if d.Header() != nil {
+		t.Error("expected nil header before Start")
+	}
+	decodeAll(t, d)
+
+	if !reflect.DeepEqual(expected, d.Header()) {
+		t.Errorf("\nExpected: %#v\nActual:   %#v", expected, d.Header())
+	}
+	if !d.Header().HasReplication() {
+		t.Error("expected replication state")
+	}
+}
+
+func TestHeaderDefaults(t *testing.T) {
+	d := NewDecoder(encodeObjects(t))
+	decodeAll(t, d)
+
+	expected := &Header{
+		RequiredFeatures: []string{"OsmSchema-V0.6", "DenseNodes"},
+		WritingProgram:   writingProgram,
+	}
+	if !reflect.DeepEqual(expected, d.Header()) {
+		t.Errorf("\nExpected: %#v\nActual:   %#v", expected, d.Header())
+	}
+	if d.Header().HasReplication() {
+		t.Error("unexpected replication state")
+	}
+}
+
+func TestConcatenated(t *testing.T) {
+	var data []byte
+	for _, program := range []string{"first", "second"} {
+		var buf bytes.Buffer
+		e := NewEncoder(&buf)
+		e.SetHeader(&Header{OptionalFeatures: []string{"Sort.Type_then_ID"}, WritingProgram: program})
+		for _, o := range []Object{en, ew, er} {
+			if err := e.Encode(o); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := e.Close(); err != nil {
+			t.Fatal(err)
+		}
+		data = append(data, buf.Bytes()...)
+	}
+
+	// sort order starts over in each stream
+	for _, d := range []*Decoder{
+		NewDecoder(bytes.NewReader(data), WithStrict(true)),
+		NewReaderAtDecoder(bytes.NewReader(data), int64(len(data)), WithStrict(true)),
+	} {
+		if objects := decodeAll(t, d); len(objects) != 6 {
+			t.Errorf("expected 6 objects, got %d", len(objects))
+		}
+		headers := d.Headers()
+		if len(headers) != 2 || headers[0] != d.Header() || headers[1].WritingProgram != "second" {
+			t.Errorf("unexpected headers %#v", headers)
+		}
+	}
+
+	d := NewDecoder(bytes.NewReader(data))
+	var blocks int
+	for {
+		if _, err := d.NextPrimitiveBlock(); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		blocks++
+	}
+	if blocks != 6 || len(d.Headers()) != 2 {
+		t.Errorf("expected 6 blocks and 2 headers, got %d and %d", blocks, len(d.Headers()))
+	}
+}
+
+func TestBound(t *testing.T) {
+	encode := func(b *Bound, objects ...Object) []byte {
+		var buf bytes.Buffer
+		e := NewEncoder(&buf)
+		if err := e.Encode(b); err != nil {
+			t.Fatal(err)
+		}
+		for _, o := range objects {
+			if err := e.Encode(o); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := e.Encode(b); err == nil {
+			t.Error("expected error for bound after objects")
+		}
+		if err := e.Close(); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+	first := &Bound{BBox{Left: -1, Right: 1, Top: 2, Bottom: -2}}
+	second := &Bound{BBox{Left: 1, Right: 3, Top: 2, Bottom: -2}}
+	data := encode(first, en, ew)
+
+	expected := []interface{}{first, en, ew}
+	if decoded := decodeAll(t, NewDecoder(bytes.NewReader(data), WithBound(true))); !reflect.DeepEqual(expected, decoded) {
+		t.Errorf("\nExpected: %#v\nActual:   %#v", expected, decoded)
+	}
+	if decoded := decodeAll(t, NewDecoder(bytes.NewReader(data))); !reflect.DeepEqual(expected[1:], decoded) {
+		t.Errorf("\nExpected: %#v\nActual:   %#v", expected[1:], decoded)
+	}
+
+	// each input stream of concatenation has its bound
+	concatenated := append(append([]byte{}, data...), encode(second, er)...)
+	expected = []interface{}{first, en, ew, second, er}
+	if decoded := decodeAll(t, NewDecoder(bytes.NewReader(concatenated), WithBound(true))); !reflect.DeepEqual(expected, decoded) {
+		t.Errorf("\nExpected: %#v\nActual:   %#v", expected, decoded)
+	}
+
+	// bounds precede objects of all inputs
+	md := NewMultiDecoder([]io.Reader{bytes.NewReader(data), bytes.NewReader(encode(second, er))}, MergeSorted, WithBound(true))
+	if err := md.Start(2); err != nil {
+		t.Fatal(err)
+	}
+	expected = []interface{}{first, second, en, ew, er}
+	for _, o := range expected {
+		v, err := md.Decode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(o, v) {
+			t.Errorf("expected %#v, got %#v", o, v)
+		}
+	}
+	md.Close()
+}
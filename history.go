@@ -0,0 +1,106 @@
+package osmpbf
+
+import (
+	"time"
+)
+
+// VersionMode controls which versions of objects in history files are returned, see WithVersions.
+type VersionMode int
+
+const (
+	// AllVersions returns all versions, which is the default.
+	AllVersions VersionMode = iota
+
+	// LatestVersion returns only the last version of each object, including deleted ones.
+	LatestVersion
+)
+
+// SetVersions is the same as WithVersions option. Must be called before Start.
+func (dec *Decoder) SetVersions(mode VersionMode, since, until time.Time) {
+	WithVersions(mode, since, until)(dec)
+}
+
+// SetSnapshot is the same as WithSnapshot option. Must be called before Start.
+func (dec *Decoder) SetSnapshot(t time.Time) {
+	WithSnapshot(t)(dec)
+}
+
+// Keeps versions of objects with timestamp in range, optionally only the last one of each object.
+// Versions of an object must be consecutive and ordered, so it is used by serializer goroutine.
+type versionFilter struct {
+	since, until time.Time // not limited if zero
+	latest       bool
+	dropDeleted  bool
+
+	// last kept version of the current object, returned when the next object starts
+	pending       Object
+	pendingOffset int64
+	kind          Kind
+	id            int64
+}
+
+// Returns a new filter with settings of f.
+func (f *versionFilter) clone() *versionFilter {
+	return &versionFilter{since: f.since, until: f.until, latest: f.latest, dropDeleted: f.dropDeleted}
+}
+
+// Adds version o read from fileblock at offset, returns object to send with its offset, or nil:
+// o itself if all versions are kept, or the previous object if o starts the next one.
+func (f *versionFilter) next(o Object, offset int64) (Object, int64) {
+	inRange := f.inRange(objectInfo(o).Timestamp)
+	if !f.latest {
+		if !inRange {
+			o.Release()
+			return nil, 0
+		}
+		return o, offset
+	}
+
+	var done Object
+	var doneOffset int64
+	kind, id := kindID(o)
+	if kind != f.kind || id != f.id {
+		done, doneOffset = f.flush()
+		f.kind, f.id = kind, id
+	}
+
+	if !inRange {
+		o.Release()
+		return done, doneOffset
+	}
+	if f.pending != nil {
+		// older version of the same object
+		f.pending.Release()
+	}
+	f.pending, f.pendingOffset = o, offset
+	return done, doneOffset
+}
+
+// Returns the last kept version of the current object, or nil if it is deleted.
+func (f *versionFilter) flush() (Object, int64) {
+	o := f.pending
+	f.pending = nil
+	if o != nil && f.dropDeleted && !objectInfo(o).Visible {
+		o.Release()
+		return nil, 0
+	}
+	return o, f.pendingOffset
+}
+
+// Returns true if timestamp t is inside range of filter.
+func (f *versionFilter) inRange(t time.Time) bool {
+	return (f.since.IsZero() || !t.Before(f.since)) && (f.until.IsZero() || !t.After(f.until))
+}
+
+// Returns Info of Node, Way or Relation, or nil for other objects.
+func objectInfo(o Object) *Info {
+	switch o := o.(type) {
+	case *Node:
+		return &o.Info
+	case *Way:
+		return &o.Info
+	case *Relation:
+		return &o.Info
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package osmpbf
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestHistory(t *testing.T) {
+	info := func(version int16, visible bool) Info {
+		return Info{
+			Version:   version,
+			Timestamp: parseTime("2014-03-24T10:00:00Z").AddDate(0, 0, int(version)),
+			Changeset: uint64(1000 + version),
+			Uid:       42,
+			User:      "history",
+			Visible:   visible,
+		}
+	}
+	objects := []interface{}{
+		&Node{ID: 1, Lat: 51.5, Lon: -0.1, Tags: map[string]string{}, Info: info(1, true)},
+		&Node{ID: 1, Lat: 51.6, Lon: -0.1, Tags: map[string]string{"name": "moved"}, Info: info(2, true)},
+		&Node{ID: 1, Tags: map[string]string{}, Info: info(3, false)},
+		&Node{ID: 2, Lat: 51.6, Lon: -0.2, Tags: map[string]string{}, Info: info(1, true)},
+		&Way{ID: 10, NodeIDs: []int64{1, 2}, Tags: map[string]string{}, Info: info(1, true)},
+		&Way{ID: 10, Tags: map[string]string{}, NodeIDs: []int64{}, Info: info(2, false)},
+	}
+
+	var buf bytes.Buffer
+	e := NewEncoder(&buf)
+	e.SetHeader(&Header{RequiredFeatures: []string{"HistoricalInformation"}})
+	for _, o := range objects {
+		if err := e.Encode(o); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := e.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	d := NewDecoder(&buf)
+	decoded := decodeAll(t, d)
+
+	expectedFeatures := []string{"OsmSchema-V0.6", "DenseNodes", "HistoricalInformation"}
+	if !reflect.DeepEqual(expectedFeatures, d.Header().RequiredFeatures) {
+		t.Errorf("expected %v, got %v", expectedFeatures, d.Header().RequiredFeatures)
+	}
+	if !reflect.DeepEqual(objects, decoded) {
+		t.Errorf("\nExpected: %#v\nActual:   %#v", objects, decoded)
+	}
+}
+
+// Returns history file with deleted node 1 and way 10, versions are made at day of their timestamp.
+func encodeHistory(t *testing.T) []byte {
+	var buf bytes.Buffer
+	e := NewEncoder(&buf)
+	e.SetHeader(&Header{RequiredFeatures: []string{"HistoricalInformation"}})
+	for _, o := range []Object{
+		&Node{ID: 1, Lat: 1, Info: historyInfo(1, 1, true)},
+		&Node{ID: 1, Lat: 2, Info: historyInfo(2, 2, true)},
+		&Node{ID: 1, Info: historyInfo(3, 3, false)},
+		&Node{ID: 2, Info: historyInfo(1, 1, true)},
+		&Node{ID: 3, Info: historyInfo(1, 3, true)},
+		&Way{ID: 10, NodeIDs: []int64{1, 2}, Info: historyInfo(1, 1, true)},
+		&Way{ID: 10, Info: historyInfo(2, 2, false)},
+	} {
+		if err := e.Encode(o); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := e.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func historyInfo(version int16, day int, visible bool) Info {
+	return Info{Version: version, Timestamp: historyDay(day), Visible: visible}
+}
+
+func historyDay(day int) time.Time {
+	return parseTime("2014-03-24T10:00:00Z").AddDate(0, 0, day)
+}
+
+// Returns kind and ID of objects, and their versions.
+func objectVersions(objects []interface{}) ([]string, []int16) {
+	versions := []int16{}
+	for _, o := range objects {
+		versions = append(versions, objectInfo(o.(Object)).Version)
+	}
+	return objectIDs(objects), versions
+}
+
+func TestSnapshot(t *testing.T) {
+	data := encodeHistory(t)
+	for _, c := range []struct {
+		day      int
+		expected []string
+		versions []int16
+	}{
+		{0, []string{}, []int16{}},
+		{1, []string{"n1", "n2", "w10"}, []int16{1, 1, 1}},
+		{2, []string{"n1", "n2"}, []int16{2, 1}},
+		{3, []string{"n2", "n3"}, []int16{1, 1}},
+	} {
+		d := NewDecoder(bytes.NewReader(data), WithSnapshot(historyDay(c.day)))
+		ids, versions := objectVersions(decodeAll(t, d))
+		if !reflect.DeepEqual(c.expected, ids) || !reflect.DeepEqual(c.versions, versions) {
+			t.Errorf("day %d: expected %v %v, got %v %v", c.day, c.expected, c.versions, ids, versions)
+		}
+	}
+
+	d := NewDecoder(bytes.NewReader(data), WithSnapshot(time.Now()), WithUnordered(true))
+	if err := d.Start(1); err == nil {
+		d.Close()
+		t.Error("expected error of snapshot in unordered mode")
+	}
+}
+
+func TestVersions(t *testing.T) {
+	data := encodeHistory(t)
+	for i, c := range []struct {
+		mode         VersionMode
+		since, until time.Time
+		expected     []string
+		versions     []int16
+	}{
+		{AllVersions, time.Time{}, time.Time{}, []string{"n1", "n1", "n1", "n2", "n3", "w10", "w10"}, []int16{1, 2, 3, 1, 1, 1, 2}},
+		{LatestVersion, time.Time{}, time.Time{}, []string{"n1", "n2", "n3", "w10"}, []int16{3, 1, 1, 2}},
+		{AllVersions, historyDay(2), historyDay(2), []string{"n1", "w10"}, []int16{2, 2}},
+		{AllVersions, historyDay(2), time.Time{}, []string{"n1", "n1", "n3", "w10"}, []int16{2, 3, 1, 2}},
+		{LatestVersion, time.Time{}, historyDay(2), []string{"n1", "n2", "w10"}, []int16{2, 1, 2}},
+	} {
+		d := NewDecoder(bytes.NewReader(data), WithVersions(c.mode, c.since, c.until))
+		ids, versions := objectVersions(decodeAll(t, d))
+		if !reflect.DeepEqual(c.expected, ids) || !reflect.DeepEqual(c.versions, versions) {
+			t.Errorf("case %d: expected %v %v, got %v %v", i, c.expected, c.versions, ids, versions)
+		}
+	}
+}
@@ -0,0 +1,259 @@
+package osmpbf
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	defaultReadAhead = 4 * 1024 * 1024
+	defaultChunks    = 32
+)
+
+// HTTPConfig configures reading of remote input stream by HTTP Range requests, see NewHTTPDecoder.
+// Zero value is valid configuration.
+type HTTPConfig struct {
+	// Client used for requests, http.DefaultClient if nil.
+	Client *http.Client
+
+	// Retries is number of retries of failed request, with exponential backoff starting at 100ms.
+	Retries int
+
+	// ReadAhead is size of chunks file is requested by, default value is 4MB. Chunks are
+	// kept in memory, so neighbouring reads, like reads of BlobHeaders, do not need requests.
+	ReadAhead int
+
+	// Chunks is maximum number of chunks kept in memory, default value is 32.
+	Chunks int
+
+	// CacheDir is directory for local cache of downloaded chunks, which are not downloaded again
+	// by subsequent decoders of the same file. If empty, chunks are not cached.
+	CacheDir string
+}
+
+// NewHTTPDecoder returns a new decoder that reads remote file from url by HTTP Range requests,
+// without downloading the whole file first. Server must support Range requests. Decoder works
+// in ReaderAt mode, see NewReaderAtDecoder. Size of the file is requested by NewHTTPDecoder.
+func NewHTTPDecoder(ctx context.Context, url string, config HTTPConfig, opts ...Option) (*Decoder, error) {
+	r, err := newHTTPReaderAt(ctx, url, config)
+	if err != nil {
+		return nil, err
+	}
+	return NewReaderAtDecoder(r, r.size, opts...), nil
+}
+
+// ReaderAt over remote file, reading it by chunks of fixed size.
+type httpReaderAt struct {
+	ctx    context.Context
+	url    string
+	config HTTPConfig
+	size   int64
+
+	// prefix of cached chunk files, empty if cache is disabled
+	cachePrefix string
+
+	mu     sync.Mutex
+	chunks map[int64]*list.Element // of *httpChunk, by index
+	lru    list.List               // most recently used first
+}
+
+type httpChunk struct {
+	index int64
+	data  []byte
+	err   error
+	done  chan struct{} // closed when data or err is set
+}
+
+func newHTTPReaderAt(ctx context.Context, url string, config HTTPConfig) (*httpReaderAt, error) {
+	if config.Client == nil {
+		config.Client = http.DefaultClient
+	}
+	if config.ReadAhead <= 0 {
+		config.ReadAhead = defaultReadAhead
+	}
+	if config.Chunks <= 0 {
+		config.Chunks = defaultChunks
+	}
+
+	r := &httpReaderAt{
+		ctx:    ctx,
+		url:    url,
+		config: config,
+		chunks: make(map[int64]*list.Element),
+	}
+
+	resp, err := r.do(http.MethodHead, "", http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	if resp.ContentLength < 0 {
+		return nil, errors.New("unknown size of remote file")
+	}
+	r.size = resp.ContentLength
+
+	if config.CacheDir != "" {
+		// cached chunks are not used if remote file changes
+		h := sha256.Sum256([]byte(url + "\n" + resp.Header.Get("ETag") + "\n" + resp.Header.Get("Last-Modified") +
+			"\n" + strconv.Itoa(config.ReadAhead)))
+		r.cachePrefix = filepath.Join(config.CacheDir, hex.EncodeToString(h[:8]))
+	}
+	return r, nil
+}
+
+// ReadAt reads len(p) bytes at offset off, it is safe for concurrent use.
+func (r *httpReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.New("negative offset")
+	}
+
+	var n int
+	for n < len(p) {
+		if off >= r.size {
+			return n, io.EOF
+		}
+		size := int64(r.config.ReadAhead)
+		c := r.chunk(off / size)
+		<-c.done
+		if c.err != nil {
+			return n, c.err
+		}
+		copied := copy(p[n:], c.data[off%size:])
+		n += copied
+		off += int64(copied)
+	}
+	return n, nil
+}
+
+// Returns chunk with given index, starting its download if it is not available.
+func (r *httpReaderAt) chunk(index int64) *httpChunk {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if e, ok := r.chunks[index]; ok {
+		c := e.Value.(*httpChunk)
+		if !isDone(c) || c.err == nil {
+			r.lru.MoveToFront(e)
+			return c
+		}
+		// failed download is retried by the next read
+		r.lru.Remove(e)
+		delete(r.chunks, index)
+	}
+
+	c := &httpChunk{index: index, done: make(chan struct{})}
+	r.chunks[index] = r.lru.PushFront(c)
+	for r.lru.Len() > r.config.Chunks {
+		e := r.lru.Back()
+		r.lru.Remove(e)
+		delete(r.chunks, e.Value.(*httpChunk).index)
+	}
+
+	go func() {
+		c.data, c.err = r.load(index)
+		close(c.done)
+	}()
+	return c
+}
+
+func isDone(c *httpChunk) bool {
+	select {
+	case <-c.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// Returns data of chunk from local cache, or downloads it.
+func (r *httpReaderAt) load(index int64) ([]byte, error) {
+	start := index * int64(r.config.ReadAhead)
+	end := start + int64(r.config.ReadAhead)
+	if end > r.size {
+		end = r.size
+	}
+
+	var path string
+	if r.cachePrefix != "" {
+		path = r.cachePrefix + "." + strconv.FormatInt(index, 10)
+		if data, err := os.ReadFile(path); err == nil && int64(len(data)) == end-start {
+			return data, nil
+		}
+	}
+
+	resp, err := r.do(http.MethodGet, fmt.Sprintf("bytes=%d-%d", start, end-1), http.StatusPartialContent)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data := make([]byte, end-start)
+	if _, err := io.ReadFull(resp.Body, data); err != nil {
+		return nil, err
+	}
+
+	if path != "" {
+		// cache is best effort, chunk is written to temporary file first to avoid partial chunks
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err == nil {
+			tmp := path + ".tmp" + strconv.FormatInt(time.Now().UnixNano(), 36)
+			if err := os.WriteFile(tmp, data, 0644); err == nil {
+				if os.Rename(tmp, path) != nil {
+					os.Remove(tmp)
+				}
+			}
+		}
+	}
+	return data, nil
+}
+
+// Sends request, retrying it on failure. Response status must be equal to status.
+func (r *httpReaderAt) do(method, byteRange string, status int) (*http.Response, error) {
+	backoff := 100 * time.Millisecond
+	for attempt := 0; ; attempt++ {
+		resp, err := r.request(method, byteRange)
+		if err == nil && resp.StatusCode == status {
+			return resp, nil
+		}
+
+		retry := err != nil || resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode == http.StatusOK && byteRange != "" {
+				return nil, errors.New("server does not support range requests")
+			}
+			err = fmt.Errorf("%s %s: %s", method, r.url, resp.Status)
+		}
+		if !retry || attempt >= r.config.Retries {
+			return nil, err
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-r.ctx.Done():
+			return nil, r.ctx.Err()
+		}
+		backoff *= 2
+	}
+}
+
+func (r *httpReaderAt) request(method, byteRange string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(r.ctx, method, r.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if byteRange != "" {
+		req.Header.Set("Range", byteRange)
+	}
+	return r.config.Client.Do(req)
+}
@@ -0,0 +1,89 @@
+package osmpbf
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHTTPDecoder(t *testing.T) {
+	data := encodeNodesWays(t, maxBlockEntities*2+1, 10).Bytes()
+	var requests, failures int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			atomic.AddInt32(&requests, 1)
+			if atomic.AddInt32(&failures, -1) >= 0 {
+				http.Error(w, "unavailable", http.StatusServiceUnavailable)
+				return
+			}
+		}
+		http.ServeContent(w, r, "test.osm.pbf", time.Time{}, bytes.NewReader(data))
+	}))
+	defer srv.Close()
+
+	config := HTTPConfig{Retries: 1, ReadAhead: 64 * 1024, CacheDir: t.TempDir()}
+	atomic.StoreInt32(&failures, 1)
+	d, err := NewHTTPDecoder(context.Background(), srv.URL, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if objects := decodeAll(t, d); len(objects) != maxBlockEntities*2+11 {
+		t.Errorf("expected %d objects, got %d", maxBlockEntities*2+11, len(objects))
+	}
+	chunks := int32((len(data) + config.ReadAhead - 1) / config.ReadAhead)
+	if n := atomic.LoadInt32(&requests); n != chunks+1 {
+		t.Errorf("expected %d requests, got %d", chunks+1, n)
+	}
+
+	// all chunks are cached
+	atomic.StoreInt32(&requests, 0)
+	d, err = NewHTTPDecoder(context.Background(), srv.URL, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if objects := decodeAll(t, d); len(objects) != maxBlockEntities*2+11 {
+		t.Errorf("expected %d objects, got %d", maxBlockEntities*2+11, len(objects))
+	}
+	if n := atomic.LoadInt32(&requests); n != 0 {
+		t.Errorf("expected no requests, got %d", n)
+	}
+
+	// retries are exhausted
+	config.CacheDir = ""
+	atomic.StoreInt32(&failures, 2)
+	d, err = NewHTTPDecoder(context.Background(), srv.URL, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Start(1); err == nil {
+		t.Error("expected error")
+	}
+	d.Close()
+}
+
+func TestHTTPDecoderNoRange(t *testing.T) {
+	data := encodeObjects(t, en).Bytes()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Range header is ignored
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		if r.Method == http.MethodGet {
+			w.Write(data)
+		}
+	}))
+	defer srv.Close()
+
+	d, err := NewHTTPDecoder(context.Background(), srv.URL, HTTPConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Start(1); err == nil || !strings.Contains(err.Error(), "range requests") {
+		t.Errorf("expected range requests error, got %v", err)
+	}
+	d.Close()
+}
@@ -0,0 +1,135 @@
+package osmpbf
+
+import (
+	"math/bits"
+	"sort"
+)
+
+const (
+	idPageBits = 16 // IDs in one page are 1<<idPageBits
+	idPageSize = 1 << idPageBits / 64
+)
+
+// page of bitset, bit of ID is set if ID is in the set
+type idPage [idPageSize]uint64
+
+// IDTracker is a set of object IDs, for example IDs of nodes referenced by ways, which need to be
+// kept by the next pass over input stream. IDs are stored in a bitset allocated by pages of 65536
+// IDs, 8KB each, so dense ranges of IDs, like nodes of an extract, take about one bit per ID.
+// Negative IDs are supported.
+//
+// IDTracker is not safe for concurrent use, except for concurrent Contains calls.
+type IDTracker struct {
+	pages map[int64]*idPage // by ID >> idPageBits
+	n     int
+}
+
+// NewIDTracker returns an empty set.
+func NewIDTracker() *IDTracker {
+	return &IDTracker{pages: make(map[int64]*idPage)}
+}
+
+// Add adds ID to the set.
+func (t *IDTracker) Add(id int64) {
+	p := t.pages[id>>idPageBits]
+	if p == nil {
+		p = new(idPage)
+		t.pages[id>>idPageBits] = p
+	}
+	i, bit := idBit(id)
+	if p[i]&bit == 0 {
+		p[i] |= bit
+		t.n++
+	}
+}
+
+// Remove removes ID from the set.
+func (t *IDTracker) Remove(id int64) {
+	p := t.pages[id>>idPageBits]
+	if p == nil {
+		return
+	}
+	i, bit := idBit(id)
+	if p[i]&bit != 0 {
+		p[i] &^= bit
+		t.n--
+	}
+}
+
+// Contains returns true if ID is in the set.
+func (t *IDTracker) Contains(id int64) bool {
+	p := t.pages[id>>idPageBits]
+	if p == nil {
+		return false
+	}
+	i, bit := idBit(id)
+	return p[i]&bit != 0
+}
+
+// Returns index of word and bit of ID in its page.
+func idBit(id int64) (int, uint64) {
+	offset := id & (1<<idPageBits - 1)
+	return int(offset / 64), 1 << uint(offset%64)
+}
+
+// Len returns number of IDs in the set.
+func (t *IDTracker) Len() int {
+	return t.n
+}
+
+// Or adds all IDs of other set to this set.
+func (t *IDTracker) Or(other *IDTracker) {
+	for index, op := range other.pages {
+		p := t.pages[index]
+		if p == nil {
+			p = new(idPage)
+			t.pages[index] = p
+		}
+		for i := range p {
+			t.n += bits.OnesCount64(op[i] &^ p[i])
+			p[i] |= op[i]
+		}
+	}
+}
+
+// And removes IDs which are not in other set from this set.
+func (t *IDTracker) And(other *IDTracker) {
+	for index, p := range t.pages {
+		op := other.pages[index]
+		empty := true
+		for i := range p {
+			var o uint64
+			if op != nil {
+				o = op[i]
+			}
+			t.n -= bits.OnesCount64(p[i] &^ o)
+			p[i] &= o
+			empty = empty && p[i] == 0
+		}
+		if empty {
+			delete(t.pages, index)
+		}
+	}
+}
+
+// Range calls fn with IDs of the set in ascending order, until fn returns false.
+func (t *IDTracker) Range(fn func(id int64) bool) {
+	indexes := make([]int64, 0, len(t.pages))
+	for index := range t.pages {
+		indexes = append(indexes, index)
+	}
+	sort.Slice(indexes, func(i, j int) bool { return indexes[i] < indexes[j] })
+
+	for _, index := range indexes {
+		p := t.pages[index]
+		for i, word := range p {
+			for word != 0 {
+				bit := bits.TrailingZeros64(word)
+				word &^= 1 << uint(bit)
+				if !fn(index<<idPageBits + int64(i*64+bit)) {
+					return
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package osmpbf
+
+import (
+	"reflect"
+	"testing"
+)
+
+func trackerIDs(t *IDTracker) []int64 {
+	var ids []int64
+	t.Range(func(id int64) bool {
+		ids = append(ids, id)
+		return true
+	})
+	return ids
+}
+
+func TestIDTracker(t *testing.T) {
+	a := NewIDTracker()
+	for _, id := range []int64{5, -3, 1 << 40, 65535, 65536, 5} {
+		a.Add(id)
+	}
+	if a.Len() != 5 {
+		t.Errorf("expected 5 IDs, got %d", a.Len())
+	}
+	for _, id := range []int64{5, -3, 1 << 40, 65535, 65536} {
+		if !a.Contains(id) {
+			t.Errorf("expected ID %d in set", id)
+		}
+	}
+	for _, id := range []int64{0, 4, -2, 1<<40 + 1, 65537} {
+		if a.Contains(id) {
+			t.Errorf("unexpected ID %d in set", id)
+		}
+	}
+	if ids := trackerIDs(a); !reflect.DeepEqual([]int64{-3, 5, 65535, 65536, 1 << 40}, ids) {
+		t.Errorf("unexpected IDs %v", ids)
+	}
+
+	a.Remove(65535)
+	a.Remove(65535)
+	a.Remove(7)
+	if a.Len() != 4 || a.Contains(65535) {
+		t.Errorf("expected ID to be removed, got %v", trackerIDs(a))
+	}
+
+	b := NewIDTracker()
+	b.Add(5)
+	b.Add(6)
+	b.Add(1 << 40)
+
+	or := NewIDTracker()
+	or.Or(a)
+	or.Or(b)
+	if ids := trackerIDs(or); or.Len() != 5 || !reflect.DeepEqual([]int64{-3, 5, 6, 65536, 1 << 40}, ids) {
+		t.Errorf("unexpected union %v, length %d", ids, or.Len())
+	}
+
+	a.And(b)
+	if ids := trackerIDs(a); a.Len() != 2 || !reflect.DeepEqual([]int64{5, 1 << 40}, ids) {
+		t.Errorf("unexpected intersection %v, length %d", ids, a.Len())
+	}
+
+	var first []int64
+	or.Range(func(id int64) bool {
+		first = append(first, id)
+		return len(first) < 2
+	})
+	if !reflect.DeepEqual([]int64{-3, 5}, first) {
+		t.Errorf("expected Range to stop after 2 IDs, got %v", first)
+	}
+}
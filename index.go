@@ -0,0 +1,197 @@
+package osmpbf
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+
+	"github.com/brechtbm/osmpbf/OSMPBF"
+	"google.golang.org/protobuf/proto"
+)
+
+// IDRange is a range of object IDs.
+type IDRange struct {
+	Min   int64
+	Max   int64
+	Count int // number of objects, range is empty if zero
+}
+
+// Contains returns true if id is inside range.
+func (r IDRange) Contains(id int64) bool {
+	return r.Count > 0 && id >= r.Min && id <= r.Max
+}
+
+func (r *IDRange) add(id int64) {
+	if r.Count == 0 || id < r.Min {
+		r.Min = id
+	}
+	if r.Count == 0 || id > r.Max {
+		r.Max = id
+	}
+	r.Count++
+}
+
+// IndexEntry describes one fileblock of the input stream.
+type IndexEntry struct {
+	Offset int64 // of fileblock start, that is BlobHeader size
+	Size   int64 // of whole fileblock
+	Type   string
+
+	// Compression of blob data, and its uncompressed size.
+	Compression Compression
+	RawSize     int64
+
+	// ID ranges of contained objects, empty for OSMHeader
+	Nodes     IDRange
+	Ways      IDRange
+	Relations IDRange
+
+	// BBox is bounding box of contained nodes, nil if there are none.
+	BBox *BBox `json:",omitempty"`
+}
+
+// Range returns ID range of contained objects of kind k.
+func (e *IndexEntry) Range(k Kind) IDRange {
+	switch k {
+	case NodeKind:
+		return e.Nodes
+	case WayKind:
+		return e.Ways
+	case RelationKind:
+		return e.Relations
+	default:
+		return IDRange{}
+	}
+}
+
+// Index lists fileblocks of the input stream in order, so decoding can start at fileblock
+// containing objects of interest.
+type Index struct {
+	Entries []IndexEntry
+
+	cache *blockCache // for lookups, nil if reader is not set
+}
+
+// BuildIndex reads r and returns index of its fileblocks. OSMData blobs are uncompressed and
+// unmarshalled to find ID ranges, but objects are not decoded.
+func BuildIndex(r io.Reader) (*Index, error) {
+	d := NewDecoder(r)
+	idx := new(Index)
+	var buf bytes.Buffer
+	for {
+		offset := d.offset
+		blobHeader, blob, err := d.readFileBlock()
+		if err == io.EOF && d.offset == offset {
+			return idx, nil
+		} else if err == io.EOF {
+			return nil, io.ErrUnexpectedEOF
+		} else if err != nil {
+			return nil, err
+		}
+
+		e := IndexEntry{Offset: offset, Size: d.offset - offset, Type: blobHeader.GetType()}
+		c, data, err := blobCompression(blob)
+		if err != nil {
+			return nil, err
+		}
+		e.Compression, e.RawSize = c, int64(blob.GetRawSize())
+		if c == Uncompressed {
+			e.RawSize = int64(len(data))
+		}
+		if e.Type == "OSMData" {
+			if err := e.addIDs(blob, &buf); err != nil {
+				return nil, err
+			}
+		}
+		idx.Entries = append(idx.Entries, e)
+	}
+}
+
+func (e *IndexEntry) addIDs(blob *OSMPBF.Blob, buf *bytes.Buffer) error {
+	data, err := getData(blob, buf)
+	if err != nil {
+		return err
+	}
+	bbox, ok, err := blockBBox(data)
+	if err != nil {
+		return err
+	}
+	if ok {
+		e.BBox = &bbox
+	}
+
+	pb := new(OSMPBF.PrimitiveBlock)
+	if err := proto.Unmarshal(data, pb); err != nil {
+		return err
+	}
+
+	for _, pg := range pb.GetPrimitivegroup() {
+		for _, node := range pg.GetNodes() {
+			e.Nodes.add(node.GetId())
+		}
+		var id int64
+		for _, delta := range pg.GetDense().GetId() {
+			id += delta
+			e.Nodes.add(id)
+		}
+		for _, way := range pg.GetWays() {
+			e.Ways.add(way.GetId())
+		}
+		for _, rel := range pg.GetRelations() {
+			e.Relations.add(rel.GetId())
+		}
+	}
+	return nil
+}
+
+// FindBBox returns fileblocks with nodes inside bounding box b, by their IndexEntry.BBox.
+func (idx *Index) FindBBox(b BBox) []IndexEntry {
+	var entries []IndexEntry
+	for _, e := range idx.Entries {
+		if e.BBox != nil && e.BBox.Intersects(b) {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// SetIndexBBox is the same as WithIndexBBox option. Must be called before Start.
+func (dec *Decoder) SetIndexBBox(idx *Index, b BBox) {
+	WithIndexBBox(idx, b)(dec)
+}
+
+// ReadIndex reads index written by Index.Write.
+func ReadIndex(r io.Reader) (*Index, error) {
+	idx := new(Index)
+	if err := json.NewDecoder(r).Decode(idx); err != nil {
+		return nil, err
+	}
+	return idx, nil
+}
+
+// Write writes index as JSON, for example to a sidecar file next to the input file.
+func (idx *Index) Write(w io.Writer) error {
+	return json.NewEncoder(w).Encode(idx)
+}
+
+// First returns first OSMData fileblock containing objects of kind k.
+func (idx *Index) First(k Kind) (IndexEntry, bool) {
+	for _, e := range idx.Entries {
+		if e.Range(k).Count > 0 {
+			return e, true
+		}
+	}
+	return IndexEntry{}, false
+}
+
+// Find returns fileblocks which ID range of kind k contains id. In sorted files there is
+// at most one such fileblock.
+func (idx *Index) Find(k Kind, id int64) []IndexEntry {
+	var entries []IndexEntry
+	for _, e := range idx.Entries {
+		if e.Range(k).Contains(id) {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
@@ -0,0 +1,127 @@
+package osmpbf
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func encodeNodesWays(t *testing.T, nodes, ways int) *bytes.Buffer {
+	var buf bytes.Buffer
+	e := NewEncoder(&buf)
+	for i := 1; i <= nodes; i++ {
+		if err := e.Encode(&Node{ID: int64(i), Lat: float64(i) / 1e3, Lon: -float64(i) / 1e3}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 1; i <= ways; i++ {
+		if err := e.Encode(&Way{ID: int64(i), NodeIDs: []int64{int64(i), int64(i + 1)}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := e.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return &buf
+}
+
+func TestBuildIndex(t *testing.T) {
+	buf := encodeNodesWays(t, maxBlockEntities*2+1, 10)
+	size := int64(buf.Len())
+
+	idx, err := BuildIndex(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// OSMHeader, 3 node blocks, 1 way block
+	if len(idx.Entries) != 5 {
+		t.Fatalf("expected 5 entries, got %d", len(idx.Entries))
+	}
+	var offset int64
+	for _, e := range idx.Entries {
+		if e.Offset != offset {
+			t.Errorf("expected offset %d, got %d", offset, e.Offset)
+		}
+		offset += e.Size
+	}
+	if offset != size {
+		t.Errorf("expected total size %d, got %d", size, offset)
+	}
+
+	if idx.Entries[0].Type != "OSMHeader" {
+		t.Errorf("expected OSMHeader, got %s", idx.Entries[0].Type)
+	}
+	expected := IDRange{Min: maxBlockEntities + 1, Max: maxBlockEntities * 2, Count: maxBlockEntities}
+	if r := idx.Entries[2].Nodes; r != expected {
+		t.Errorf("\nExpected: %#v\nActual:   %#v", expected, r)
+	}
+	if e := idx.Entries[2]; e.Compression != Zlib || e.RawSize <= 0 {
+		t.Errorf("unexpected compression %v, raw size %d", e.Compression, e.RawSize)
+	}
+	if idx.Entries[2].BBox == nil || idx.Entries[4].BBox != nil {
+		t.Errorf("expected bounding box of nodes only, got %v, %v", idx.Entries[2].BBox, idx.Entries[4].BBox)
+	}
+
+	if e, ok := idx.First(WayKind); !ok || e.Offset != idx.Entries[4].Offset {
+		t.Errorf("expected ways in last entry, got %#v", e)
+	}
+	if _, ok := idx.First(RelationKind); ok {
+		t.Error("expected no relations")
+	}
+	if entries := idx.Find(NodeKind, maxBlockEntities*2+1); len(entries) != 1 || entries[0].Offset != idx.Entries[3].Offset {
+		t.Errorf("expected node in fourth entry, got %#v", entries)
+	}
+
+	var w bytes.Buffer
+	if err := idx.Write(&w); err != nil {
+		t.Fatal(err)
+	}
+	read, err := ReadIndex(&w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(idx, read) {
+		t.Errorf("\nExpected: %#v\nActual:   %#v", idx, read)
+	}
+}
+
+func TestBuildIndexTruncated(t *testing.T) {
+	data := encodeObjects(t, en, ew, er).Bytes()
+	if _, err := BuildIndex(bytes.NewReader(data[:len(data)-10])); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestIndexBBox(t *testing.T) {
+	data := encodeNodesWays(t, maxBlockEntities*2+1, 10).Bytes()
+	idx, err := BuildIndex(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// nodes of the second node block only
+	b := BBox{Left: -10, Right: -9, Bottom: 9, Top: 10}
+	if entries := idx.FindBBox(b); len(entries) != 1 || entries[0].Offset != idx.Entries[2].Offset {
+		t.Errorf("expected nodes in third entry, got %#v", entries)
+	}
+
+	d := NewDecoder(bytes.NewReader(data), WithIndexBBox(idx, b))
+	objects := decodeAll(t, d)
+	if len(objects) != maxBlockEntities+10 {
+		t.Fatalf("expected %d objects, got %d", maxBlockEntities+10, len(objects))
+	}
+	if n, ok := objects[0].(*Node); !ok || n.ID != maxBlockEntities+1 {
+		t.Errorf("unexpected first object %#v", objects[0])
+	}
+	if w, ok := objects[len(objects)-1].(*Way); !ok || w.ID != 10 {
+		t.Errorf("unexpected last object %#v", objects[len(objects)-1])
+	}
+	// fileblocks skipped by index are specific to input stream
+	if err := d.Reset(bytes.NewReader(data)); err != nil {
+		t.Fatal(err)
+	}
+	if objects := decodeAll(t, d); len(objects) != maxBlockEntities*2+11 {
+		t.Errorf("expected %d objects after Reset, got %d", maxBlockEntities*2+11, len(objects))
+	}
+}
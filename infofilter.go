@@ -0,0 +1,21 @@
+package osmpbf
+
+// InfoFilter selects objects by Info metadata, see WithInfoFilter. Objects must match all non-empty
+// sets, empty sets match any value.
+type InfoFilter struct {
+	Uids       map[int32]bool
+	Users      map[string]bool
+	Changesets map[uint64]bool
+}
+
+// SetInfoFilter is the same as WithInfoFilter option. Must be called before Start.
+func (dec *Decoder) SetInfoFilter(f InfoFilter) {
+	WithInfoFilter(f)(dec)
+}
+
+// Returns true if info matches all sets of filter.
+func (f *InfoFilter) match(info *Info) bool {
+	return (len(f.Uids) == 0 || f.Uids[info.Uid]) &&
+		(len(f.Users) == 0 || f.Users[info.User]) &&
+		(len(f.Changesets) == 0 || f.Changesets[info.Changeset])
+}
@@ -0,0 +1,44 @@
+package osmpbf
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestInfoFilter(t *testing.T) {
+	info := func(uid int32, user string, changeset uint64) Info {
+		return Info{Version: 1, Timestamp: time.Unix(1e9, 0).UTC(), Changeset: changeset, Uid: uid, User: user, Visible: true}
+	}
+	data := encodeObjects(t,
+		&Node{ID: 1, Tags: map[string]string{"a": "b"}, Info: info(1, "alice", 10)},
+		&Node{ID: 2, Info: info(2, "bob", 11)},
+		&Node{ID: 3, Tags: map[string]string{"c": "d"}, Info: info(1, "alice", 12)},
+		&Way{ID: 10, NodeIDs: []int64{1, 2}, Info: info(2, "bob", 11)},
+		&Way{ID: 11, NodeIDs: []int64{1, 3}, Info: info(1, "alice", 12)},
+		&Relation{ID: 20, Info: info(1, "alice", 10)},
+	).Bytes()
+
+	for _, c := range []struct {
+		filter   InfoFilter
+		expected []string
+	}{
+		{InfoFilter{Uids: map[int32]bool{1: true}}, []string{"n1", "n3", "w11", "r20"}},
+		{InfoFilter{Users: map[string]bool{"bob": true}}, []string{"n2", "w10"}},
+		{InfoFilter{Changesets: map[uint64]bool{10: true, 11: true}}, []string{"n1", "n2", "w10", "r20"}},
+		{InfoFilter{Users: map[string]bool{"alice": true}, Changesets: map[uint64]bool{12: true}}, []string{"n3", "w11"}},
+		{InfoFilter{}, []string{"n1", "n2", "n3", "w10", "w11", "r20"}},
+	} {
+		d := NewDecoder(bytes.NewReader(data), WithInfoFilter(c.filter))
+		if ids := objectIDs(decodeAll(t, d)); !reflect.DeepEqual(c.expected, ids) {
+			t.Errorf("filter %v:\nExpected: %v\nActual:   %v", c.filter, c.expected, ids)
+		}
+	}
+
+	d := NewDecoder(bytes.NewReader(data), WithInfoFilter(InfoFilter{}), WithSkipInfo(true))
+	if err := d.Start(1); err == nil {
+		d.Close()
+		t.Error("expected error of info filter with skipped Info")
+	}
+}
@@ -0,0 +1,169 @@
+package osmpbf
+
+import "io"
+
+// MissingRefs describes references of objects of one kind to objects missing in the input stream.
+type MissingRefs struct {
+	// Objects is number of objects with at least one missing reference.
+	Objects int64
+
+	// Missing is number of distinct missing objects.
+	Missing int64
+
+	// Samples of objects with missing references and of missing objects, in input stream order.
+	SampleObjects []ElementID
+	SampleMissing []ElementID
+}
+
+// IntegrityReport describes referential integrity of the input stream, see IntegrityChecker.
+type IntegrityReport struct {
+	Ways      MissingRefs // ways referencing missing nodes
+	Relations MissingRefs // relations referencing missing members
+}
+
+// OK returns true if no missing references were found.
+func (r *IntegrityReport) OK() bool {
+	return r.Ways.Objects == 0 && r.Relations.Objects == 0
+}
+
+// An IntegrityChecker finds ways referencing missing nodes and relations referencing missing
+// members, for example to check quality of an extract before import. Objects must be added in
+// input stream order, sorted by type, then by ID, so references of ways and relations to nodes and
+// ways are checked immediately, while references to relations are checked by Report. It takes about
+// one bit per ID of all objects. Not safe for concurrent use.
+type IntegrityChecker struct {
+	samples int
+	order   sortOrder
+
+	objects [3]*IDTracker // by Kind
+	ways    *missingRefs
+	rels    *missingRefs
+
+	// relation members referencing relations not added yet
+	pending []relationRef
+}
+
+// Reference of relation to member relation.
+type relationRef struct {
+	relation, member int64
+}
+
+// Collects MissingRefs of objects of one kind.
+type missingRefs struct {
+	MissingRefs
+	objects *IDTracker
+	missing [3]*IDTracker // by Kind
+}
+
+func newMissingRefs() *missingRefs {
+	return &missingRefs{
+		objects: NewIDTracker(),
+		missing: [3]*IDTracker{NewIDTracker(), NewIDTracker(), NewIDTracker()},
+	}
+}
+
+// Adds reference of object to missing object of kind k with given ID.
+func (m *missingRefs) add(object ElementID, k Kind, id int64, samples int) {
+	if !m.objects.Contains(object.ID()) {
+		m.objects.Add(object.ID())
+		if len(m.SampleObjects) < samples {
+			m.SampleObjects = append(m.SampleObjects, object)
+		}
+	}
+	if !m.missing[k].Contains(id) {
+		m.missing[k].Add(id)
+		if len(m.SampleMissing) < samples {
+			m.SampleMissing = append(m.SampleMissing, NewElementID(k, id))
+		}
+	}
+}
+
+// Returns collected MissingRefs.
+func (m *missingRefs) refs() MissingRefs {
+	r := m.MissingRefs
+	r.Objects = int64(m.objects.Len())
+	for _, t := range m.missing {
+		r.Missing += int64(t.Len())
+	}
+	return r
+}
+
+// NewIntegrityChecker returns a new checker collecting at most samples sample IDs of each list.
+func NewIntegrityChecker(samples int) *IntegrityChecker {
+	c := &IntegrityChecker{
+		samples: samples,
+		objects: [3]*IDTracker{NewIDTracker(), NewIDTracker(), NewIDTracker()},
+		ways:    newMissingRefs(),
+		rels:    newMissingRefs(),
+	}
+	return c
+}
+
+// Add checks references of object and records its ID. Error is returned if objects are not sorted.
+// Other objects than Node, Way and Relation are ignored.
+func (c *IntegrityChecker) Add(o Object) error {
+	kind, id := kindID(o)
+	if kind > RelationKind {
+		return nil
+	}
+	if err := c.order.next(kind, id); err != nil {
+		return err
+	}
+	c.objects[kind].Add(id)
+
+	switch o := o.(type) {
+	case *Way:
+		for _, ref := range o.NodeIDs {
+			c.check(c.ways, o.ElementID(), NodeKind, ref)
+		}
+	case *Relation:
+		for _, m := range o.Members {
+			k := m.Type.Kind()
+			if k == RelationKind && !c.objects[k].Contains(m.ID) {
+				c.pending = append(c.pending, relationRef{o.ID, m.ID})
+				continue
+			}
+			c.check(c.rels, o.ElementID(), k, m.ID)
+		}
+	}
+	return nil
+}
+
+// Records reference of object to missing object of kind k with given ID, if it was not added.
+func (c *IntegrityChecker) check(m *missingRefs, object ElementID, k Kind, id int64) {
+	if !c.objects[k].Contains(id) {
+		m.add(object, k, id, c.samples)
+	}
+}
+
+// Report checks references to relations and returns report of all added objects. Objects must
+// not be added after Report.
+func (c *IntegrityChecker) Report() *IntegrityReport {
+	for _, ref := range c.pending {
+		c.check(c.rels, NewElementID(RelationKind, ref.relation), RelationKind, ref.member)
+	}
+	c.pending = nil
+
+	return &IntegrityReport{Ways: c.ways.refs(), Relations: c.rels.refs()}
+}
+
+// CheckIntegrity reads objects from started decoder until the end of input stream, which must be
+// sorted by type, then by ID, and returns report of IntegrityChecker with at most samples sample
+// IDs of each list.
+func CheckIntegrity(dec *Decoder, samples int) (*IntegrityReport, error) {
+	c := NewIntegrityChecker(samples)
+	for {
+		v, err := dec.Decode()
+		if err == io.EOF {
+			return c.Report(), nil
+		} else if err != nil {
+			return nil, err
+		}
+		o := v.(Object)
+		err = c.Add(o)
+		o.Release()
+		if err != nil {
+			return nil, err
+		}
+	}
+}
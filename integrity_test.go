@@ -0,0 +1,62 @@
+package osmpbf
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestCheckIntegrity(t *testing.T) {
+	d := NewDecoder(encodeObjects(t,
+		&Node{ID: 1}, &Node{ID: 2},
+		&Way{ID: 10, NodeIDs: []int64{1, 3, 4}},
+		&Way{ID: 11, NodeIDs: []int64{1, 2}},
+		&Way{ID: 12, NodeIDs: []int64{3}},
+		&Relation{ID: 20, Members: []Member{{ID: 21, Type: RelationType}, {ID: 3, Type: NodeType}}},
+		&Relation{ID: 21, Members: []Member{{ID: 10, Type: WayType}, {ID: 13, Type: WayType}}},
+		&Relation{ID: 22, Members: []Member{{ID: 23, Type: RelationType}}},
+	))
+	if err := d.Start(2); err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	r, err := CheckIntegrity(d, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := &IntegrityReport{
+		Ways: MissingRefs{
+			Objects:       2,
+			Missing:       2,
+			SampleObjects: []ElementID{NewElementID(WayKind, 10)},
+			SampleMissing: []ElementID{NewElementID(NodeKind, 3)},
+		},
+		Relations: MissingRefs{
+			Objects:       3,
+			Missing:       3,
+			SampleObjects: []ElementID{NewElementID(RelationKind, 20)},
+			SampleMissing: []ElementID{NewElementID(NodeKind, 3)},
+		},
+	}
+	if !reflect.DeepEqual(expected, r) {
+		t.Errorf("\nExpected: %+v\nActual:   %+v", expected, r)
+	}
+	if r.OK() {
+		t.Error("expected missing references")
+	}
+	data, _ := json.Marshal(r.Ways.SampleObjects)
+	if string(data) != `["way/10"]` {
+		t.Errorf("unexpected JSON %s", data)
+	}
+
+	c := NewIntegrityChecker(10)
+	c.Add(&Node{ID: 1})
+	c.Add(&Way{ID: 1, NodeIDs: []int64{1}})
+	if !c.Report().OK() {
+		t.Error("expected no missing references")
+	}
+	if err := c.Add(&Node{ID: 2}); err == nil {
+		t.Error("expected error for unsorted objects")
+	}
+}
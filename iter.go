@@ -0,0 +1,70 @@
+//go:build go1.23
+// +build go1.23
+
+package osmpbf
+
+import (
+	"io"
+	"iter"
+)
+
+// All returns an iterator over objects decoded by started Decoder. Iteration stops at the end
+// of the input stream; any other error is yielded once as the last value.
+// Breaking out of the loop closes Decoder, see Close.
+func (dec *Decoder) All() iter.Seq2[Object, error] {
+	return func(yield func(Object, error) bool) {
+		for {
+			v, err := dec.Decode()
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if !yield(v.(Object), nil) {
+				dec.Close()
+				return
+			}
+		}
+	}
+}
+
+// Nodes returns an iterator over decoded nodes, skipping other objects. See All for details.
+func (dec *Decoder) Nodes() iter.Seq2[*Node, error] {
+	return func(yield func(*Node, error) bool) {
+		for o, err := range dec.All() {
+			if n, ok := o.(*Node); ok || err != nil {
+				if !yield(n, err) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Ways returns an iterator over decoded ways, skipping other objects. See All for details.
+func (dec *Decoder) Ways() iter.Seq2[*Way, error] {
+	return func(yield func(*Way, error) bool) {
+		for o, err := range dec.All() {
+			if w, ok := o.(*Way); ok || err != nil {
+				if !yield(w, err) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Relations returns an iterator over decoded relations, skipping other objects. See All for details.
+func (dec *Decoder) Relations() iter.Seq2[*Relation, error] {
+	return func(yield func(*Relation, error) bool) {
+		for o, err := range dec.All() {
+			if r, ok := o.(*Relation); ok || err != nil {
+				if !yield(r, err) {
+					return
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,51 @@
+//go:build go1.23
+// +build go1.23
+
+package osmpbf
+
+import (
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestIterators(t *testing.T) {
+	d := NewDecoder(encodeObjects(t, ew, er))
+	if err := d.Start(2); err != nil {
+		t.Fatal(err)
+	}
+
+	var ways []*Way
+	for w, err := range d.Ways() {
+		if err != nil {
+			t.Fatal(err)
+		}
+		ways = append(ways, w)
+	}
+	if expected := []*Way{ew}; !reflect.DeepEqual(expected, ways) {
+		t.Errorf("\nExpected: %#v\nActual:   %#v", expected, ways)
+	}
+}
+
+func TestIteratorsBreak(t *testing.T) {
+	objects := make([]Object, maxBlockEntities*3)
+	for i := range objects {
+		objects[i] = &Node{ID: int64(i + 1)}
+	}
+
+	d := NewDecoder(encodeObjects(t, objects...))
+	if err := d.Start(2); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, err := range d.All() {
+		if err != nil {
+			t.Fatal(err)
+		}
+		break
+	}
+
+	if v, err := d.Decode(); err != io.EOF {
+		t.Errorf("expected io.EOF, got %#v, %v", v, err)
+	}
+}
@@ -0,0 +1,184 @@
+package osmpbf
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// OSM JSON element, in the format of Overpass API output. Fields are in the order of Overpass API.
+type jsonElement struct {
+	Type      string            `json:"type"`
+	ID        int64             `json:"id"`
+	Lat       *float64          `json:"lat,omitempty"`
+	Lon       *float64          `json:"lon,omitempty"`
+	Timestamp string            `json:"timestamp,omitempty"`
+	Version   int16             `json:"version,omitempty"`
+	Changeset uint64            `json:"changeset,omitempty"`
+	User      string            `json:"user,omitempty"`
+	Uid       int32             `json:"uid,omitempty"`
+	Nodes     []int64           `json:"nodes,omitempty"`
+	Geometry  []jsonLatLon      `json:"geometry,omitempty"`
+	Members   []jsonMember      `json:"members,omitempty"`
+	Tags      map[string]string `json:"tags,omitempty"`
+}
+
+type jsonLatLon struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
+
+type jsonMember struct {
+	Type string `json:"type"`
+	Ref  int64  `json:"ref"`
+	Role string `json:"role"`
+}
+
+func newJSONElement(typ string, id int64, info Info, tags map[string]string, list TagList) *jsonElement {
+	e := &jsonElement{
+		Type:      typ,
+		ID:        id,
+		Version:   info.Version,
+		Changeset: info.Changeset,
+		User:      info.User,
+		Uid:       info.Uid,
+		Tags:      tags,
+	}
+	if !info.Timestamp.IsZero() {
+		e.Timestamp = info.Timestamp.UTC().Format(time.RFC3339)
+	}
+	if len(tags) == 0 && len(list) > 0 {
+		e.Tags = list.Map()
+	}
+	return e
+}
+
+// MarshalJSON encodes node as OSM JSON element, as in Overpass API output:
+// {"type":"node","id":1,"lat":51.5,"lon":-0.1,"tags":{"amenity":"pub"}}. Metadata fields are
+// omitted if they are not set, tags are omitted if there are none.
+func (n Node) MarshalJSON() ([]byte, error) {
+	e := newJSONElement("node", n.ID, n.Info, n.Tags, n.TagList)
+	e.Lat, e.Lon = &n.Lat, &n.Lon
+	return json.Marshal(e)
+}
+
+// MarshalJSON encodes way as OSM JSON element, see Node.MarshalJSON. Way.NodeLocations are encoded
+// as geometry, like in output of Overpass API "out geom" statement.
+func (w Way) MarshalJSON() ([]byte, error) {
+	e := newJSONElement("way", w.ID, w.Info, w.Tags, w.TagList)
+	e.Nodes = w.NodeIDs
+	if len(w.NodeLocations) == len(w.NodeIDs) {
+		for _, l := range w.NodeLocations {
+			e.Geometry = append(e.Geometry, jsonLatLon(l))
+		}
+	}
+	return json.Marshal(e)
+}
+
+// MarshalJSON encodes relation as OSM JSON element, see Node.MarshalJSON.
+func (r Relation) MarshalJSON() ([]byte, error) {
+	e := newJSONElement("relation", r.ID, r.Info, r.Tags, r.TagList)
+	e.Members = make([]jsonMember, len(r.Members))
+	for i, m := range r.Members {
+		var typ string
+		switch m.Type {
+		case NodeType:
+			typ = "node"
+		case WayType:
+			typ = "way"
+		case RelationType:
+			typ = "relation"
+		default:
+			return nil, fmt.Errorf("relation %d: unknown member type %d", r.ID, m.Type)
+		}
+		e.Members[i] = jsonMember{typ, m.ID, m.Role}
+	}
+	return json.Marshal(e)
+}
+
+// A JSONEncoder writes objects to an output stream as OSM JSON document, in the format of Overpass
+// API output, which can be read by tools like osmtogeojson.
+type JSONEncoder struct {
+	w *bufio.Writer
+
+	header  Header
+	started bool
+	n       int // number of written elements
+}
+
+// NewJSONEncoder returns a new encoder that writes to w.
+func NewJSONEncoder(w io.Writer) *JSONEncoder {
+	return &JSONEncoder{w: bufio.NewWriter(w), header: Header{WritingProgram: writingProgram}}
+}
+
+// SetHeader sets metadata written to document: WritingProgram as generator and bounding box as
+// bounds, like in OSM API output. Must be called before Encode.
+func (enc *JSONEncoder) SetHeader(h *Header) {
+	enc.header = *h
+	if enc.header.WritingProgram == "" {
+		enc.header.WritingProgram = writingProgram
+	}
+}
+
+// Encode writes a pointer to Node, Way or Relation struct to the output stream as element of
+// document, in the same way as Encoder: Bound sets bounds, so it must be passed before objects.
+// Output is buffered, so Close must be called to write remaining data.
+func (enc *JSONEncoder) Encode(v interface{}) error {
+	var data []byte
+	var err error
+	switch o := v.(type) {
+	case *Bound:
+		if enc.started {
+			return errors.New("bound after objects")
+		}
+		bbox := o.BBox
+		enc.header.BBox = &bbox
+		return nil
+	case *Node, *Way, *Relation:
+		data, err = json.Marshal(o)
+	default:
+		return fmt.Errorf("unknown type %T", v)
+	}
+	if err != nil {
+		return err
+	}
+
+	enc.writeStart()
+	if enc.n > 0 {
+		enc.w.WriteString(",\n")
+	}
+	enc.n++
+	_, err = enc.w.Write(data)
+	return err
+}
+
+// Close writes the end of document and flushes buffered data. It does not close the underlying
+// writer.
+func (enc *JSONEncoder) Close() error {
+	enc.writeStart()
+	enc.w.WriteString("\n]}\n")
+	return enc.w.Flush()
+}
+
+// Writes document up to its elements, if it is not written yet.
+func (enc *JSONEncoder) writeStart() {
+	if enc.started {
+		return
+	}
+	enc.started = true
+
+	generator, _ := json.Marshal(enc.header.WritingProgram)
+	enc.w.WriteString(`{"version":0.6,"generator":`)
+	enc.w.Write(generator)
+	if b := enc.header.BBox; b != nil {
+		bounds, _ := json.Marshal(map[string]float64{
+			"minlat": b.Bottom, "minlon": b.Left, "maxlat": b.Top, "maxlon": b.Right,
+		})
+		enc.w.WriteString(`,"bounds":`)
+		enc.w.Write(bounds)
+	}
+	enc.w.WriteString(`,"elements":[` + "\n")
+}
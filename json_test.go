@@ -0,0 +1,69 @@
+package osmpbf
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestMarshalJSON(t *testing.T) {
+	info := Info{Version: 2, Timestamp: time.Unix(1e9, 0), Changeset: 3, Uid: 4, User: "u", Visible: true}
+	for _, c := range []struct {
+		v        interface{}
+		expected string
+	}{
+		{&Node{ID: 1, Lat: 51.5, Lon: -0.1, Tags: map[string]string{"amenity": "pub"}, Info: info},
+			`{"type":"node","id":1,"lat":51.5,"lon":-0.1,"timestamp":"2001-09-09T01:46:40Z","version":2,` +
+				`"changeset":3,"user":"u","uid":4,"tags":{"amenity":"pub"}}`},
+		{Node{ID: 1}, `{"type":"node","id":1,"lat":0,"lon":0}`},
+		{&Way{ID: 2, NodeIDs: []int64{1, 2}, TagList: TagList{{"highway", "path"}}},
+			`{"type":"way","id":2,"nodes":[1,2],"tags":{"highway":"path"}}`},
+		{&Way{ID: 2, NodeIDs: []int64{1}, NodeLocations: []LatLon{{1.5, 2.5}}},
+			`{"type":"way","id":2,"nodes":[1],"geometry":[{"lat":1.5,"lon":2.5}]}`},
+		{&Relation{ID: 3, Members: []Member{{1, NodeType, "stop"}, {2, WayType, ""}}},
+			`{"type":"relation","id":3,"members":[{"type":"node","ref":1,"role":"stop"},{"type":"way","ref":2,"role":""}]}`},
+	} {
+		data, err := json.Marshal(c.v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != c.expected {
+			t.Errorf("\nExpected: %s\nActual:   %s", c.expected, data)
+		}
+	}
+
+	if _, err := json.Marshal(&Relation{Members: []Member{{Type: 7}}}); err == nil {
+		t.Error("expected error for unknown member type")
+	}
+}
+
+func TestJSONEncoder(t *testing.T) {
+	var buf bytes.Buffer
+	e := NewJSONEncoder(&buf)
+	e.SetHeader(&Header{WritingProgram: "test"})
+	for _, v := range []interface{}{
+		&Bound{BBox{Left: -1, Right: 1, Top: 2, Bottom: -2}},
+		&Node{ID: 1},
+		&Way{ID: 2, NodeIDs: []int64{1}},
+	} {
+		if err := e.Encode(v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := e.Encode(&Bound{}); err == nil {
+		t.Error("expected error for bound after objects")
+	}
+	if err := e.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{"version":0.6,"generator":"test","bounds":{"maxlat":2,"maxlon":1,"minlat":-2,"minlon":-1},"elements":[
+{"type":"node","id":1,"lat":0,"lon":0},
+{"type":"way","id":2,"nodes":[1]}
+]}
+`
+	if buf.String() != expected {
+		t.Errorf("\nExpected: %s\nActual:   %s", expected, buf.String())
+	}
+}
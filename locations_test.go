@@ -0,0 +1,42 @@
+package osmpbf
+
+import (
+	"bytes"
+	"math"
+	"testing"
+)
+
+func TestLocationsOnWays(t *testing.T) {
+	w := &Way{
+		ID:            1,
+		NodeIDs:       []int64{10, 11, 12},
+		NodeLocations: []LatLon{{51.5442632, -0.2010027}, {51.5442700, -0.2010100}, {-33.8688197, 151.2092955}},
+	}
+
+	var buf bytes.Buffer
+	e := NewEncoder(&buf)
+	e.SetHeader(&Header{OptionalFeatures: []string{"LocationsOnWays"}})
+	if err := e.Encode(w); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	decoded := decodeAll(t, NewDecoder(&buf))
+	dw := decoded[0].(*Way)
+	if len(dw.NodeLocations) != len(w.NodeLocations) {
+		t.Fatalf("expected %d locations, got %d", len(w.NodeLocations), len(dw.NodeLocations))
+	}
+	for i, l := range dw.NodeLocations {
+		if math.Abs(l.Lat-w.NodeLocations[i].Lat) > 1e-7 || math.Abs(l.Lon-w.NodeLocations[i].Lon) > 1e-7 {
+			t.Errorf("expected %v, got %v", w.NodeLocations[i], l)
+		}
+	}
+
+	// ways without locations
+	decoded = decodeAll(t, NewDecoder(encodeObjects(t, ew)))
+	if dw = decoded[0].(*Way); dw.NodeLocations != nil {
+		t.Errorf("expected no locations, got %v", dw.NodeLocations)
+	}
+}
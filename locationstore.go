@@ -0,0 +1,231 @@
+package osmpbf
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+)
+
+// NodeLocationStore stores locations of nodes by their IDs, so geometry of ways can be built from
+// Way.NodeIDs, see WithNodeLocations. Locations are stored with precision of 100 nanodegrees, the
+// default granularity of PBF files.
+type NodeLocationStore interface {
+	// Set stores location of node. It must not be called concurrently with other methods.
+	Set(id int64, l LatLon) error
+
+	// Location returns location of node, or false if it is not stored. It is safe for concurrent use.
+	Location(id int64) (LatLon, bool)
+
+	// Close releases resources of store, it must not be used after Close.
+	Close() error
+}
+
+// SetNodeLocations is the same as WithNodeLocations option. Must be called before Start.
+func (dec *Decoder) SetNodeLocations(s NodeLocationStore) {
+	WithNodeLocations(s)(dec)
+}
+
+// Stores location of node, or sets locations of way from the store if it has none.
+// Way locations are set only if locations of all its nodes are known.
+func resolveLocations(s NodeLocationStore, o Object) error {
+	switch o := o.(type) {
+	case *Node:
+		return s.Set(o.ID, LatLon{o.Lat, o.Lon})
+	case *Way:
+		if len(o.NodeLocations) > 0 {
+			return nil
+		}
+		for _, id := range o.NodeIDs {
+			l, ok := s.Location(id)
+			if !ok {
+				o.NodeLocations = o.NodeLocations[:0]
+				return nil
+			}
+			o.NodeLocations = append(o.NodeLocations, l)
+		}
+	}
+	return nil
+}
+
+// Packs location into uint64, zero means unknown location: latitude is stored with flipped sign
+// bit, so it is never zero for valid latitudes.
+func packLocation(l LatLon) uint64 {
+	lat := int32(math.Round(l.Lat * 1e7))
+	lon := int32(math.Round(l.Lon * 1e7))
+	return uint64(uint32(lat)^1<<31)<<32 | uint64(uint32(lon))
+}
+
+func unpackLocation(v uint64) (LatLon, bool) {
+	if v == 0 {
+		return LatLon{}, false
+	}
+	lat := int32(uint32(v>>32) ^ 1<<31)
+	lon := int32(uint32(v))
+	return LatLon{float64(lat) / 1e7, float64(lon) / 1e7}, true
+}
+
+func negativeIDError(id int64) error {
+	return fmt.Errorf("negative node ID %d", id)
+}
+
+// entries in one page of dense store
+const densePageSize = 1 << 16
+
+// Array indexed by node ID, allocated by pages.
+type denseLocationStore struct {
+	pages [][]uint64
+}
+
+// NewDenseLocationStore returns a store keeping locations in memory in an array indexed by node ID,
+// which takes 8 bytes for each ID up to the largest one; parts of array without stored locations are
+// not allocated. It is the fastest store, suitable for files with many nodes, like country extracts.
+// Negative IDs are not supported.
+func NewDenseLocationStore() NodeLocationStore {
+	return new(denseLocationStore)
+}
+
+func (s *denseLocationStore) Set(id int64, l LatLon) error {
+	if id < 0 {
+		return negativeIDError(id)
+	}
+	page := int(id / densePageSize)
+	if page >= len(s.pages) {
+		s.pages = append(s.pages, make([][]uint64, page+1-len(s.pages))...)
+	}
+	if s.pages[page] == nil {
+		s.pages[page] = make([]uint64, densePageSize)
+	}
+	s.pages[page][id%densePageSize] = packLocation(l)
+	return nil
+}
+
+func (s *denseLocationStore) Location(id int64) (LatLon, bool) {
+	if id < 0 || id/densePageSize >= int64(len(s.pages)) {
+		return LatLon{}, false
+	}
+	page := s.pages[id/densePageSize]
+	if page == nil {
+		return LatLon{}, false
+	}
+	return unpackLocation(page[id%densePageSize])
+}
+
+func (s *denseLocationStore) Close() error {
+	s.pages = nil
+	return nil
+}
+
+// Map by node ID.
+type sparseLocationStore struct {
+	locations map[int64]uint64
+}
+
+// NewSparseLocationStore returns a store keeping locations in memory in a map by node ID. It takes
+// more memory per node than dense store, but does not depend on range of IDs, so it is suitable for
+// small files and for negative IDs.
+func NewSparseLocationStore() NodeLocationStore {
+	return &sparseLocationStore{make(map[int64]uint64)}
+}
+
+func (s *sparseLocationStore) Set(id int64, l LatLon) error {
+	s.locations[id] = packLocation(l)
+	return nil
+}
+
+func (s *sparseLocationStore) Location(id int64) (LatLon, bool) {
+	return unpackLocation(s.locations[id])
+}
+
+func (s *sparseLocationStore) Close() error {
+	s.locations = nil
+	return nil
+}
+
+// minimum size the mmap store file grows by, 64MB
+const mmapStoreGrowth = 1 << 26
+
+// Flat file indexed by node ID, mapped into memory.
+type mmapLocationStore struct {
+	f    *os.File
+	data []byte
+}
+
+// OpenMmapLocationStore returns a store keeping locations in a flat file at path indexed by node ID,
+// mapped into memory, so the OS pages it in and out as needed. The file takes 8 bytes for each ID
+// up to the largest one, but it is created sparse on file systems supporting it. It is suitable for
+// planet files, which do not fit into memory with dense store. The file is kept after Close, so its
+// locations can be used by later processes. Negative IDs are not supported.
+func OpenMmapLocationStore(path string) (NodeLocationStore, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	s := &mmapLocationStore{f: f}
+	if fi.Size() > 0 {
+		if s.data, err = mmapWritable(f, fi.Size()); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+func (s *mmapLocationStore) Set(id int64, l LatLon) error {
+	if id < 0 {
+		return negativeIDError(id)
+	}
+	off := id * 8
+	if off+8 > int64(len(s.data)) {
+		if err := s.grow(off + 8); err != nil {
+			return err
+		}
+	}
+	binary.LittleEndian.PutUint64(s.data[off:], packLocation(l))
+	return nil
+}
+
+// Extends file to hold at least size bytes and maps it again.
+func (s *mmapLocationStore) grow(size int64) error {
+	newSize := 2 * int64(len(s.data))
+	if newSize < size {
+		newSize = (size + mmapStoreGrowth - 1) / mmapStoreGrowth * mmapStoreGrowth
+	}
+	if s.data != nil {
+		if err := munmap(s.data); err != nil {
+			return err
+		}
+		s.data = nil
+	}
+	if err := s.f.Truncate(newSize); err != nil {
+		return err
+	}
+	var err error
+	s.data, err = mmapWritable(s.f, newSize)
+	return err
+}
+
+func (s *mmapLocationStore) Location(id int64) (LatLon, bool) {
+	if id < 0 || id*8+8 > int64(len(s.data)) {
+		return LatLon{}, false
+	}
+	return unpackLocation(binary.LittleEndian.Uint64(s.data[id*8:]))
+}
+
+func (s *mmapLocationStore) Close() error {
+	var err error
+	if s.data != nil {
+		err = munmap(s.data)
+		s.data = nil
+	}
+	if cerr := s.f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,95 @@
+package osmpbf
+
+import (
+	"bytes"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestNodeLocationStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "locations")
+	mmapStore, err := OpenMmapLocationStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	locations := map[int64]LatLon{
+		0:                  {0, 0},
+		1:                  {-90, -180},
+		2:                  {90, 180},
+		densePageSize + 1:  {51.5442632, -0.2010027},
+		10 * densePageSize: {-33.8688197, 151.2092955},
+	}
+	for name, s := range map[string]NodeLocationStore{
+		"dense":  NewDenseLocationStore(),
+		"sparse": NewSparseLocationStore(),
+		"mmap":   mmapStore,
+	} {
+		for id, l := range locations {
+			if err := s.Set(id, l); err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+		}
+		for id, expected := range locations {
+			if l, ok := s.Location(id); !ok || l != expected {
+				t.Errorf("%s: expected location %v of node %d, got %v, %v", name, expected, id, l, ok)
+			}
+		}
+		for _, id := range []int64{3, densePageSize, 5 * densePageSize, 1 << 40} {
+			if l, ok := s.Location(id); ok {
+				t.Errorf("%s: expected unknown location of node %d, got %v", name, id, l)
+			}
+		}
+		if name != "sparse" {
+			if err := s.Set(-1, LatLon{}); err == nil {
+				t.Errorf("%s: expected error for negative ID", name)
+			}
+		}
+		if err := s.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// locations are kept in file
+	mmapStore, err = OpenMmapLocationStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mmapStore.Close()
+	if l, ok := mmapStore.Location(densePageSize + 1); !ok || l != locations[densePageSize+1] {
+		t.Errorf("expected location %v, got %v, %v", locations[densePageSize+1], l, ok)
+	}
+}
+
+func TestWithNodeLocations(t *testing.T) {
+	buf := encodeObjects(t,
+		&Node{ID: 1, Lat: 1, Lon: 2},
+		&Node{ID: 2, Lat: 3, Lon: 4},
+		&Way{ID: 1, NodeIDs: []int64{1, 2}},
+		&Way{ID: 2, NodeIDs: []int64{2, 3}},
+	)
+	data := buf.Bytes()
+
+	s := NewDenseLocationStore()
+	objects := decodeAll(t, NewDecoder(bytes.NewReader(data), WithNodeLocations(s)))
+	if l := objects[2].(*Way).NodeLocations; !reflect.DeepEqual(l, []LatLon{{1, 2}, {3, 4}}) {
+		t.Errorf("expected locations of way 1, got %v", l)
+	}
+	if l := objects[3].(*Way).NodeLocations; len(l) != 0 {
+		t.Errorf("expected no locations of way 2 with unknown node, got %v", l)
+	}
+
+	// second pass over ways only
+	d := NewDecoder(bytes.NewReader(data), WithNodeLocations(s))
+	d.SkipToWays()
+	objects = decodeAll(t, d)
+	if l := objects[0].(*Way).NodeLocations; !reflect.DeepEqual(l, []LatLon{{1, 2}, {3, 4}}) {
+		t.Errorf("expected locations of way 1 in second pass, got %v", l)
+	}
+
+	d = NewDecoder(bytes.NewReader(data), WithNodeLocations(s), WithUnordered(true))
+	if err := d.Start(2); err == nil {
+		t.Error("expected error for unordered decoding")
+	}
+}
@@ -0,0 +1,135 @@
+package osmpbf
+
+import (
+	"bytes"
+	"container/list"
+	"errors"
+	"io"
+	"sync"
+)
+
+// DefaultBlockCacheSize is the default number of decoded fileblocks cached by Index lookups.
+const DefaultBlockCacheSize = 16
+
+// Decoded objects of one fileblock, by ElementID.
+type cachedBlock struct {
+	offset  int64
+	objects map[ElementID]Object
+}
+
+// LRU cache of decoded fileblocks of the input stream of index.
+type blockCache struct {
+	r    io.ReaderAt
+	size int
+
+	mu     sync.Mutex
+	blocks map[int64]*list.Element // of *cachedBlock, by offset
+	lru    list.List               // most recently used first
+}
+
+// SetReaderAt sets input stream the index is built from, which enables GetNode, GetWay and
+// GetRelation. At most blocks decoded fileblocks are cached, DefaultBlockCacheSize if blocks is
+// not positive. r must be safe for concurrent ReadAt calls, as *os.File is.
+func (idx *Index) SetReaderAt(r io.ReaderAt, blocks int) {
+	if blocks <= 0 {
+		blocks = DefaultBlockCacheSize
+	}
+	idx.cache = &blockCache{r: r, size: blocks, blocks: make(map[int64]*list.Element)}
+}
+
+// GetNode returns node with given ID, nil if it is not found. Only fileblocks which ID range
+// contains id are decoded. Returned objects are shared by the cache and must not be modified.
+func (idx *Index) GetNode(id int64) (*Node, error) {
+	o, err := idx.get(NodeKind, id)
+	if o == nil {
+		return nil, err
+	}
+	return o.(*Node), nil
+}
+
+// GetWay returns way with given ID, nil if it is not found, see GetNode.
+func (idx *Index) GetWay(id int64) (*Way, error) {
+	o, err := idx.get(WayKind, id)
+	if o == nil {
+		return nil, err
+	}
+	return o.(*Way), nil
+}
+
+// GetRelation returns relation with given ID, nil if it is not found, see GetNode.
+func (idx *Index) GetRelation(id int64) (*Relation, error) {
+	o, err := idx.get(RelationKind, id)
+	if o == nil {
+		return nil, err
+	}
+	return o.(*Relation), nil
+}
+
+// Returns object of kind k with given ID from fileblocks which ID range contains it, or nil.
+func (idx *Index) get(k Kind, id int64) (Object, error) {
+	if idx.cache == nil {
+		return nil, errors.New("reader of index is not set")
+	}
+	for _, e := range idx.Find(k, id) {
+		b, err := idx.cache.block(e)
+		if err != nil {
+			return nil, err
+		}
+		if o, ok := b.objects[NewElementID(k, id)]; ok {
+			return o, nil
+		}
+	}
+	return nil, nil
+}
+
+// Returns decoded fileblock of entry e, reading it if it is not cached.
+func (c *blockCache) block(e IndexEntry) (*cachedBlock, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.blocks[e.Offset]; ok {
+		c.lru.MoveToFront(el)
+		return el.Value.(*cachedBlock), nil
+	}
+
+	b, err := c.load(e)
+	if err != nil {
+		return nil, err
+	}
+	c.blocks[e.Offset] = c.lru.PushFront(b)
+	for c.lru.Len() > c.size {
+		el := c.lru.Back()
+		c.lru.Remove(el)
+		delete(c.blocks, el.Value.(*cachedBlock).offset)
+	}
+	return b, nil
+}
+
+// Reads and decodes fileblock of entry e.
+func (c *blockCache) load(e IndexEntry) (*cachedBlock, error) {
+	data := make([]byte, e.Size)
+	if _, err := c.r.ReadAt(data, e.Offset); err != nil && err != io.EOF {
+		return nil, err
+	}
+	blobHeader, blob, err := NewDecoder(bytes.NewReader(data)).readFileBlock()
+	if err == io.EOF {
+		return nil, io.ErrUnexpectedEOF
+	} else if err != nil {
+		return nil, err
+	}
+	if blobHeader.GetType() != "OSMData" {
+		return nil, &UnexpectedBlockTypeError{blobHeader.GetType(), "OSMData"}
+	}
+
+	objects, err := DecodeBlob(blob)
+	if err != nil {
+		return nil, err
+	}
+	b := &cachedBlock{offset: e.Offset, objects: make(map[ElementID]Object, len(objects))}
+	for _, o := range objects {
+		// the last version of object is kept in history files
+		k, id := kindID(o)
+		b.objects[NewElementID(k, id)] = o
+	}
+	return b, nil
+}
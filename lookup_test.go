@@ -0,0 +1,48 @@
+package osmpbf
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestIndexGet(t *testing.T) {
+	data := encodeNodesWays(t, maxBlockEntities*2+1, 10).Bytes()
+	idx, err := BuildIndex(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := idx.GetNode(1); err == nil {
+		t.Error("expected error without reader")
+	}
+	idx.SetReaderAt(bytes.NewReader(data), 1)
+
+	n, err := idx.GetNode(maxBlockEntities + 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n == nil || n.ID != maxBlockEntities+5 || n.Lat != float64(maxBlockEntities+5)/1e3 {
+		t.Errorf("unexpected node %#v", n)
+	}
+	w, err := idx.GetWay(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w == nil || len(w.NodeIDs) != 2 || w.NodeIDs[1] != 4 {
+		t.Errorf("unexpected way %#v", w)
+	}
+	if len(idx.cache.blocks) != 1 {
+		t.Errorf("expected one cached block, got %d", len(idx.cache.blocks))
+	}
+
+	if n, err := idx.GetNode(maxBlockEntities*2 + 2); n != nil || err != nil {
+		t.Errorf("expected missing node, got %v, %v", n, err)
+	}
+	if r, err := idx.GetRelation(1); r != nil || err != nil {
+		t.Errorf("expected missing relation, got %v, %v", r, err)
+	}
+
+	idx.SetReaderAt(bytes.NewReader(data[:len(data)-10]), 0)
+	if _, err := idx.GetWay(3); err == nil {
+		t.Error("expected error of truncated input")
+	}
+}
@@ -0,0 +1,132 @@
+package osmpbf
+
+import (
+	"errors"
+	"io"
+	"os"
+
+	"github.com/brechtbm/osmpbf/OSMPBF"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+// OpenMmapDecoder returns a new decoder that reads file at path mapped into memory. Decoder works
+// in ReaderAt mode, see NewReaderAtDecoder, but Blobs are not copied into buffers: compressed data
+// is inflated directly from mapped memory, so reading is left to the OS page cache.
+// Close must be called to unmap the file.
+func OpenMmapDecoder(path string, opts ...Option) (*Decoder, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	r := new(mmapReaderAt)
+	if fi.Size() > 0 {
+		if r.data, err = mmap(f, fi.Size()); err != nil {
+			return nil, err
+		}
+	}
+
+	dec := NewReaderAtDecoder(r, fi.Size(), opts...)
+	dec.closer = r
+	return dec, nil
+}
+
+// ReaderAt over file mapped into memory.
+type mmapReaderAt struct {
+	data []byte
+}
+
+func (r *mmapReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.New("negative offset")
+	}
+	if off >= int64(len(r.data)) {
+		return 0, io.EOF
+	}
+	n := copy(p, r.data[off:])
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// Returns n bytes at offset off without copying.
+func (r *mmapReaderAt) slice(off int64, n int) ([]byte, error) {
+	if off < 0 || n < 0 || off+int64(n) > int64(len(r.data)) {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return r.data[off : off+int64(n) : off+int64(n)], nil
+}
+
+// Close unmaps the file, mapped memory must not be referenced after it.
+func (r *mmapReaderAt) Close() error {
+	if r.data == nil {
+		return nil
+	}
+	data := r.data
+	r.data = nil
+	return munmap(data)
+}
+
+var errBlobFormat = errors.New("invalid Blob")
+
+// Unmarshals Blob without copying: byte fields of returned Blob reference data.
+func unmarshalBlobInPlace(data []byte) (*OSMPBF.Blob, error) {
+	blob := new(OSMPBF.Blob)
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return nil, errBlobFormat
+		}
+		data = data[n:]
+
+		switch typ {
+		case protowire.VarintType:
+			v, n := protowire.ConsumeVarint(data)
+			if n < 0 {
+				return nil, errBlobFormat
+			}
+			data = data[n:]
+			if num == 2 {
+				blob.RawSize = proto.Int32(int32(v))
+			}
+
+		case protowire.BytesType:
+			field, n := protowire.ConsumeBytes(data)
+			if n < 0 {
+				return nil, errBlobFormat
+			}
+			field = field[:len(field):len(field)]
+			data = data[n:]
+
+			switch num {
+			case 1:
+				blob.Raw = field
+			case 3:
+				blob.ZlibData = field
+			case 4:
+				blob.LzmaData = field
+			case 5:
+				blob.OBSOLETEBzip2Data = field
+			case 6:
+				blob.Lz4Data = field
+			case 7:
+				blob.ZstdData = field
+			}
+
+		default:
+			n := protowire.ConsumeFieldValue(num, typ, data)
+			if n < 0 {
+				return nil, errBlobFormat
+			}
+			data = data[n:]
+		}
+	}
+	return blob, nil
+}
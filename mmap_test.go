@@ -0,0 +1,95 @@
+package osmpbf
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+
+	"github.com/brechtbm/osmpbf/OSMPBF"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestMmapDecoder(t *testing.T) {
+	if runtime.GOOS == "windows" || runtime.GOOS == "plan9" || runtime.GOOS == "js" {
+		t.Skip("memory mapping is not supported")
+	}
+
+	for _, c := range []Compression{Zlib, Zstd, Uncompressed} {
+		var buf bytes.Buffer
+		e := NewEncoder(&buf)
+		e.SetCompression(c)
+		for _, o := range []Object{en, ew, er} {
+			if err := e.Encode(o); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := e.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		path := filepath.Join(t.TempDir(), "test.osm.pbf")
+		if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+		d, err := OpenMmapDecoder(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		objects := decodeAll(t, d)
+		if err := d.Close(); err != nil {
+			t.Error(err)
+		}
+		if err := d.Close(); err != nil {
+			t.Error(err)
+		}
+
+		if expected := []interface{}{en, ew, er}; !reflect.DeepEqual(expected, objects) {
+			t.Errorf("compression %d:\nExpected: %#v\nActual:   %#v", c, expected, objects)
+		}
+	}
+
+	if _, err := OpenMmapDecoder(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestUnmarshalBlobInPlace(t *testing.T) {
+	for _, expected := range []*OSMPBF.Blob{
+		{Raw: []byte("raw")},
+		{RawSize: proto.Int32(1000), ZlibData: []byte("zlib")},
+		{RawSize: proto.Int32(-1), Lz4Data: []byte("lz4"), ZstdData: []byte("zstd"), LzmaData: []byte("lzma")},
+	} {
+		data, err := proto.Marshal(expected)
+		if err != nil {
+			t.Fatal(err)
+		}
+		blob, err := unmarshalBlobInPlace(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !proto.Equal(expected, blob) {
+			t.Errorf("\nExpected: %#v\nActual:   %#v", expected, blob)
+		}
+		if _, err := unmarshalBlobInPlace(data[:len(data)-1]); err == nil {
+			t.Error("expected error for truncated Blob")
+		}
+	}
+}
+
+func TestMmapSlice(t *testing.T) {
+	r := &mmapReaderAt{data: []byte("data")}
+	if b, err := r.slice(1, 2); err != nil || string(b) != "at" {
+		t.Errorf("expected \"at\", got %q, %v", b, err)
+	}
+	for _, c := range []struct {
+		off int64
+		n   int
+	}{{-1, 1}, {1, -1}, {2, 3}} {
+		if _, err := r.slice(c.off, c.n); err == nil {
+			t.Errorf("expected error for offset %d and size %d", c.off, c.n)
+		}
+	}
+}
@@ -0,0 +1,269 @@
+package osmpbf
+
+import (
+	"context"
+	"io"
+	"os"
+	"sync"
+)
+
+// MergePolicy defines order of objects returned by MultiDecoder.
+type MergePolicy int
+
+const (
+	// Concatenate returns all objects of the first input, then all objects of the second one and so on.
+	Concatenate MergePolicy = iota
+
+	// MergeSorted merges inputs sorted by type, then by ID (Sort.Type_then_ID) into one sorted
+	// stream. Objects with the same type and ID are returned in order of inputs, duplicates are
+	// not removed.
+	MergeSorted
+
+	// MergeUnique merges sorted inputs like MergeSorted, but returns only one object of each type
+	// and ID: the one with the highest Info.Version, or of the first input if versions are equal.
+	MergeUnique
+)
+
+// A MultiDecoder decodes several input streams, for example extracts of neighbouring regions,
+// and returns their objects as a single stream. Inputs are decoded concurrently, each by its
+// own Decoder.
+type MultiDecoder struct {
+	decoders []*Decoder
+	policy   MergePolicy
+	files    []*os.File
+
+	mu      sync.Mutex
+	stopped bool // after the first error
+
+	// Concatenate: index of decoder returning objects
+	current int
+
+	// MergeSorted and MergeUnique: next object of each decoder, nil if decoder reached the end of input stream
+	heads     []Object
+	headsRead bool
+}
+
+// NewMultiDecoder returns a new decoder that reads from readers, combining them by policy.
+// Options are applied to decoder of each input.
+func NewMultiDecoder(readers []io.Reader, policy MergePolicy, opts ...Option) *MultiDecoder {
+	md := &MultiDecoder{policy: policy}
+	for _, r := range readers {
+		md.decoders = append(md.decoders, NewDecoder(r, opts...))
+	}
+	return md
+}
+
+// OpenMultiDecoder opens files with given paths and returns a new decoder reading from them,
+// see NewMultiDecoder. Files are read in ReaderAt mode, see NewReaderAtDecoder. Files are closed
+// by Close.
+func OpenMultiDecoder(paths []string, policy MergePolicy, opts ...Option) (*MultiDecoder, error) {
+	md := &MultiDecoder{policy: policy}
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			md.Close()
+			return nil, err
+		}
+		md.files = append(md.files, f)
+
+		fi, err := f.Stat()
+		if err != nil {
+			md.Close()
+			return nil, err
+		}
+		md.decoders = append(md.decoders, NewReaderAtDecoder(f, fi.Size(), opts...))
+	}
+	return md, nil
+}
+
+// Decoders returns decoders of inputs, in order of inputs. They can be used to get headers
+// and progress of each input, but objects must be read by MultiDecoder.Decode.
+func (md *MultiDecoder) Decoders() []*Decoder {
+	return md.decoders
+}
+
+// Start decoding process of all inputs, each with n goroutines. See Decoder.Start for meaning of n.
+func (md *MultiDecoder) Start(n int) error {
+	return md.StartWithContext(context.Background(), n)
+}
+
+// StartWithContext starts decoding process of all inputs, see Start. Decoding stops when ctx
+// is cancelled: Decode returns ctx.Err().
+func (md *MultiDecoder) StartWithContext(ctx context.Context, n int) error {
+	for _, d := range md.decoders {
+		if err := d.StartWithContext(ctx, n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Decode reads the next object from inputs and returns either a pointer to Node, Way or Relation
+// struct, or error encountered. The end of all input streams is reported by an io.EOF error.
+// Only the first error encountered in any input will be returned, subsequent invocations will
+// return io.EOF. Decode is safe for parallel execution, though objects are returned one by one.
+func (md *MultiDecoder) Decode() (interface{}, error) {
+	md.mu.Lock()
+	defer md.mu.Unlock()
+
+	if md.stopped {
+		return nil, io.EOF
+	}
+
+	var v interface{}
+	var err error
+	if md.policy == MergeSorted || md.policy == MergeUnique {
+		v, err = md.decodeSorted()
+	} else {
+		v, err = md.decodeNext()
+	}
+	md.stopped = err != nil
+	return v, err
+}
+
+func (md *MultiDecoder) decodeNext() (interface{}, error) {
+	for md.current < len(md.decoders) {
+		v, err := md.decoders[md.current].Decode()
+		if err != io.EOF {
+			return v, err
+		}
+		md.current++
+	}
+	return nil, io.EOF
+}
+
+func (md *MultiDecoder) decodeSorted() (interface{}, error) {
+	if !md.headsRead {
+		md.heads = make([]Object, len(md.decoders))
+		for i := range md.decoders {
+			if err := md.next(i); err != nil {
+				return nil, err
+			}
+		}
+		md.headsRead = true
+	}
+
+	// few inputs are expected, so linear search is fast enough
+	first := -1
+	for i, o := range md.heads {
+		if o != nil && (first < 0 || precedes(o, md.heads[first])) {
+			first = i
+		}
+	}
+	if first < 0 {
+		return nil, io.EOF
+	}
+
+	o := md.heads[first]
+	if err := md.next(first); err != nil {
+		return nil, err
+	}
+	if md.policy == MergeUnique {
+		return md.dropDuplicates(o)
+	}
+	return o, nil
+}
+
+// Returns the newest version of object o and its duplicates at heads, which are skipped.
+func (md *MultiDecoder) dropDuplicates(o Object) (Object, error) {
+	kind, id := kindID(o)
+	if kind == BoundKind {
+		return o, nil
+	}
+	for i, head := range md.heads {
+		for head != nil {
+			if k, headID := kindID(head); k != kind || headID != id {
+				break
+			}
+			if objectInfo(head).Version > objectInfo(o).Version {
+				o, head = head, o
+			}
+			head.Release()
+			if err := md.next(i); err != nil {
+				return nil, err
+			}
+			head = md.heads[i]
+		}
+	}
+	return o, nil
+}
+
+// Reads the next object of i-th decoder into heads.
+func (md *MultiDecoder) next(i int) error {
+	v, err := md.decoders[i].Decode()
+	if err == io.EOF {
+		md.heads[i] = nil
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	md.heads[i] = v.(Object)
+	return nil
+}
+
+// Returns true if a precedes b in type, then ID order. Bounds precede objects.
+func precedes(a, b Object) bool {
+	aKind, aID := kindID(a)
+	bKind, bID := kindID(b)
+	if aKind == BoundKind || bKind == BoundKind {
+		return aKind == BoundKind && bKind != BoundKind
+	}
+	return aKind < bKind || aKind == bKind && aID < bID
+}
+
+// Merge reads PBF data sorted by type, then by ID from readers and writes it to w merged into one
+// sorted stream, like osmium merge. Objects present in several inputs are written once, in their
+// newest version, see MergeUnique. OSMHeader of the first input is written, with bounding box
+// containing bounding boxes of all inputs, or without it if some input has none.
+func Merge(w io.Writer, readers ...io.Reader) error {
+	md := NewMultiDecoder(readers, MergeUnique)
+	defer md.Close()
+	if err := md.Start(0); err != nil {
+		return err
+	}
+
+	var h Header
+	for i, d := range md.decoders {
+		dh := d.Header()
+		if i == 0 {
+			h = *dh
+		} else if h.BBox != nil && dh.BBox != nil {
+			bbox := unionBBox(*h.BBox, *dh.BBox)
+			h.BBox = &bbox
+		} else {
+			h.BBox = nil
+		}
+	}
+
+	enc := NewEncoder(w)
+	enc.SetHeader(&h)
+	for {
+		v, err := md.Decode()
+		if err == io.EOF {
+			return enc.Close()
+		} else if err != nil {
+			return err
+		}
+		if err := enc.Encode(v); err != nil {
+			return err
+		}
+	}
+}
+
+// Close stops decoding of all inputs and releases resources, see Decoder.Close. Files opened
+// by OpenMultiDecoder are closed.
+func (md *MultiDecoder) Close() error {
+	for _, d := range md.decoders {
+		d.Close()
+	}
+
+	var err error
+	for _, f := range md.files {
+		if e := f.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	md.files = nil
+	return err
+}
@@ -0,0 +1,132 @@
+package osmpbf
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestMultiDecoder(t *testing.T) {
+	inputs := [][]Object{
+		{&Node{ID: 1}, &Node{ID: 3}, &Way{ID: 2}},
+		{&Node{ID: 2}, &Way{ID: 1}, &Relation{ID: 1}},
+	}
+	var data [][]byte
+	for _, objects := range inputs {
+		data = append(data, encodeObjects(t, objects...).Bytes())
+	}
+
+	readers := func() []io.Reader {
+		var rs []io.Reader
+		for _, b := range data {
+			rs = append(rs, bytes.NewReader(b))
+		}
+		return rs
+	}
+	decode := func(md *MultiDecoder) []string {
+		defer md.Close()
+		if err := md.Start(2); err != nil {
+			t.Fatal(err)
+		}
+		var ids []string
+		for {
+			v, err := md.Decode()
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			kind, id := kindID(v.(Object))
+			ids = append(ids, kind.String()+string(rune('0'+id)))
+		}
+		return ids
+	}
+
+	if ids, expected := decode(NewMultiDecoder(readers(), Concatenate)), []string{
+		"node1", "node3", "way2", "node2", "way1", "relation1",
+	}; !reflect.DeepEqual(expected, ids) {
+		t.Errorf("\nExpected: %v\nActual:   %v", expected, ids)
+	}
+
+	expected := []string{"node1", "node2", "node3", "way1", "way2", "relation1"}
+	if ids := decode(NewMultiDecoder(readers(), MergeSorted)); !reflect.DeepEqual(expected, ids) {
+		t.Errorf("\nExpected: %v\nActual:   %v", expected, ids)
+	}
+
+	dir := t.TempDir()
+	var paths []string
+	for i, b := range data {
+		path := filepath.Join(dir, string(rune('a'+i))+".osm.pbf")
+		if err := os.WriteFile(path, b, 0644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+	md, err := OpenMultiDecoder(paths, MergeSorted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := decode(md); !reflect.DeepEqual(expected, ids) {
+		t.Errorf("\nExpected: %v\nActual:   %v", expected, ids)
+	}
+
+	if _, err := OpenMultiDecoder(append(paths, filepath.Join(dir, "missing")), Concatenate); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestMultiDecoderError(t *testing.T) {
+	data := encodeObjects(t, en, ew).Bytes()
+	corrupt := append([]byte(nil), data...)
+	for i := len(corrupt) - 8; i < len(corrupt); i++ {
+		corrupt[i] ^= 0xff
+	}
+	md := NewMultiDecoder([]io.Reader{bytes.NewReader(data), bytes.NewReader(corrupt)}, MergeSorted)
+	defer md.Close()
+	if err := md.Start(1); err != nil {
+		t.Fatal(err)
+	}
+	var err error
+	for err == nil {
+		_, err = md.Decode()
+	}
+	if err == io.EOF {
+		t.Error("expected decoding error")
+	}
+	if _, err := md.Decode(); err != io.EOF {
+		t.Errorf("expected io.EOF after error, got %v", err)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	first := encodeObjects(t,
+		&Bound{BBox: BBox{Left: 0, Right: 1, Bottom: 0, Top: 1}},
+		&Node{ID: 1, Info: Info{Version: 1}}, &Node{ID: 2, Info: Info{Version: 3}}, &Way{ID: 1, Info: Info{Version: 1}},
+	)
+	second := encodeObjects(t,
+		&Bound{BBox: BBox{Left: 1, Right: 2, Bottom: -1, Top: 0}},
+		&Node{ID: 1, Info: Info{Version: 2}}, &Node{ID: 2, Info: Info{Version: 1}}, &Node{ID: 3}, &Relation{ID: 1},
+	)
+
+	var buf bytes.Buffer
+	if err := Merge(&buf, first, second); err != nil {
+		t.Fatal(err)
+	}
+	d := NewDecoder(&buf)
+	objects := decodeAll(t, d)
+	if ids, expected := objectIDs(objects), []string{"n1", "n2", "n3", "w1", "r1"}; !reflect.DeepEqual(expected, ids) {
+		t.Errorf("\nExpected: %v\nActual:   %v", expected, ids)
+	}
+	if v := objects[0].(*Node).Info.Version; v != 2 {
+		t.Errorf("expected newer version of node 1, got %d", v)
+	}
+	if v := objects[1].(*Node).Info.Version; v != 3 {
+		t.Errorf("expected newer version of node 2, got %d", v)
+	}
+	if bbox := d.Header().BBox; bbox == nil || *bbox != (BBox{Left: 0, Right: 2, Bottom: -1, Top: 1}) {
+		t.Errorf("unexpected bounding box %v", bbox)
+	}
+}
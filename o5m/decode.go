@@ -0,0 +1,408 @@
+package o5m
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/brechtbm/osmpbf"
+)
+
+var errDataset = errors.New("invalid o5m dataset")
+
+// A Decoder reads and decodes o5m data from an input stream. Change files (o5c) are decoded too,
+// deleted objects have Info.Visible set to false.
+type Decoder struct {
+	r      *bufio.Reader
+	header *osmpbf.Header
+
+	mu      sync.Mutex
+	started bool
+	err     error // returned by all subsequent Decode calls
+
+	// dataset read by Start after header datasets
+	pendingType byte
+	pending     []byte
+
+	buf   []byte
+	state state
+}
+
+// Delta coding state, cleared by reset dataset.
+type state struct {
+	ids       [3]int64 // by dataset type
+	refs      [3]int64 // node refs of ways and member refs of relations, by member type
+	timestamp int64
+	changeset int64
+	lat, lon  int64
+	strings   stringTable
+}
+
+func (s *state) reset() {
+	s.ids, s.refs = [3]int64{}, [3]int64{}
+	s.timestamp, s.changeset, s.lat, s.lon = 0, 0, 0, 0
+	s.strings.reset()
+}
+
+// NewDecoder returns a new decoder that reads from r.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: bufio.NewReader(r)}
+}
+
+// Start reads header datasets: file format, bounding box and file timestamp, which is returned
+// as Header.ReplicationTimestamp. Decoding is done by Decode in the calling goroutine, n is
+// ignored; it is accepted for compatibility with osmpbf.Decoder.
+func (dec *Decoder) Start(n int) error {
+	dec.mu.Lock()
+	defer dec.mu.Unlock()
+
+	if dec.started {
+		return errors.New("decoder is started")
+	}
+	dec.started = true
+
+	dec.header = &osmpbf.Header{RequiredFeatures: []string{"OsmSchema-V0.6"}}
+	for {
+		typ, data, err := dec.readDataset()
+		if err == io.EOF {
+			return io.ErrUnexpectedEOF
+		} else if err != nil {
+			return err
+		}
+
+		switch typ {
+		case resetDataset:
+			dec.state.reset()
+
+		case headerDataset:
+			if format := string(data); format != fileFormat && format != changeFormat {
+				return fmt.Errorf("unsupported format %q", format)
+			}
+
+		case bboxDataset:
+			var c [4]int64
+			for i := range c {
+				v, n := binary.Varint(data)
+				if n <= 0 {
+					return errDataset
+				}
+				c[i], data = v, data[n:]
+			}
+			dec.header.BBox = &osmpbf.BBox{
+				Left:   float64(c[0]) / coordinateScale,
+				Bottom: float64(c[1]) / coordinateScale,
+				Right:  float64(c[2]) / coordinateScale,
+				Top:    float64(c[3]) / coordinateScale,
+			}
+
+		case timestampDataset:
+			v, n := binary.Varint(data)
+			if n <= 0 {
+				return errDataset
+			}
+			dec.header.ReplicationTimestamp = time.Unix(v, 0).UTC()
+
+		default:
+			// objects and end of file are returned by Decode
+			dec.pendingType, dec.pending = typ, data
+			return nil
+		}
+	}
+}
+
+// Header returns metadata from header datasets, available after Start.
+func (dec *Decoder) Header() *osmpbf.Header {
+	return dec.header
+}
+
+// Decode reads the next object from the input stream and returns a pointer to Node, Way or
+// Relation struct, or error encountered. The end of the input stream is reported by an io.EOF
+// error. Decode is safe for parallel execution. Only first error encountered will be returned,
+// subsequent invocations will return io.EOF.
+func (dec *Decoder) Decode() (interface{}, error) {
+	dec.mu.Lock()
+	defer dec.mu.Unlock()
+
+	if dec.err != nil {
+		return nil, io.EOF
+	}
+	if !dec.started {
+		dec.err = errors.New("decoder is not started")
+		return nil, dec.err
+	}
+
+	v, err := dec.decode()
+	if err != nil {
+		dec.err = err
+		return nil, err
+	}
+	return v, nil
+}
+
+func (dec *Decoder) decode() (interface{}, error) {
+	for {
+		typ, data := dec.pendingType, dec.pending
+		if data == nil && typ == 0 {
+			var err error
+			if typ, data, err = dec.readDataset(); err != nil {
+				return nil, err
+			}
+		}
+		dec.pendingType, dec.pending = 0, nil
+
+		switch typ {
+		case resetDataset:
+			dec.state.reset()
+		case endDataset:
+			return nil, io.EOF
+		case nodeDataset, wayDataset, relationDataset:
+			p := parser{data: data, state: &dec.state}
+			v := p.object(typ)
+			if p.err != nil {
+				return nil, p.err
+			}
+			return v, nil
+		}
+		// other datasets, like sync and jump, are skipped
+	}
+}
+
+// Reads the next dataset, data is valid until the next call.
+func (dec *Decoder) readDataset() (byte, []byte, error) {
+	typ, err := dec.r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	if typ >= 0xf0 {
+		// single byte datasets
+		return typ, nil, nil
+	}
+
+	size, err := binary.ReadUvarint(dec.r)
+	if err != nil {
+		return 0, nil, noEOF(err)
+	}
+	if size > 1<<30 {
+		return 0, nil, fmt.Errorf("dataset size %d is too large", size)
+	}
+	if uint64(cap(dec.buf)) < size {
+		dec.buf = make([]byte, size)
+	}
+	dec.buf = dec.buf[:size]
+	if _, err := io.ReadFull(dec.r, dec.buf); err != nil {
+		return 0, nil, noEOF(err)
+	}
+	return typ, dec.buf, nil
+}
+
+// Returns io.ErrUnexpectedEOF instead of io.EOF, which is returned only between datasets.
+func noEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// Close stops decoding, subsequent Decode calls return io.EOF. It does not close the input stream.
+func (dec *Decoder) Close() error {
+	dec.mu.Lock()
+	defer dec.mu.Unlock()
+	if dec.err == nil {
+		dec.err = io.EOF
+	}
+	return nil
+}
+
+// Parser of object dataset, the first error is kept in err.
+type parser struct {
+	data  []byte
+	state *state
+	err   error
+}
+
+func (p *parser) uvarint() uint64 {
+	v, n := binary.Uvarint(p.data)
+	if n <= 0 {
+		p.fail()
+		return 0
+	}
+	p.data = p.data[n:]
+	return v
+}
+
+func (p *parser) varint() int64 {
+	v, n := binary.Varint(p.data)
+	if n <= 0 {
+		p.fail()
+		return 0
+	}
+	p.data = p.data[n:]
+	return v
+}
+
+func (p *parser) fail() {
+	if p.err == nil {
+		p.err = errDataset
+	}
+	p.data = nil
+}
+
+// Reads zero terminated string.
+func (p *parser) cstring() string {
+	i := 0
+	for i < len(p.data) && p.data[i] != 0 {
+		i++
+	}
+	if i == len(p.data) {
+		p.fail()
+		return ""
+	}
+	s := string(p.data[:i])
+	p.data = p.data[i+1:]
+	return s
+}
+
+// Reads string, or string pair if pair is set, stored inline or referenced in string table.
+func (p *parser) string(pair bool) string {
+	if len(p.data) > 0 && p.data[0] == 0 {
+		p.data = p.data[1:]
+		s := p.cstring()
+		if pair {
+			s += "\x00" + p.cstring()
+		}
+		if p.err == nil {
+			p.state.strings.add(s)
+		}
+		return s
+	}
+
+	s, ok := p.state.strings.get(p.uvarint())
+	if !ok {
+		p.fail()
+	}
+	return s
+}
+
+func (p *parser) pair() (string, string) {
+	s := p.string(true)
+	i := strings.IndexByte(s, 0)
+	if i < 0 {
+		p.fail()
+		return "", ""
+	}
+	return s[:i], s[i+1:]
+}
+
+// Reads version and author information.
+func (p *parser) info() osmpbf.Info {
+	info := osmpbf.Info{Visible: true}
+	info.Version = int16(p.uvarint())
+	if info.Version == 0 {
+		return info
+	}
+
+	p.state.timestamp += p.varint()
+	if p.state.timestamp == 0 {
+		return info
+	}
+	info.Timestamp = time.Unix(p.state.timestamp, 0).UTC()
+	p.state.changeset += p.varint()
+	info.Changeset = uint64(p.state.changeset)
+
+	uid, user := p.pair()
+	if uid != "" {
+		v, n := binary.Uvarint([]byte(uid))
+		if n != len(uid) {
+			p.fail()
+		}
+		info.Uid = int32(v)
+	}
+	info.User = user
+	return info
+}
+
+// Reads tags until the end of dataset.
+func (p *parser) tags() map[string]string {
+	tags := make(map[string]string)
+	for len(p.data) > 0 {
+		k, v := p.pair()
+		tags[k] = v
+	}
+	return tags
+}
+
+func (p *parser) object(typ byte) interface{} {
+	kind := typ - nodeDataset
+	p.state.ids[kind] += p.varint()
+	id := p.state.ids[kind]
+	info := p.info()
+	// dataset of deleted object ends after author information
+	info.Visible = info.Visible && len(p.data) > 0
+
+	switch typ {
+	case nodeDataset:
+		n := &osmpbf.Node{ID: id, Info: info}
+		if info.Visible {
+			p.state.lon += p.varint()
+			p.state.lat += p.varint()
+			n.Lon = float64(p.state.lon) / coordinateScale
+			n.Lat = float64(p.state.lat) / coordinateScale
+			n.Tags = p.tags()
+		}
+		return n
+
+	case wayDataset:
+		w := &osmpbf.Way{ID: id, Info: info}
+		if info.Visible {
+			size := p.uvarint()
+			if size > uint64(len(p.data)) {
+				p.fail()
+				return nil
+			}
+			refs := parser{data: p.data[:size], state: p.state}
+			p.data = p.data[size:]
+			for len(refs.data) > 0 {
+				p.state.refs[osmpbf.NodeType] += refs.varint()
+				w.NodeIDs = append(w.NodeIDs, p.state.refs[osmpbf.NodeType])
+			}
+			if refs.err != nil {
+				p.fail()
+			}
+			w.Tags = p.tags()
+		}
+		return w
+
+	default:
+		r := &osmpbf.Relation{ID: id, Info: info}
+		if info.Visible {
+			size := p.uvarint()
+			if size > uint64(len(p.data)) {
+				p.fail()
+				return nil
+			}
+			refs := parser{data: p.data[:size], state: p.state}
+			p.data = p.data[size:]
+			for len(refs.data) > 0 {
+				delta := refs.varint()
+				s := refs.string(false)
+				if s == "" || s[0] < '0' || s[0] > '2' {
+					refs.fail()
+					break
+				}
+				t := osmpbf.MemberType(s[0] - '0')
+				p.state.refs[t] += delta
+				r.Members = append(r.Members, osmpbf.Member{ID: p.state.refs[t], Type: t, Role: s[1:]})
+			}
+			if refs.err != nil {
+				p.fail()
+			}
+			r.Tags = p.tags()
+		}
+		return r
+	}
+}
@@ -0,0 +1,101 @@
+package o5m
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/brechtbm/osmpbf"
+)
+
+// decoder is implemented by osmpbf.Decoder and Decoder.
+type decoder interface {
+	Start(n int) error
+	Decode() (interface{}, error)
+	Close() error
+}
+
+func decodeAll(t *testing.T, d decoder) []interface{} {
+	if err := d.Start(2); err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+
+	var objects []interface{}
+	for {
+		v, err := d.Decode()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		objects = append(objects, v)
+	}
+	return objects
+}
+
+func TestDecode(t *testing.T) {
+	data := []byte{
+		0xff,
+		0xe0, 0x04, 'o', '5', 'm', '2',
+		0x10, 0x39,
+		0xce, 0xad, 0x0f, // id 125799
+		0x05,                         // version
+		0xe4, 0x8e, 0xa7, 0xca, 0x09, // timestamp 1285874610
+		0x94, 0xfe, 0xd2, 0x05, // changeset 5922698
+		0x00, 0x85, 0xe3, 0x02, 0x00, 'U', 'S', 'c', 'h', 0x00, // uid 45445, user
+		0xb0, 0xc2, 0xb6, 0x54, // lon 8.8527
+		0x80, 0xa9, 0xc0, 0xe8, 0x03, // lat 51.2232
+		0x00, 'h', 'i', 'g', 'h', 'w', 'a', 'y', 0x00,
+		't', 'r', 'a', 'f', 'f', 'i', 'c', '_', 's', 'i', 'g', 'n', 'a', 'l', 's', 0x00,
+		0x10, 0x05,
+		0x02,       // id delta 1
+		0x00,       // no version
+		0x00, 0x00, // the same location
+		0x01, // the most recent string pair
+		0xfe,
+	}
+	expected := []interface{}{
+		&osmpbf.Node{
+			ID: 125799, Lat: 51.2232, Lon: 8.8527,
+			Tags: map[string]string{"highway": "traffic_signals"},
+			Info: osmpbf.Info{
+				Version: 5, Timestamp: time.Unix(1285874610, 0).UTC(), Changeset: 5922698,
+				Uid: 45445, User: "USch", Visible: true,
+			},
+		},
+		&osmpbf.Node{
+			ID: 125800, Lat: 51.2232, Lon: 8.8527,
+			Tags: map[string]string{"highway": "traffic_signals"},
+			Info: osmpbf.Info{Visible: true},
+		},
+	}
+	if decoded := decodeAll(t, NewDecoder(bytes.NewReader(data))); !reflect.DeepEqual(expected, decoded) {
+		t.Errorf("\nExpected: %#v\nActual:   %#v", expected, decoded)
+	}
+}
+
+func TestDecodeError(t *testing.T) {
+	for _, c := range []struct {
+		name     string
+		data     []byte
+		expected string
+	}{
+		{"format", []byte{0xff, 0xe0, 0x04, 'o', '5', 'm', '1'}, "unsupported format"},
+		{"truncated", []byte{0xff, 0xe0, 0x04, 'o', '5', 'm', '2', 0x10, 0x05, 0x02}, "unexpected EOF"},
+		{"string reference", []byte{0xff, 0x10, 0x05, 0x02, 0x00, 0x00, 0x00, 0x01}, "invalid o5m dataset"},
+		{"member type", []byte{0xff, 0x12, 0x07, 0x02, 0x00, 0x04, 0x02, 0x00, '5', 0x00}, "invalid o5m dataset"},
+	} {
+		d := NewDecoder(bytes.NewReader(c.data))
+		err := d.Start(1)
+		if err == nil {
+			_, err = d.Decode()
+		}
+		if err == nil || !strings.Contains(err.Error(), c.expected) {
+			t.Errorf("%s: expected error containing %q, got %v", c.name, c.expected, err)
+		}
+	}
+}
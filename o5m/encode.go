@@ -0,0 +1,266 @@
+package o5m
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+
+	"github.com/brechtbm/osmpbf"
+)
+
+// An Encoder writes o5m data to an output stream.
+type Encoder struct {
+	w *bufio.Writer
+
+	header        osmpbf.Header
+	headerWritten bool
+
+	// type of the last written object dataset, 0 before the first one
+	lastType byte
+
+	state state
+	buf   []byte // dataset being encoded
+	refs  []byte // references section of dataset being encoded
+}
+
+// NewEncoder returns a new encoder that writes to w.
+func NewEncoder(w io.Writer) *Encoder {
+	enc := &Encoder{w: bufio.NewWriter(w)}
+	enc.state.strings.index = make(map[string]int)
+	return enc
+}
+
+// SetHeader sets metadata written to header datasets: bounding box and, if set,
+// ReplicationTimestamp as file timestamp. Must be called before Encode.
+func (enc *Encoder) SetHeader(h *osmpbf.Header) {
+	enc.header = *h
+}
+
+// Encode writes a pointer to Node, Way or Relation struct to the output stream, in the same way
+// as osmpbf.Encoder: Bound sets bounding box, so it must be passed before objects, and objects
+// should be passed in the usual OSM order: nodes, then ways, then relations. Objects with
+// Info.Visible set to false are written as deleted if they have version or header has
+// HistoricalInformation feature, so objects with zero Info are not deleted. Output is buffered,
+// so Close must be called to write remaining data.
+func (enc *Encoder) Encode(v interface{}) error {
+	if b, ok := v.(*osmpbf.Bound); ok {
+		if enc.headerWritten {
+			return errors.New("bound after objects")
+		}
+		bbox := b.BBox
+		enc.header.BBox = &bbox
+		return nil
+	}
+
+	var typ byte
+	var id int64
+	var info osmpbf.Info
+	switch o := v.(type) {
+	case *osmpbf.Node:
+		typ, id, info = nodeDataset, o.ID, o.Info
+	case *osmpbf.Way:
+		typ, id, info = wayDataset, o.ID, o.Info
+	case *osmpbf.Relation:
+		typ, id, info = relationDataset, o.ID, o.Info
+		for _, m := range o.Members {
+			if m.Type < osmpbf.NodeType || m.Type > osmpbf.RelationType {
+				return fmt.Errorf("relation %d: unknown member type %d", o.ID, m.Type)
+			}
+		}
+	default:
+		return fmt.Errorf("unknown type %T", v)
+	}
+
+	if err := enc.writeHeader(); err != nil {
+		return err
+	}
+	if enc.lastType != 0 && enc.lastType != typ {
+		// each object type starts with reset, like in files written by osmconvert
+		if err := enc.w.WriteByte(resetDataset); err != nil {
+			return err
+		}
+		enc.state.reset()
+	}
+	enc.lastType = typ
+
+	kind := typ - nodeDataset
+	enc.buf = enc.buf[:0]
+	enc.buf = binary.AppendVarint(enc.buf, id-enc.state.ids[kind])
+	enc.state.ids[kind] = id
+	enc.appendInfo(info)
+
+	if !enc.deleted(&info) {
+		switch o := v.(type) {
+		case *osmpbf.Node:
+			lon, lat := coordinate(o.Lon), coordinate(o.Lat)
+			enc.buf = binary.AppendVarint(enc.buf, lon-enc.state.lon)
+			enc.buf = binary.AppendVarint(enc.buf, lat-enc.state.lat)
+			enc.state.lon, enc.state.lat = lon, lat
+			enc.appendTags(o.Tags, o.TagList)
+
+		case *osmpbf.Way:
+			enc.refs = enc.refs[:0]
+			for _, ref := range o.NodeIDs {
+				enc.refs = binary.AppendVarint(enc.refs, ref-enc.state.refs[osmpbf.NodeType])
+				enc.state.refs[osmpbf.NodeType] = ref
+			}
+			enc.buf = binary.AppendUvarint(enc.buf, uint64(len(enc.refs)))
+			enc.buf = append(enc.buf, enc.refs...)
+			enc.appendTags(o.Tags, o.TagList)
+
+		case *osmpbf.Relation:
+			// strings of members are in references section, so it is encoded in place
+			// after its size is known
+			start := len(enc.buf)
+			for _, m := range o.Members {
+				enc.buf = binary.AppendVarint(enc.buf, m.ID-enc.state.refs[m.Type])
+				enc.state.refs[m.Type] = m.ID
+				enc.appendString(string(rune('0'+m.Type))+m.Role, "", false)
+			}
+			enc.refs = append(enc.refs[:0], enc.buf[start:]...)
+			enc.buf = binary.AppendUvarint(enc.buf[:start], uint64(len(enc.refs)))
+			enc.buf = append(enc.buf, enc.refs...)
+			enc.appendTags(o.Tags, o.TagList)
+		}
+	}
+	return enc.writeDataset(typ, enc.buf)
+}
+
+// Returns true if object with info is written as deleted.
+func (enc *Encoder) deleted(info *osmpbf.Info) bool {
+	if info.Visible {
+		return false
+	}
+	if info.Version != 0 {
+		return true
+	}
+	for _, f := range enc.header.RequiredFeatures {
+		if f == "HistoricalInformation" {
+			return true
+		}
+	}
+	return false
+}
+
+// Close writes end of file dataset and flushes buffered data. It does not close the underlying
+// writer. If no objects were encoded, only header datasets are written.
+func (enc *Encoder) Close() error {
+	if err := enc.writeHeader(); err != nil {
+		return err
+	}
+	if err := enc.w.WriteByte(endDataset); err != nil {
+		return err
+	}
+	return enc.w.Flush()
+}
+
+// Writes header datasets if they are not written yet.
+func (enc *Encoder) writeHeader() error {
+	if enc.headerWritten {
+		return nil
+	}
+	enc.headerWritten = true
+
+	if err := enc.w.WriteByte(resetDataset); err != nil {
+		return err
+	}
+	if err := enc.writeDataset(headerDataset, []byte(fileFormat)); err != nil {
+		return err
+	}
+	if b := enc.header.BBox; b != nil {
+		var data []byte
+		for _, c := range []float64{b.Left, b.Bottom, b.Right, b.Top} {
+			data = binary.AppendVarint(data, coordinate(c))
+		}
+		if err := enc.writeDataset(bboxDataset, data); err != nil {
+			return err
+		}
+	}
+	if t := enc.header.ReplicationTimestamp; !t.IsZero() {
+		if err := enc.writeDataset(timestampDataset, binary.AppendVarint(nil, t.Unix())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (enc *Encoder) writeDataset(typ byte, data []byte) error {
+	var size [binary.MaxVarintLen64 + 1]byte
+	size[0] = typ
+	n := binary.PutUvarint(size[1:], uint64(len(data)))
+	if _, err := enc.w.Write(size[:n+1]); err != nil {
+		return err
+	}
+	_, err := enc.w.Write(data)
+	return err
+}
+
+// Appends version and author information; objects without version have none.
+func (enc *Encoder) appendInfo(info osmpbf.Info) {
+	enc.buf = binary.AppendUvarint(enc.buf, uint64(info.Version))
+	if info.Version == 0 {
+		return
+	}
+
+	var timestamp int64
+	if !info.Timestamp.IsZero() {
+		timestamp = info.Timestamp.Unix()
+	}
+	enc.buf = binary.AppendVarint(enc.buf, timestamp-enc.state.timestamp)
+	enc.state.timestamp = timestamp
+	if timestamp == 0 {
+		return
+	}
+
+	changeset := int64(info.Changeset)
+	enc.buf = binary.AppendVarint(enc.buf, changeset-enc.state.changeset)
+	enc.state.changeset = changeset
+
+	var uid string
+	if info.Uid != 0 {
+		uid = string(binary.AppendUvarint(nil, uint64(uint32(info.Uid))))
+	}
+	enc.appendString(uid, info.User, true)
+}
+
+// Appends tags sorted by key, unless object has TagList only.
+func (enc *Encoder) appendTags(tags map[string]string, list osmpbf.TagList) {
+	if len(tags) > 0 {
+		keys := make([]string, 0, len(tags))
+		for k := range tags {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			enc.appendString(k, tags[k], true)
+		}
+		return
+	}
+	for _, tag := range list {
+		enc.appendString(tag.Key, tag.Value, true)
+	}
+}
+
+// Appends string, or string pair of s and s2 if pair is set, as reference to string table if
+// it contains it.
+func (enc *Encoder) appendString(s, s2 string, pair bool) {
+	if pair {
+		s += "\x00" + s2
+	}
+	if ref, ok := enc.state.strings.ref(s); ok {
+		enc.buf = binary.AppendUvarint(enc.buf, ref)
+		return
+	}
+	enc.buf = append(enc.buf, 0)
+	enc.buf = append(enc.buf, s...)
+	enc.buf = append(enc.buf, 0)
+	enc.state.strings.add(s)
+}
+
+func coordinate(c float64) int64 {
+	return int64(math.Round(c * coordinateScale))
+}
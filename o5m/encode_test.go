@@ -0,0 +1,147 @@
+package o5m
+
+import (
+	"bytes"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/brechtbm/osmpbf"
+)
+
+var testObjects = []interface{}{
+	&osmpbf.Node{
+		ID: 18088578, Lat: 51.5442632, Lon: -0.2010027,
+		Tags: map[string]string{"amenity": "pub", "name": "The Luminaire"},
+		Info: osmpbf.Info{Version: 2, Timestamp: time.Unix(1242815334, 0).UTC(), Changeset: 1260468, Uid: 508, User: "Welshie", Visible: true},
+	},
+	&osmpbf.Node{
+		ID: 18088579, Lat: -51.5, Lon: 179.9999999,
+		Tags: map[string]string{"amenity": "pub"},
+		Info: osmpbf.Info{Version: 1, Timestamp: time.Unix(1242815334, 0).UTC(), Changeset: 1, User: "anonymous", Visible: true},
+	},
+	&osmpbf.Node{ID: 18088580, Info: osmpbf.Info{Version: 3, Timestamp: time.Unix(1242815335, 0).UTC(), Changeset: 2, Uid: 508, User: "Welshie"}},
+	&osmpbf.Way{
+		ID: 4257116, NodeIDs: []int64{18088578, 18088579, 18088578},
+		Tags: map[string]string{"highway": "pedestrian", "name": strings.Repeat("x", 300)},
+		Info: osmpbf.Info{Version: 7, Timestamp: time.Unix(1375877319, 0).UTC(), Changeset: 17253164, Uid: 1016290, User: "Amaroussi", Visible: true},
+	},
+	&osmpbf.Way{ID: 4257117, Tags: map[string]string{}, Info: osmpbf.Info{Visible: true}},
+	&osmpbf.Relation{
+		ID: 7677,
+		Members: []osmpbf.Member{
+			{ID: 4875932, Type: osmpbf.WayType, Role: "outer"},
+			{ID: 18088578, Type: osmpbf.NodeType},
+			{ID: 7677, Type: osmpbf.RelationType, Role: "subarea"},
+		},
+		Tags: map[string]string{"type": "multipolygon"},
+		Info: osmpbf.Info{Version: 4, Timestamp: time.Unix(1216479843, 0).UTC(), Changeset: 1, Uid: 1, User: "x", Visible: true},
+	},
+}
+
+func encode(t *testing.T, h *osmpbf.Header, objects ...interface{}) []byte {
+	var buf bytes.Buffer
+	e := NewEncoder(&buf)
+	if h != nil {
+		e.SetHeader(h)
+	}
+	for _, o := range objects {
+		if err := e.Encode(o); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := e.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestEncode(t *testing.T) {
+	h := &osmpbf.Header{
+		BBox:                 &osmpbf.BBox{Left: -0.5103751, Right: 0.3340155, Top: 51.6918741, Bottom: 51.2867602},
+		ReplicationTimestamp: time.Unix(1395619200, 0).UTC(),
+	}
+	d := NewDecoder(bytes.NewReader(encode(t, h, testObjects...)))
+	if decoded := decodeAll(t, d); !reflect.DeepEqual(testObjects, decoded) {
+		t.Errorf("\nExpected: %#v\nActual:   %#v", testObjects, decoded)
+	}
+	expected := &osmpbf.Header{
+		BBox:                 h.BBox,
+		RequiredFeatures:     []string{"OsmSchema-V0.6"},
+		ReplicationTimestamp: h.ReplicationTimestamp,
+	}
+	if !reflect.DeepEqual(expected, d.Header()) {
+		t.Errorf("\nExpected: %#v\nActual:   %#v", expected, d.Header())
+	}
+
+	// tag list order is kept
+	n := &osmpbf.Node{ID: 1, TagList: osmpbf.TagList{{Key: "z", Value: "1"}, {Key: "a", Value: "2"}}, Info: osmpbf.Info{Visible: true}}
+	decoded := decodeAll(t, NewDecoder(bytes.NewReader(encode(t, nil, n))))
+	if tags := decoded[0].(*osmpbf.Node).Tags; !reflect.DeepEqual(map[string]string{"z": "1", "a": "2"}, tags) {
+		t.Errorf("unexpected tags %v", tags)
+	}
+
+	// repeated strings are referenced
+	var nodes []interface{}
+	for i := 0; i < 100; i++ {
+		nodes = append(nodes, &osmpbf.Node{ID: int64(i), Tags: map[string]string{"amenity": "bench"}, Info: osmpbf.Info{Visible: true}})
+	}
+	if data := encode(t, nil, nodes...); len(data) > 100*7+50 {
+		t.Errorf("expected referenced strings, got %d bytes", len(data))
+	}
+
+	if err := NewEncoder(new(bytes.Buffer)).Encode(&osmpbf.Relation{Members: []osmpbf.Member{{Type: 3}}}); err == nil {
+		t.Error("expected member type error")
+	}
+}
+
+func TestEncodeZeroInfo(t *testing.T) {
+	// Info is zero for objects built by user or decoded without it, they are not deleted
+	objects := []interface{}{
+		&osmpbf.Node{ID: 1, Lat: 1.5, Lon: -2.5, Tags: map[string]string{"amenity": "bench"}},
+		&osmpbf.Way{ID: 2, NodeIDs: []int64{1, 3}, Tags: map[string]string{"highway": "path"}},
+	}
+	expected := []interface{}{
+		&osmpbf.Node{ID: 1, Lat: 1.5, Lon: -2.5, Tags: map[string]string{"amenity": "bench"}, Info: osmpbf.Info{Visible: true}},
+		&osmpbf.Way{ID: 2, NodeIDs: []int64{1, 3}, Tags: map[string]string{"highway": "path"}, Info: osmpbf.Info{Visible: true}},
+	}
+	if decoded := decodeAll(t, NewDecoder(bytes.NewReader(encode(t, nil, objects...)))); !reflect.DeepEqual(expected, decoded) {
+		t.Errorf("\nExpected: %#v\nActual:   %#v", expected, decoded)
+	}
+
+	// in history files they are deleted
+	h := &osmpbf.Header{RequiredFeatures: []string{"OsmSchema-V0.6", "HistoricalInformation"}}
+	for _, o := range decodeAll(t, NewDecoder(bytes.NewReader(encode(t, h, objects...)))) {
+		if n, ok := o.(*osmpbf.Node); ok && (n.Info.Visible || n.Tags != nil) {
+			t.Errorf("expected deleted node, got %#v", n)
+		}
+	}
+}
+
+func TestStringTable(t *testing.T) {
+	table := stringTable{index: make(map[string]int)}
+	for i := 0; i < tableSize+10; i++ {
+		table.add(strconv.Itoa(i))
+	}
+	if _, ok := table.ref("9"); ok {
+		t.Error("expected evicted string")
+	}
+	ref, ok := table.ref("10")
+	if !ok || ref != tableSize {
+		t.Errorf("expected reference %d, got %d", tableSize, ref)
+	}
+	if s, ok := table.get(ref); !ok || s != "10" {
+		t.Errorf("expected 10, got %q", s)
+	}
+	if _, ok := table.get(tableSize + 1); ok {
+		t.Error("expected invalid reference")
+	}
+
+	table.add(strings.Repeat("x", maxTableString) + "\x00")
+	table.add(strings.Repeat("x", maxTableString+1))
+	if s, _ := table.get(1); s != strings.Repeat("x", maxTableString)+"\x00" {
+		t.Error("expected string pair of maximum length in table")
+	}
+}
@@ -0,0 +1,87 @@
+// Package o5m reads and writes o5m files, as used by osmconvert and osmfilter, using Node, Way
+// and Relation structs of package osmpbf. Decoder and Encoder have the same methods as
+// osmpbf.Decoder and osmpbf.Encoder, so applications can accept both formats with one code path.
+//
+// o5m format is described at https://wiki.openstreetmap.org/wiki/O5m.
+package o5m
+
+import "strings"
+
+// Dataset types.
+const (
+	nodeDataset      = 0x10
+	wayDataset       = 0x11
+	relationDataset  = 0x12
+	bboxDataset      = 0xdb
+	timestampDataset = 0xdc
+	headerDataset    = 0xe0
+	endDataset       = 0xfe
+	resetDataset     = 0xff
+)
+
+const (
+	// o5m file format, o5c is format of change files
+	fileFormat   = "o5m2"
+	changeFormat = "o5c2"
+
+	// coordinates are in units of 100 nanodegrees
+	coordinateScale = 1e7
+
+	// string table holds up to tableSize most recent strings not longer than maxTableString
+	tableSize      = 15000
+	maxTableString = 250
+)
+
+// Table of recently used strings and string pairs, referenced by their position from the end.
+type stringTable struct {
+	entries [tableSize]string
+	n       int // number of added strings
+
+	// positions of entries by string, maintained by encoder only
+	index map[string]int
+}
+
+func (t *stringTable) reset() {
+	t.n = 0
+	for k := range t.index {
+		delete(t.index, k)
+	}
+}
+
+// Adds string, or string pair separated by zero byte, if it is not too long: length of pair
+// does not include separator.
+func (t *stringTable) add(s string) {
+	size := len(s)
+	if strings.IndexByte(s, 0) >= 0 {
+		size--
+	}
+	if size > maxTableString {
+		return
+	}
+	i := t.n % tableSize
+	if t.index != nil {
+		if old, ok := t.index[t.entries[i]]; ok && old == t.n-tableSize {
+			delete(t.index, t.entries[i])
+		}
+		t.index[s] = t.n
+	}
+	t.entries[i] = s
+	t.n++
+}
+
+// Returns string referenced by ref, 1 is the most recently added one.
+func (t *stringTable) get(ref uint64) (string, bool) {
+	if ref == 0 || ref > tableSize || ref > uint64(t.n) {
+		return "", false
+	}
+	return t.entries[(t.n-int(ref))%tableSize], true
+}
+
+// Returns reference to s, or false if s is not in the table.
+func (t *stringTable) ref(s string) (uint64, bool) {
+	pos, ok := t.index[s]
+	if !ok {
+		return 0, false
+	}
+	return uint64(t.n - pos), true
+}
@@ -0,0 +1,57 @@
+package osmpbf
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestStartOffset(t *testing.T) {
+	data := encodeNodesWays(t, maxBlockEntities*2+1, 10).Bytes()
+	idx, err := BuildIndex(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := idx.Entries[2]
+
+	for _, d := range []*Decoder{
+		NewReaderAtDecoder(bytes.NewReader(data), int64(len(data))),
+		NewDecoder(bytes.NewReader(data[start.Offset:])),
+	} {
+		d.SetStartOffset(start.Offset)
+		if err := d.Start(2); err != nil {
+			t.Fatal(err)
+		}
+		if d.Header() != nil {
+			t.Error("expected no header")
+		}
+		if offset := d.Offset(); offset != start.Offset {
+			t.Errorf("expected offset %d, got %d", start.Offset, offset)
+		}
+
+		v, err := d.Decode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id := v.(*Node).ID; id != start.Nodes.Min {
+			t.Errorf("expected node %d, got %d", start.Nodes.Min, id)
+		}
+		if offset := d.Offset(); offset != start.Offset {
+			t.Errorf("expected offset %d, got %d", start.Offset, offset)
+		}
+
+		var last interface{}
+		for {
+			v, err := d.Decode()
+			if err != nil {
+				break
+			}
+			last = v
+		}
+		if id := last.(*Way).ID; id != 10 {
+			t.Errorf("expected way 10, got %d", id)
+		}
+		if offset := d.Offset(); offset != idx.Entries[4].Offset {
+			t.Errorf("expected offset %d, got %d", idx.Entries[4].Offset, offset)
+		}
+	}
+}
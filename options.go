@@ -0,0 +1,317 @@
+package osmpbf
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"time"
+)
+
+// Option configures Decoder, see NewDecoder. Each option has a corresponding Decoder setter,
+// which must be called before Start.
+type Option func(dec *Decoder)
+
+// WithBufferSize sets initial size of decoding buffer. Default value is 1MB, you can set higher value
+// (for example, MaxBlobSize) for (probably) faster decoding, or lower value for reduced memory consumption.
+// Any value will produce valid results; buffer will grow automatically if required.
+func WithBufferSize(n int) Option {
+	return func(dec *Decoder) {
+		dec.buf = bytes.NewBuffer(make([]byte, 0, n))
+	}
+}
+
+// WithWorkers sets number of data decoder goroutines used if Start is called with n <= 0.
+func WithWorkers(n int) Option {
+	return func(dec *Decoder) {
+		dec.workers = n
+	}
+}
+
+// WithMaxBlobHeaderSize sets maximum accepted BlobHeader size, default value is MaxBlobHeaderSize.
+// Larger BlobHeaders cause decoding error.
+func WithMaxBlobHeaderSize(n int) Option {
+	return func(dec *Decoder) {
+		dec.maxBlobHeaderSize = uint32(n)
+	}
+}
+
+// WithMaxBlobSize sets maximum accepted Blob size, default value is MaxBlobSize.
+// Larger Blobs cause decoding error. Some tools write Blobs slightly larger than default maximum.
+// Values above math.MaxInt32, the largest size in BlobHeader, are clamped to it.
+func WithMaxBlobSize(n int) Option {
+	return func(dec *Decoder) {
+		if n > math.MaxInt32 {
+			n = math.MaxInt32
+		}
+		dec.maxBlobSize = int32(n)
+	}
+}
+
+// WithStartOffset sets offset of fileblock decoding starts at, as returned by Offset or Index.
+// OSMHeader is not read then, so Header returns nil. For decoder returned by NewReaderAtDecoder
+// input is read from offset, otherwise reader must be already positioned there (for example, by Seek).
+func WithStartOffset(offset int64) Option {
+	return func(dec *Decoder) {
+		dec.startOffset = offset
+	}
+}
+
+// WithUnordered sets whether objects may be returned out of the input stream order: objects of each
+// PrimitiveBlock are returned as soon as any data decoder finishes it, so slow blocks do not hold back
+// others. Objects of one block are still returned in order. This increases throughput for processing
+// which does not depend on order, like counting or indexing. Spatial filtering of ways and relations
+// is not supported then.
+func WithUnordered(unordered bool) Option {
+	return func(dec *Decoder) {
+		dec.unordered = unordered
+	}
+}
+
+// WithStrict sets whether decoded data is checked for violations of PBF format specification,
+// which are otherwise ignored or may cause panic: string table indexes out of range, inconsistent
+// lengths of parallel arrays, unterminated DenseNodes keys_vals, invalid granularity or locations,
+// raw blob size mismatch and, if OSMHeader declares Sort.Type_then_ID, unsorted objects (checked
+// in ordered mode only).
+// Violation is returned as *ValidationError. Checks make decoding slower.
+func WithStrict(strict bool) Option {
+	return func(dec *Decoder) {
+		dec.opts.strict = strict
+	}
+}
+
+// WithBound sets whether bounding box from OSMHeader is returned by Decode as *Bound before objects,
+// like osmium does. Bound is returned only if OSMHeader contains bounding box; in concatenated input
+// stream it is returned for each OSMHeader. Handle does not pass bounds to handler.
+func WithBound(bound bool) Option {
+	return func(dec *Decoder) {
+		dec.opts.bound = bound
+	}
+}
+
+// WithZlibReader sets constructor of zlib readers used to inflate blobs, for example NewReader of
+// github.com/klauspost/compress/zlib, which is faster than the standard library. Default is
+// zlib.NewReader of the standard library. Each data decoder goroutine keeps one reader and reuses
+// it for subsequent blobs if it implements zlib.Resetter.
+func WithZlibReader(newReader func(r io.Reader) (io.ReadCloser, error)) Option {
+	return func(dec *Decoder) {
+		dec.opts.zlibReader = newReader
+	}
+}
+
+// WithDecompressor sets decompressor of blobs compressed by method c, for example a faster or cgo
+// implementation. newDecompressor is called once by each data decoder goroutine, so Decompressor
+// does not have to be safe for concurrent use. Blobs compressed by methods without decompressor are
+// decompressed by built-in implementation. Nil newDecompressor removes decompressor of c.
+func WithDecompressor(c Compression, newDecompressor func() Decompressor) Option {
+	return func(dec *Decoder) {
+		if newDecompressor == nil {
+			delete(dec.opts.decompressors, c)
+			return
+		}
+		if dec.opts.decompressors == nil {
+			dec.opts.decompressors = make(map[Compression]func() Decompressor)
+		}
+		dec.opts.decompressors[c] = newDecompressor
+	}
+}
+
+// WithSkipCorrupt sets whether fileblocks which can not be decoded are skipped instead of stopping
+// decoding with error: for example, blobs failing to uncompress or unmarshal, or blocks with string
+// table indexes out of range. Errors are collected and returned by Decoder.Skipped. Corrupt
+// BlobHeaders can not be skipped, as the next fileblock can not be found then.
+func WithSkipCorrupt(skip bool) Option {
+	return func(dec *Decoder) {
+		dec.skipCorrupt = skip
+	}
+}
+
+// WithOutputBuffer sets number of decoded objects buffered for Decode, default value is 8000
+// (typical number of objects in PrimitiveBlock). Larger buffer smooths out irregular Decode calls
+// at the cost of memory.
+func WithOutputBuffer(n int) Option {
+	return func(dec *Decoder) {
+		dec.serializer = make(chan *pair, n)
+	}
+}
+
+// WithQueueSize sets number of blobs queued for each data decoder goroutine, and number of decoded
+// blobs queued after it; default value is 0, or 1 if number of goroutines is chosen by Start.
+// Queues let reading and decoding proceed while other goroutines are busy, but each queued blob
+// holds its data or decoded objects in memory.
+func WithQueueSize(n int) Option {
+	return func(dec *Decoder) {
+		dec.queueSize = n
+	}
+}
+
+// WithMaxInFlight limits number of blobs read from the input stream but not yet returned by Decode,
+// bounding memory used by decoding: each blob takes up to MaxBlobSize of data and several times more
+// of decoded objects. Zero n, the default, means that limit is set only by number of goroutines and
+// queue sizes.
+func WithMaxInFlight(n int) Option {
+	return func(dec *Decoder) {
+		dec.maxInFlight = n
+	}
+}
+
+// WithSkipNodes sets whether nodes are skipped: PrimitiveGroups containing nodes are not decoded
+// and Decode does not return them.
+func WithSkipNodes(skip bool) Option {
+	return func(dec *Decoder) {
+		dec.opts.skipNodes = skip
+	}
+}
+
+// WithSkipWays sets whether ways are skipped, see WithSkipNodes.
+func WithSkipWays(skip bool) Option {
+	return func(dec *Decoder) {
+		dec.opts.skipWays = skip
+	}
+}
+
+// WithSkipRelations sets whether relations are skipped, see WithSkipNodes.
+func WithSkipRelations(skip bool) Option {
+	return func(dec *Decoder) {
+		dec.opts.skipRelations = skip
+	}
+}
+
+// WithSkipInfo sets whether Info metadata (version, timestamp, changeset, user) is skipped:
+// it is not decoded and Info of returned objects is zero value, including Visible field.
+func WithSkipInfo(skip bool) Option {
+	return func(dec *Decoder) {
+		dec.opts.skipInfo = skip
+	}
+}
+
+// WithInternStrings enables interning of strings from string tables (tag keys and values, user names,
+// member roles): identical strings from different PrimitiveBlocks share memory, which shrinks heap
+// of in-memory pipelines. Each data decoder goroutine keeps up to n distinct strings, less frequent
+// ones are not interned after that. Zero n disables interning, it is default.
+func WithInternStrings(n int) Option {
+	return func(dec *Decoder) {
+		dec.opts.internSize = n
+	}
+}
+
+// WithFilter sets function deciding which objects are returned by Decode. It is called with object kind
+// and tags during decoding, before the rest of the object is decoded; objects for which it returns false
+// are dropped. Filter is called from several goroutines concurrently and must not modify tags.
+func WithFilter(filter func(kind Kind, tags map[string]string) bool) Option {
+	return func(dec *Decoder) {
+		dec.opts.filter = filter
+	}
+}
+
+// WithInfoFilter sets filter of objects by Info metadata, for example edits of one user. Unlike
+// WithFilter, objects are dropped before their tags are decoded. Can not be used with WithSkipInfo.
+func WithInfoFilter(f InfoFilter) Option {
+	return func(dec *Decoder) {
+		dec.opts.infoFilter = &f
+	}
+}
+
+// WithRegion sets area for spatial filtering: nodes outside of it are dropped by data decoders,
+// mode controls whether ways and relations are dropped too.
+func WithRegion(r Region, mode SpatialMode) Option {
+	return func(dec *Decoder) {
+		dec.opts.region = r
+		dec.spatialMode = mode
+	}
+}
+
+// WithBBox sets bounding box for spatial filtering, see WithRegion.
+func WithBBox(b BBox, mode SpatialMode) Option {
+	return WithRegion(b, mode)
+}
+
+// WithIndexBBox skips OSMData fileblocks of index idx with nodes outside of bounding box b, using
+// IndexEntry.BBox, so only blobs of interest are decoded from big files sorted geographically.
+// Fileblocks without nodes are not skipped. Objects of decoded blobs are not filtered, so it is
+// usually combined with WithBBox. Index must be built from the same input stream.
+func WithIndexBBox(idx *Index, b BBox) Option {
+	return func(dec *Decoder) {
+		dec.skipBlobs = make(map[int64]bool)
+		for _, e := range idx.Entries {
+			if e.BBox != nil && !e.BBox.Intersects(b) {
+				dec.skipBlobs[e.Offset] = true
+			}
+		}
+	}
+}
+
+// WithTransforms sets transforms applied to decoded nodes, ways and relations in order, after
+// other filters and before objects are returned by Decode. They are called by one goroutine in
+// input stream order, so they may keep state, except with Handle: then they are called before
+// handler methods, concurrently like them. Objects dropped by a transform are released.
+func WithTransforms(fns ...Transform) Option {
+	return func(dec *Decoder) {
+		dec.transforms = fns
+	}
+}
+
+// WithNodeLocations sets store locations of decoded nodes are put into. Ways without NodeLocations
+// get them from the store, if locations of all their nodes are known, so geometry of ways is resolved
+// in one pass over a file sorted by type, or in a second pass over a file with the same store.
+// Nodes are stored in the input stream order before spatial filtering, by one goroutine. Requires
+// ordered decoding.
+func WithNodeLocations(s NodeLocationStore) Option {
+	return func(dec *Decoder) {
+		dec.locations = s
+	}
+}
+
+// WithVersions sets which versions of objects in history files are returned: versions with timestamp
+// not before since and not after until, zero times do not limit the range, and in LatestVersion mode
+// only the last one of them for each object. Versions of an object must be consecutive and ordered by
+// version, as in sorted history files. Requires ordered decoding.
+func WithVersions(mode VersionMode, since, until time.Time) Option {
+	return func(dec *Decoder) {
+		if mode == AllVersions && since.IsZero() && until.IsZero() {
+			dec.versions = nil
+			return
+		}
+		dec.versions = &versionFilter{since: since, until: until, latest: mode == LatestVersion}
+	}
+}
+
+// WithSnapshot sets time of snapshot made from history file, like osmium time-filter: only the last
+// version of each object with timestamp not after t is returned, and objects deleted by then are
+// dropped. Versions of an object must be consecutive and ordered by version, as in sorted history
+// files. Returned objects are regular snapshot, so HistoricalInformation feature should be removed
+// from header when they are encoded. It overrides WithVersions. Requires ordered decoding.
+func WithSnapshot(t time.Time) Option {
+	return func(dec *Decoder) {
+		dec.versions = &versionFilter{until: t, latest: true, dropDeleted: true}
+	}
+}
+
+// WithCollectStats sets whether decoding statistics are collected, see Decoder.Stats.
+// Collecting has a small overhead, so it is disabled by default.
+func WithCollectStats(collect bool) Option {
+	return func(dec *Decoder) {
+		if collect {
+			dec.opts.stats = new(Stats)
+		} else {
+			dec.opts.stats = nil
+		}
+	}
+}
+
+// WithRawLocations sets whether Raw field of nodes points to their location as stored in PBF data,
+// so it can be used without loss of precision by conversion to float64, see RawLocation. Consumers
+// keeping many nodes in memory can store RawLocation.E7 instead of Lat and Lon, which takes half of
+// memory and allows exact comparison and hashing of locations.
+func WithRawLocations(raw bool) Option {
+	return func(dec *Decoder) {
+		dec.opts.rawLocations = raw
+	}
+}
+
+// WithTagList sets whether tags of returned objects are stored in TagList field instead of Tags map.
+// Tags are in the input stream order. If filter is set, map is still made for each object to call it.
+func WithTagList(tagList bool) Option {
+	return func(dec *Decoder) {
+		dec.opts.tagList = tagList
+	}
+}
@@ -0,0 +1,376 @@
+package osmpbf
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"runtime"
+	"testing"
+
+	"github.com/brechtbm/osmpbf/OSMPBF"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestSkip(t *testing.T) {
+	for _, c := range []struct {
+		nodes, ways, relations bool
+		expected               []interface{}
+	}{
+		{false, false, false, []interface{}{en, ew, er}},
+		{true, false, false, []interface{}{ew, er}},
+		{false, true, false, []interface{}{en, er}},
+		{true, true, false, []interface{}{er}},
+		{true, true, true, nil},
+	} {
+		d := NewDecoder(encodeObjects(t, en, ew, er))
+		d.SetSkipNodes(c.nodes)
+		d.SetSkipWays(c.ways)
+		d.SetSkipRelations(c.relations)
+		objects := decodeAll(t, d)
+
+		var kinds, expected []Kind
+		for _, o := range objects {
+			kinds = append(kinds, o.(Object).Kind())
+		}
+		for _, o := range c.expected {
+			expected = append(expected, o.(Object).Kind())
+		}
+		if !reflect.DeepEqual(expected, kinds) {
+			t.Errorf("skip %v %v %v: expected %v, got %v", c.nodes, c.ways, c.relations, expected, kinds)
+		}
+	}
+}
+
+func TestFilter(t *testing.T) {
+	d := NewDecoder(encodeObjects(t, en, &Node{ID: 1}, ew, er))
+	d.SetFilter(func(kind Kind, tags map[string]string) bool {
+		return kind == RelationKind || tags["name"] != ""
+	})
+
+	var ids []int64
+	for _, o := range decodeAll(t, d) {
+		switch o := o.(type) {
+		case *Node:
+			ids = append(ids, o.ID)
+		case *Way:
+			ids = append(ids, o.ID)
+		case *Relation:
+			ids = append(ids, o.ID)
+		}
+	}
+	if expected := []int64{en.ID, ew.ID, er.ID}; !reflect.DeepEqual(expected, ids) {
+		t.Errorf("expected %v, got %v", expected, ids)
+	}
+
+	d = NewDecoder(encodeObjects(t, en, ew, er))
+	d.SetFilter(func(kind Kind, tags map[string]string) bool {
+		return tags["highway"] == "pedestrian"
+	})
+	if objects := decodeAll(t, d); !reflect.DeepEqual([]interface{}{ew}, objects) {
+		t.Errorf("expected only way, got %#v", objects)
+	}
+}
+
+func TestSkipInfo(t *testing.T) {
+	d := NewDecoder(encodeObjects(t, en, ew, er))
+	d.SetSkipInfo(true)
+	objects := decodeAll(t, d)
+	if len(objects) != 3 {
+		t.Fatalf("expected 3 objects, got %d", len(objects))
+	}
+
+	w := *ew
+	w.Info = Info{}
+	if !reflect.DeepEqual(&w, objects[1]) {
+		t.Errorf("\nExpected: %#v\nActual:   %#v", &w, objects[1])
+	}
+	for _, o := range objects {
+		var info Info
+		switch o := o.(type) {
+		case *Node:
+			info = o.Info
+		case *Way:
+			info = o.Info
+		case *Relation:
+			info = o.Info
+		}
+		if info != (Info{}) {
+			t.Errorf("expected zero Info, got %#v", info)
+		}
+	}
+}
+
+func TestMaxBlobSize(t *testing.T) {
+	d := NewDecoder(encodeObjects(t, en, ew, er))
+	d.SetMaxBlobSize(10)
+	if err := d.Start(2); err == nil {
+		t.Error("expected Blob size error")
+	}
+
+	d = NewDecoder(encodeObjects(t, en, ew, er))
+	d.SetMaxBlobHeaderSize(4)
+	if err := d.Start(2); err == nil {
+		t.Error("expected BlobHeader size error")
+	}
+
+	d = NewDecoder(encodeObjects(t, en, ew, er))
+	d.SetMaxBlobSize(MaxBlobSize * 2)
+	if objects := decodeAll(t, d); len(objects) != 3 {
+		t.Errorf("expected 3 objects, got %d", len(objects))
+	}
+
+	// maximum int does not wrap around to negative size
+	d = NewDecoder(encodeObjects(t, en, ew, er))
+	d.SetMaxBlobSize(int(^uint(0) >> 1))
+	if objects := decodeAll(t, d); len(objects) != 3 {
+		t.Errorf("expected 3 objects, got %d", len(objects))
+	}
+}
+
+func TestSkipTo(t *testing.T) {
+	var objects []Object
+	for i := 1; i <= maxBlockEntities*2; i++ {
+		objects = append(objects, &Node{ID: int64(i)})
+	}
+	data := encodeObjects(t, append(objects, ew, er)...).Bytes()
+
+	for _, readerAt := range []bool{false, true} {
+		d := NewDecoder(bytes.NewReader(data))
+		if readerAt {
+			d = NewReaderAtDecoder(bytes.NewReader(data), int64(len(data)))
+		}
+		d.SkipToWays()
+		if decoded := decodeAll(t, d); !reflect.DeepEqual(decoded, []interface{}{ew, er}) {
+			t.Errorf("expected way and relation, got %d objects", len(decoded))
+		}
+
+		d = NewDecoder(bytes.NewReader(data))
+		if readerAt {
+			d = NewReaderAtDecoder(bytes.NewReader(data), int64(len(data)))
+		}
+		d.SkipToRelations()
+		if decoded := decodeAll(t, d); !reflect.DeepEqual(decoded, []interface{}{er}) {
+			t.Errorf("expected relation, got %d objects", len(decoded))
+		}
+	}
+
+	// no ways
+	d := NewDecoder(encodeObjects(t, en))
+	d.SkipToWays()
+	if decoded := decodeAll(t, d); len(decoded) != 0 {
+		t.Errorf("expected no objects, got %d", len(decoded))
+	}
+}
+
+func TestInternStrings(t *testing.T) {
+	var objects []Object
+	for i := 1; i <= maxBlockEntities+1; i++ {
+		objects = append(objects, &Node{ID: int64(i), Tags: map[string]string{"highway": "crossing"}})
+	}
+	d := NewDecoder(encodeObjects(t, objects...))
+	d.SetInternStrings(100)
+	decoded := decodeAll(t, d)
+	if len(decoded) != len(objects) {
+		t.Fatalf("expected %d objects, got %d", len(objects), len(decoded))
+	}
+	for _, o := range decoded {
+		if tags := o.(*Node).Tags; tags["highway"] != "crossing" {
+			t.Fatalf("unexpected tags %v", tags)
+		}
+	}
+
+	dd := &dataDecoder{opts: decodeOptions{internSize: 2}}
+	dd.intern([]string{"", "highway", "crossing"})
+	dd.intern([]string{"", "highway", "traffic_signals"})
+	if expected := map[string]string{"": "", "highway": "highway"}; !reflect.DeepEqual(expected, dd.strings) {
+		t.Errorf("expected %v, got %v", expected, dd.strings)
+	}
+}
+
+func TestUnordered(t *testing.T) {
+	n := maxBlockEntities*4 + 1
+	d := NewDecoder(encodeNodesWays(t, n, 10))
+	d.SetUnordered(true)
+	seen := make(map[int64]bool)
+	var ways int
+	for _, o := range decodeAll(t, d) {
+		switch o := o.(type) {
+		case *Node:
+			seen[o.ID] = true
+		case *Way:
+			ways++
+		}
+	}
+	if len(seen) != n || ways != 10 {
+		t.Errorf("expected %d nodes and 10 ways, got %d and %d", n, len(seen), ways)
+	}
+
+	d = NewDecoder(encodeObjects(t, en, ew))
+	d.SetUnordered(true)
+	d.SetBBox(BBox{Left: -1, Right: 1, Top: 52, Bottom: 51}, SpatialWays)
+	if err := d.Start(2); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestBackpressure(t *testing.T) {
+	n := maxBlockEntities*4 + 1
+	data := encodeNodesWays(t, n, 10).Bytes()
+	for _, unordered := range []bool{false, true} {
+		d := NewDecoder(bytes.NewReader(data))
+		d.SetUnordered(unordered)
+		d.SetOutputBuffer(10)
+		d.SetQueueSize(2)
+		d.SetMaxInFlight(1)
+		if decoded := decodeAll(t, d); len(decoded) != n+10 {
+			t.Errorf("expected %d objects, got %d", n+10, len(decoded))
+		}
+	}
+}
+
+func TestDefaultParallelism(t *testing.T) {
+	n := maxBlockEntities*2 + 1
+	d := NewDecoder(encodeNodesWays(t, n, 10))
+	if err := d.Start(0); err != nil {
+		t.Fatal(err)
+	}
+	if len(d.inputs) != runtime.GOMAXPROCS(0) {
+		t.Errorf("expected %d data decoders, got %d", runtime.GOMAXPROCS(0), len(d.inputs))
+	}
+	if cap(d.inputs[0]) != 1 {
+		t.Errorf("expected queue size 1, got %d", cap(d.inputs[0]))
+	}
+
+	var count int
+	for {
+		if _, err := d.Decode(); err != nil {
+			break
+		}
+		count++
+	}
+	if count != n+10 {
+		t.Errorf("expected %d objects, got %d", n+10, count)
+	}
+}
+
+func TestOptions(t *testing.T) {
+	d := NewDecoder(encodeObjects(t, en, ew, er),
+		WithBufferSize(1024),
+		WithSkipNodes(true),
+		WithFilter(func(kind Kind, tags map[string]string) bool { return kind == WayKind }),
+		WithMaxBlobSize(MaxBlobSize*2),
+	)
+	if objects := decodeAll(t, d); !reflect.DeepEqual([]interface{}{ew}, objects) {
+		t.Errorf("expected only way, got %#v", objects)
+	}
+
+	d = NewDecoder(encodeObjects(t, en, ew, er), WithWorkers(3), WithQueueSize(2))
+	if err := d.Start(0); err != nil {
+		t.Fatal(err)
+	}
+	if len(d.inputs) != 3 || cap(d.inputs[0]) != 2 {
+		t.Errorf("expected 3 data decoders with queue size 2, got %d and %d", len(d.inputs), cap(d.inputs[0]))
+	}
+	d.Close()
+}
+
+func TestSkipCorrupt(t *testing.T) {
+	data := encodeNodesWays(t, maxBlockEntities*3, 10).Bytes()
+	idx, err := BuildIndex(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// break compressed data of the second node block
+	corrupt := idx.Entries[2]
+	for i := corrupt.Offset + corrupt.Size - 8; i < corrupt.Offset+corrupt.Size; i++ {
+		data[i] ^= 0xff
+	}
+
+	d := NewDecoder(bytes.NewReader(data))
+	if err := d.Start(2); err != nil {
+		t.Fatal(err)
+	}
+	for err == nil {
+		_, err = d.Decode()
+	}
+	if err == io.EOF {
+		t.Error("expected decoding error")
+	}
+	d.Close()
+
+	for _, d := range []*Decoder{
+		NewDecoder(bytes.NewReader(data), WithSkipCorrupt(true)),
+		NewReaderAtDecoder(bytes.NewReader(data), int64(len(data)), WithSkipCorrupt(true)),
+	} {
+		var nodes, ways int
+		for _, o := range decodeAll(t, d) {
+			switch o.(type) {
+			case *Node:
+				nodes++
+			case *Way:
+				ways++
+			}
+		}
+		if nodes != maxBlockEntities*2 || ways != 10 {
+			t.Errorf("expected %d nodes and 10 ways, got %d and %d", maxBlockEntities*2, nodes, ways)
+		}
+
+		skipped := d.Skipped()
+		if len(skipped) != 1 || skipped[0].Offset != corrupt.Offset {
+			t.Errorf("expected one skipped block at %d, got %v", corrupt.Offset, skipped)
+		}
+	}
+}
+
+func TestSkipCorruptIndexes(t *testing.T) {
+	// the way block is replaced by dense nodes with user string index out of range
+	pb := &OSMPBF.PrimitiveBlock{
+		Stringtable: &OSMPBF.StringTable{S: []string{""}},
+		Primitivegroup: []*OSMPBF.PrimitiveGroup{{Dense: &OSMPBF.DenseNodes{
+			Id:  []int64{1, 1},
+			Lat: []int64{0, 0},
+			Lon: []int64{0, 0},
+			Denseinfo: &OSMPBF.DenseInfo{
+				Version:   []int32{1, 1},
+				Timestamp: []int64{0, 0},
+				Changeset: []int64{0, 0},
+				Uid:       []int32{0, 0},
+				UserSid:   []int32{0, -3},
+			},
+		}}},
+	}
+	raw, err := proto.Marshal(pb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	d := NewDecoder(encodeObjects(t, en, ew, er))
+	e := NewEncoder(&buf)
+	for i := 0; ; i++ {
+		blobHeader, blob, err := d.readFileBlock()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		if i == 2 {
+			blob = &OSMPBF.Blob{Raw: raw, RawSize: proto.Int32(int32(len(raw)))}
+		}
+		if err = e.writeBlob(blobHeader.GetType(), blob); err != nil {
+			t.Fatal(err)
+		}
+	}
+	data := buf.Bytes()
+
+	if n, err := decodeUntilError(t, NewDecoder(bytes.NewReader(data))); n != 1 || err == nil || err == io.EOF {
+		t.Errorf("expected 1 object and decoding error, got %d and %v", n, err)
+	}
+
+	d = NewDecoder(bytes.NewReader(data), WithSkipCorrupt(true))
+	if objects := decodeAll(t, d); !reflect.DeepEqual(objects, []interface{}{en, er}) {
+		t.Errorf("\nExpected: %#v\nActual:   %#v", []interface{}{en, er}, objects)
+	}
+	if skipped := d.Skipped(); len(skipped) != 1 {
+		t.Errorf("expected one skipped block, got %v", skipped)
+	}
+}
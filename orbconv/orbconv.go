@@ -0,0 +1,157 @@
+// Package orbconv converts objects decoded by package osmpbf to geometries of github.com/paulmach/orb
+// and to objects of github.com/paulmach/osm, so they can be used with libraries of those packages.
+//
+// Geometries of ways need resolved node locations: Way.NodeLocations, set in files with
+// LocationsOnWays feature or by osmpbf.WithNodeLocations, or WayGeometry made by GeometryBuilder.
+package orbconv
+
+import (
+	"sort"
+
+	"github.com/brechtbm/osmpbf"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/osm"
+)
+
+// Point returns location of node.
+func Point(n *osmpbf.Node) orb.Point {
+	return orb.Point{n.Lon, n.Lat}
+}
+
+// LineString returns line of way, or false if locations of its nodes are not resolved.
+func LineString(w *osmpbf.Way) (orb.LineString, bool) {
+	if len(w.NodeLocations) == 0 || len(w.NodeLocations) != len(w.NodeIDs) {
+		return nil, false
+	}
+	return lineString(w.NodeLocations), true
+}
+
+// Polygon returns polygon of closed way, or false if way is not closed or locations of its nodes
+// are not resolved. Way tags are not checked, see osmpbf.IsClosedWayArea.
+func Polygon(w *osmpbf.Way) (orb.Polygon, bool) {
+	ids := w.NodeIDs
+	if len(ids) < 4 || ids[0] != ids[len(ids)-1] {
+		return nil, false
+	}
+	line, ok := LineString(w)
+	if !ok {
+		return nil, false
+	}
+	return orb.Polygon{orb.Ring(line)}, true
+}
+
+// MultiLineString returns parts of way geometry.
+func MultiLineString(g *osmpbf.WayGeometry) orb.MultiLineString {
+	lines := make(orb.MultiLineString, len(g.Parts))
+	for i, part := range g.Parts {
+		lines[i] = lineString(part)
+	}
+	return lines
+}
+
+// MultiPolygon returns polygons of area, with the same orientation of rings.
+func MultiPolygon(a *osmpbf.Area) orb.MultiPolygon {
+	polygons := make(orb.MultiPolygon, len(a.Polygons))
+	for i, rings := range a.Polygons {
+		polygons[i] = make(orb.Polygon, len(rings))
+		for j, ring := range rings {
+			polygons[i][j] = orb.Ring(lineString(ring))
+		}
+	}
+	return polygons
+}
+
+func lineString(locations []osmpbf.LatLon) orb.LineString {
+	line := make(orb.LineString, len(locations))
+	for i, l := range locations {
+		line[i] = orb.Point{l.Lon, l.Lat}
+	}
+	return line
+}
+
+// Node returns node as osm.Node.
+func Node(n *osmpbf.Node) *osm.Node {
+	return &osm.Node{
+		ID:          osm.NodeID(n.ID),
+		Lat:         n.Lat,
+		Lon:         n.Lon,
+		User:        n.Info.User,
+		UserID:      osm.UserID(n.Info.Uid),
+		Visible:     n.Info.Visible,
+		Version:     int(n.Info.Version),
+		ChangesetID: osm.ChangesetID(n.Info.Changeset),
+		Timestamp:   n.Info.Timestamp,
+		Tags:        tags(n.Tags, n.TagList),
+	}
+}
+
+// Way returns way as osm.Way. Locations of way nodes are set if they are resolved.
+func Way(w *osmpbf.Way) *osm.Way {
+	nodes := make(osm.WayNodes, len(w.NodeIDs))
+	for i, id := range w.NodeIDs {
+		nodes[i].ID = osm.NodeID(id)
+		if len(w.NodeLocations) == len(w.NodeIDs) {
+			nodes[i].Lat, nodes[i].Lon = w.NodeLocations[i].Lat, w.NodeLocations[i].Lon
+		}
+	}
+	return &osm.Way{
+		ID:          osm.WayID(w.ID),
+		User:        w.Info.User,
+		UserID:      osm.UserID(w.Info.Uid),
+		Visible:     w.Info.Visible,
+		Version:     int(w.Info.Version),
+		ChangesetID: osm.ChangesetID(w.Info.Changeset),
+		Timestamp:   w.Info.Timestamp,
+		Nodes:       nodes,
+		Tags:        tags(w.Tags, w.TagList),
+	}
+}
+
+// Relation returns relation as osm.Relation.
+func Relation(r *osmpbf.Relation) *osm.Relation {
+	members := make(osm.Members, len(r.Members))
+	for i, m := range r.Members {
+		members[i] = osm.Member{Type: memberType(m.Type), Ref: m.ID, Role: m.Role}
+	}
+	return &osm.Relation{
+		ID:          osm.RelationID(r.ID),
+		User:        r.Info.User,
+		UserID:      osm.UserID(r.Info.Uid),
+		Visible:     r.Info.Visible,
+		Version:     int(r.Info.Version),
+		ChangesetID: osm.ChangesetID(r.Info.Changeset),
+		Timestamp:   r.Info.Timestamp,
+		Tags:        tags(r.Tags, r.TagList),
+		Members:     members,
+	}
+}
+
+func memberType(t osmpbf.MemberType) osm.Type {
+	switch t {
+	case osmpbf.WayType:
+		return osm.TypeWay
+	case osmpbf.RelationType:
+		return osm.TypeRelation
+	}
+	return osm.TypeNode
+}
+
+// Returns tags in TagList order, or sorted by key if they are in map.
+func tags(m map[string]string, list osmpbf.TagList) osm.Tags {
+	if len(list) > 0 {
+		tags := make(osm.Tags, len(list))
+		for i, tag := range list {
+			tags[i] = osm.Tag{Key: tag.Key, Value: tag.Value}
+		}
+		return tags
+	}
+	if len(m) == 0 {
+		return nil
+	}
+	tags := make(osm.Tags, 0, len(m))
+	for k, v := range m {
+		tags = append(tags, osm.Tag{Key: k, Value: v})
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Key < tags[j].Key })
+	return tags
+}
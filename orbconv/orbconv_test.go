@@ -0,0 +1,68 @@
+package orbconv
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/brechtbm/osmpbf"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/osm"
+)
+
+func TestGeometry(t *testing.T) {
+	if p := Point(&osmpbf.Node{Lat: 1, Lon: 2}); p != (orb.Point{2, 1}) {
+		t.Errorf("unexpected point %v", p)
+	}
+
+	locations := []osmpbf.LatLon{{Lat: 0, Lon: 0}, {Lat: 0, Lon: 1}, {Lat: 1, Lon: 1}, {Lat: 0, Lon: 0}}
+	w := &osmpbf.Way{NodeIDs: []int64{1, 2, 3, 1}, NodeLocations: locations}
+	expected := orb.LineString{{0, 0}, {1, 0}, {1, 1}, {0, 0}}
+	if line, ok := LineString(w); !ok || !reflect.DeepEqual(expected, line) {
+		t.Errorf("unexpected line %v", line)
+	}
+	if polygon, ok := Polygon(w); !ok || !reflect.DeepEqual(orb.Polygon{orb.Ring(expected)}, polygon) {
+		t.Errorf("unexpected polygon %v", polygon)
+	}
+	if _, ok := LineString(&osmpbf.Way{NodeIDs: []int64{1, 2}}); ok {
+		t.Error("expected no line of way without locations")
+	}
+	if _, ok := Polygon(&osmpbf.Way{NodeIDs: []int64{1, 2, 3}, NodeLocations: locations[:3]}); ok {
+		t.Error("expected no polygon of open way")
+	}
+
+	g := &osmpbf.WayGeometry{Parts: [][]osmpbf.LatLon{locations[:2], locations[2:]}}
+	if lines := MultiLineString(g); !reflect.DeepEqual(orb.MultiLineString{expected[:2], expected[2:]}, lines) {
+		t.Errorf("unexpected lines %v", lines)
+	}
+	a := &osmpbf.Area{Polygons: [][][]osmpbf.LatLon{{locations}}}
+	if polygons := MultiPolygon(a); !reflect.DeepEqual(orb.MultiPolygon{{orb.Ring(expected)}}, polygons) {
+		t.Errorf("unexpected polygons %v", polygons)
+	}
+}
+
+func TestObjects(t *testing.T) {
+	info := osmpbf.Info{Version: 2, Timestamp: time.Unix(1e9, 0).UTC(), Changeset: 3, Uid: 4, User: "u", Visible: true}
+
+	n := Node(&osmpbf.Node{ID: 1, Lat: 1, Lon: 2, Tags: map[string]string{"b": "2", "a": "1"}, Info: info})
+	if n.ID != 1 || n.Lat != 1 || n.Lon != 2 || n.Version != 2 || n.ChangesetID != 3 || n.UserID != 4 ||
+		n.User != "u" || !n.Visible || !n.Timestamp.Equal(info.Timestamp) {
+		t.Errorf("unexpected node %+v", n)
+	}
+	if expected := (osm.Tags{{Key: "a", Value: "1"}, {Key: "b", Value: "2"}}); !reflect.DeepEqual(expected, n.Tags) {
+		t.Errorf("unexpected tags %v", n.Tags)
+	}
+
+	w := Way(&osmpbf.Way{ID: 10, NodeIDs: []int64{1, 2}, NodeLocations: []osmpbf.LatLon{{Lat: 1, Lon: 2}, {Lat: 3, Lon: 4}},
+		TagList: osmpbf.TagList{{Key: "z", Value: "1"}, {Key: "a", Value: "2"}}})
+	expectedNodes := osm.WayNodes{{ID: 1, Lat: 1, Lon: 2}, {ID: 2, Lat: 3, Lon: 4}}
+	if w.ID != 10 || !reflect.DeepEqual(expectedNodes, w.Nodes) || w.Tags[0].Key != "z" {
+		t.Errorf("unexpected way %+v", w)
+	}
+
+	r := Relation(&osmpbf.Relation{ID: 20, Members: []osmpbf.Member{{ID: 10, Type: osmpbf.WayType, Role: "outer"}}})
+	expectedMembers := osm.Members{{Type: osm.TypeWay, Ref: 10, Role: "outer"}}
+	if r.ID != 20 || !reflect.DeepEqual(expectedMembers, r.Members) || r.Tags != nil {
+		t.Errorf("unexpected relation %+v", r)
+	}
+}
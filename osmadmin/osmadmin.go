@@ -0,0 +1,256 @@
+// Package osmadmin extracts administrative boundaries from input decoded by package osmpbf:
+// boundary=administrative relations are assembled into multipolygons and written with their
+// admin_level and names as GeoJSON or WKB, without osmium and ogr2ogr.
+package osmadmin
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/brechtbm/osmpbf"
+	"github.com/brechtbm/osmpbf/geojson"
+)
+
+// Boundary is an administrative boundary assembled from relation.
+type Boundary struct {
+	Area *osmpbf.Area
+
+	// AdminLevel is value of admin_level tag, zero if it is missing or not a number.
+	AdminLevel int
+
+	// Name is value of name tag, and Names are localized names by language of name:<lang> tags.
+	Name  string
+	Names map[string]string
+}
+
+// IsBoundary returns true if relation is boundary or multipolygon relation with
+// boundary=administrative tag.
+func IsBoundary(r *osmpbf.Relation) bool {
+	boundary, ok := r.Tags["boundary"]
+	if !ok {
+		boundary = r.TagList.Value("boundary")
+	}
+	return boundary == "administrative" && osmpbf.IsAreaRelation(r)
+}
+
+// NewBoundary returns boundary of area with attributes from tags of its relation.
+func NewBoundary(area *osmpbf.Area) *Boundary {
+	tags := area.Relation.Tags
+	if len(tags) == 0 {
+		tags = area.Relation.TagList.Map()
+	}
+	b := &Boundary{Area: area, Name: tags["name"], Names: make(map[string]string)}
+	if level, err := strconv.Atoi(tags["admin_level"]); err == nil {
+		b.AdminLevel = level
+	}
+	for k, v := range tags {
+		if strings.HasPrefix(k, "name:") {
+			b.Names[k[len("name:"):]] = v
+		}
+	}
+	return b
+}
+
+// Extract reads input by two passes with new decoders returned by open, which are started by
+// Extract, like osmpbf.Extract: relations in the first one, and member ways with locations of
+// nodes put into store s in the second one. Input must be sorted by type. It calls fn with each
+// boundary, and returns errors of relations which are not assembled, see osmpbf.AreaAssembler.
+func Extract(open func() (*osmpbf.Decoder, error), s osmpbf.NodeLocationStore, fn func(b *Boundary) error) ([]*osmpbf.AreaError, error) {
+	a := osmpbf.NewAreaAssembler(s)
+	err := readPass(open, true, func(o osmpbf.Object) {
+		if r, ok := o.(*osmpbf.Relation); !ok || !IsBoundary(r) || !a.AddRelation(r) {
+			o.Release()
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	dec, err := open()
+	if err != nil {
+		return nil, err
+	}
+	defer dec.Close()
+	if err := dec.Start(0); err != nil {
+		return nil, err
+	}
+	if err := a.ReadWays(dec); err != nil {
+		return nil, err
+	}
+
+	err = a.Assemble(func(area *osmpbf.Area) error {
+		return fn(NewBoundary(area))
+	})
+	return a.Invalid(), err
+}
+
+// Reads all objects of a new decoder returned by open, skipping to relations if relationsOnly is set.
+func readPass(open func() (*osmpbf.Decoder, error), relationsOnly bool, fn func(o osmpbf.Object)) error {
+	dec, err := open()
+	if err != nil {
+		return err
+	}
+	defer dec.Close()
+	if relationsOnly {
+		dec.SkipToRelations()
+	}
+	if err := dec.Start(0); err != nil {
+		return err
+	}
+	for {
+		v, err := dec.Decode()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		fn(v.(osmpbf.Object))
+	}
+}
+
+// Feature returns GeoJSON feature of boundary with MultiPolygon geometry. Properties are
+// admin_level, name and name:<lang> tags.
+func (b *Boundary) Feature() *geojson.Feature {
+	coordinates := make([][][][]float64, len(b.Area.Polygons))
+	for i, polygon := range b.Area.Polygons {
+		coordinates[i] = make([][][]float64, len(polygon))
+		for j, ring := range polygon {
+			coordinates[i][j] = make([][]float64, len(ring))
+			for k, l := range ring {
+				coordinates[i][j][k] = []float64{l.Lon, l.Lat}
+			}
+		}
+	}
+
+	properties := make(map[string]string)
+	if b.Name != "" {
+		properties["name"] = b.Name
+	}
+	if b.AdminLevel != 0 {
+		properties["admin_level"] = strconv.Itoa(b.AdminLevel)
+	}
+	for lang, name := range b.Names {
+		properties["name:"+lang] = name
+	}
+	return &geojson.Feature{
+		Type:       "Feature",
+		ID:         b.Area.Relation.ElementID().String(),
+		Geometry:   &geojson.Geometry{Type: "MultiPolygon", Coordinates: coordinates},
+		Properties: properties,
+	}
+}
+
+// WKB returns geometry of boundary as little endian WKB MultiPolygon.
+func (b *Boundary) WKB() []byte {
+	data := appendUint32([]byte{1}, 6)
+	data = appendUint32(data, uint32(len(b.Area.Polygons)))
+	for _, polygon := range b.Area.Polygons {
+		data = appendUint32(append(data, 1), 3)
+		data = appendUint32(data, uint32(len(polygon)))
+		for _, ring := range polygon {
+			data = appendUint32(data, uint32(len(ring)))
+			for _, l := range ring {
+				var buf [16]byte
+				binary.LittleEndian.PutUint64(buf[:8], math.Float64bits(l.Lon))
+				binary.LittleEndian.PutUint64(buf[8:], math.Float64bits(l.Lat))
+				data = append(data, buf[:]...)
+			}
+		}
+	}
+	return data
+}
+
+func appendUint32(b []byte, v uint32) []byte {
+	var buf [4]byte
+	binary.LittleEndian.PutUint32(buf[:], v)
+	return append(b, buf[:]...)
+}
+
+// Format is output format of Encoder.
+type Format int
+
+const (
+	// GeoJSON is FeatureCollection of features returned by Boundary.Feature.
+	GeoJSON Format = iota
+
+	// WKB is tab-separated lines of relation ID, admin_level, name and hex encoded
+	// Boundary.WKB, which can be loaded by PostgreSQL COPY or GDAL.
+	WKB
+)
+
+// ParseFormat returns format by its name: geojson or wkb.
+func ParseFormat(s string) (Format, error) {
+	switch strings.ToLower(s) {
+	case "geojson":
+		return GeoJSON, nil
+	case "wkb":
+		return WKB, nil
+	}
+	return 0, fmt.Errorf("unknown format %q", s)
+}
+
+// An Encoder writes boundaries to an output stream.
+type Encoder struct {
+	w       *bufio.Writer
+	format  Format
+	started bool
+}
+
+// NewEncoder returns a new encoder that writes boundaries in format to w.
+func NewEncoder(w io.Writer, format Format) *Encoder {
+	return &Encoder{w: bufio.NewWriter(w), format: format}
+}
+
+// Encode writes boundary. Output is buffered, so Close must be called to write remaining data.
+func (enc *Encoder) Encode(b *Boundary) error {
+	if enc.format == WKB {
+		enc.w.WriteString(strconv.FormatInt(b.Area.Relation.ID, 10))
+		enc.w.WriteByte('\t')
+		enc.w.WriteString(strconv.Itoa(b.AdminLevel))
+		enc.w.WriteByte('\t')
+		enc.w.WriteString(escaper.Replace(b.Name))
+		enc.w.WriteByte('\t')
+		enc.w.WriteString(strings.ToUpper(hex.EncodeToString(b.WKB())))
+		_, err := enc.w.WriteString("\n")
+		return err
+	}
+
+	data, err := json.Marshal(b.Feature())
+	if err != nil {
+		return err
+	}
+	if enc.started {
+		enc.w.WriteString(",\n")
+	} else {
+		enc.writeStart()
+	}
+	_, err = enc.w.Write(data)
+	return err
+}
+
+// Close writes the end of FeatureCollection and flushes buffered data. It does not close the
+// underlying writer.
+func (enc *Encoder) Close() error {
+	if enc.format == GeoJSON {
+		if !enc.started {
+			enc.writeStart()
+		}
+		enc.w.WriteString("\n]}\n")
+	}
+	return enc.w.Flush()
+}
+
+func (enc *Encoder) writeStart() {
+	enc.started = true
+	enc.w.WriteString(`{"type":"FeatureCollection","features":[` + "\n")
+}
+
+// Escapes names in WKB format, like COPY text format.
+var escaper = strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`)
@@ -0,0 +1,129 @@
+package osmadmin
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/brechtbm/osmpbf"
+)
+
+func testData(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	enc := osmpbf.NewEncoder(&buf)
+	for _, o := range []osmpbf.Object{
+		&osmpbf.Node{ID: 1, Lat: 0, Lon: 0}, &osmpbf.Node{ID: 2, Lat: 0, Lon: 1},
+		&osmpbf.Node{ID: 3, Lat: 1, Lon: 1}, &osmpbf.Node{ID: 4, Lat: 1, Lon: 0},
+		&osmpbf.Way{ID: 10, NodeIDs: []int64{1, 2, 3}},
+		&osmpbf.Way{ID: 11, NodeIDs: []int64{3, 4, 1}},
+		&osmpbf.Relation{ID: 20, Tags: map[string]string{"type": "boundary", "boundary": "administrative",
+			"admin_level": "8", "name": "Town", "name:fr": "Ville"},
+			Members: []osmpbf.Member{{ID: 10, Type: osmpbf.WayType, Role: "outer"}, {ID: 11, Type: osmpbf.WayType, Role: "outer"}}},
+		&osmpbf.Relation{ID: 21, Tags: map[string]string{"type": "multipolygon", "landuse": "forest"},
+			Members: []osmpbf.Member{{ID: 10, Type: osmpbf.WayType}, {ID: 11, Type: osmpbf.WayType}}},
+		&osmpbf.Relation{ID: 22, Tags: map[string]string{"type": "boundary", "boundary": "administrative"},
+			Members: []osmpbf.Member{{ID: 12, Type: osmpbf.WayType}}},
+	} {
+		if err := enc.Encode(o); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestExtract(t *testing.T) {
+	data := testData(t)
+	open := func() (*osmpbf.Decoder, error) {
+		return osmpbf.NewDecoder(bytes.NewReader(data)), nil
+	}
+
+	var boundaries []*Boundary
+	invalid, err := Extract(open, osmpbf.NewSparseLocationStore(), func(b *Boundary) error {
+		boundaries = append(boundaries, b)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(invalid) != 1 || invalid[0].RelationID != 22 {
+		t.Errorf("unexpected invalid relations %v", invalid)
+	}
+	if len(boundaries) != 1 {
+		t.Fatalf("expected one boundary, got %d", len(boundaries))
+	}
+	b := boundaries[0]
+	if b.Area.Relation.ID != 20 || b.AdminLevel != 8 || b.Name != "Town" || !reflect.DeepEqual(b.Names, map[string]string{"fr": "Ville"}) {
+		t.Errorf("unexpected boundary %+v", b)
+	}
+
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf, GeoJSON)
+	if err := enc.Encode(b); err != nil {
+		t.Fatal(err)
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatal(err)
+	}
+	var fc struct {
+		Features []struct {
+			ID       string
+			Geometry struct {
+				Type        string
+				Coordinates [][][][]float64
+			}
+			Properties map[string]string
+		}
+	}
+	if err := json.Unmarshal(buf.Bytes(), &fc); err != nil {
+		t.Fatal(err)
+	}
+	if len(fc.Features) != 1 {
+		t.Fatalf("unexpected output %s", buf.String())
+	}
+	f := fc.Features[0]
+	expectedProperties := map[string]string{"admin_level": "8", "name": "Town", "name:fr": "Ville"}
+	if f.ID != "relation/20" || f.Geometry.Type != "MultiPolygon" || !reflect.DeepEqual(f.Properties, expectedProperties) {
+		t.Errorf("unexpected feature %s", buf.String())
+	}
+	if c := f.Geometry.Coordinates; len(c) != 1 || len(c[0]) != 1 || len(c[0][0]) != 5 {
+		t.Errorf("unexpected coordinates %v", c)
+	}
+
+	buf.Reset()
+	b.Name = "Town\tCenter"
+	enc = NewEncoder(&buf, WKB)
+	if err := enc.Encode(b); err != nil {
+		t.Fatal(err)
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatal(err)
+	}
+	fields := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\t")
+	if len(fields) != 4 || fields[0] != "20" || fields[1] != "8" || fields[2] != `Town\tCenter` {
+		t.Fatalf("unexpected row %q", buf.String())
+	}
+	// MultiPolygon of one polygon with one ring of 5 points
+	wkb, err := hex.DecodeString(fields[3])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(wkb) != 9+9+4+5*16 || !bytes.HasPrefix(wkb, []byte{1, 6, 0, 0, 0, 1, 0, 0, 0, 1, 3, 0, 0, 0, 1, 0, 0, 0, 5, 0, 0, 0}) {
+		t.Errorf("unexpected WKB %x", wkb)
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	if f, err := ParseFormat("WKB"); f != WKB || err != nil {
+		t.Errorf("unexpected format %v, %v", f, err)
+	}
+	if _, err := ParseFormat("shp"); err == nil {
+		t.Error("expected error")
+	}
+}
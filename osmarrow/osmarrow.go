@@ -0,0 +1,219 @@
+// Package osmarrow writes objects decoded by package osmpbf as Apache Arrow record batches, in Arrow
+// IPC file format or as Parquet files, for analytics by tools like DuckDB or Spark.
+//
+// Nodes, ways and relations have separate schemas, so each kind is written by its own Writer. Tags
+// are a map column; Info metadata columns are included in all schemas.
+package osmarrow
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/apache/arrow/go/v14/arrow"
+	"github.com/apache/arrow/go/v14/arrow/array"
+	"github.com/apache/arrow/go/v14/arrow/ipc"
+	"github.com/apache/arrow/go/v14/arrow/memory"
+	"github.com/apache/arrow/go/v14/parquet"
+	"github.com/apache/arrow/go/v14/parquet/pqarrow"
+	"github.com/brechtbm/osmpbf"
+)
+
+// Format is output file format.
+type Format int
+
+const (
+	// IPC is Arrow IPC file format, also known as Feather version 2.
+	IPC Format = iota
+
+	// Parquet is Apache Parquet file format.
+	Parquet
+)
+
+// BatchSize is number of rows of written record batches.
+const BatchSize = 64 * 1024
+
+var (
+	tagsType = arrow.MapOf(arrow.BinaryTypes.String, arrow.BinaryTypes.String)
+
+	// columns following kind specific ones
+	infoFields = []arrow.Field{
+		{Name: "tags", Type: tagsType},
+		{Name: "version", Type: arrow.PrimitiveTypes.Int32},
+		{Name: "timestamp", Type: arrow.FixedWidthTypes.Timestamp_ms, Nullable: true},
+		{Name: "changeset", Type: arrow.PrimitiveTypes.Int64},
+		{Name: "uid", Type: arrow.PrimitiveTypes.Int32},
+		{Name: "user", Type: arrow.BinaryTypes.String},
+		{Name: "visible", Type: arrow.FixedWidthTypes.Boolean},
+	}
+
+	memberType = arrow.StructOf(
+		arrow.Field{Name: "type", Type: arrow.BinaryTypes.String},
+		arrow.Field{Name: "ref", Type: arrow.PrimitiveTypes.Int64},
+		arrow.Field{Name: "role", Type: arrow.BinaryTypes.String},
+	)
+
+	// NodeSchema is schema of nodes: id, lat, lon, then tags and Info columns.
+	NodeSchema = newSchema(
+		arrow.Field{Name: "id", Type: arrow.PrimitiveTypes.Int64},
+		arrow.Field{Name: "lat", Type: arrow.PrimitiveTypes.Float64},
+		arrow.Field{Name: "lon", Type: arrow.PrimitiveTypes.Float64},
+	)
+
+	// WaySchema is schema of ways: id, node_ids, then tags and Info columns.
+	WaySchema = newSchema(
+		arrow.Field{Name: "id", Type: arrow.PrimitiveTypes.Int64},
+		arrow.Field{Name: "node_ids", Type: arrow.ListOf(arrow.PrimitiveTypes.Int64)},
+	)
+
+	// RelationSchema is schema of relations: id, members with type, ref and role, then tags and
+	// Info columns.
+	RelationSchema = newSchema(
+		arrow.Field{Name: "id", Type: arrow.PrimitiveTypes.Int64},
+		arrow.Field{Name: "members", Type: arrow.ListOf(memberType)},
+	)
+)
+
+func newSchema(fields ...arrow.Field) *arrow.Schema {
+	return arrow.NewSchema(append(fields, infoFields...), nil)
+}
+
+// Writes records to a file of one format.
+type recordWriter interface {
+	Write(rec arrow.Record) error
+	Close() error
+}
+
+// A Writer writes objects of one kind as record batches to an output stream.
+type Writer struct {
+	kind osmpbf.Kind
+	w    recordWriter
+	b    *array.RecordBuilder
+	rows int
+}
+
+// NewWriter returns a new writer of objects of kind k, which must be NodeKind, WayKind or
+// RelationKind, to w in given format.
+func NewWriter(w io.Writer, k osmpbf.Kind, format Format) (*Writer, error) {
+	var schema *arrow.Schema
+	switch k {
+	case osmpbf.NodeKind:
+		schema = NodeSchema
+	case osmpbf.WayKind:
+		schema = WaySchema
+	case osmpbf.RelationKind:
+		schema = RelationSchema
+	default:
+		return nil, fmt.Errorf("unsupported kind %v", k)
+	}
+
+	mem := memory.NewGoAllocator()
+	var rw recordWriter
+	var err error
+	switch format {
+	case IPC:
+		rw, err = ipc.NewFileWriter(w, ipc.WithSchema(schema), ipc.WithAllocator(mem))
+	case Parquet:
+		rw, err = pqarrow.NewFileWriter(schema, w, parquet.NewWriterProperties(), pqarrow.DefaultWriterProps())
+	default:
+		return nil, fmt.Errorf("unknown format %d", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &Writer{kind: k, w: rw, b: array.NewRecordBuilder(mem, schema)}, nil
+}
+
+// Write adds object to the current record batch, which is written when it has BatchSize rows.
+// Objects of other kinds than kind of the writer are skipped.
+func (w *Writer) Write(o osmpbf.Object) error {
+	if o.Kind() != w.kind {
+		return nil
+	}
+
+	var info *osmpbf.Info
+	var tags map[string]string
+	var list osmpbf.TagList
+	switch o := o.(type) {
+	case *osmpbf.Node:
+		w.b.Field(0).(*array.Int64Builder).Append(o.ID)
+		w.b.Field(1).(*array.Float64Builder).Append(o.Lat)
+		w.b.Field(2).(*array.Float64Builder).Append(o.Lon)
+		info, tags, list = &o.Info, o.Tags, o.TagList
+	case *osmpbf.Way:
+		w.b.Field(0).(*array.Int64Builder).Append(o.ID)
+		lb := w.b.Field(1).(*array.ListBuilder)
+		lb.Append(true)
+		vb := lb.ValueBuilder().(*array.Int64Builder)
+		for _, id := range o.NodeIDs {
+			vb.Append(id)
+		}
+		info, tags, list = &o.Info, o.Tags, o.TagList
+	case *osmpbf.Relation:
+		w.b.Field(0).(*array.Int64Builder).Append(o.ID)
+		lb := w.b.Field(1).(*array.ListBuilder)
+		lb.Append(true)
+		sb := lb.ValueBuilder().(*array.StructBuilder)
+		for _, m := range o.Members {
+			sb.Append(true)
+			sb.FieldBuilder(0).(*array.StringBuilder).Append(m.Type.Kind().String())
+			sb.FieldBuilder(1).(*array.Int64Builder).Append(m.ID)
+			sb.FieldBuilder(2).(*array.StringBuilder).Append(m.Role)
+		}
+		info, tags, list = &o.Info, o.Tags, o.TagList
+	}
+	w.appendInfo(len(w.b.Schema().Fields())-len(infoFields), info, tags, list)
+
+	w.rows++
+	if w.rows == BatchSize {
+		return w.flush()
+	}
+	return nil
+}
+
+// Appends tags and Info to columns starting at i.
+func (w *Writer) appendInfo(i int, info *osmpbf.Info, tags map[string]string, list osmpbf.TagList) {
+	mb := w.b.Field(i).(*array.MapBuilder)
+	mb.Append(true)
+	kb := mb.KeyBuilder().(*array.StringBuilder)
+	ib := mb.ItemBuilder().(*array.StringBuilder)
+	for k, v := range tags {
+		kb.Append(k)
+		ib.Append(v)
+	}
+	for _, tag := range list {
+		kb.Append(tag.Key)
+		ib.Append(tag.Value)
+	}
+
+	w.b.Field(i + 1).(*array.Int32Builder).Append(int32(info.Version))
+	if tb := w.b.Field(i + 2).(*array.TimestampBuilder); info.Timestamp.IsZero() {
+		tb.AppendNull()
+	} else {
+		tb.Append(arrow.Timestamp(info.Timestamp.UnixNano() / 1e6))
+	}
+	w.b.Field(i + 3).(*array.Int64Builder).Append(int64(info.Changeset))
+	w.b.Field(i + 4).(*array.Int32Builder).Append(info.Uid)
+	w.b.Field(i + 5).(*array.StringBuilder).Append(info.User)
+	w.b.Field(i + 6).(*array.BooleanBuilder).Append(info.Visible)
+}
+
+// Writes the current record batch if it is not empty.
+func (w *Writer) flush() error {
+	if w.rows == 0 {
+		return nil
+	}
+	rec := w.b.NewRecord()
+	defer rec.Release()
+	w.rows = 0
+	return w.w.Write(rec)
+}
+
+// Close writes the last record batch and the end of file. It does not close the underlying writer.
+func (w *Writer) Close() error {
+	defer w.b.Release()
+	if err := w.flush(); err != nil {
+		w.w.Close()
+		return err
+	}
+	return w.w.Close()
+}
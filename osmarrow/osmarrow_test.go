@@ -0,0 +1,59 @@
+package osmarrow
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/apache/arrow/go/v14/arrow/array"
+	"github.com/apache/arrow/go/v14/arrow/ipc"
+	"github.com/brechtbm/osmpbf"
+)
+
+func TestWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, osmpbf.WayKind, IPC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	objects := []osmpbf.Object{
+		&osmpbf.Node{ID: 1},
+		&osmpbf.Way{ID: 10, NodeIDs: []int64{1, 2}, Tags: map[string]string{"highway": "primary"}},
+		&osmpbf.Way{ID: 11, NodeIDs: []int64{2, 3, 4}},
+		&osmpbf.Relation{ID: 20},
+	}
+	for _, o := range objects {
+		if err := w.Write(o); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := ipc.NewFileReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if r.NumRecords() != 1 {
+		t.Fatalf("expected 1 record batch, got %d", r.NumRecords())
+	}
+	rec, err := r.Record(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.NumRows() != 2 {
+		t.Fatalf("expected 2 ways, got %d", rec.NumRows())
+	}
+	ids := rec.Column(0).(*array.Int64)
+	if ids.Value(0) != 10 || ids.Value(1) != 11 {
+		t.Errorf("unexpected IDs %d, %d", ids.Value(0), ids.Value(1))
+	}
+	if start, end := rec.Column(1).(*array.List).ValueOffsets(1); end-start != 3 {
+		t.Errorf("expected 3 nodes of second way, got %d", end-start)
+	}
+
+	if _, err := NewWriter(&buf, osmpbf.BoundKind, Parquet); err == nil {
+		t.Error("expected error of unsupported kind")
+	}
+}
@@ -0,0 +1,184 @@
+// Package osmcsv writes objects decoded by package osmpbf as CSV or TSV rows with configurable
+// columns, for example to load them by PostgreSQL COPY with CSV format or into data analysis tools.
+//
+// Columns are named like in osmium export: names starting with @ are object attributes, other names
+// are tag keys.
+package osmcsv
+
+import (
+	"compress/gzip"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/brechtbm/osmpbf"
+)
+
+// Attribute columns. Locations are empty for ways and relations.
+const (
+	ID        = "@id"
+	Type      = "@type" // node, way or relation
+	Lat       = "@lat"
+	Lon       = "@lon"
+	Version   = "@version"
+	Timestamp = "@timestamp" // in RFC 3339 format
+	Changeset = "@changeset"
+	Uid       = "@uid"
+	User      = "@user"
+)
+
+// DefaultColumns are columns used if none are given to NewEncoder.
+var DefaultColumns = []string{ID, Type, Lat, Lon}
+
+// An Encoder writes objects as CSV rows to an output stream.
+type Encoder struct {
+	w       io.Writer
+	columns []string
+
+	comma  rune
+	header bool
+	level  int // of gzip compression, not compressed if zero
+
+	csv     *csv.Writer
+	gzip    *gzip.Writer
+	started bool
+	row     []string
+}
+
+// NewEncoder returns a new encoder that writes rows with given columns to w. It returns error
+// for unknown attribute columns.
+func NewEncoder(w io.Writer, columns ...string) (*Encoder, error) {
+	if len(columns) == 0 {
+		columns = DefaultColumns
+	}
+	for _, c := range columns {
+		if len(c) > 0 && c[0] == '@' {
+			switch c {
+			case ID, Type, Lat, Lon, Version, Timestamp, Changeset, Uid, User:
+			default:
+				return nil, fmt.Errorf("unknown column %s", c)
+			}
+		}
+	}
+	return &Encoder{w: w, columns: columns, comma: ',', row: make([]string, len(columns))}, nil
+}
+
+// SetComma sets field delimiter, default is comma; use '\t' for TSV. Must be called before Encode.
+func (enc *Encoder) SetComma(c rune) {
+	enc.comma = c
+}
+
+// SetHeader sets whether the first row contains column names. Must be called before Encode.
+func (enc *Encoder) SetHeader(header bool) {
+	enc.header = header
+}
+
+// SetGzip sets whether output is compressed by gzip with given compression level, for example
+// gzip.DefaultCompression. Level 0 disables compression. Must be called before Encode.
+func (enc *Encoder) SetGzip(level int) error {
+	if level != 0 {
+		if _, err := gzip.NewWriterLevel(nil, level); err != nil {
+			return err
+		}
+	}
+	enc.level = level
+	return nil
+}
+
+// Encode writes row of a pointer to Node, Way or Relation struct. Bound is skipped. Output is
+// buffered, so Close must be called to write remaining data.
+func (enc *Encoder) Encode(v interface{}) error {
+	enc.start()
+	if _, ok := v.(*osmpbf.Bound); ok {
+		return nil
+	}
+
+	var kind osmpbf.Kind
+	var id int64
+	var location *osmpbf.LatLon
+	var info *osmpbf.Info
+	var tags map[string]string
+	var list osmpbf.TagList
+	switch o := v.(type) {
+	case *osmpbf.Node:
+		kind, id, location, info, tags, list = osmpbf.NodeKind, o.ID, &osmpbf.LatLon{Lat: o.Lat, Lon: o.Lon}, &o.Info, o.Tags, o.TagList
+	case *osmpbf.Way:
+		kind, id, info, tags, list = osmpbf.WayKind, o.ID, &o.Info, o.Tags, o.TagList
+	case *osmpbf.Relation:
+		kind, id, info, tags, list = osmpbf.RelationKind, o.ID, &o.Info, o.Tags, o.TagList
+	default:
+		return fmt.Errorf("unknown type %T", v)
+	}
+
+	for i, c := range enc.columns {
+		var value string
+		switch c {
+		case ID:
+			value = strconv.FormatInt(id, 10)
+		case Type:
+			value = kind.String()
+		case Lat:
+			if location != nil {
+				value = strconv.FormatFloat(location.Lat, 'f', -1, 64)
+			}
+		case Lon:
+			if location != nil {
+				value = strconv.FormatFloat(location.Lon, 'f', -1, 64)
+			}
+		case Version:
+			value = strconv.Itoa(int(info.Version))
+		case Timestamp:
+			if !info.Timestamp.IsZero() {
+				value = info.Timestamp.UTC().Format(time.RFC3339)
+			}
+		case Changeset:
+			value = strconv.FormatUint(info.Changeset, 10)
+		case Uid:
+			value = strconv.FormatInt(int64(info.Uid), 10)
+		case User:
+			value = info.User
+		default:
+			if tags != nil {
+				value = tags[c]
+			} else {
+				value = list.Value(c)
+			}
+		}
+		enc.row[i] = value
+	}
+	return enc.csv.Write(enc.row)
+}
+
+// Close flushes buffered data and finishes gzip stream. It does not close the underlying writer.
+// Header row is written if no objects were encoded.
+func (enc *Encoder) Close() error {
+	enc.start()
+	enc.csv.Flush()
+	if err := enc.csv.Error(); err != nil {
+		return err
+	}
+	if enc.gzip != nil {
+		return enc.gzip.Close()
+	}
+	return nil
+}
+
+// Creates writers and writes header row on first call.
+func (enc *Encoder) start() {
+	if enc.started {
+		return
+	}
+	enc.started = true
+	w := enc.w
+	if enc.level != 0 {
+		enc.gzip, _ = gzip.NewWriterLevel(w, enc.level) // level is checked by SetGzip
+		w = enc.gzip
+	}
+	enc.csv = csv.NewWriter(w)
+	enc.csv.Comma = enc.comma
+	if enc.header {
+		enc.csv.Write(enc.columns)
+	}
+}
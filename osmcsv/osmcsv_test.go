@@ -0,0 +1,83 @@
+package osmcsv
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/brechtbm/osmpbf"
+)
+
+func TestEncoder(t *testing.T) {
+	info := osmpbf.Info{Version: 2, Timestamp: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC), Changeset: 7, Uid: 8, User: "a, \"b\""}
+	objects := []interface{}{
+		&osmpbf.Bound{},
+		&osmpbf.Node{ID: 1, Lat: 51.5, Lon: -0.125, Tags: map[string]string{"name": "Pub, \"The\""}, Info: info},
+		&osmpbf.Way{ID: 10, TagList: osmpbf.TagList{{Key: "name", Value: "Main\nStreet"}, {Key: "highway", Value: "primary"}}},
+		&osmpbf.Relation{ID: 20},
+	}
+
+	var buf bytes.Buffer
+	enc, err := NewEncoder(&buf, ID, Type, Lat, Lon, Version, Timestamp, Changeset, Uid, User, "name", "highway")
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc.SetHeader(true)
+	for _, o := range objects {
+		if err := enc.Encode(o); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatal(err)
+	}
+	expected := `@id,@type,@lat,@lon,@version,@timestamp,@changeset,@uid,@user,name,highway
+1,node,51.5,-0.125,2,2020-01-02T03:04:05Z,7,8,"a, ""b""","Pub, ""The""",
+10,way,,,0,,0,0,,"Main
+Street",primary
+20,relation,,,0,,0,0,,,
+`
+	if buf.String() != expected {
+		t.Errorf("\nExpected: %s\nActual:   %s", expected, buf.String())
+	}
+
+	if _, err := NewEncoder(&buf, "@unknown"); err == nil {
+		t.Error("expected error of unknown column")
+	}
+}
+
+func TestEncoderGzip(t *testing.T) {
+	var buf bytes.Buffer
+	enc, err := NewEncoder(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc.SetComma('\t')
+	if err := enc.SetGzip(gzip.BestSpeed); err != nil {
+		t.Fatal(err)
+	}
+	if err := enc.Encode(&osmpbf.Node{ID: 1, Lat: 1, Lon: 2}); err != nil {
+		t.Fatal(err)
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := gzip.NewReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := string(data); s != "1\tnode\t1\t2\n" {
+		t.Errorf("unexpected output %q", s)
+	}
+
+	if err := enc.SetGzip(100); err == nil {
+		t.Error("expected error of invalid level")
+	}
+}
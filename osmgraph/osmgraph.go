@@ -0,0 +1,274 @@
+// Package osmgraph builds a routing graph of the highway network of objects decoded by package
+// osmpbf: highway ways are split at junctions, nodes shared by several ways, into edges with
+// length, oneway and speed attributes derived from tags, so routing engines get a ready input.
+package osmgraph
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/brechtbm/osmpbf"
+)
+
+// DefaultSpeeds are speeds in km/h by highway tag value, used for ways without valid maxspeed tag.
+var DefaultSpeeds = map[string]float64{
+	"motorway":       120,
+	"motorway_link":  60,
+	"trunk":          100,
+	"trunk_link":     50,
+	"primary":        80,
+	"primary_link":   40,
+	"secondary":      70,
+	"secondary_link": 35,
+	"tertiary":       60,
+	"tertiary_link":  30,
+	"unclassified":   50,
+	"residential":    30,
+	"living_street":  10,
+	"service":        20,
+	"road":           40,
+	"track":          15,
+	"cycleway":       15,
+	"pedestrian":     5,
+	"footway":        5,
+	"path":           5,
+	"steps":          2,
+}
+
+// Mean radius of the Earth in meters.
+const earthRadius = 6371008.8
+
+// Edge is a part of highway way between two vertices, without other vertices inside.
+type Edge struct {
+	From, To int64 // node IDs of vertices
+	Way      int64
+
+	Length float64 // in meters
+	Speed  float64 // in km/h
+	Oneway bool    // edge can be traversed only from From to To
+
+	Geometry []osmpbf.LatLon // locations of nodes from From to To
+}
+
+// Graph is a routing graph of highway network. Vertices are junctions and end nodes of ways.
+type Graph struct {
+	Vertices map[int64]osmpbf.LatLon
+	Edges    []Edge
+
+	// Dropped is number of highway ways dropped, because locations of their nodes are unknown.
+	Dropped int
+}
+
+// Highway way kept until all ways are read, so junctions are known.
+type way struct {
+	id        int64
+	nodeIDs   []int64
+	locations []osmpbf.LatLon
+	oneway    int // 1 forward, -1 backward, 0 both directions
+	speed     float64
+}
+
+// Build reads objects from started decoder until the end of input stream and returns graph of
+// ways with highway tag value in speeds, DefaultSpeeds if nil. Locations of nodes are put into
+// store s and ways get them like in GeometryBuilder.Build, so input must be sorted by type. Ways
+// with nodes of unknown location are dropped. Ways are kept in memory until the end of input.
+func Build(dec *osmpbf.Decoder, s osmpbf.NodeLocationStore, speeds map[string]float64) (*Graph, error) {
+	if speeds == nil {
+		speeds = DefaultSpeeds
+	}
+	g := &Graph{Vertices: make(map[int64]osmpbf.LatLon)}
+	uses := make(map[int64]int)
+	var ways []way
+	b := osmpbf.NewGeometryBuilder(s, osmpbf.DropIncompleteWays)
+	for {
+		v, err := dec.Decode()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		switch o := v.(type) {
+		case *osmpbf.Node:
+			if err := s.Set(o.ID, osmpbf.LatLon{Lat: o.Lat, Lon: o.Lon}); err != nil {
+				return nil, err
+			}
+		case *osmpbf.Way:
+			tags := osmpbf.Tags(o.Tags)
+			if len(tags) == 0 {
+				tags = o.TagList.Map()
+			}
+			speed, ok := speeds[tags["highway"]]
+			if !ok {
+				continue
+			}
+			if maxspeed, ok := parseSpeed(tags["maxspeed"]); ok {
+				speed = maxspeed
+			}
+			wg := b.Build(o)
+			if wg == nil {
+				g.Dropped++
+				continue
+			}
+
+			for i, id := range o.NodeIDs {
+				uses[id]++
+				if i == 0 || i == len(o.NodeIDs)-1 {
+					// end nodes are always vertices
+					uses[id]++
+				}
+			}
+			ways = append(ways, way{
+				id:        o.ID,
+				nodeIDs:   append([]int64(nil), o.NodeIDs...),
+				locations: append([]osmpbf.LatLon(nil), wg.Parts[0]...),
+				oneway:    oneway(tags),
+				speed:     speed,
+			})
+		}
+	}
+
+	for _, w := range ways {
+		g.split(w, uses)
+	}
+	return g, nil
+}
+
+// Adds edges of way w split at nodes used more than once.
+func (g *Graph) split(w way, uses map[int64]int) {
+	start := 0
+	length := 0.0
+	for i := 1; i < len(w.nodeIDs); i++ {
+		length += distance(w.locations[i-1], w.locations[i])
+		if uses[w.nodeIDs[i]] < 2 {
+			continue
+		}
+
+		e := Edge{
+			From:     w.nodeIDs[start],
+			To:       w.nodeIDs[i],
+			Way:      w.id,
+			Length:   length,
+			Speed:    w.speed,
+			Oneway:   w.oneway != 0,
+			Geometry: w.locations[start : i+1],
+		}
+		if w.oneway < 0 {
+			e.From, e.To = e.To, e.From
+			e.Geometry = reversed(e.Geometry)
+		}
+		g.Vertices[w.nodeIDs[start]] = w.locations[start]
+		g.Vertices[w.nodeIDs[i]] = w.locations[i]
+		g.Edges = append(g.Edges, e)
+		start, length = i, 0
+	}
+}
+
+// Returns direction of oneway way: 1 forward, -1 backward, 0 both directions. Motorways and
+// roundabouts are oneway, unless tagged otherwise.
+func oneway(tags osmpbf.Tags) int {
+	switch tags["oneway"] {
+	case "yes", "true", "1":
+		return 1
+	case "-1", "reverse":
+		return -1
+	case "no", "false", "0":
+		return 0
+	}
+	if tags["highway"] == "motorway" || tags["junction"] == "roundabout" || tags["junction"] == "circular" {
+		return 1
+	}
+	return 0
+}
+
+// Returns speed in km/h of maxspeed tag value like "50" or "30 mph", false if it is not a number.
+func parseSpeed(s string) (float64, bool) {
+	factor := 1.0
+	if strings.HasSuffix(s, "mph") {
+		s, factor = strings.TrimSuffix(s, "mph"), 1.609344
+	}
+	v, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil || v <= 0 {
+		return 0, false
+	}
+	return v * factor, true
+}
+
+// Returns great-circle distance between a and b in meters.
+func distance(a, b osmpbf.LatLon) float64 {
+	lat1, lat2 := a.Lat*math.Pi/180, b.Lat*math.Pi/180
+	dLat, dLon := lat2-lat1, (b.Lon-a.Lon)*math.Pi/180
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(h))
+}
+
+func reversed(l []osmpbf.LatLon) []osmpbf.LatLon {
+	r := make([]osmpbf.LatLon, len(l))
+	for i, v := range l {
+		r[len(l)-1-i] = v
+	}
+	return r
+}
+
+// Arc is an edge of graph leaving a vertex, in adjacency list written by Graph.Write.
+type Arc struct {
+	To     int64   `json:"to"`
+	Way    int64   `json:"way"`
+	Length float64 `json:"length"`
+	Speed  float64 `json:"speed"`
+}
+
+// Vertex is a line of adjacency list written by Graph.Write.
+type Vertex struct {
+	ID   int64   `json:"id"`
+	Lat  float64 `json:"lat"`
+	Lon  float64 `json:"lon"`
+	Arcs []Arc   `json:"arcs"`
+}
+
+// Adjacency returns edges leaving each vertex by their indexes in Edges. Edges which are not
+// oneway leave both of their vertices.
+func (g *Graph) Adjacency() map[int64][]int {
+	adj := make(map[int64][]int, len(g.Vertices))
+	for i, e := range g.Edges {
+		adj[e.From] = append(adj[e.From], i)
+		if !e.Oneway && e.To != e.From {
+			adj[e.To] = append(adj[e.To], i)
+		}
+	}
+	return adj
+}
+
+// Write writes graph as adjacency list to w: one JSON Vertex per line, ordered by ID.
+func (g *Graph) Write(w io.Writer) error {
+	ids := make([]int64, 0, len(g.Vertices))
+	for id := range g.Vertices {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	adj := g.Adjacency()
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	for _, id := range ids {
+		l := g.Vertices[id]
+		v := Vertex{ID: id, Lat: l.Lat, Lon: l.Lon, Arcs: []Arc{}}
+		for _, i := range adj[id] {
+			e := g.Edges[i]
+			to := e.To
+			if e.To == id {
+				to = e.From
+			}
+			v.Arcs = append(v.Arcs, Arc{To: to, Way: e.Way, Length: e.Length, Speed: e.Speed})
+		}
+		if err := enc.Encode(&v); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
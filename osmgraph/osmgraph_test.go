@@ -0,0 +1,105 @@
+package osmgraph
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/brechtbm/osmpbf"
+)
+
+func decoder(t *testing.T, objects ...osmpbf.Object) *osmpbf.Decoder {
+	t.Helper()
+	var buf bytes.Buffer
+	enc := osmpbf.NewEncoder(&buf)
+	for _, o := range objects {
+		if err := enc.Encode(o); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatal(err)
+	}
+	dec := osmpbf.NewDecoder(&buf)
+	if err := dec.Start(1); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { dec.Close() })
+	return dec
+}
+
+func TestBuild(t *testing.T) {
+	dec := decoder(t,
+		&osmpbf.Node{ID: 1, Lon: 0}, &osmpbf.Node{ID: 2, Lon: 0.001}, &osmpbf.Node{ID: 3, Lon: 0.002},
+		&osmpbf.Node{ID: 4, Lat: 0.001, Lon: 0.001}, &osmpbf.Node{ID: 5, Lat: 0.001, Lon: 0.002},
+		&osmpbf.Way{ID: 10, NodeIDs: []int64{1, 2, 3}, Tags: map[string]string{"highway": "residential"}},
+		&osmpbf.Way{ID: 11, NodeIDs: []int64{4, 2}, Tags: map[string]string{"highway": "primary", "oneway": "-1", "maxspeed": "30 mph"}},
+		&osmpbf.Way{ID: 12, NodeIDs: []int64{3, 5}, Tags: map[string]string{"highway": "proposed"}},
+		&osmpbf.Way{ID: 13, NodeIDs: []int64{3, 6}, Tags: map[string]string{"highway": "service"}},
+		&osmpbf.Way{ID: 14, NodeIDs: []int64{1, 5}, Tags: map[string]string{"building": "yes"}},
+	)
+	g, err := Build(dec, osmpbf.NewSparseLocationStore(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(g.Vertices) != 4 || g.Dropped != 1 {
+		t.Errorf("unexpected vertices %v, dropped %d", g.Vertices, g.Dropped)
+	}
+	if len(g.Edges) != 3 {
+		t.Fatalf("expected 3 edges, got %#v", g.Edges)
+	}
+	for i, expected := range []struct {
+		from, to, way int64
+		speed         float64
+		oneway        bool
+	}{
+		{1, 2, 10, 30, false},
+		{2, 3, 10, 30, false},
+		{2, 4, 11, 30 * 1.609344, true},
+	} {
+		e := g.Edges[i]
+		if e.From != expected.from || e.To != expected.to || e.Way != expected.way || e.Speed != expected.speed || e.Oneway != expected.oneway {
+			t.Errorf("%d: unexpected edge %#v", i, e)
+		}
+		if math.Abs(e.Length-111.2) > 0.1 {
+			t.Errorf("%d: unexpected length %f", i, e.Length)
+		}
+	}
+	if expected := []osmpbf.LatLon{{Lat: 0, Lon: 0.001}, {Lat: 0.001, Lon: 0.001}}; !reflect.DeepEqual(g.Edges[2].Geometry, expected) {
+		t.Errorf("unexpected geometry %v", g.Edges[2].Geometry)
+	}
+
+	var buf bytes.Buffer
+	if err := g.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("expected 4 lines, got %q", lines)
+	}
+	var v Vertex
+	if err := json.Unmarshal([]byte(lines[1]), &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.ID != 2 || len(v.Arcs) != 3 || v.Arcs[0].To != 1 || v.Arcs[1].To != 3 || v.Arcs[2].To != 4 {
+		t.Errorf("unexpected vertex %s", lines[1])
+	}
+	if err := json.Unmarshal([]byte(lines[3]), &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.ID != 4 || len(v.Arcs) != 0 {
+		t.Errorf("unexpected vertex %s", lines[3])
+	}
+}
+
+func TestParseSpeed(t *testing.T) {
+	for s, expected := range map[string]float64{"50": 50, "20 mph": 20 * 1.609344, "none": 0, "": 0, "-5": 0} {
+		if v, _ := parseSpeed(s); v != expected {
+			t.Errorf("%q: expected %f, got %f", s, expected, v)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+// Package osmpoi extracts addresses and points of interest from objects decoded by package osmpbf
+// into flat records with a location and normalized tags, for example to build geocoder indexes.
+// Objects are selected by configurable presets; ways get the centroid of their nodes.
+package osmpoi
+
+import (
+	"io"
+	"math"
+	"strings"
+
+	"github.com/brechtbm/osmpbf"
+)
+
+// Preset selects objects by tags and tags kept in their records.
+type Preset struct {
+	// Name is stored in records of the preset, like "address" or "shop".
+	Name string
+
+	// Filter is a list of tag expressions of osmpbf.Tags.Match, objects matching any of them are
+	// selected.
+	Filter []string
+
+	// Keys of kept tags, a key ending with "*" keeps all keys with its prefix, like "addr:*".
+	// All tags are kept if empty.
+	Keys []string
+}
+
+// Keys of tags kept in records of POI presets.
+var poiKeys = []string{"name", "name:*", "addr:*", "opening_hours", "website", "phone", "wheelchair"}
+
+// Address and common POI presets.
+var (
+	Address   = Preset{"address", []string{"addr:housenumber"}, []string{"addr:*", "name"}}
+	Amenity   = Preset{"amenity", []string{"amenity"}, append([]string{"amenity", "cuisine"}, poiKeys...)}
+	Shop      = Preset{"shop", []string{"shop"}, append([]string{"shop", "brand"}, poiKeys...)}
+	Tourism   = Preset{"tourism", []string{"tourism"}, append([]string{"tourism", "stars"}, poiKeys...)}
+	Leisure   = Preset{"leisure", []string{"leisure=park,playground,sports_centre,stadium,swimming_pool"}, append([]string{"leisure", "sport"}, poiKeys...)}
+	Office    = Preset{"office", []string{"office"}, append([]string{"office"}, poiKeys...)}
+	Transport = Preset{"transport", []string{"railway=station,halt,tram_stop", "highway=bus_stop", "aeroway=aerodrome"},
+		append([]string{"railway", "highway", "aeroway", "ref"}, poiKeys...)}
+)
+
+// DefaultPresets are presets used if none are given to NewExtractor.
+var DefaultPresets = []Preset{Address, Amenity, Shop, Tourism, Leisure, Office, Transport}
+
+// Record is a flat record of object selected by preset.
+type Record struct {
+	ID     osmpbf.ElementID  `json:"id"`
+	Preset string            `json:"preset"`
+	Lat    float64           `json:"lat"`
+	Lon    float64           `json:"lon"`
+	Tags   map[string]string `json:"tags"`
+}
+
+// An Extractor returns records of nodes and ways selected by presets.
+type Extractor struct {
+	presets   []Preset
+	locations osmpbf.NodeLocationStore
+	geometry  *osmpbf.GeometryBuilder
+}
+
+// NewExtractor returns a new extractor with presets, DefaultPresets if none are given, taking
+// locations of nodes of ways from s, which may be nil if ways have NodeLocations.
+func NewExtractor(s osmpbf.NodeLocationStore, presets ...Preset) *Extractor {
+	if len(presets) == 0 {
+		presets = DefaultPresets
+	}
+	return &Extractor{presets: presets, locations: s, geometry: osmpbf.NewGeometryBuilder(s, osmpbf.SkipMissingNodes)}
+}
+
+// Records returns a record for each preset selecting object. Nodes are located at their location
+// and ways at their centroid, ways with less than two known node locations and relations have
+// no records.
+func (e *Extractor) Records(o osmpbf.Object) []Record {
+	var id osmpbf.ElementID
+	var tags osmpbf.Tags
+	var list osmpbf.TagList
+	switch o := o.(type) {
+	case *osmpbf.Node:
+		id, tags, list = o.ElementID(), o.Tags, o.TagList
+	case *osmpbf.Way:
+		id, tags, list = o.ElementID(), o.Tags, o.TagList
+	default:
+		return nil
+	}
+	if len(tags) == 0 && len(list) > 0 {
+		tags = list.Map()
+	}
+
+	var records []Record
+	var l osmpbf.LatLon
+	for _, p := range e.presets {
+		if !p.match(tags) {
+			continue
+		}
+		if records == nil {
+			var ok bool
+			if l, ok = e.location(o); !ok {
+				return nil
+			}
+		}
+		records = append(records, Record{ID: id, Preset: p.Name, Lat: l.Lat, Lon: l.Lon, Tags: p.keep(tags)})
+	}
+	return records
+}
+
+// Read reads objects from started decoder until the end of input stream: locations of nodes are
+// put into the store, if it is set, and fn is called with each record. All objects are released.
+// Input must be sorted by type, so nodes are read before ways, unless the store already contains
+// their locations.
+func (e *Extractor) Read(dec *osmpbf.Decoder, fn func(r *Record) error) error {
+	for {
+		v, err := dec.Decode()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		o := v.(osmpbf.Object)
+		if n, ok := o.(*osmpbf.Node); ok && e.locations != nil {
+			if err := e.locations.Set(n.ID, osmpbf.LatLon{Lat: n.Lat, Lon: n.Lon}); err != nil {
+				return err
+			}
+		}
+		records := e.Records(o)
+		o.Release()
+		for i := range records {
+			if err := fn(&records[i]); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Returns true if tags match any filter of preset.
+func (p *Preset) match(tags osmpbf.Tags) bool {
+	for _, expr := range p.Filter {
+		if tags.Match(expr) {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns normalized tags kept by preset: keys and values are trimmed and runs of white space
+// in values are replaced by single spaces; empty tags are dropped.
+func (p *Preset) keep(tags osmpbf.Tags) map[string]string {
+	kept := make(map[string]string)
+	for k, v := range tags {
+		k = strings.TrimSpace(k)
+		v = strings.Join(strings.Fields(v), " ")
+		if k == "" || v == "" || !p.keeps(k) {
+			continue
+		}
+		kept[k] = v
+	}
+	return kept
+}
+
+// Returns true if tag with key is kept by preset.
+func (p *Preset) keeps(key string) bool {
+	if len(p.Keys) == 0 {
+		return true
+	}
+	for _, k := range p.Keys {
+		if k == key || strings.HasSuffix(k, "*") && strings.HasPrefix(key, k[:len(k)-1]) {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns location of node, or centroid of way, false if it is unknown.
+func (e *Extractor) location(o osmpbf.Object) (osmpbf.LatLon, bool) {
+	switch o := o.(type) {
+	case *osmpbf.Node:
+		return osmpbf.LatLon{Lat: o.Lat, Lon: o.Lon}, true
+	case *osmpbf.Way:
+		g := e.geometry.Build(o)
+		if g == nil {
+			return osmpbf.LatLon{}, false
+		}
+		if g.Closed {
+			if c, ok := ringCentroid(g.Parts[0]); ok {
+				return c, true
+			}
+		}
+		return meanLocation(g.Parts), true
+	}
+	return osmpbf.LatLon{}, false
+}
+
+// Returns centroid of area of closed ring, false if ring has no area.
+func ringCentroid(ring []osmpbf.LatLon) (osmpbf.LatLon, bool) {
+	// relative to the first location, for precision
+	o := ring[0]
+	var area, lat, lon float64
+	for i := 1; i+1 < len(ring); i++ {
+		x1, y1 := ring[i].Lon-o.Lon, ring[i].Lat-o.Lat
+		x2, y2 := ring[i+1].Lon-o.Lon, ring[i+1].Lat-o.Lat
+		cross := x1*y2 - x2*y1
+		area += cross
+		lon += (x1 + x2) * cross
+		lat += (y1 + y2) * cross
+	}
+	if math.Abs(area) < 1e-18 {
+		return osmpbf.LatLon{}, false
+	}
+	return osmpbf.LatLon{Lat: o.Lat + lat/(3*area), Lon: o.Lon + lon/(3*area)}, true
+}
+
+// Returns mean of locations of parts.
+func meanLocation(parts [][]osmpbf.LatLon) osmpbf.LatLon {
+	var sum osmpbf.LatLon
+	n := 0
+	for _, part := range parts {
+		for _, l := range part {
+			sum.Lat += l.Lat
+			sum.Lon += l.Lon
+			n++
+		}
+	}
+	return osmpbf.LatLon{Lat: sum.Lat / float64(n), Lon: sum.Lon / float64(n)}
+}
@@ -0,0 +1,81 @@
+package osmpoi
+
+import (
+	"bytes"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/brechtbm/osmpbf"
+)
+
+func TestRead(t *testing.T) {
+	var buf bytes.Buffer
+	enc := osmpbf.NewEncoder(&buf)
+	for _, o := range []osmpbf.Object{
+		&osmpbf.Node{ID: 1, Lat: 0, Lon: 0},
+		&osmpbf.Node{ID: 2, Lat: 0, Lon: 2},
+		&osmpbf.Node{ID: 3, Lat: 2, Lon: 2},
+		&osmpbf.Node{ID: 4, Lat: 2, Lon: 0},
+		&osmpbf.Node{ID: 5, Lat: 1, Lon: 1, Tags: map[string]string{
+			"amenity": "cafe", "name": "  Corner \t Cafe ", "addr:housenumber": "5", "addr:street": "Main Street",
+			"fixme": "check", "source": "survey"}},
+		&osmpbf.Node{ID: 6, Lat: 1, Lon: 1, Tags: map[string]string{"highway": "crossing"}},
+		&osmpbf.Way{ID: 10, NodeIDs: []int64{1, 2, 3, 4, 1}, Tags: map[string]string{"building": "yes", "addr:housenumber": "7"}},
+		&osmpbf.Way{ID: 11, NodeIDs: []int64{1, 2, 9}, Tags: map[string]string{"shop": "kiosk", "name": ""}},
+		&osmpbf.Relation{ID: 20, Tags: map[string]string{"amenity": "school"}},
+	} {
+		if err := enc.Encode(o); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	dec := osmpbf.NewDecoder(&buf)
+	if err := dec.Start(1); err != nil {
+		t.Fatal(err)
+	}
+	defer dec.Close()
+	var records []Record
+	e := NewExtractor(osmpbf.NewSparseLocationStore())
+	if err := e.Read(dec, func(r *Record) error {
+		records = append(records, *r)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Record{
+		{osmpbf.NewElementID(osmpbf.NodeKind, 5), "address", 1, 1,
+			map[string]string{"name": "Corner Cafe", "addr:housenumber": "5", "addr:street": "Main Street"}},
+		{osmpbf.NewElementID(osmpbf.NodeKind, 5), "amenity", 1, 1,
+			map[string]string{"amenity": "cafe", "name": "Corner Cafe", "addr:housenumber": "5", "addr:street": "Main Street"}},
+		{osmpbf.NewElementID(osmpbf.WayKind, 10), "address", 1, 1, map[string]string{"addr:housenumber": "7"}},
+		{osmpbf.NewElementID(osmpbf.WayKind, 11), "shop", 0, 1, map[string]string{"shop": "kiosk"}},
+	}
+	if len(records) != len(expected) {
+		t.Fatalf("expected %d records, got %+v", len(expected), records)
+	}
+	for i, r := range records {
+		if math.Abs(r.Lat-expected[i].Lat) < 1e-9 && math.Abs(r.Lon-expected[i].Lon) < 1e-9 {
+			r.Lat, r.Lon = expected[i].Lat, expected[i].Lon
+		}
+		if !reflect.DeepEqual(r, expected[i]) {
+			t.Errorf("\nExpected: %+v\nActual:   %+v", expected[i], r)
+		}
+	}
+}
+
+func TestPresetKeeps(t *testing.T) {
+	p := Preset{Keys: []string{"name", "addr:*"}}
+	for key, expected := range map[string]bool{"name": true, "name:en": false, "addr:city": true, "addr": false} {
+		if p.keeps(key) != expected {
+			t.Errorf("%s: expected %t", key, expected)
+		}
+	}
+	if !(&Preset{}).keeps("any") {
+		t.Error("expected all keys kept without Keys")
+	}
+}
@@ -0,0 +1,217 @@
+// Package osmsqlite loads objects decoded by package osmpbf into SQLite database, for quick local
+// querying of extracts. It uses database/sql, so a SQLite driver must be registered by importing
+// it, for example github.com/mattn/go-sqlite3 or modernc.org/sqlite.
+//
+// Objects are stored in tables nodes, ways, way_nodes, relations, members and tags:
+//
+//	nodes(id, lat, lon, version, timestamp, changeset, uid, user)
+//	ways(id, version, timestamp, changeset, uid, user)
+//	way_nodes(way_id, seq, node_id)
+//	relations(id, version, timestamp, changeset, uid, user)
+//	members(relation_id, seq, type, ref, role)
+//	tags(type, id, key, value)
+//
+// Type columns contain node, way or relation. Optional R*Tree index node_index(id, min_lat, max_lat,
+// min_lon, max_lon) contains node locations.
+package osmsqlite
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/brechtbm/osmpbf"
+)
+
+// DefaultBatchSize is default number of objects inserted by one transaction.
+const DefaultBatchSize = 10000
+
+const infoColumns = "version INTEGER, timestamp TEXT, changeset INTEGER, uid INTEGER, user TEXT"
+
+var tables = []string{
+	"CREATE TABLE IF NOT EXISTS nodes (id INTEGER PRIMARY KEY, lat REAL, lon REAL, " + infoColumns + ")",
+	"CREATE TABLE IF NOT EXISTS ways (id INTEGER PRIMARY KEY, " + infoColumns + ")",
+	"CREATE TABLE IF NOT EXISTS way_nodes (way_id INTEGER, seq INTEGER, node_id INTEGER, PRIMARY KEY (way_id, seq))",
+	"CREATE TABLE IF NOT EXISTS relations (id INTEGER PRIMARY KEY, " + infoColumns + ")",
+	"CREATE TABLE IF NOT EXISTS members (relation_id INTEGER, seq INTEGER, type TEXT, ref INTEGER, role TEXT, PRIMARY KEY (relation_id, seq))",
+	"CREATE TABLE IF NOT EXISTS tags (type TEXT, id INTEGER, key TEXT, value TEXT, PRIMARY KEY (type, id, key))",
+}
+
+const rtreeTable = "CREATE VIRTUAL TABLE IF NOT EXISTS node_index USING rtree(id, min_lat, max_lat, min_lon, max_lon)"
+
+// statements by index
+const (
+	insertNode = iota
+	insertWay
+	insertWayNode
+	insertRelation
+	insertMember
+	insertTag
+	insertIndex
+)
+
+var statements = []string{
+	"INSERT OR REPLACE INTO nodes VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+	"INSERT OR REPLACE INTO ways VALUES (?, ?, ?, ?, ?, ?)",
+	"INSERT OR REPLACE INTO way_nodes VALUES (?, ?, ?)",
+	"INSERT OR REPLACE INTO relations VALUES (?, ?, ?, ?, ?, ?)",
+	"INSERT OR REPLACE INTO members VALUES (?, ?, ?, ?, ?)",
+	"INSERT OR REPLACE INTO tags VALUES (?, ?, ?, ?)",
+	"INSERT OR REPLACE INTO node_index VALUES (?, ?, ?, ?, ?)",
+}
+
+// A Sink inserts objects into SQLite database by batched transactions.
+type Sink struct {
+	db        *sql.DB
+	rtree     bool
+	batchSize int
+
+	tx    *sql.Tx
+	stmts []*sql.Stmt
+	n     int // objects inserted by current transaction
+}
+
+// NewSink creates tables in db if they do not exist and returns a new sink inserting into them.
+// If rtree is set, node locations are indexed by R*Tree, which requires SQLite with R*Tree module.
+func NewSink(db *sql.DB, rtree bool) (*Sink, error) {
+	queries := tables
+	if rtree {
+		queries = append(queries[:len(queries):len(queries)], rtreeTable)
+	}
+	for _, q := range queries {
+		if _, err := db.Exec(q); err != nil {
+			return nil, err
+		}
+	}
+	return &Sink{db: db, rtree: rtree, batchSize: DefaultBatchSize}, nil
+}
+
+// SetBatchSize sets number of objects inserted by one transaction, default is DefaultBatchSize.
+func (s *Sink) SetBatchSize(n int) {
+	s.batchSize = n
+}
+
+// Write inserts a pointer to Node, Way or Relation struct, replacing object with the same ID.
+// Other objects are skipped. Transaction is committed after each batch, so Close must be called
+// to commit the last one.
+func (s *Sink) Write(o osmpbf.Object) error {
+	if err := s.begin(); err != nil {
+		return err
+	}
+
+	var err error
+	switch o := o.(type) {
+	case *osmpbf.Node:
+		err = s.exec(insertNode, append([]interface{}{o.ID, o.Lat, o.Lon}, infoValues(&o.Info)...)...)
+		if err == nil && s.rtree {
+			err = s.exec(insertIndex, o.ID, o.Lat, o.Lat, o.Lon, o.Lon)
+		}
+		if err == nil {
+			err = s.writeTags(osmpbf.NodeKind, o.ID, o.Tags, o.TagList)
+		}
+	case *osmpbf.Way:
+		err = s.exec(insertWay, append([]interface{}{o.ID}, infoValues(&o.Info)...)...)
+		for i, id := range o.NodeIDs {
+			if err != nil {
+				break
+			}
+			err = s.exec(insertWayNode, o.ID, i, id)
+		}
+		if err == nil {
+			err = s.writeTags(osmpbf.WayKind, o.ID, o.Tags, o.TagList)
+		}
+	case *osmpbf.Relation:
+		err = s.exec(insertRelation, append([]interface{}{o.ID}, infoValues(&o.Info)...)...)
+		for i, m := range o.Members {
+			if err != nil {
+				break
+			}
+			err = s.exec(insertMember, o.ID, i, m.Type.Kind().String(), m.ID, m.Role)
+		}
+		if err == nil {
+			err = s.writeTags(osmpbf.RelationKind, o.ID, o.Tags, o.TagList)
+		}
+	default:
+		return nil
+	}
+	if err != nil {
+		s.rollback()
+		return err
+	}
+
+	s.n++
+	if s.n >= s.batchSize {
+		return s.commit()
+	}
+	return nil
+}
+
+// Close commits the last transaction. It does not close the database.
+func (s *Sink) Close() error {
+	return s.commit()
+}
+
+func (s *Sink) writeTags(kind osmpbf.Kind, id int64, tags map[string]string, list osmpbf.TagList) error {
+	for k, v := range tags {
+		if err := s.exec(insertTag, kind.String(), id, k, v); err != nil {
+			return err
+		}
+	}
+	for _, tag := range list {
+		if err := s.exec(insertTag, kind.String(), id, tag.Key, tag.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Returns values of Info columns.
+func infoValues(info *osmpbf.Info) []interface{} {
+	var timestamp interface{}
+	if !info.Timestamp.IsZero() {
+		timestamp = info.Timestamp.UTC().Format(time.RFC3339)
+	}
+	return []interface{}{info.Version, timestamp, int64(info.Changeset), info.Uid, info.User}
+}
+
+func (s *Sink) exec(stmt int, args ...interface{}) error {
+	_, err := s.stmts[stmt].Exec(args...)
+	return err
+}
+
+// Starts transaction and prepares statements, if it is not started yet.
+func (s *Sink) begin() error {
+	if s.tx != nil {
+		return nil
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	n := len(statements)
+	if !s.rtree {
+		n = insertIndex
+	}
+	stmts := make([]*sql.Stmt, n)
+	for i := range stmts {
+		if stmts[i], err = tx.Prepare(statements[i]); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	s.tx, s.stmts = tx, stmts
+	return nil
+}
+
+func (s *Sink) commit() error {
+	if s.tx == nil {
+		return nil
+	}
+	err := s.tx.Commit() // closes statements
+	s.tx, s.stmts, s.n = nil, nil, 0
+	return err
+}
+
+func (s *Sink) rollback() {
+	s.tx.Rollback()
+	s.tx, s.stmts, s.n = nil, nil, 0
+}
@@ -0,0 +1,125 @@
+package osmsqlite
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/brechtbm/osmpbf"
+)
+
+// Test driver recording executed statements, as SQLite driver is not a dependency.
+type recorder struct {
+	mu      sync.Mutex
+	execs   []string // statements with their arguments
+	commits int
+}
+
+func (r *recorder) Open(name string) (driver.Conn, error) { return conn{r}, nil }
+
+type conn struct{ r *recorder }
+
+func (c conn) Prepare(query string) (driver.Stmt, error) { return stmt{c.r, query}, nil }
+func (c conn) Close() error                              { return nil }
+func (c conn) Begin() (driver.Tx, error)                 { return tx{c.r}, nil }
+
+type tx struct{ r *recorder }
+
+func (t tx) Commit() error {
+	t.r.mu.Lock()
+	t.r.commits++
+	t.r.mu.Unlock()
+	return nil
+}
+func (t tx) Rollback() error { return nil }
+
+type stmt struct {
+	r     *recorder
+	query string
+}
+
+func (s stmt) Close() error  { return nil }
+func (s stmt) NumInput() int { return -1 }
+func (s stmt) Query(args []driver.Value) (driver.Rows, error) {
+	return nil, fmt.Errorf("not supported")
+}
+func (s stmt) Exec(args []driver.Value) (driver.Result, error) {
+	table := strings.Fields(s.query)
+	e := table[0]
+	if e == "INSERT" {
+		e = table[4] // INSERT OR REPLACE INTO table
+	}
+	for _, a := range args {
+		e += fmt.Sprintf(" %v", a)
+	}
+	s.r.mu.Lock()
+	s.r.execs = append(s.r.execs, e)
+	s.r.mu.Unlock()
+	return driver.RowsAffected(1), nil
+}
+
+var rec = &recorder{}
+
+func init() {
+	sql.Register("osmsqlite-test", rec)
+}
+
+func TestSink(t *testing.T) {
+	db, err := sql.Open("osmsqlite-test", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	s, err := NewSink(db, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.SetBatchSize(2)
+	info := osmpbf.Info{Version: 2, Timestamp: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC), Changeset: 7, Uid: 8, User: "a"}
+	objects := []osmpbf.Object{
+		&osmpbf.Node{ID: 1, Lat: 51.5, Lon: -0.25, Tags: map[string]string{"name": "A"}, Info: info},
+		&osmpbf.Bound{},
+		&osmpbf.Way{ID: 10, NodeIDs: []int64{1, 2}, TagList: osmpbf.TagList{{Key: "highway", Value: "primary"}}},
+		&osmpbf.Relation{ID: 20, Members: []osmpbf.Member{{ID: 10, Type: osmpbf.WayType, Role: "outer"}}},
+	}
+	for _, o := range objects {
+		if err := s.Write(o); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"nodes 1 51.5 -0.25 2 2020-01-02T03:04:05Z 7 8 a",
+		"node_index 1 51.5 51.5 -0.25 -0.25",
+		"tags node 1 name A",
+		"ways 10 0 <nil> 0 0 ",
+		"way_nodes 10 0 1",
+		"way_nodes 10 1 2",
+		"tags way 10 highway primary",
+		"relations 20 0 <nil> 0 0 ",
+		"members 20 0 way 10 outer",
+	}
+	if len(rec.execs) != len(tables)+1+len(expected) {
+		t.Fatalf("unexpected statements %q", rec.execs)
+	}
+	for _, e := range rec.execs[:len(tables)+1] {
+		if !strings.HasPrefix(e, "CREATE") {
+			t.Errorf("expected CREATE statement, got %q", e)
+		}
+	}
+	if execs := rec.execs[len(tables)+1:]; !reflect.DeepEqual(expected, execs) {
+		t.Errorf("\nExpected: %q\nActual:   %q", expected, execs)
+	}
+	if rec.commits != 2 {
+		t.Errorf("expected 2 commits, got %d", rec.commits)
+	}
+}
@@ -0,0 +1,211 @@
+package osmxml
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/brechtbm/osmpbf"
+)
+
+const sortedFeature = "Sort.Type_then_ID"
+
+// Apply reads PBF data from src, applies osmChange diffs to it in order and writes updated PBF data
+// to dst, sorted by type, then by ID. Objects of src must be sorted in this order, as in extracts
+// and planet files. Deleted objects are dropped; if an object is changed by several diffs, its
+// version with the highest Info.Version is written. OSMHeader of src is kept.
+//
+// Diffs are read into memory, src is streamed. Way.NodeLocations of changed ways are not set,
+// so files with LocationsOnWays feature are not updated consistently. Full-history files are
+// not supported.
+func Apply(dst io.Writer, src io.Reader, diffs ...io.Reader) error {
+	return ApplyHeader(dst, src, nil, diffs...)
+}
+
+// ApplyHeader is the same as Apply, but update, if not nil, is called with OSMHeader of src before
+// it is written to dst, for example to set replication state of applied diffs.
+func ApplyHeader(dst io.Writer, src io.Reader, update func(h *osmpbf.Header) error, diffs ...io.Reader) error {
+	changes, err := readChanges(diffs)
+	if err != nil {
+		return err
+	}
+
+	r, err := newSortedReader(src)
+	if err != nil {
+		return err
+	}
+	defer r.d.Close()
+
+	h := *r.d.Header()
+	sorted := false
+	for _, feature := range h.OptionalFeatures {
+		sorted = sorted || feature == sortedFeature
+	}
+	if !sorted {
+		h.OptionalFeatures = append(append([]string{}, h.OptionalFeatures...), sortedFeature)
+	}
+	if update != nil {
+		if err := update(&h); err != nil {
+			return err
+		}
+	}
+
+	e := osmpbf.NewEncoder(dst)
+	e.SetHeader(&h)
+	m := merger{e: e, changes: changes}
+	for {
+		o, err := r.next()
+		if err != nil {
+			return err
+		} else if o == nil {
+			break
+		}
+
+		// changed objects preceding o, or o replaced by its change
+		replaced, err := m.writeUntil(o.Kind(), objectID(o))
+		if err != nil {
+			return err
+		}
+		if !replaced {
+			if err := e.Encode(o); err != nil {
+				return err
+			}
+		}
+	}
+
+	if _, err := m.writeUntil(osmpbf.BoundKind, 0); err != nil {
+		return err
+	}
+	return e.Close()
+}
+
+// Reads objects of PBF data sorted by type, then by ID, checking their order.
+type sortedReader struct {
+	d    *osmpbf.Decoder
+	n    int // number of read objects
+	kind osmpbf.Kind
+	id   int64
+}
+
+// Starts decoding of sorted PBF data from r. Full-history files are not supported.
+func newSortedReader(r io.Reader) (*sortedReader, error) {
+	d := osmpbf.NewDecoder(r)
+	if err := d.Start(0); err != nil {
+		return nil, err
+	}
+	for _, feature := range d.Header().RequiredFeatures {
+		if feature == historicalFeature {
+			d.Close()
+			return nil, errors.New("full-history files are not supported")
+		}
+	}
+	return &sortedReader{d: d}, nil
+}
+
+// Returns the next object, or nil at the end of input.
+func (r *sortedReader) next() (osmpbf.Object, error) {
+	v, err := r.d.Decode()
+	if err == io.EOF {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	o := v.(osmpbf.Object)
+	kind, id := o.Kind(), objectID(o)
+	if r.n > 0 && (kind < r.kind || kind == r.kind && id <= r.id) {
+		return nil, fmt.Errorf("input is not sorted: %v %d after %v %d", kind, id, r.kind, r.id)
+	}
+	r.n++
+	r.kind, r.id = kind, id
+	return o, nil
+}
+
+// Writes changed objects, sorted by ID for each kind, merging them with objects of input.
+type merger struct {
+	e       *osmpbf.Encoder
+	changes [3][]*Change // by kind
+}
+
+// Writes changes of objects preceding object of given kind and ID, and its change. Returns true
+// if object has a change, so it must not be written.
+func (m *merger) writeUntil(kind osmpbf.Kind, id int64) (bool, error) {
+	for k := osmpbf.NodeKind; k <= osmpbf.RelationKind && k <= kind; k++ {
+		changes := m.changes[k]
+		for len(changes) > 0 && (k < kind || objectID(changes[0].Object) <= id) {
+			c := changes[0]
+			changes = changes[1:]
+			m.changes[k] = changes
+			if c.Action != Delete {
+				if err := m.e.Encode(c.Object); err != nil {
+					return false, err
+				}
+			}
+			if k == kind && objectID(c.Object) == id {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// Reads all diffs and returns the last change of each object, sorted by ID for each kind.
+func readChanges(diffs []io.Reader) ([3][]*Change, error) {
+	type key struct {
+		kind osmpbf.Kind
+		id   int64
+	}
+	last := make(map[key]*Change)
+	for _, diff := range diffs {
+		d := NewChangeDecoder(diff)
+		for {
+			c, err := d.Decode()
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				return [3][]*Change{}, err
+			}
+
+			k := key{c.Object.Kind(), objectID(c.Object)}
+			if prev, ok := last[k]; !ok || version(prev.Object) <= version(c.Object) {
+				last[k] = c
+			}
+		}
+	}
+
+	var changes [3][]*Change
+	for k, c := range last {
+		changes[k.kind] = append(changes[k.kind], c)
+	}
+	for _, cs := range changes {
+		sort.Slice(cs, func(i, j int) bool {
+			return objectID(cs[i].Object) < objectID(cs[j].Object)
+		})
+	}
+	return changes, nil
+}
+
+func objectID(o osmpbf.Object) int64 {
+	switch o := o.(type) {
+	case *osmpbf.Node:
+		return o.ID
+	case *osmpbf.Way:
+		return o.ID
+	case *osmpbf.Relation:
+		return o.ID
+	}
+	return 0
+}
+
+func version(o osmpbf.Object) int16 {
+	switch o := o.(type) {
+	case *osmpbf.Node:
+		return o.Info.Version
+	case *osmpbf.Way:
+		return o.Info.Version
+	case *osmpbf.Relation:
+		return o.Info.Version
+	}
+	return 0
+}
@@ -0,0 +1,102 @@
+package osmxml
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/brechtbm/osmpbf"
+)
+
+func encodePBF(t *testing.T, h *osmpbf.Header, objects ...osmpbf.Object) *bytes.Buffer {
+	var buf bytes.Buffer
+	e := osmpbf.NewEncoder(&buf)
+	e.SetHeader(h)
+	for _, o := range objects {
+		if err := e.Encode(o); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := e.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return &buf
+}
+
+func TestApply(t *testing.T) {
+	info := osmpbf.Info{Version: 1, Timestamp: time.Unix(1e9, 0).UTC(), Visible: true}
+	src := encodePBF(t, &osmpbf.Header{WritingProgram: "test"},
+		&osmpbf.Node{ID: 1, Info: info},
+		&osmpbf.Node{ID: 2, Info: info},
+		&osmpbf.Node{ID: 3, Info: info},
+		&osmpbf.Way{ID: 10, NodeIDs: []int64{1, 2}, Info: info},
+		&osmpbf.Relation{ID: 20, Info: info},
+	)
+	diff1 := `<osmChange version="0.6">
+ <modify>
+  <node id="2" version="2" lat="1" lon="1"/>
+  <relation id="20" version="2"><tag k="type" v="route"/></relation>
+ </modify>
+ <create>
+  <node id="4" version="1" lat="1" lon="1"/>
+  <way id="5" version="1"><nd ref="4"/></way>
+ </create>
+ <delete>
+  <node id="3" version="2" lat="0" lon="0"/>
+ </delete>
+</osmChange>`
+	diff2 := `<osmChange version="0.6">
+ <delete>
+  <way id="10" version="2"/>
+ </delete>
+ <modify>
+  <node id="2" version="3" lat="2" lon="2"/>
+  <relation id="30" version="2"/>
+ </modify>
+</osmChange>`
+	// older version of node 2 is ignored
+	diff3 := `<osmChange version="0.6"><modify><node id="2" version="2" lat="1" lon="1"/></modify></osmChange>`
+
+	var dst bytes.Buffer
+	err := Apply(&dst, src, strings.NewReader(diff1), strings.NewReader(diff2), strings.NewReader(diff3))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := osmpbf.NewDecoder(&dst)
+	var actual []string
+	for _, v := range decodeAll(t, d) {
+		o := v.(osmpbf.Object)
+		actual = append(actual, fmt.Sprintf("%v %d v%d", o.Kind(), objectID(o), version(o)))
+	}
+	expected := []string{"node 1 v1", "node 2 v3", "node 4 v1", "way 5 v1", "relation 20 v2", "relation 30 v2"}
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("\nExpected: %v\nActual:   %v", expected, actual)
+	}
+	h := d.Header()
+	if h.WritingProgram != "test" || !reflect.DeepEqual(h.OptionalFeatures, []string{sortedFeature}) {
+		t.Errorf("unexpected header %#v", h)
+	}
+}
+
+func TestApplyError(t *testing.T) {
+	unsorted := encodePBF(t, &osmpbf.Header{}, &osmpbf.Node{ID: 2}, &osmpbf.Node{ID: 1})
+	err := Apply(io.Discard, unsorted)
+	if err == nil || !strings.Contains(err.Error(), "not sorted") {
+		t.Errorf("expected unsorted input error, got %v", err)
+	}
+
+	history := encodePBF(t, &osmpbf.Header{RequiredFeatures: []string{historicalFeature}})
+	if err := Apply(io.Discard, history); err == nil {
+		t.Error("expected error for full-history file")
+	}
+
+	src := encodePBF(t, &osmpbf.Header{}, &osmpbf.Node{ID: 1})
+	if err := Apply(io.Discard, src, strings.NewReader("<osm/>")); err == nil {
+		t.Error("expected error for invalid diff")
+	}
+}
@@ -0,0 +1,223 @@
+package osmxml
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/brechtbm/osmpbf"
+)
+
+// Action is kind of change in osmChange file.
+type Action int
+
+const (
+	Create Action = iota
+	Modify
+	Delete
+)
+
+func (a Action) String() string {
+	switch a {
+	case Create:
+		return "create"
+	case Modify:
+		return "modify"
+	case Delete:
+		return "delete"
+	default:
+		return fmt.Sprintf("Action(%d)", int(a))
+	}
+}
+
+// Change is a change of one object: its new version, or deleted version with Info.Visible
+// set to false.
+type Change struct {
+	Action Action
+	Object osmpbf.Object
+}
+
+// A ChangeDecoder reads and decodes osmChange (.osc) data, for example replication diffs, from
+// an input stream. Gzip-compressed input (.osc.gz) is detected and uncompressed.
+type ChangeDecoder struct {
+	r io.Reader
+	d *xml.Decoder
+
+	mu  sync.Mutex
+	err error // returned by all subsequent Decode calls
+
+	// current action element, -1 outside of them
+	action Action
+}
+
+// NewChangeDecoder returns a new decoder that reads from r.
+func NewChangeDecoder(r io.Reader) *ChangeDecoder {
+	return &ChangeDecoder{r: r, action: -1}
+}
+
+// Decode reads the next change from the input stream, or returns error encountered. Changes are
+// returned in the input stream order. The end of the input stream is reported by an io.EOF error.
+// Decode is safe for parallel execution. Only first error encountered will be returned,
+// subsequent invocations will return io.EOF.
+func (dec *ChangeDecoder) Decode() (*Change, error) {
+	dec.mu.Lock()
+	defer dec.mu.Unlock()
+
+	if dec.err != nil {
+		return nil, io.EOF
+	}
+	c, err := dec.decode()
+	if err != nil {
+		dec.err = err
+		return nil, err
+	}
+	return c, nil
+}
+
+func (dec *ChangeDecoder) decode() (*Change, error) {
+	if dec.d == nil {
+		if err := dec.start(); err != nil {
+			return nil, err
+		}
+	}
+
+	for {
+		se, err := next(dec.d)
+		if err == io.EOF && dec.action >= 0 {
+			// end of action element
+			dec.action = -1
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		if dec.action < 0 {
+			switch se.Name.Local {
+			case "create":
+				dec.action = Create
+			case "modify":
+				dec.action = Modify
+			case "delete":
+				dec.action = Delete
+			default:
+				if err := dec.d.Skip(); err != nil {
+					return nil, err
+				}
+			}
+			continue
+		}
+
+		o, err := decodeObject(dec.d, se)
+		if err != nil {
+			return nil, err
+		}
+		if o == nil {
+			continue
+		}
+		if dec.action == Delete {
+			setVisible(o, false)
+		}
+		return &Change{dec.action, o}, nil
+	}
+}
+
+// Detects compression of input stream and reads osmChange root element.
+func (dec *ChangeDecoder) start() error {
+	br := bufio.NewReader(dec.r)
+	r := io.Reader(br)
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return err
+		}
+		r = zr
+	}
+	dec.d = xml.NewDecoder(r)
+
+	root, err := next(dec.d)
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	} else if err != nil {
+		return err
+	}
+	if root.Name.Local != "osmChange" {
+		return fmt.Errorf("unexpected root element %s", root.Name.Local)
+	}
+	return nil
+}
+
+func setVisible(o osmpbf.Object, visible bool) {
+	switch o := o.(type) {
+	case *osmpbf.Node:
+		o.Info.Visible = visible
+	case *osmpbf.Way:
+		o.Info.Visible = visible
+	case *osmpbf.Relation:
+		o.Info.Visible = visible
+	}
+}
+
+// A ChangeEncoder writes osmChange data to an output stream.
+type ChangeEncoder struct {
+	enc Encoder
+
+	started bool
+	action  Action // of open action element, -1 if there is none
+}
+
+// NewChangeEncoder returns a new encoder that writes to w.
+func NewChangeEncoder(w io.Writer) *ChangeEncoder {
+	return &ChangeEncoder{enc: Encoder{w: w, indent: "  "}, action: -1}
+}
+
+// Encode writes a change to the output stream. Consecutive changes with the same action are
+// written to one action element. Objects are written in the same way as by Encoder.
+func (enc *ChangeEncoder) Encode(c *Change) error {
+	if c.Action < Create || c.Action > Delete {
+		return fmt.Errorf("unknown action %v", c.Action)
+	}
+	members, err := validate(c.Object)
+	if err != nil {
+		return err
+	}
+
+	enc.writeStart()
+	if c.Action != enc.action {
+		enc.writeEnd()
+		enc.enc.buf.WriteString(" <" + c.Action.String() + ">\n")
+		enc.action = c.Action
+	}
+	enc.enc.writeObject(c.Object, members)
+	return enc.enc.flush()
+}
+
+// Close writes the end of osmChange root element. It does not close the underlying writer.
+func (enc *ChangeEncoder) Close() error {
+	enc.writeStart()
+	enc.writeEnd()
+	enc.enc.buf.WriteString("</osmChange>\n")
+	return enc.enc.flush()
+}
+
+// Writes XML declaration and osmChange root element if they are not written yet.
+func (enc *ChangeEncoder) writeStart() {
+	if enc.started {
+		return
+	}
+	enc.started = true
+	enc.enc.buf.WriteString(xml.Header)
+	enc.enc.buf.WriteString(`<osmChange version="0.6"`)
+	enc.enc.writeAttr("generator", writingProgram)
+	enc.enc.buf.WriteString(">\n")
+}
+
+// Writes the end of open action element.
+func (enc *ChangeEncoder) writeEnd() {
+	if enc.action >= 0 {
+		enc.enc.buf.WriteString(" </" + enc.action.String() + ">\n")
+		enc.action = -1
+	}
+}
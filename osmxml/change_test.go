@@ -0,0 +1,109 @@
+package osmxml
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/brechtbm/osmpbf"
+)
+
+const testChange = `<?xml version="1.0" encoding="UTF-8"?>
+<osmChange version="0.6" generator="test">
+ <modify>
+  <node id="1" version="2" timestamp="2009-05-20T10:28:54Z" lat="1.5" lon="2.5">
+   <tag k="amenity" v="pub"/>
+  </node>
+  <way id="2" version="3" timestamp="2009-05-20T10:28:54Z">
+   <nd ref="1"/>
+  </way>
+ </modify>
+ <create>
+  <relation id="3" version="1" timestamp="2009-05-20T10:28:54Z">
+   <member type="way" ref="2" role="outer"/>
+  </relation>
+ </create>
+ <delete>
+  <node id="4" version="5" timestamp="2009-05-20T10:28:54Z" lat="0" lon="0"/>
+ </delete>
+</osmChange>
+`
+
+func TestChangeDecoder(t *testing.T) {
+	ts := parseTime("2009-05-20T10:28:54Z")
+	expected := []*Change{
+		{Modify, &osmpbf.Node{ID: 1, Lat: 1.5, Lon: 2.5, Tags: map[string]string{"amenity": "pub"},
+			Info: osmpbf.Info{Version: 2, Timestamp: ts, Visible: true}}},
+		{Modify, &osmpbf.Way{ID: 2, NodeIDs: []int64{1}, Tags: map[string]string{},
+			Info: osmpbf.Info{Version: 3, Timestamp: ts, Visible: true}}},
+		{Create, &osmpbf.Relation{ID: 3, Members: []osmpbf.Member{{ID: 2, Type: osmpbf.WayType, Role: "outer"}},
+			Tags: map[string]string{}, Info: osmpbf.Info{Version: 1, Timestamp: ts, Visible: true}}},
+		{Delete, &osmpbf.Node{ID: 4, Tags: map[string]string{}, Info: osmpbf.Info{Version: 5, Timestamp: ts}}},
+	}
+
+	var gz bytes.Buffer
+	w := gzip.NewWriter(&gz)
+	w.Write([]byte(testChange))
+	w.Close()
+
+	for _, r := range []io.Reader{strings.NewReader(testChange), &gz} {
+		d := NewChangeDecoder(r)
+		var changes []*Change
+		for {
+			c, err := d.Decode()
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			changes = append(changes, c)
+		}
+		if !reflect.DeepEqual(expected, changes) {
+			t.Errorf("\nExpected: %#v\nActual:   %#v", expected, changes)
+		}
+	}
+
+	if _, err := NewChangeDecoder(strings.NewReader(testXML)).Decode(); err == nil || !strings.Contains(err.Error(), "unexpected root element osm") {
+		t.Errorf("expected root element error, got %v", err)
+	}
+}
+
+func TestChangeEncoder(t *testing.T) {
+	var buf bytes.Buffer
+	e := NewChangeEncoder(&buf)
+	for _, c := range []*Change{
+		{Create, &osmpbf.Node{ID: 1, Lat: 1.5, Lon: 2.5, Info: osmpbf.Info{Version: 1, Visible: true}}},
+		{Create, &osmpbf.Way{ID: 2, NodeIDs: []int64{1}, Info: osmpbf.Info{Version: 1, Visible: true}}},
+		{Delete, &osmpbf.Node{ID: 3, Info: osmpbf.Info{Version: 2}}},
+	} {
+		if err := e.Encode(c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := e.Encode(&Change{Modify, &osmpbf.Relation{Members: []osmpbf.Member{{Type: 7}}}}); err == nil {
+		t.Error("expected error for unknown member type")
+	}
+	if err := e.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `<?xml version="1.0" encoding="UTF-8"?>
+<osmChange version="0.6" generator="osmpbf">
+ <create>
+  <node id="1" version="1" lat="1.5" lon="2.5"/>
+  <way id="2" version="1">
+   <nd ref="1"/>
+  </way>
+ </create>
+ <delete>
+  <node id="3" visible="false" version="2" lat="0" lon="0"/>
+ </delete>
+</osmChange>
+`
+	if buf.String() != expected {
+		t.Errorf("\nExpected: %s\nActual:   %s", expected, buf.String())
+	}
+}
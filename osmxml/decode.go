@@ -0,0 +1,321 @@
+// Package osmxml decodes and encodes OpenStreetMap XML (.osm) files, using Node, Way and Relation
+// structs of package osmpbf. Decoder has the same Start, Decode and Close methods as osmpbf.Decoder
+// and Encoder has the same methods as osmpbf.Encoder, so applications can accept both formats with
+// one code path.
+//
+// ChangeDecoder and ChangeEncoder read and write osmChange (.osc) files, like replication diffs.
+// Apply applies them to PBF files and Diff generates them from two PBF files.
+package osmxml
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/brechtbm/osmpbf"
+)
+
+// A Decoder reads and decodes OpenStreetMap XML data from an input stream.
+type Decoder struct {
+	d      *xml.Decoder
+	header *osmpbf.Header
+
+	mu      sync.Mutex
+	started bool
+	err     error // returned by all subsequent Decode calls
+
+	// element read by Start after bounds
+	pending *xml.StartElement
+}
+
+// NewDecoder returns a new decoder that reads from r.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{d: xml.NewDecoder(r)}
+}
+
+// Start reads osm root element and bounds. Decoding is done by Decode in the calling goroutine,
+// n is ignored; it is accepted for compatibility with osmpbf.Decoder.
+func (dec *Decoder) Start(n int) error {
+	dec.mu.Lock()
+	defer dec.mu.Unlock()
+
+	if dec.started {
+		return errors.New("decoder is started")
+	}
+	dec.started = true
+
+	root, err := dec.next()
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	} else if err != nil {
+		return err
+	}
+	if root.Name.Local != "osm" {
+		return fmt.Errorf("unexpected root element %s", root.Name.Local)
+	}
+
+	dec.header = &osmpbf.Header{
+		RequiredFeatures: []string{"OsmSchema-V0.6"},
+		WritingProgram:   attr(root, "generator"),
+	}
+	for {
+		se, err := dec.next()
+		if err == io.EOF {
+			// empty file
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if se.Name.Local != "bounds" {
+			dec.pending = se
+			return nil
+		}
+
+		var b xmlBounds
+		if err := dec.d.DecodeElement(&b, se); err != nil {
+			return err
+		}
+		dec.header.BBox = &osmpbf.BBox{Left: b.MinLon, Right: b.MaxLon, Top: b.MaxLat, Bottom: b.MinLat}
+	}
+}
+
+// Header returns metadata from osm root element and bounds, available after Start.
+func (dec *Decoder) Header() *osmpbf.Header {
+	return dec.header
+}
+
+// Decode reads the next object from the input stream and returns a pointer to Node, Way or
+// Relation struct, or error encountered. The end of the input stream is reported by an io.EOF
+// error. Decode is safe for parallel execution. Only first error encountered will be returned,
+// subsequent invocations will return io.EOF.
+func (dec *Decoder) Decode() (interface{}, error) {
+	dec.mu.Lock()
+	defer dec.mu.Unlock()
+
+	if dec.err != nil {
+		return nil, io.EOF
+	}
+	if !dec.started {
+		dec.err = errors.New("decoder is not started")
+		return nil, dec.err
+	}
+
+	v, err := dec.decode()
+	if err != nil {
+		dec.err = err
+		return nil, err
+	}
+	return v, nil
+}
+
+func (dec *Decoder) decode() (interface{}, error) {
+	for {
+		se := dec.pending
+		dec.pending = nil
+		if se == nil {
+			var err error
+			if se, err = dec.next(); err != nil {
+				return nil, err
+			}
+		}
+
+		if o, err := decodeObject(dec.d, se); o != nil || err != nil {
+			return o, err
+		}
+	}
+}
+
+// Returns the next start element of the current element, io.EOF if it ends.
+func (dec *Decoder) next() (*xml.StartElement, error) {
+	return next(dec.d)
+}
+
+func next(d *xml.Decoder) (*xml.StartElement, error) {
+	for {
+		t, err := d.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch t := t.(type) {
+		case xml.StartElement:
+			return &t, nil
+		case xml.EndElement:
+			return nil, io.EOF
+		}
+	}
+}
+
+// Decodes object from element se. Other elements, like changesets and notes, are skipped:
+// nil is returned for them.
+func decodeObject(d *xml.Decoder, se *xml.StartElement) (osmpbf.Object, error) {
+	switch se.Name.Local {
+	case "node":
+		var n xmlNode
+		if err := d.DecodeElement(&n, se); err != nil {
+			return nil, err
+		}
+		return n.object(), nil
+
+	case "way":
+		var w xmlWay
+		if err := d.DecodeElement(&w, se); err != nil {
+			return nil, err
+		}
+		return w.object(), nil
+
+	case "relation":
+		var r xmlRelation
+		if err := d.DecodeElement(&r, se); err != nil {
+			return nil, err
+		}
+		return r.object()
+
+	default:
+		return nil, d.Skip()
+	}
+}
+
+// Close stops decoding, subsequent Decode calls return io.EOF. It does not close the input stream.
+func (dec *Decoder) Close() error {
+	dec.mu.Lock()
+	defer dec.mu.Unlock()
+	if dec.err == nil {
+		dec.err = io.EOF
+	}
+	return nil
+}
+
+func attr(se *xml.StartElement, name string) string {
+	for _, a := range se.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+type xmlBounds struct {
+	MinLat float64 `xml:"minlat,attr"`
+	MinLon float64 `xml:"minlon,attr"`
+	MaxLat float64 `xml:"maxlat,attr"`
+	MaxLon float64 `xml:"maxlon,attr"`
+}
+
+type xmlTag struct {
+	K string `xml:"k,attr"`
+	V string `xml:"v,attr"`
+}
+
+type xmlInfo struct {
+	Version   int16     `xml:"version,attr"`
+	Timestamp time.Time `xml:"timestamp,attr"`
+	Changeset uint64    `xml:"changeset,attr"`
+	Uid       int32     `xml:"uid,attr"`
+	User      string    `xml:"user,attr"`
+	Visible   *bool     `xml:"visible,attr"`
+}
+
+func (i *xmlInfo) info() osmpbf.Info {
+	return osmpbf.Info{
+		Version:   i.Version,
+		Timestamp: i.Timestamp.UTC(),
+		Changeset: i.Changeset,
+		Uid:       i.Uid,
+		User:      i.User,
+		Visible:   i.Visible == nil || *i.Visible,
+	}
+}
+
+// Returns tags map, which is empty but not nil for objects without tags, like in osmpbf.
+func tags(xmlTags []xmlTag) map[string]string {
+	tags := make(map[string]string, len(xmlTags))
+	for _, t := range xmlTags {
+		tags[t.K] = t.V
+	}
+	return tags
+}
+
+type xmlNode struct {
+	xmlInfo
+	ID   int64    `xml:"id,attr"`
+	Lat  float64  `xml:"lat,attr"`
+	Lon  float64  `xml:"lon,attr"`
+	Tags []xmlTag `xml:"tag"`
+}
+
+func (n *xmlNode) object() *osmpbf.Node {
+	return &osmpbf.Node{ID: n.ID, Lat: n.Lat, Lon: n.Lon, Tags: tags(n.Tags), Info: n.info()}
+}
+
+type xmlWay struct {
+	xmlInfo
+	ID  int64 `xml:"id,attr"`
+	Nds []struct {
+		Ref int64 `xml:"ref,attr"`
+
+		// present in Overpass API output with geometry
+		Lat *float64 `xml:"lat,attr"`
+		Lon *float64 `xml:"lon,attr"`
+	} `xml:"nd"`
+	Tags []xmlTag `xml:"tag"`
+}
+
+func (w *xmlWay) object() *osmpbf.Way {
+	way := &osmpbf.Way{ID: w.ID, Tags: tags(w.Tags), Info: w.info()}
+	if len(w.Nds) > 0 {
+		way.NodeIDs = make([]int64, len(w.Nds))
+		way.NodeLocations = make([]osmpbf.LatLon, len(w.Nds))
+	}
+	for i, nd := range w.Nds {
+		way.NodeIDs[i] = nd.Ref
+		if nd.Lat == nil || nd.Lon == nil {
+			// locations are returned only if all nodes have them
+			way.NodeLocations = nil
+		} else if way.NodeLocations != nil {
+			way.NodeLocations[i] = osmpbf.LatLon{Lat: *nd.Lat, Lon: *nd.Lon}
+		}
+	}
+	return way
+}
+
+type xmlRelation struct {
+	xmlInfo
+	ID      int64 `xml:"id,attr"`
+	Members []struct {
+		Type string `xml:"type,attr"`
+		Ref  int64  `xml:"ref,attr"`
+		Role string `xml:"role,attr"`
+	} `xml:"member"`
+	Tags []xmlTag `xml:"tag"`
+}
+
+func (r *xmlRelation) object() (*osmpbf.Relation, error) {
+	relation := &osmpbf.Relation{ID: r.ID, Tags: tags(r.Tags), Info: r.info()}
+	if len(r.Members) > 0 {
+		relation.Members = make([]osmpbf.Member, len(r.Members))
+	}
+	for i, m := range r.Members {
+		t, err := memberType(m.Type)
+		if err != nil {
+			return nil, fmt.Errorf("relation %d: %w", r.ID, err)
+		}
+		relation.Members[i] = osmpbf.Member{ID: m.Ref, Type: t, Role: m.Role}
+	}
+	return relation, nil
+}
+
+func memberType(s string) (osmpbf.MemberType, error) {
+	switch s {
+	case "node":
+		return osmpbf.NodeType, nil
+	case "way":
+		return osmpbf.WayType, nil
+	case "relation":
+		return osmpbf.RelationType, nil
+	default:
+		return 0, fmt.Errorf("unknown member type %q", s)
+	}
+}
@@ -0,0 +1,147 @@
+package osmxml
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/brechtbm/osmpbf"
+)
+
+const testXML = `<?xml version="1.0" encoding="UTF-8"?>
+<osm version="0.6" generator="test">
+ <bounds minlat="51.5" minlon="-0.3" maxlat="51.6" maxlon="-0.1"/>
+ <node id="18088578" visible="true" version="2" changeset="1260468" timestamp="2009-05-20T10:28:54Z" user="Tom&amp;Jerry" uid="508" lat="51.5442632" lon="-0.2010027">
+  <tag k="amenity" v="pub"/>
+  <tag k="name" v="The &quot;Luminaire&quot;"/>
+ </node>
+ <node id="18088579" version="1" changeset="1" timestamp="2009-05-20T10:28:54Z" user="x" uid="1" lat="51.5" lon="-0.2"/>
+ <changeset id="1"><tag k="comment" v="skipped"/></changeset>
+ <way id="4257116" version="7" changeset="17253164" timestamp="2013-08-07T12:08:39Z" user="Ed" uid="1016290">
+  <nd ref="18088578"/>
+  <nd ref="18088579"/>
+  <tag k="highway" v="pedestrian"/>
+ </way>
+ <relation id="7677" visible="false" version="4" changeset="1" timestamp="2008-07-19T15:04:03Z" user="x" uid="1">
+  <member type="way" ref="4875932" role="outer"/>
+  <member type="node" ref="18088578" role=""/>
+  <tag k="type" v="multipolygon"/>
+ </relation>
+</osm>
+`
+
+func parseTime(s string) time.Time {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+var testObjects = []interface{}{
+	&osmpbf.Node{
+		ID: 18088578, Lat: 51.5442632, Lon: -0.2010027,
+		Tags: map[string]string{"amenity": "pub", "name": `The "Luminaire"`},
+		Info: osmpbf.Info{Version: 2, Timestamp: parseTime("2009-05-20T10:28:54Z"), Changeset: 1260468, Uid: 508, User: "Tom&Jerry", Visible: true},
+	},
+	&osmpbf.Node{
+		ID: 18088579, Lat: 51.5, Lon: -0.2,
+		Tags: map[string]string{},
+		Info: osmpbf.Info{Version: 1, Timestamp: parseTime("2009-05-20T10:28:54Z"), Changeset: 1, Uid: 1, User: "x", Visible: true},
+	},
+	&osmpbf.Way{
+		ID: 4257116, NodeIDs: []int64{18088578, 18088579},
+		Tags: map[string]string{"highway": "pedestrian"},
+		Info: osmpbf.Info{Version: 7, Timestamp: parseTime("2013-08-07T12:08:39Z"), Changeset: 17253164, Uid: 1016290, User: "Ed", Visible: true},
+	},
+	&osmpbf.Relation{
+		ID: 7677,
+		Members: []osmpbf.Member{
+			{ID: 4875932, Type: osmpbf.WayType, Role: "outer"},
+			{ID: 18088578, Type: osmpbf.NodeType},
+		},
+		Tags: map[string]string{"type": "multipolygon"},
+		Info: osmpbf.Info{Version: 4, Timestamp: parseTime("2008-07-19T15:04:03Z"), Changeset: 1, Uid: 1, User: "x"},
+	},
+}
+
+// decoder is implemented by osmpbf.Decoder and Decoder.
+type decoder interface {
+	Start(n int) error
+	Decode() (interface{}, error)
+	Close() error
+}
+
+func decodeAll(t *testing.T, d decoder) []interface{} {
+	if err := d.Start(2); err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+
+	var objects []interface{}
+	for {
+		v, err := d.Decode()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		objects = append(objects, v)
+	}
+	return objects
+}
+
+func TestDecode(t *testing.T) {
+	d := NewDecoder(strings.NewReader(testXML))
+	decoded := decodeAll(t, d)
+	if !reflect.DeepEqual(testObjects, decoded) {
+		t.Errorf("\nExpected: %#v\nActual:   %#v", testObjects, decoded)
+	}
+	expected := &osmpbf.Header{
+		BBox:             &osmpbf.BBox{Left: -0.3, Right: -0.1, Top: 51.6, Bottom: 51.5},
+		RequiredFeatures: []string{"OsmSchema-V0.6"},
+		WritingProgram:   "test",
+	}
+	if !reflect.DeepEqual(expected, d.Header()) {
+		t.Errorf("\nExpected: %#v\nActual:   %#v", expected, d.Header())
+	}
+
+	// the same objects are decoded from PBF
+	var buf bytes.Buffer
+	e := osmpbf.NewEncoder(&buf)
+	e.SetHeader(&osmpbf.Header{RequiredFeatures: []string{"HistoricalInformation"}})
+	for _, o := range testObjects {
+		if err := e.Encode(o); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := e.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if pbf := decodeAll(t, osmpbf.NewDecoder(&buf)); !reflect.DeepEqual(pbf, decoded) {
+		t.Errorf("\nPBF: %#v\nXML: %#v", pbf, decoded)
+	}
+}
+
+func TestDecodeError(t *testing.T) {
+	for _, c := range []struct {
+		xml      string
+		expected string
+	}{
+		{`<gpx></gpx>`, "unexpected root element gpx"},
+		{`<osm><relation id="1"><member type="area" ref="1"/></relation></osm>`, `unknown member type "area"`},
+		{`<osm><node id="x"/></osm>`, "invalid syntax"},
+	} {
+		d := NewDecoder(strings.NewReader(c.xml))
+		err := d.Start(1)
+		if err == nil {
+			_, err = d.Decode()
+		}
+		if err == nil || !strings.Contains(err.Error(), c.expected) {
+			t.Errorf("%s: expected error containing %q, got %v", c.xml, c.expected, err)
+		}
+	}
+}
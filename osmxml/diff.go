@@ -0,0 +1,109 @@
+package osmxml
+
+import (
+	"io"
+	"reflect"
+
+	"github.com/brechtbm/osmpbf"
+)
+
+// Diff compares PBF data of old and new, for example two versions of an extract, and calls fn
+// with changes from old to new, sorted by type, then by ID. Objects missing in old are created,
+// objects which differ are modified and objects missing in new are deleted: their old version
+// is passed with Info.Visible set to false. Objects of both inputs must be sorted in this order.
+// Both inputs are streamed.
+func Diff(old, new io.Reader, fn func(c *Change) error) error {
+	oldReader, err := newSortedReader(old)
+	if err != nil {
+		return err
+	}
+	defer oldReader.d.Close()
+	newReader, err := newSortedReader(new)
+	if err != nil {
+		return err
+	}
+	defer newReader.d.Close()
+
+	o, err := oldReader.next()
+	if err != nil {
+		return err
+	}
+	n, err := newReader.next()
+	if err != nil {
+		return err
+	}
+	for o != nil || n != nil {
+		var c *Change
+		switch cmp := compareObjects(o, n); {
+		case cmp < 0:
+			setVisible(o, false)
+			c = &Change{Delete, o}
+			o, err = oldReader.next()
+		case cmp > 0:
+			c = &Change{Create, n}
+			n, err = newReader.next()
+		default:
+			if !reflect.DeepEqual(o, n) {
+				c = &Change{Modify, n}
+			}
+			if o, err = oldReader.next(); err == nil {
+				n, err = newReader.next()
+			}
+		}
+		if err != nil {
+			return err
+		}
+		if c != nil {
+			if err := fn(c); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// DiffChange writes changes from old to new, see Diff, to dst as osmChange data.
+func DiffChange(dst io.Writer, old, new io.Reader) error {
+	e := NewChangeEncoder(dst)
+	if err := Diff(old, new, e.Encode); err != nil {
+		return err
+	}
+	return e.Close()
+}
+
+// DiffPBF writes changes from old to new, see Diff, to dst as PBF data with HistoricalInformation
+// feature, so deleted objects are kept with Info.Visible set to false.
+func DiffPBF(dst io.Writer, old, new io.Reader) error {
+	e := osmpbf.NewEncoder(dst)
+	e.SetHeader(&osmpbf.Header{
+		RequiredFeatures: []string{historicalFeature},
+		OptionalFeatures: []string{sortedFeature},
+	})
+	err := Diff(old, new, func(c *Change) error {
+		return e.Encode(c.Object)
+	})
+	if err != nil {
+		return err
+	}
+	return e.Close()
+}
+
+// Compares kinds and IDs of objects, nil is greater than any object.
+func compareObjects(a, b osmpbf.Object) int {
+	switch {
+	case a == nil:
+		return 1
+	case b == nil:
+		return -1
+	case a.Kind() != b.Kind():
+		return int(a.Kind()) - int(b.Kind())
+	}
+	aID, bID := objectID(a), objectID(b)
+	switch {
+	case aID < bID:
+		return -1
+	case aID > bID:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,82 @@
+package osmxml
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/brechtbm/osmpbf"
+)
+
+func TestDiff(t *testing.T) {
+	info := osmpbf.Info{Version: 1, Timestamp: time.Unix(1e9, 0).UTC(), Visible: true}
+	modified := info
+	modified.Version = 2
+	old := encodePBF(t, &osmpbf.Header{},
+		&osmpbf.Node{ID: 1, Info: info},
+		&osmpbf.Node{ID: 2, Info: info},
+		&osmpbf.Node{ID: 3, Info: info},
+		&osmpbf.Way{ID: 10, NodeIDs: []int64{1, 2}, Info: info},
+	).Bytes()
+	new := encodePBF(t, &osmpbf.Header{},
+		&osmpbf.Node{ID: 1, Info: info},
+		&osmpbf.Node{ID: 2, Tags: map[string]string{"highway": "stop"}, Info: modified},
+		&osmpbf.Node{ID: 4, Info: info},
+		&osmpbf.Way{ID: 10, NodeIDs: []int64{1, 2}, Info: info},
+		&osmpbf.Relation{ID: 5, Info: info},
+	).Bytes()
+	expected := []string{"modify node 2 v2", "delete node 3 v1", "create node 4 v1", "create relation 5 v1"}
+
+	var changes []string
+	err := Diff(bytes.NewReader(old), bytes.NewReader(new), func(c *Change) error {
+		changes = append(changes, fmt.Sprintf("%v %v %d v%d", c.Action, c.Object.Kind(), objectID(c.Object), version(c.Object)))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(expected, changes) {
+		t.Errorf("\nExpected: %v\nActual:   %v", expected, changes)
+	}
+
+	// osmChange is read by ChangeDecoder
+	var osc bytes.Buffer
+	if err := DiffChange(&osc, bytes.NewReader(old), bytes.NewReader(new)); err != nil {
+		t.Fatal(err)
+	}
+	d := NewChangeDecoder(&osc)
+	changes = changes[:0]
+	for {
+		c, err := d.Decode()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		changes = append(changes, fmt.Sprintf("%v %v %d v%d", c.Action, c.Object.Kind(), objectID(c.Object), version(c.Object)))
+	}
+	if !reflect.DeepEqual(expected, changes) {
+		t.Errorf("\nExpected: %v\nActual:   %v", expected, changes)
+	}
+
+	// PBF keeps deleted objects as not visible
+	var pbf bytes.Buffer
+	if err := DiffPBF(&pbf, bytes.NewReader(old), bytes.NewReader(new)); err != nil {
+		t.Fatal(err)
+	}
+	var visible []bool
+	for _, v := range decodeAll(t, osmpbf.NewDecoder(&pbf)) {
+		switch o := v.(type) {
+		case *osmpbf.Node:
+			visible = append(visible, o.Info.Visible)
+		case *osmpbf.Relation:
+			visible = append(visible, o.Info.Visible)
+		}
+	}
+	if !reflect.DeepEqual([]bool{true, false, true, true}, visible) {
+		t.Errorf("unexpected visible flags %v", visible)
+	}
+}
@@ -0,0 +1,258 @@
+package osmxml
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/brechtbm/osmpbf"
+)
+
+const (
+	writingProgram    = "osmpbf"
+	historicalFeature = "HistoricalInformation"
+)
+
+// An Encoder writes OpenStreetMap XML data to an output stream.
+type Encoder struct {
+	w   io.Writer
+	buf bytes.Buffer
+
+	header        osmpbf.Header
+	headerWritten bool
+	historical    bool
+
+	indent string // of object elements
+}
+
+// NewEncoder returns a new encoder that writes to w.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w, header: osmpbf.Header{WritingProgram: writingProgram}, indent: " "}
+}
+
+// SetHeader sets metadata written to osm root element and bounds. Visible attribute is written
+// for all objects if RequiredFeatures contains HistoricalInformation, otherwise only for deleted
+// ones with version, so objects with zero Info are not deleted. Must be called before Encode.
+func (enc *Encoder) SetHeader(h *osmpbf.Header) {
+	enc.header = *h
+	if enc.header.WritingProgram == "" {
+		enc.header.WritingProgram = writingProgram
+	}
+
+	enc.historical = false
+	for _, feature := range h.RequiredFeatures {
+		if feature == historicalFeature {
+			enc.historical = true
+		}
+	}
+}
+
+// Encode writes a pointer to Node, Way or Relation struct to the output stream, in the same way
+// as osmpbf.Encoder: Bound sets bounds, so it must be passed before objects, and objects should
+// be passed in the usual OSM order: nodes, then ways, then relations. Tags are written sorted by key,
+// unless object has TagList only. Way.NodeLocations are written as lat and lon attributes of nd
+// elements, like in Overpass API output.
+func (enc *Encoder) Encode(v interface{}) error {
+	if b, ok := v.(*osmpbf.Bound); ok {
+		if enc.headerWritten {
+			return errors.New("bound after objects")
+		}
+		bbox := b.BBox
+		enc.header.BBox = &bbox
+		return nil
+	}
+
+	members, err := validate(v)
+	if err != nil {
+		return err
+	}
+	enc.writeHeader()
+	enc.writeObject(v, members)
+	return enc.flush()
+}
+
+// Close writes the end of osm root element. It does not close the underlying writer.
+// If no objects were encoded, only root element and bounds are written.
+func (enc *Encoder) Close() error {
+	enc.writeHeader()
+	enc.buf.WriteString("</osm>\n")
+	return enc.flush()
+}
+
+func (enc *Encoder) flush() error {
+	_, err := enc.buf.WriteTo(enc.w)
+	return err
+}
+
+// Writes XML declaration, osm root element and bounds if they are not written yet.
+func (enc *Encoder) writeHeader() {
+	if enc.headerWritten {
+		return
+	}
+	enc.headerWritten = true
+
+	enc.buf.WriteString(xml.Header)
+	enc.buf.WriteString(`<osm version="0.6"`)
+	enc.writeAttr("generator", enc.header.WritingProgram)
+	enc.buf.WriteString(">\n")
+	if b := enc.header.BBox; b != nil {
+		enc.buf.WriteString(` <bounds`)
+		enc.writeAttr("minlat", formatFloat(b.Bottom))
+		enc.writeAttr("minlon", formatFloat(b.Left))
+		enc.writeAttr("maxlat", formatFloat(b.Top))
+		enc.writeAttr("maxlon", formatFloat(b.Right))
+		enc.buf.WriteString("/>\n")
+	}
+}
+
+// Checks object before anything is written, returns names of relation member types.
+func validate(v interface{}) ([]string, error) {
+	switch o := v.(type) {
+	case *osmpbf.Node, *osmpbf.Way:
+	case *osmpbf.Relation:
+		members, err := memberTypes(o.Members)
+		if err != nil {
+			return nil, fmt.Errorf("relation %d: %w", o.ID, err)
+		}
+		return members, nil
+	default:
+		return nil, fmt.Errorf("unknown type %T", v)
+	}
+	return nil, nil
+}
+
+// Writes object checked by validate.
+func (enc *Encoder) writeObject(v interface{}, members []string) {
+	switch o := v.(type) {
+	case *osmpbf.Node:
+		enc.buf.WriteString(enc.indent + "<node")
+		enc.writeAttrs(o.ID, o.Info)
+		enc.writeAttr("lat", formatFloat(o.Lat))
+		enc.writeAttr("lon", formatFloat(o.Lon))
+		enc.writeTags("node", o.Tags, o.TagList, nil)
+
+	case *osmpbf.Way:
+		enc.buf.WriteString(enc.indent + "<way")
+		enc.writeAttrs(o.ID, o.Info)
+		enc.writeTags("way", o.Tags, o.TagList, func() {
+			locations := len(o.NodeLocations) == len(o.NodeIDs)
+			for i, id := range o.NodeIDs {
+				enc.buf.WriteString(enc.indent + " <nd")
+				enc.writeAttr("ref", strconv.FormatInt(id, 10))
+				if locations {
+					enc.writeAttr("lat", formatFloat(o.NodeLocations[i].Lat))
+					enc.writeAttr("lon", formatFloat(o.NodeLocations[i].Lon))
+				}
+				enc.buf.WriteString("/>\n")
+			}
+		})
+
+	case *osmpbf.Relation:
+		enc.buf.WriteString(enc.indent + "<relation")
+		enc.writeAttrs(o.ID, o.Info)
+		enc.writeTags("relation", o.Tags, o.TagList, func() {
+			for i, m := range o.Members {
+				enc.buf.WriteString(enc.indent + " <member")
+				enc.writeAttr("type", members[i])
+				enc.writeAttr("ref", strconv.FormatInt(m.ID, 10))
+				enc.writeAttr("role", m.Role)
+				enc.buf.WriteString("/>\n")
+			}
+		})
+	}
+}
+
+// Writes attribute with escaped value.
+func (enc *Encoder) writeAttr(name, value string) {
+	enc.buf.WriteString(" " + name + `="`)
+	xml.EscapeText(&enc.buf, []byte(value))
+	enc.buf.WriteByte('"')
+}
+
+// Writes id and metadata attributes in the order used by OSM API.
+func (enc *Encoder) writeAttrs(id int64, info osmpbf.Info) {
+	enc.writeAttr("id", strconv.FormatInt(id, 10))
+	if enc.historical || !info.Visible && info.Version != 0 {
+		enc.writeAttr("visible", strconv.FormatBool(info.Visible))
+	}
+	if info.Version != 0 {
+		enc.writeAttr("version", strconv.Itoa(int(info.Version)))
+	}
+	if info.Changeset != 0 {
+		enc.writeAttr("changeset", strconv.FormatUint(info.Changeset, 10))
+	}
+	if !info.Timestamp.IsZero() {
+		enc.writeAttr("timestamp", info.Timestamp.UTC().Format(time.RFC3339))
+	}
+	if info.User != "" || info.Uid != 0 {
+		enc.writeAttr("user", info.User)
+		enc.writeAttr("uid", strconv.Itoa(int(info.Uid)))
+	}
+}
+
+// Writes child elements by children, if not nil, followed by tags, and closes element.
+func (enc *Encoder) writeTags(element string, tags map[string]string, list osmpbf.TagList, children func()) {
+	sorted := sortedTags(tags, list)
+	if len(sorted) == 0 && children == nil {
+		enc.buf.WriteString("/>\n")
+		return
+	}
+
+	enc.buf.WriteString(">\n")
+	if children != nil {
+		children()
+	}
+	for _, tag := range sorted {
+		enc.buf.WriteString(enc.indent + " <tag")
+		enc.writeAttr("k", tag.Key)
+		enc.writeAttr("v", tag.Value)
+		enc.buf.WriteString("/>\n")
+	}
+	enc.buf.WriteString(enc.indent + "</" + element + ">\n")
+}
+
+// Returns tags from map sorted by key, so output does not depend on map iteration order.
+// If map is empty, list is returned as is.
+func sortedTags(tags map[string]string, list osmpbf.TagList) osmpbf.TagList {
+	if len(tags) == 0 {
+		return list
+	}
+
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	l := make(osmpbf.TagList, len(keys))
+	for i, key := range keys {
+		l[i] = osmpbf.Tag{Key: key, Value: tags[key]}
+	}
+	return l
+}
+
+func memberTypes(members []osmpbf.Member) ([]string, error) {
+	types := make([]string, len(members))
+	for i, m := range members {
+		switch m.Type {
+		case osmpbf.NodeType:
+			types[i] = "node"
+		case osmpbf.WayType:
+			types[i] = "way"
+		case osmpbf.RelationType:
+			types[i] = "relation"
+		default:
+			return nil, fmt.Errorf("unknown member type %d", m.Type)
+		}
+	}
+	return types, nil
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
@@ -0,0 +1,101 @@
+package osmxml
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/brechtbm/osmpbf"
+)
+
+func TestEncode(t *testing.T) {
+	var buf bytes.Buffer
+	e := NewEncoder(&buf)
+	e.SetHeader(&osmpbf.Header{WritingProgram: "test"})
+	bound := &osmpbf.Bound{BBox: osmpbf.BBox{Left: -0.3, Right: -0.1, Top: 51.6, Bottom: 51.5}}
+	if err := e.Encode(bound); err != nil {
+		t.Fatal(err)
+	}
+	for _, o := range testObjects {
+		if err := e.Encode(o); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := e.Encode(bound); err == nil {
+		t.Error("expected error for bound after objects")
+	}
+	if err := e.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	d := NewDecoder(bytes.NewReader(buf.Bytes()))
+	if decoded := decodeAll(t, d); !reflect.DeepEqual(testObjects, decoded) {
+		t.Errorf("\nExpected: %#v\nActual:   %#v", testObjects, decoded)
+	}
+	if !reflect.DeepEqual(&bound.BBox, d.Header().BBox) || d.Header().WritingProgram != "test" {
+		t.Errorf("unexpected header %#v", d.Header())
+	}
+}
+
+func TestEncodeElements(t *testing.T) {
+	for _, c := range []struct {
+		o        interface{}
+		expected string
+	}{
+		{
+			&osmpbf.Node{ID: 1, Lat: 1.5, Lon: -2, Tags: map[string]string{"b": "<\"&'>\n", "a": "1"}, Info: osmpbf.Info{Visible: true}},
+			` <node id="1" lat="1.5" lon="-2">
+  <tag k="a" v="1"/>
+  <tag k="b" v="&lt;&#34;&amp;&#39;&gt;&#xA;"/>
+ </node>
+`,
+		},
+		{
+			// zero Info is not deleted
+			&osmpbf.Node{ID: -1, TagList: osmpbf.TagList{{Key: "z", Value: "1"}, {Key: "a", Value: "2"}}},
+			` <node id="-1" lat="0" lon="0">
+  <tag k="z" v="1"/>
+  <tag k="a" v="2"/>
+ </node>
+`,
+		},
+		{
+			&osmpbf.Node{ID: 3, Info: osmpbf.Info{Version: 2}},
+			` <node id="3" visible="false" version="2" lat="0" lon="0"/>
+`,
+		},
+		{
+			&osmpbf.Way{ID: 2, NodeIDs: []int64{1, 3}, NodeLocations: []osmpbf.LatLon{{Lat: 1, Lon: 2}, {Lat: 3, Lon: 4}},
+				Info: osmpbf.Info{Version: 1, User: "x", Uid: 5, Visible: true}},
+			` <way id="2" version="1" user="x" uid="5">
+  <nd ref="1" lat="1" lon="2"/>
+  <nd ref="3" lat="3" lon="4"/>
+ </way>
+`,
+		},
+	} {
+		var buf bytes.Buffer
+		e := NewEncoder(&buf)
+		if err := e.Encode(c.o); err != nil {
+			t.Fatal(err)
+		}
+		if err := e.Close(); err != nil {
+			t.Fatal(err)
+		}
+		expected := "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<osm version=\"0.6\" generator=\"osmpbf\">\n" +
+			c.expected + "</osm>\n"
+		if buf.String() != expected {
+			t.Errorf("\nExpected: %s\nActual:   %s", expected, buf.String())
+		}
+	}
+
+	e := NewEncoder(new(bytes.Buffer))
+	err := e.Encode(&osmpbf.Relation{ID: 1, Members: []osmpbf.Member{{Type: 5}}})
+	if err == nil || !strings.Contains(err.Error(), "unknown member type 5") {
+		t.Errorf("expected member type error, got %v", err)
+	}
+	if err := e.Encode(osmpbf.Node{}); err == nil {
+		t.Error("expected error for unknown type")
+	}
+}
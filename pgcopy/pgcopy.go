@@ -0,0 +1,219 @@
+// Package pgcopy writes objects decoded by package osmpbf as rows of PostgreSQL COPY text format,
+// with PostGIS geometry, so they can be imported by a pure Go pipeline without osm2pgsql.
+//
+// Each row has columns osm_type, osm_id, tags and geom, for example for a table created by:
+//
+//	CREATE TABLE osm (osm_type text, osm_id bigint, tags hstore, geom geometry);
+//
+// and filled by COPY osm FROM STDIN. Geometry is hex encoded EWKB in WGS 84 (SRID 4326): Point of
+// node, LineString or Polygon of way and MultiPolygon of area. Tags are hstore or jsonb.
+package pgcopy
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/brechtbm/osmpbf"
+)
+
+// SRID is spatial reference ID of geometries, WGS 84.
+const SRID = 4326
+
+// TagsFormat is format of tags column.
+type TagsFormat int
+
+const (
+	// Hstore writes tags as hstore, like "key"=>"value".
+	Hstore TagsFormat = iota
+
+	// JSONB writes tags as JSON object, for json or jsonb column.
+	JSONB
+)
+
+// Locations is a source of node locations, used to build geometry of ways. It is implemented by
+// osmpbf.NodeLocationStore.
+type Locations interface {
+	// Location returns location of node with given ID, or false if it is unknown.
+	Location(id int64) (osmpbf.LatLon, bool)
+}
+
+// An Encoder writes rows of objects to an output stream.
+type Encoder struct {
+	w         *bufio.Writer
+	locations Locations
+	tags      TagsFormat
+}
+
+// NewEncoder returns a new encoder that writes rows to w, with way geometry built from locations,
+// which may be nil if ways have NodeLocations.
+func NewEncoder(w io.Writer, locations Locations, tags TagsFormat) *Encoder {
+	return &Encoder{w: bufio.NewWriter(w), locations: locations, tags: tags}
+}
+
+// Encode writes row of a pointer to Node, Way or Area struct. Objects without geometry, like
+// relations and ways with less than two known node locations, are skipped; unknown nodes of ways
+// are left out. Closed ways with all locations known are Polygons if osmpbf.IsClosedWayArea
+// returns true, otherwise ways are LineStrings. Output is buffered, so Close must be called to
+// write remaining data.
+func (enc *Encoder) Encode(v interface{}) error {
+	var kind osmpbf.Kind
+	var id int64
+	var tags map[string]string
+	var list osmpbf.TagList
+	var geom []byte
+
+	switch v := v.(type) {
+	case *osmpbf.Node:
+		kind, id, tags, list = osmpbf.NodeKind, v.ID, v.Tags, v.TagList
+		geom = appendPoint(ewkbHeader(1), osmpbf.LatLon{Lat: v.Lat, Lon: v.Lon})
+	case *osmpbf.Way:
+		kind, id, tags, list = osmpbf.WayKind, v.ID, v.Tags, v.TagList
+		line := make([]osmpbf.LatLon, 0, len(v.NodeIDs))
+		for i := range v.NodeIDs {
+			if l, ok := enc.wayNode(v, i); ok {
+				line = append(line, l)
+			}
+		}
+		if len(line) < 2 {
+			return nil
+		}
+		if len(line) == len(v.NodeIDs) && osmpbf.IsClosedWayArea(v) {
+			geom = appendUint32(ewkbHeader(3), 1)
+		} else {
+			geom = ewkbHeader(2)
+		}
+		geom = appendLine(geom, line)
+	case *osmpbf.Area:
+		if v.Relation == nil || len(v.Polygons) == 0 {
+			return nil
+		}
+		kind, id, tags, list = osmpbf.RelationKind, v.Relation.ID, v.Relation.Tags, v.Relation.TagList
+		geom = appendUint32(ewkbHeader(6), uint32(len(v.Polygons)))
+		for _, polygon := range v.Polygons {
+			// polygons of MultiPolygon have their own headers, without SRID
+			geom = append(geom, 1)
+			geom = appendUint32(geom, 3)
+			geom = appendUint32(geom, uint32(len(polygon)))
+			for _, ring := range polygon {
+				geom = appendLine(geom, ring)
+			}
+		}
+	case *osmpbf.Relation, *osmpbf.Bound, *osmpbf.Header:
+		return nil
+	default:
+		return fmt.Errorf("unknown type %T", v)
+	}
+
+	enc.w.WriteString(kind.String())
+	enc.w.WriteByte('\t')
+	enc.w.WriteString(strconv.FormatInt(id, 10))
+	enc.w.WriteByte('\t')
+	enc.w.WriteString(escape(enc.formatTags(sortedTags(tags, list))))
+	enc.w.WriteByte('\t')
+	enc.w.WriteString(strings.ToUpper(hex.EncodeToString(geom)))
+	_, err := enc.w.WriteString("\n")
+	return err
+}
+
+// Close flushes buffered data. It does not close the underlying writer.
+func (enc *Encoder) Close() error {
+	return enc.w.Flush()
+}
+
+// Returns location of i-th node of way.
+func (enc *Encoder) wayNode(w *osmpbf.Way, i int) (osmpbf.LatLon, bool) {
+	if len(w.NodeLocations) == len(w.NodeIDs) {
+		return w.NodeLocations[i], true
+	}
+	if enc.locations == nil {
+		return osmpbf.LatLon{}, false
+	}
+	return enc.locations.Location(w.NodeIDs[i])
+}
+
+// Returns tags from map sorted by key, or tags of list in their order.
+func sortedTags(tags map[string]string, list osmpbf.TagList) osmpbf.TagList {
+	if len(tags) == 0 {
+		return list
+	}
+	sorted := make(osmpbf.TagList, 0, len(tags))
+	for k, v := range tags {
+		sorted = append(sorted, osmpbf.Tag{Key: k, Value: v})
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Key < sorted[j].Key })
+	return sorted
+}
+
+// Returns tags in hstore or JSON syntax.
+func (enc *Encoder) formatTags(tags osmpbf.TagList) string {
+	var b strings.Builder
+	if enc.tags == JSONB {
+		b.WriteByte('{')
+		for i, tag := range tags {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			k, _ := json.Marshal(tag.Key)
+			v, _ := json.Marshal(tag.Value)
+			b.Write(k)
+			b.WriteByte(':')
+			b.Write(v)
+		}
+		b.WriteByte('}')
+		return b.String()
+	}
+
+	quote := strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+	for i, tag := range tags {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(`"` + quote.Replace(tag.Key) + `"=>"` + quote.Replace(tag.Value) + `"`)
+	}
+	return b.String()
+}
+
+var copyEscaper = strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`)
+
+// Returns value escaped for COPY text format.
+func escape(s string) string {
+	return copyEscaper.Replace(s)
+}
+
+// Returns little endian EWKB header of geometry type with SRID.
+func ewkbHeader(geometryType uint32) []byte {
+	const sridFlag = 0x20000000
+	b := []byte{1}
+	b = appendUint32(b, geometryType|sridFlag)
+	return appendUint32(b, SRID)
+}
+
+func appendUint32(b []byte, v uint32) []byte {
+	var buf [4]byte
+	binary.LittleEndian.PutUint32(buf[:], v)
+	return append(b, buf[:]...)
+}
+
+func appendPoint(b []byte, l osmpbf.LatLon) []byte {
+	var buf [16]byte
+	binary.LittleEndian.PutUint64(buf[:8], math.Float64bits(l.Lon))
+	binary.LittleEndian.PutUint64(buf[8:], math.Float64bits(l.Lat))
+	return append(b, buf[:]...)
+}
+
+// Appends number of points and points of line or ring.
+func appendLine(b []byte, line []osmpbf.LatLon) []byte {
+	b = appendUint32(b, uint32(len(line)))
+	for _, l := range line {
+		b = appendPoint(b, l)
+	}
+	return b
+}
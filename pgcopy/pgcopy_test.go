@@ -0,0 +1,74 @@
+package pgcopy
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/brechtbm/osmpbf"
+)
+
+func TestEncoder(t *testing.T) {
+	locations := osmpbf.NewSparseLocationStore()
+	locations.Set(1, osmpbf.LatLon{Lat: 0, Lon: 0})
+	locations.Set(2, osmpbf.LatLon{Lat: 0, Lon: 1})
+	locations.Set(3, osmpbf.LatLon{Lat: 1, Lon: 1})
+
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf, locations, Hstore)
+	objects := []interface{}{
+		&osmpbf.Node{ID: 1, Lat: 2, Lon: 1, Tags: map[string]string{"name": "a\"b\\c\td", "amenity": "cafe"}},
+		&osmpbf.Way{ID: 10, NodeIDs: []int64{1, 2}, TagList: osmpbf.TagList{{Key: "highway", Value: "primary"}}},
+		&osmpbf.Way{ID: 11, NodeIDs: []int64{1, 2, 3, 1}, Tags: map[string]string{"building": "yes"}},
+		&osmpbf.Way{ID: 12, NodeIDs: []int64{1, 4}},
+		&osmpbf.Relation{ID: 20},
+	}
+	for _, o := range objects {
+		if err := enc.Encode(o); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	rows := strings.Split(buf.String(), "\n")
+	if len(rows) != 4 || rows[3] != "" {
+		t.Fatalf("unexpected rows %q", rows)
+	}
+	// SRID=4326;POINT(1 2)
+	expected := `node	1	"amenity"=>"cafe", "name"=>"a\\"b\\\\c\td"	0101000020E6100000000000000000F03F0000000000000040`
+	if rows[0] != expected {
+		t.Errorf("\nExpected: %s\nActual:   %s", expected, rows[0])
+	}
+	// SRID=4326;LINESTRING(0 0,1 0)
+	expected = `way	10	"highway"=>"primary"	0102000020E61000000200000000000000000000000000000000000000000000000000F03F0000000000000000`
+	if rows[1] != expected {
+		t.Errorf("\nExpected: %s\nActual:   %s", expected, rows[1])
+	}
+	if !strings.HasPrefix(rows[2], "way\t11\t\"building\"=>\"yes\"\t0103000020E61000000100000004000000") {
+		t.Errorf("expected polygon, got %s", rows[2])
+	}
+}
+
+func TestEncoderArea(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf, nil, JSONB)
+	ring := []osmpbf.LatLon{{Lat: 0, Lon: 0}, {Lat: 0, Lon: 1}, {Lat: 1, Lon: 1}, {Lat: 0, Lon: 0}}
+	area := &osmpbf.Area{
+		Relation: &osmpbf.Relation{ID: 20, Tags: map[string]string{"type": "multipolygon", "name": "x\ny"}},
+		Polygons: [][][]osmpbf.LatLon{{ring}},
+	}
+	if err := enc.Encode(area); err != nil {
+		t.Fatal(err)
+	}
+	if err := enc.Encode(struct{}{}); err == nil {
+		t.Error("expected error for unknown type")
+	}
+	enc.Close()
+
+	expected := `relation	20	{"name":"x\\ny","type":"multipolygon"}	0106000020E610000001000000010300000001000000040000000`
+	if !strings.HasPrefix(buf.String(), expected) {
+		t.Errorf("\nExpected: %s...\nActual:   %s", expected, buf.String())
+	}
+}
@@ -0,0 +1,251 @@
+package osmpbf
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Polygon is an area bounded by rings. Point is inside polygon if a ray from it crosses rings
+// odd number of times, so holes may be given as rings inside outer rings, and several outer
+// rings make a multipolygon.
+//
+// Edges are indexed by latitude strips, so Contains checks only a few edges near the point.
+type Polygon struct {
+	bbox   BBox
+	strips [][]edge
+	height float64 // of one strip
+}
+
+type edge struct {
+	lat1, lon1 float64
+	lat2, lon2 float64
+}
+
+// NewPolygon returns polygon bounded by rings. Rings are closed automatically.
+func NewPolygon(rings ...[]LatLon) *Polygon {
+	p := &Polygon{
+		bbox: BBox{Left: math.Inf(1), Right: math.Inf(-1), Top: math.Inf(-1), Bottom: math.Inf(1)},
+	}
+
+	var edges []edge
+	for _, ring := range rings {
+		for i, a := range ring {
+			b := ring[(i+1)%len(ring)]
+			if a != b {
+				edges = append(edges, edge{a.Lat, a.Lon, b.Lat, b.Lon})
+			}
+
+			p.bbox.Left = math.Min(p.bbox.Left, a.Lon)
+			p.bbox.Right = math.Max(p.bbox.Right, a.Lon)
+			p.bbox.Bottom = math.Min(p.bbox.Bottom, a.Lat)
+			p.bbox.Top = math.Max(p.bbox.Top, a.Lat)
+		}
+	}
+	if len(edges) == 0 {
+		return p
+	}
+
+	// a few edges per strip on average
+	n := len(edges)/4 + 1
+	if n > 4096 {
+		n = 4096
+	}
+	p.strips = make([][]edge, n)
+	p.height = (p.bbox.Top - p.bbox.Bottom) / float64(n)
+	for _, e := range edges {
+		from, to := p.strip(math.Min(e.lat1, e.lat2)), p.strip(math.Max(e.lat1, e.lat2))
+		for i := from; i <= to; i++ {
+			p.strips[i] = append(p.strips[i], e)
+		}
+	}
+	return p
+}
+
+// BBox returns bounding box of polygon.
+func (p *Polygon) BBox() BBox {
+	return p.bbox
+}
+
+// Returns index of strip containing latitude.
+func (p *Polygon) strip(lat float64) int {
+	if p.height == 0 {
+		return 0
+	}
+	i := int((lat - p.bbox.Bottom) / p.height)
+	if i < 0 {
+		return 0
+	}
+	if i >= len(p.strips) {
+		return len(p.strips) - 1
+	}
+	return i
+}
+
+// Contains returns true if point is inside polygon.
+func (p *Polygon) Contains(lat, lon float64) bool {
+	if len(p.strips) == 0 || !p.bbox.Contains(lat, lon) {
+		return false
+	}
+
+	var inside bool
+	for _, e := range p.strips[p.strip(lat)] {
+		if (e.lat1 > lat) != (e.lat2 > lat) {
+			// longitude where edge crosses point's latitude
+			crossLon := e.lon1 + (lat-e.lat1)*(e.lon2-e.lon1)/(e.lat2-e.lat1)
+			if lon < crossLon {
+				inside = !inside
+			}
+		}
+	}
+	return inside
+}
+
+// ParsePoly reads polygon in Osmosis polygon filter file format (.poly). Sections with names
+// starting with "!" are holes.
+func ParsePoly(r io.Reader) (*Polygon, error) {
+	s := bufio.NewScanner(r)
+	next := func() (string, bool) {
+		for s.Scan() {
+			if line := strings.TrimSpace(s.Text()); line != "" {
+				return line, true
+			}
+		}
+		return "", false
+	}
+
+	// first line is polygon name
+	if _, ok := next(); !ok {
+		return nil, errors.New("empty polygon file")
+	}
+
+	var rings [][]LatLon
+	for {
+		section, ok := next()
+		if !ok {
+			return nil, errors.New("unexpected end of polygon file")
+		}
+		if section == "END" {
+			break
+		}
+
+		var ring []LatLon
+		for {
+			line, ok := next()
+			if !ok {
+				return nil, fmt.Errorf("unexpected end of polygon file in section %s", section)
+			}
+			if line == "END" {
+				break
+			}
+
+			fields := strings.Fields(line)
+			if len(fields) != 2 {
+				return nil, fmt.Errorf("invalid polygon file line %q", line)
+			}
+			lon, err := strconv.ParseFloat(fields[0], 64)
+			if err != nil {
+				return nil, err
+			}
+			lat, err := strconv.ParseFloat(fields[1], 64)
+			if err != nil {
+				return nil, err
+			}
+			ring = append(ring, LatLon{lat, lon})
+		}
+		rings = append(rings, ring)
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	return NewPolygon(rings...), nil
+}
+
+type geoJSONObject struct {
+	Type        string           `json:"type"`
+	Coordinates json.RawMessage  `json:"coordinates"`
+	Geometry    *geoJSONObject   `json:"geometry"`
+	Features    []*geoJSONObject `json:"features"`
+}
+
+// ParseGeoJSONPolygon reads polygon from GeoJSON Polygon or MultiPolygon geometry, or from Feature
+// or FeatureCollection with such geometries; all of them are joined into one polygon.
+func ParseGeoJSONPolygon(r io.Reader) (*Polygon, error) {
+	o := new(geoJSONObject)
+	if err := json.NewDecoder(r).Decode(o); err != nil {
+		return nil, err
+	}
+
+	rings, err := geoJSONRings(o)
+	if err != nil {
+		return nil, err
+	}
+	if len(rings) == 0 {
+		return nil, errors.New("no polygons in GeoJSON")
+	}
+	return NewPolygon(rings...), nil
+}
+
+func geoJSONRings(o *geoJSONObject) ([][]LatLon, error) {
+	var rings [][]LatLon
+	switch o.Type {
+	case "Polygon":
+		var coordinates [][][]float64
+		if err := json.Unmarshal(o.Coordinates, &coordinates); err != nil {
+			return nil, err
+		}
+		return appendGeoJSONRings(rings, coordinates)
+
+	case "MultiPolygon":
+		var coordinates [][][][]float64
+		if err := json.Unmarshal(o.Coordinates, &coordinates); err != nil {
+			return nil, err
+		}
+		var err error
+		for _, polygon := range coordinates {
+			if rings, err = appendGeoJSONRings(rings, polygon); err != nil {
+				return nil, err
+			}
+		}
+		return rings, nil
+
+	case "Feature":
+		if o.Geometry == nil {
+			return nil, nil
+		}
+		return geoJSONRings(o.Geometry)
+
+	case "FeatureCollection":
+		for _, f := range o.Features {
+			r, err := geoJSONRings(f)
+			if err != nil {
+				return nil, err
+			}
+			rings = append(rings, r...)
+		}
+		return rings, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported GeoJSON type %s", o.Type)
+	}
+}
+
+func appendGeoJSONRings(rings [][]LatLon, coordinates [][][]float64) ([][]LatLon, error) {
+	for _, c := range coordinates {
+		ring := make([]LatLon, len(c))
+		for i, position := range c {
+			if len(position) < 2 {
+				return nil, errors.New("invalid GeoJSON position")
+			}
+			ring[i] = LatLon{Lat: position[1], Lon: position[0]}
+		}
+		rings = append(rings, ring)
+	}
+	return rings, nil
+}
@@ -0,0 +1,124 @@
+package osmpbf
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testPoly = `square with hole
+1
+   0.0   0.0
+   10.0  0.0
+   10.0  10.0
+   0.0   10.0
+   0.0   0.0
+END
+!2
+   4.0E+00  4.0E+00
+   6.0E+00  4.0E+00
+   6.0E+00  6.0E+00
+   4.0E+00  6.0E+00
+END
+END
+`
+
+const testGeoJSON = `{
+	"type": "FeatureCollection",
+	"features": [{
+		"type": "Feature",
+		"properties": {},
+		"geometry": {
+			"type": "MultiPolygon",
+			"coordinates": [[
+				[[0, 0], [10, 0], [10, 10], [0, 10], [0, 0]],
+				[[4, 4], [6, 4], [6, 6], [4, 6], [4, 4]]
+			]]
+		}
+	}]
+}`
+
+func testPolygon(t *testing.T, p *Polygon) {
+	for _, c := range []struct {
+		lat, lon float64
+		inside   bool
+	}{
+		{1, 1, true},
+		{9.9, 5, true},
+		{5, 5, false}, // in hole
+		{5, 4.1, false},
+		{5, 3.9, true},
+		{-1, 5, false},
+		{5, 11, false},
+		{11, 11, false},
+	} {
+		if p.Contains(c.lat, c.lon) != c.inside {
+			t.Errorf("%v, %v: expected inside %v", c.lat, c.lon, c.inside)
+		}
+	}
+
+	if expected := (BBox{Left: 0, Right: 10, Top: 10, Bottom: 0}); p.BBox() != expected {
+		t.Errorf("expected %v, got %v", expected, p.BBox())
+	}
+}
+
+func TestParsePoly(t *testing.T) {
+	p, err := ParsePoly(strings.NewReader(testPoly))
+	if err != nil {
+		t.Fatal(err)
+	}
+	testPolygon(t, p)
+
+	if _, err = ParsePoly(strings.NewReader("name\n1\n 0 0\n")); err == nil {
+		t.Error("expected error for truncated file")
+	}
+}
+
+func TestParseGeoJSONPolygon(t *testing.T) {
+	p, err := ParseGeoJSONPolygon(strings.NewReader(testGeoJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	testPolygon(t, p)
+
+	if _, err = ParseGeoJSONPolygon(strings.NewReader(`{"type": "Point", "coordinates": [1, 2]}`)); err == nil {
+		t.Error("expected error for Point")
+	}
+}
+
+func TestPolygonManyEdges(t *testing.T) {
+	// star-shaped polygon with many edges spanning several strips
+	var ring []LatLon
+	for i := 0; i < 1000; i++ {
+		r := 1.0
+		if i%2 == 1 {
+			r = 0.5
+		}
+		a := 2 * math.Pi * float64(i) / 1000
+		ring = append(ring, LatLon{Lat: r * math.Sin(a), Lon: r * math.Cos(a)})
+	}
+	p := NewPolygon(ring)
+
+	if !p.Contains(0, 0) || !p.Contains(0.3, 0.3) || p.Contains(0.9, 0.9) || p.Contains(0, 1.01) {
+		t.Error("unexpected Contains result")
+	}
+}
+
+func TestSetRegion(t *testing.T) {
+	p, err := ParsePoly(strings.NewReader(testPoly))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := NewDecoder(encodeObjects(t, &Node{ID: 1, Lat: 1, Lon: 1}, &Node{ID: 2, Lat: 5, Lon: 5}, &Node{ID: 3, Lat: 20, Lon: 20}))
+	d.SetRegion(p, SpatialNodes)
+
+	var ids []string
+	for _, o := range decodeAll(t, d) {
+		ids = append(ids, objectID(o.(Object)))
+	}
+	if expected := []string{"node/1"}; !reflect.DeepEqual(expected, ids) {
+		t.Errorf("expected %v, got %v", expected, ids)
+	}
+}
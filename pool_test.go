@@ -0,0 +1,110 @@
+package osmpbf
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/brechtbm/osmpbf/OSMPBF"
+)
+
+func TestRecycle(t *testing.T) {
+	n := maxBlockEntities*2 + 1
+	d := NewDecoder(encodeNodesWays(t, n, 10))
+	if err := d.Start(4); err != nil {
+		t.Fatal(err)
+	}
+
+	var nodes, ways int
+	for {
+		v, err := d.Decode()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+
+		switch o := v.(type) {
+		case *Node:
+			nodes++
+			if o.ID != int64(nodes) {
+				t.Fatalf("expected node %d, got %d", nodes, o.ID)
+			}
+		case *Way:
+			ways++
+			if o.ID != int64(ways) || len(o.NodeIDs) != 2 || o.NodeIDs[1] != int64(ways+1) {
+				t.Fatalf("unexpected way %#v", o)
+			}
+		}
+		d.Recycle(v.(Object))
+	}
+
+	if nodes != n || ways != 10 {
+		t.Errorf("expected %d nodes and 10 ways, got %d and %d", n, nodes, ways)
+	}
+}
+
+func TestRelease(t *testing.T) {
+	data := encodeObjects(t, en, ew, er).Bytes()
+	for i := 0; i < 3; i++ {
+		decoded := decodeAll(t, NewDecoder(bytes.NewReader(data)))
+		if !reflect.DeepEqual(decoded, []interface{}{en, ew, er}) {
+			t.Fatalf("\nExpected: %#v\nActual:   %#v", []interface{}{en, ew, er}, decoded)
+		}
+		for _, o := range decoded {
+			o.(Object).Release()
+		}
+	}
+
+	// released maps and slices are cleared
+	w := &Way{ID: 1, Tags: map[string]string{"a": "b"}, NodeIDs: []int64{1, 2}}
+	w.Release()
+	if len(w.Tags) != 0 || len(w.NodeIDs) != 0 || w.ID != 0 {
+		t.Errorf("expected cleared way, got %#v", w)
+	}
+}
+
+func TestBlobBufPool(t *testing.T) {
+	// raw blob data is parsed directly from pooled buffers
+	n := maxBlockEntities*4 + 1
+	var buf bytes.Buffer
+	e := NewEncoder(&buf)
+	e.SetCompression(Uncompressed)
+	for i := 1; i <= n; i++ {
+		if err := e.Encode(&Node{ID: int64(i), Tags: map[string]string{"ref": strconv.Itoa(i)}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := e.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, skip := range []bool{false, true} {
+		d := NewDecoder(bytes.NewReader(buf.Bytes()))
+		if skip {
+			// skipped blobs are released by reader goroutine
+			d.SkipToWays()
+		}
+		var nodes int
+		for _, o := range decodeAll(t, d) {
+			nodes++
+			node := o.(*Node)
+			if node.ID != int64(nodes) || node.Tags["ref"] != strconv.Itoa(nodes) {
+				t.Fatalf("expected node %d, got %#v", nodes, node)
+			}
+		}
+		if !skip && nodes != n || skip && nodes != 0 {
+			t.Errorf("skip %v: unexpected %d nodes", skip, nodes)
+		}
+	}
+
+	pb := &pooledBlob{new(OSMPBF.Blob), new([]byte)}
+	releaseBlob(pb)
+	if pb.blob != nil || pb.buf != nil {
+		t.Error("expected released blob to be cleared")
+	}
+	releaseBlob((*pooledBlob)(nil))
+	releaseBlob(blobRef{})
+}
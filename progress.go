@@ -0,0 +1,28 @@
+package osmpbf
+
+import (
+	"sync/atomic"
+)
+
+// Progress describes decoding progress.
+type Progress struct {
+	// Bytes read from the input stream, including fileblocks which are not decoded yet.
+	// Compare with input size to show progress bar.
+	Bytes int64
+
+	// Blobs is the number of decoded OSMData blobs.
+	Blobs int64
+
+	// Objects is the number of objects returned by Decode.
+	Objects int64
+}
+
+// Progress returns current decoding progress. It is safe to call concurrently with Decode,
+// for example from a goroutine updating progress bar.
+func (dec *Decoder) Progress() Progress {
+	return Progress{
+		Bytes:   atomic.LoadInt64(&dec.progress.Bytes),
+		Blobs:   atomic.LoadInt64(&dec.progress.Blobs),
+		Objects: atomic.LoadInt64(&dec.progress.Objects),
+	}
+}
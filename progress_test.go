@@ -0,0 +1,21 @@
+package osmpbf
+
+import (
+	"testing"
+)
+
+func TestProgress(t *testing.T) {
+	buf := encodeNodesWays(t, maxBlockEntities*2+1, 10)
+	size := int64(buf.Len())
+
+	d := NewDecoder(buf)
+	if p := d.Progress(); p != (Progress{}) {
+		t.Errorf("expected zero progress, got %#v", p)
+	}
+
+	decoded := decodeAll(t, d)
+	expected := Progress{Bytes: size, Blobs: 4, Objects: int64(len(decoded))}
+	if p := d.Progress(); p != expected {
+		t.Errorf("\nExpected: %#v\nActual:   %#v", expected, p)
+	}
+}
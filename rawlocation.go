@@ -0,0 +1,42 @@
+package osmpbf
+
+// RawLocation is location of node as stored in PrimitiveBlock: coordinates in units of granularity,
+// with granularity and offsets of the block in nanodegrees. It is set if enabled by WithRawLocations.
+type RawLocation struct {
+	Lat         int64
+	Lon         int64
+	Granularity int32
+	LatOffset   int64
+	LonOffset   int64
+}
+
+// Number of RawLocations allocated at once by data decoder. Chunk is kept in memory as long as
+// any of its nodes is referenced, so it is smaller than typical PrimitiveBlock.
+const rawLocationsChunk = 1024
+
+// SetRawLocations is the same as WithRawLocations option. Must be called before Start.
+func (dec *Decoder) SetRawLocations(raw bool) {
+	WithRawLocations(raw)(dec)
+}
+
+// Nanodegrees returns latitude and longitude in nanodegrees.
+func (l RawLocation) Nanodegrees() (lat, lon int64) {
+	g := int64(l.Granularity)
+	return l.LatOffset + g*l.Lat, l.LonOffset + g*l.Lon
+}
+
+// E7 returns latitude and longitude in units of 100 nanodegrees, as stored by most OSM tools in
+// int32. Values are rounded down if granularity is finer than 100 nanodegrees.
+func (l RawLocation) E7() (lat, lon int32) {
+	nlat, nlon := l.Nanodegrees()
+	return int32(floorDiv(nlat, 100)), int32(floorDiv(nlon, 100))
+}
+
+// Returns a / b rounded towards negative infinity, b must be positive.
+func floorDiv(a, b int64) int64 {
+	q := a / b
+	if a%b < 0 {
+		q--
+	}
+	return q
+}
@@ -0,0 +1,55 @@
+package osmpbf
+
+import (
+	"testing"
+)
+
+func TestRawLocations(t *testing.T) {
+	data := encodeObjects(t, &Node{ID: 1, Lat: 51.5074321, Lon: -0.1278}, &Node{ID: 2, Lat: -33.8688, Lon: 151.2093})
+
+	d := NewDecoder(data)
+	d.SetRawLocations(true)
+	nodes := decodeAll(t, d)
+	for i, expected := range [][2]int32{{515074321, -1278000}, {-338688000, 1512093000}} {
+		n := nodes[i].(*Node)
+		if n.Raw == nil {
+			t.Fatal("expected raw location")
+		}
+		if n.Raw.Granularity != 100 {
+			t.Errorf("expected granularity 100, got %d", n.Raw.Granularity)
+		}
+		if lat, lon := n.Raw.E7(); lat != expected[0] || lon != expected[1] {
+			t.Errorf("expected %v, got %d, %d", expected, lat, lon)
+		}
+		if lat, lon := n.Raw.Nanodegrees(); float64(lat)*1e-9 != n.Lat || float64(lon)*1e-9 != n.Lon {
+			t.Errorf("expected %v, %v, got %d, %d nanodegrees", n.Lat, n.Lon, lat, lon)
+		}
+	}
+
+	d = NewDecoder(encodeObjects(t, &Node{ID: 1, Lat: 1, Lon: 1}))
+	if n := decodeAll(t, d)[0].(*Node); n.Raw != nil {
+		t.Errorf("expected no raw location, got %v", *n.Raw)
+	}
+}
+
+func TestRawLocationE7(t *testing.T) {
+	l := RawLocation{Lat: -15, Lon: 15, Granularity: 10, LatOffset: 1, LonOffset: -1}
+	if lat, lon := l.E7(); lat != -2 || lon != 1 {
+		t.Errorf("expected -2, 1, got %d, %d", lat, lon)
+	}
+}
+
+func TestRawLocationsChunks(t *testing.T) {
+	nodes := make([]Object, rawLocationsChunk+2)
+	for i := range nodes {
+		nodes[i] = &Node{ID: int64(i + 1), Lat: float64(i) * 1e-7}
+	}
+
+	d := NewDecoder(encodeObjects(t, nodes...))
+	d.SetRawLocations(true)
+	for i, o := range decodeAll(t, d) {
+		if lat, _ := o.(*Node).Raw.E7(); lat != int32(i) {
+			t.Fatalf("expected latitude %d, got %d", i, lat)
+		}
+	}
+}
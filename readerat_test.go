@@ -0,0 +1,66 @@
+package osmpbf
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestReaderAtDecoder(t *testing.T) {
+	var buf bytes.Buffer
+	e := NewEncoder(&buf)
+	n := maxBlockEntities*3 + 1
+	for i := 1; i <= n; i++ {
+		if err := e.Encode(&Node{ID: int64(i), Lat: float64(i) / 1e3, Lon: -float64(i) / 1e3}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := e.Encode(ew); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r := bytes.NewReader(buf.Bytes())
+	decoded := decodeAll(t, NewReaderAtDecoder(r, r.Size()))
+	if len(decoded) != n+1 {
+		t.Fatalf("expected %d objects, got %d", n+1, len(decoded))
+	}
+	for i, v := range decoded[:n] {
+		if id := v.(*Node).ID; id != int64(i+1) {
+			t.Fatalf("expected node %d, got %d", i+1, id)
+		}
+	}
+	if id := decoded[n].(*Way).ID; id != ew.ID {
+		t.Errorf("expected way %d, got %d", ew.ID, id)
+	}
+}
+
+func TestReaderAtDecoderTruncated(t *testing.T) {
+	data := encodeObjects(t, en, ew, er).Bytes()
+	r := bytes.NewReader(data[:len(data)-10])
+
+	d := NewReaderAtDecoder(r, r.Size())
+	if err := d.Start(2); err != nil {
+		t.Fatal(err)
+	}
+	for {
+		_, err := d.Decode()
+		if err != nil {
+			if err != io.ErrUnexpectedEOF {
+				t.Errorf("expected unexpected EOF, got %v", err)
+			}
+			break
+		}
+	}
+}
+
+func TestReaderAtDecoderNegativeBlobSize(t *testing.T) {
+	data := negativeBlobSizeStream(t)
+	r := bytes.NewReader(data)
+	n, err := decodeUntilError(t, NewReaderAtDecoder(r, r.Size(), WithSkipCorrupt(true)))
+	if n != 2 || err == nil || err == io.EOF {
+		t.Errorf("expected 2 objects and Blob size error, got %d and %v", n, err)
+	}
+}
@@ -0,0 +1,123 @@
+package osmpbf
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// A Renumberer renumbers IDs of objects to dense range 1..N of each kind, like osmium renumber.
+// Way nodes and relation members are renumbered consistently: an ID referenced before its object
+// is assigned on first reference. Mapping can be written and read back, so diffs of the original
+// data can be renumbered in the same way later. Not safe for concurrent use.
+type Renumberer struct {
+	ids [3]map[int64]int64 // new ID by old ID, by Kind
+}
+
+// NewRenumberer returns a new renumberer with empty mapping.
+func NewRenumberer() *Renumberer {
+	r := new(Renumberer)
+	for k := range r.ids {
+		r.ids[k] = make(map[int64]int64)
+	}
+	return r
+}
+
+// Renumber replaces IDs of Node, Way or Relation in place, including way node IDs and relation
+// member IDs. Way.NodeLocations are kept. Other objects are not changed.
+func (r *Renumberer) Renumber(o Object) {
+	switch o := o.(type) {
+	case *Node:
+		o.ID = r.id(NodeKind, o.ID)
+	case *Way:
+		o.ID = r.id(WayKind, o.ID)
+		for i, id := range o.NodeIDs {
+			o.NodeIDs[i] = r.id(NodeKind, id)
+		}
+	case *Relation:
+		o.ID = r.id(RelationKind, o.ID)
+		for i, m := range o.Members {
+			o.Members[i].ID = r.id(m.Type.Kind(), m.ID)
+		}
+	}
+}
+
+// ID returns new ID of object of kind k with old ID id, or false if it is not assigned.
+func (r *Renumberer) ID(k Kind, id int64) (int64, bool) {
+	if k < NodeKind || k > RelationKind {
+		return 0, false
+	}
+	newID, ok := r.ids[k][id]
+	return newID, ok
+}
+
+// Returns new ID of old ID, assigning the next one if it is not mapped yet.
+func (r *Renumberer) id(k Kind, id int64) int64 {
+	ids := r.ids[k]
+	newID, ok := ids[id]
+	if !ok {
+		newID = int64(len(ids)) + 1
+		ids[id] = newID
+	}
+	return newID
+}
+
+var kindLetters = [3]byte{'n', 'w', 'r'}
+
+// WriteMapping writes mapping to w as lines of kind letter, old ID and new ID, like "n 123 1",
+// ordered by kind and new ID.
+func (r *Renumberer) WriteMapping(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	for k, ids := range r.ids {
+		old := make([]int64, len(ids))
+		for id, newID := range ids {
+			old[newID-1] = id
+		}
+		for i, id := range old {
+			fmt.Fprintf(bw, "%c %d %d\n", kindLetters[k], id, i+1)
+		}
+	}
+	return bw.Flush()
+}
+
+// ReadRenumberer returns renumberer with mapping written by WriteMapping. New IDs must be dense,
+// so new objects get the following ones.
+func ReadRenumberer(rd io.Reader) (*Renumberer, error) {
+	r := NewRenumberer()
+	s := bufio.NewScanner(rd)
+	for line := 1; s.Scan(); line++ {
+		var c byte
+		var id, newID int64
+		if _, err := fmt.Sscanf(s.Text(), "%c %d %d", &c, &id, &newID); err != nil {
+			return nil, fmt.Errorf("mapping line %d: %w", line, err)
+		}
+		k := -1
+		for i, l := range kindLetters {
+			if c == l {
+				k = i
+			}
+		}
+		if k < 0 {
+			return nil, fmt.Errorf("mapping line %d: unknown kind %q", line, c)
+		}
+		r.ids[k][id] = newID
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	for k, ids := range r.ids {
+		newIDs := make([]int64, 0, len(ids))
+		for _, newID := range ids {
+			newIDs = append(newIDs, newID)
+		}
+		sort.Slice(newIDs, func(i, j int) bool { return newIDs[i] < newIDs[j] })
+		for i, newID := range newIDs {
+			if newID != int64(i)+1 {
+				return nil, fmt.Errorf("mapping of %s IDs is not dense", Kind(k))
+			}
+		}
+	}
+	return r, nil
+}
@@ -0,0 +1,63 @@
+package osmpbf
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRenumberer(t *testing.T) {
+	objects := []Object{
+		&Node{ID: 100}, &Node{ID: 50}, &Node{ID: 7},
+		&Way{ID: 1000, NodeIDs: []int64{7, 100, 8}},
+		&Relation{ID: 30, Members: []Member{{ID: 1000, Type: WayType}, {ID: 31, Type: RelationType}, {ID: 50, Type: NodeType}}},
+		&Relation{ID: 31},
+	}
+	r := NewRenumberer()
+	for _, o := range objects {
+		r.Renumber(o)
+	}
+	expected := []Object{
+		&Node{ID: 1}, &Node{ID: 2}, &Node{ID: 3},
+		&Way{ID: 1, NodeIDs: []int64{3, 1, 4}},
+		&Relation{ID: 1, Members: []Member{{ID: 1, Type: WayType}, {ID: 2, Type: RelationType}, {ID: 2, Type: NodeType}}},
+		&Relation{ID: 2},
+	}
+	if !reflect.DeepEqual(expected, objects) {
+		t.Errorf("\nExpected: %v\nActual:   %v", expected, objects)
+	}
+	if id, ok := r.ID(NodeKind, 8); !ok || id != 4 {
+		t.Errorf("expected node 8 mapped to 4, got %d, %v", id, ok)
+	}
+
+	var buf bytes.Buffer
+	if err := r.WriteMapping(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(buf.String(), "n 100 1\nn 50 2\nn 7 3\nn 8 4\nw 1000 1\nr 30 1\n") {
+		t.Errorf("unexpected mapping:\n%s", buf.String())
+	}
+	read, err := ReadRenumberer(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(r, read) {
+		t.Error("read mapping differs")
+	}
+
+	// diff of original data
+	n := &Node{ID: 9}
+	w := &Way{ID: 1000, NodeIDs: []int64{9, 7}}
+	read.Renumber(n)
+	read.Renumber(w)
+	if n.ID != 5 || !reflect.DeepEqual(w, &Way{ID: 1, NodeIDs: []int64{5, 3}}) {
+		t.Errorf("unexpected renumbered diff %v, %v", n, w)
+	}
+
+	for _, s := range []string{"n 1 2\n", "x 1 1\n", "n a 1\n"} {
+		if _, err := ReadRenumberer(strings.NewReader(s)); err == nil {
+			t.Errorf("%q: expected error", s)
+		}
+	}
+}
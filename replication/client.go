@@ -0,0 +1,222 @@
+package replication
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/brechtbm/osmpbf"
+	"github.com/brechtbm/osmpbf/osmxml"
+)
+
+// A Client downloads state files and diffs from replication directory.
+type Client struct {
+	baseURL string
+	client  *http.Client
+}
+
+// NewClient returns a new client of replication directory at baseURL, for example
+// https://planet.openstreetmap.org/replication/minute. If client is nil, http.DefaultClient is used.
+func NewClient(baseURL string, client *http.Client) *Client {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &Client{baseURL: strings.TrimSuffix(baseURL, "/"), client: client}
+}
+
+// HeaderClient returns a new client of replication directory of file with header h.
+func HeaderClient(h *osmpbf.Header, client *http.Client) (*Client, error) {
+	if h.ReplicationBaseURL == "" {
+		return nil, errors.New("header has no replication base URL")
+	}
+	return NewClient(h.ReplicationBaseURL, client), nil
+}
+
+// State returns the latest replication state.
+func (c *Client) State(ctx context.Context) (*State, error) {
+	body, err := c.get(ctx, c.baseURL+"/state.txt")
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	return ReadState(body)
+}
+
+// SequenceState returns replication state of diff with given sequence number.
+func (c *Client) SequenceState(ctx context.Context, seq int64) (*State, error) {
+	body, err := c.get(ctx, c.path(seq)+".state.txt")
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	return ReadState(body)
+}
+
+// Diff returns gzip-compressed osmChange data of diff with given sequence number, which can be
+// read by osmxml.ChangeDecoder. It must be closed by caller.
+func (c *Client) Diff(ctx context.Context, seq int64) (io.ReadCloser, error) {
+	return c.get(ctx, c.path(seq)+".osc.gz")
+}
+
+// Update downloads all diffs published after state and applies them to PBF data from src, writing
+// updated data with replication state of the last diff in its header to dst. Returns the new
+// state, which is state itself if there are no new diffs: src is then copied to dst unchanged.
+// Diffs are kept in memory until they are applied.
+func (c *Client) Update(ctx context.Context, dst io.Writer, src io.Reader, state *State) (*State, error) {
+	latest, err := c.State(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if latest.SequenceNumber <= state.SequenceNumber {
+		_, err := io.Copy(dst, src)
+		return state, err
+	}
+	return latest, c.apply(ctx, dst, src, state, latest)
+}
+
+// Applies diffs after state up to latest state.
+func (c *Client) apply(ctx context.Context, dst io.Writer, src io.Reader, state, latest *State) error {
+	var diffs []io.Reader
+	for seq := state.SequenceNumber + 1; seq <= latest.SequenceNumber; seq++ {
+		body, err := c.Diff(ctx, seq)
+		if err != nil {
+			return err
+		}
+		data, err := io.ReadAll(body)
+		body.Close()
+		if err != nil {
+			return fmt.Errorf("diff %d: %w", seq, err)
+		}
+		diffs = append(diffs, bytes.NewReader(data))
+	}
+
+	return osmxml.ApplyHeader(dst, src, func(h *osmpbf.Header) error {
+		h.ReplicationSequenceNumber = latest.SequenceNumber
+		h.ReplicationTimestamp = latest.Timestamp
+		h.ReplicationBaseURL = c.baseURL
+		return nil
+	}, diffs...)
+}
+
+// WriteChanges downloads all diffs published after state and writes their changes in order to dst
+// as one osmChange document, which can be applied later or to other data. Returns the new state,
+// which is state itself if there are no new diffs: an empty document is then written.
+func (c *Client) WriteChanges(ctx context.Context, dst io.Writer, state *State) (*State, error) {
+	latest, err := c.State(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if latest.SequenceNumber < state.SequenceNumber {
+		latest = state
+	}
+
+	enc := osmxml.NewChangeEncoder(dst)
+	for seq := state.SequenceNumber + 1; seq <= latest.SequenceNumber; seq++ {
+		if err := c.copyChanges(ctx, enc, seq); err != nil {
+			return nil, fmt.Errorf("diff %d: %w", seq, err)
+		}
+	}
+	return latest, enc.Close()
+}
+
+// Writes changes of diff with given sequence number to enc.
+func (c *Client) copyChanges(ctx context.Context, enc *osmxml.ChangeEncoder, seq int64) error {
+	body, err := c.Diff(ctx, seq)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	dec := osmxml.NewChangeDecoder(body)
+	for {
+		change, err := dec.Decode()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if err := enc.Encode(change); err != nil {
+			return err
+		}
+	}
+}
+
+// UpdateFile updates PBF file at path to the latest replication state, tracking it in state file
+// at statePath. If state file does not exist, initial state is read from header of the file.
+// Updated file is written to temporary file first, which replaces the file when all diffs are
+// applied. If there are no new diffs, the file is not rewritten.
+func (c *Client) UpdateFile(ctx context.Context, path, statePath string) (*State, error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	state, err := LoadState(statePath)
+	if errors.Is(err, os.ErrNotExist) {
+		d := osmpbf.NewDecoder(src)
+		if err := d.Start(0); err != nil {
+			return nil, err
+		}
+		state, err = HeaderState(d.Header())
+		d.Close()
+		if err == nil {
+			_, err = src.Seek(0, io.SeekStart)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	latest, err := c.State(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if latest.SequenceNumber <= state.SequenceNumber {
+		return state, SaveState(statePath, state)
+	}
+
+	dst, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(dst.Name())
+
+	if err := c.apply(ctx, dst, src, state, latest); err != nil {
+		dst.Close()
+		return nil, err
+	}
+	if err := dst.Close(); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(dst.Name(), path); err != nil {
+		return nil, err
+	}
+	return latest, SaveState(statePath, latest)
+}
+
+// Returns URL of files of given sequence number, without extension.
+func (c *Client) path(seq int64) string {
+	return fmt.Sprintf("%s/%03d/%03d/%03d", c.baseURL, seq/1000000, seq/1000%1000, seq%1000)
+}
+
+func (c *Client) get(ctx context.Context, url string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return resp.Body, nil
+}
@@ -0,0 +1,169 @@
+package replication
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/brechtbm/osmpbf"
+)
+
+func gzipData(s string) []byte {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	w.Write([]byte(s))
+	w.Close()
+	return buf.Bytes()
+}
+
+func replicationServer(t *testing.T) *httptest.Server {
+	files := map[string][]byte{
+		"/state.txt": []byte("#Sat Oct 15 10:02:00 UTC 2022\nsequenceNumber=1002\ntimestamp=2022-10-15T10\\:02\\:00Z\n"),
+		"/000/001/001.osc.gz": gzipData(`<osmChange version="0.6"><create>` +
+			`<node id="3" version="1" lat="1" lon="1"/></create></osmChange>`),
+		"/000/001/002.osc.gz": gzipData(`<osmChange version="0.6"><delete>` +
+			`<node id="1" version="2" lat="0" lon="0"/></delete></osmChange>`),
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(data)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestState(t *testing.T) {
+	s := &State{SequenceNumber: 5286513, Timestamp: time.Date(2022, 10, 15, 10, 0, 0, 0, time.UTC)}
+	var buf bytes.Buffer
+	if err := WriteState(&buf, s); err != nil {
+		t.Fatal(err)
+	}
+	if expected := "sequenceNumber=5286513\ntimestamp=2022-10-15T10\\:00\\:00Z\n"; buf.String() != expected {
+		t.Errorf("expected %q, got %q", expected, buf.String())
+	}
+	read, err := ReadState(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(s, read) {
+		t.Errorf("expected %v, got %v", s, read)
+	}
+
+	if _, err := ReadState(strings.NewReader("timestamp=2022-10-15T10\\:00\\:00Z\n")); err == nil {
+		t.Error("expected error for state without sequence number")
+	}
+}
+
+func TestUpdateFile(t *testing.T) {
+	srv := replicationServer(t)
+	dir := t.TempDir()
+	path, statePath := filepath.Join(dir, "extract.osm.pbf"), filepath.Join(dir, "state.txt")
+
+	var buf bytes.Buffer
+	e := osmpbf.NewEncoder(&buf)
+	e.SetHeader(&osmpbf.Header{ReplicationBaseURL: srv.URL, ReplicationSequenceNumber: 1000})
+	e.Encode(&osmpbf.Node{ID: 1})
+	e.Encode(&osmpbf.Node{ID: 2})
+	if err := e.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	d := osmpbf.NewDecoder(bytes.NewReader(buf.Bytes()))
+	if err := d.Start(0); err != nil {
+		t.Fatal(err)
+	}
+	c, err := HeaderClient(d.Header(), nil)
+	d.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := &State{SequenceNumber: 1002, Timestamp: time.Date(2022, 10, 15, 10, 2, 0, 0, time.UTC)}
+	for i := 0; i < 2; i++ {
+		// the second update has no new diffs
+		state, err := c.UpdateFile(context.Background(), path, statePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(expected, state) {
+			t.Errorf("expected %v, got %v", expected, state)
+		}
+	}
+	if state, err := LoadState(statePath); err != nil || !reflect.DeepEqual(expected, state) {
+		t.Errorf("expected saved state %v, got %v, %v", expected, state, err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	d = osmpbf.NewDecoder(f)
+	if err := d.Start(0); err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	if h := d.Header(); h.ReplicationSequenceNumber != 1002 || !h.ReplicationTimestamp.Equal(expected.Timestamp) {
+		t.Errorf("unexpected header %#v", h)
+	}
+	var ids []int64
+	for {
+		v, err := d.Decode()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, v.(*osmpbf.Node).ID)
+	}
+	if !reflect.DeepEqual([]int64{2, 3}, ids) {
+		t.Errorf("expected nodes [2 3], got %v", ids)
+	}
+
+	// missing diff
+	if _, err := NewClient(srv.URL, nil).Update(context.Background(), io.Discard, bytes.NewReader(buf.Bytes()),
+		&State{SequenceNumber: 999}); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("expected not found error, got %v", err)
+	}
+}
+
+func TestWriteChanges(t *testing.T) {
+	srv := replicationServer(t)
+	c := NewClient(srv.URL, nil)
+	var buf bytes.Buffer
+	state, err := c.WriteChanges(context.Background(), &buf, &State{SequenceNumber: 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.SequenceNumber != 1002 {
+		t.Errorf("expected state 1002, got %v", state)
+	}
+	s := buf.String()
+	if !strings.Contains(s, `<node id="3"`) || !strings.Contains(s, "<delete>") || strings.Count(s, "<osmChange") != 1 {
+		t.Errorf("unexpected changes:\n%s", s)
+	}
+
+	buf.Reset()
+	if state, err := c.WriteChanges(context.Background(), &buf, state); err != nil || state.SequenceNumber != 1002 {
+		t.Errorf("expected unchanged state, got %v, %v", state, err)
+	}
+	if strings.Contains(buf.String(), "<node") {
+		t.Errorf("expected no changes, got:\n%s", buf.String())
+	}
+}
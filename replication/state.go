@@ -0,0 +1,112 @@
+// Package replication downloads OpenStreetMap replication diffs, like minutely, hourly and daily
+// diffs of planet.openstreetmap.org, and applies them to PBF files with osmxml.Apply, to keep
+// extracts up to date.
+//
+// Replication directory layout and state files are described at
+// https://wiki.openstreetmap.org/wiki/Planet.osm/diffs.
+package replication
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/brechtbm/osmpbf"
+)
+
+// State is replication state: sequence number of a diff and timestamp of the newest data in it.
+type State struct {
+	SequenceNumber int64
+	Timestamp      time.Time
+}
+
+// HeaderState returns replication state of file with header h.
+func HeaderState(h *osmpbf.Header) (*State, error) {
+	if h.ReplicationSequenceNumber == 0 && h.ReplicationTimestamp.IsZero() {
+		return nil, errors.New("header has no replication state")
+	}
+	return &State{h.ReplicationSequenceNumber, h.ReplicationTimestamp}, nil
+}
+
+// ReadState reads state in state.txt format, as written by osmosis:
+//
+//	#Sat Oct 15 10:00:00 UTC 2022
+//	sequenceNumber=5286513
+//	timestamp=2022-10-15T10\:00\:00Z
+func ReadState(r io.Reader) (*State, error) {
+	var s State
+	var hasSequence bool
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid state line %q", line)
+		}
+		value = strings.ReplaceAll(value, `\`, "")
+
+		var err error
+		switch key {
+		case "sequenceNumber":
+			s.SequenceNumber, err = strconv.ParseInt(value, 10, 64)
+			hasSequence = true
+		case "timestamp":
+			s.Timestamp, err = time.Parse(time.RFC3339, value)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("state %s: %w", key, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if !hasSequence {
+		return nil, errors.New("state has no sequence number")
+	}
+	return &s, nil
+}
+
+// WriteState writes state in state.txt format.
+func WriteState(w io.Writer, s *State) error {
+	timestamp := strings.ReplaceAll(s.Timestamp.UTC().Format(time.RFC3339), ":", `\:`)
+	_, err := fmt.Fprintf(w, "sequenceNumber=%d\ntimestamp=%s\n", s.SequenceNumber, timestamp)
+	return err
+}
+
+// LoadState reads state file at path.
+func LoadState(path string) (*State, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadState(f)
+}
+
+// SaveState writes state file at path. State is written to temporary file first, so state file is
+// not left partially written.
+func SaveState(path string, s *State) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if err := WriteState(f, s); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
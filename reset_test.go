@@ -0,0 +1,61 @@
+package osmpbf
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestReset(t *testing.T) {
+	first := encodeObjects(t, en, ew).Bytes()
+	second := encodeObjects(t, ew, er).Bytes()
+
+	d := NewDecoder(bytes.NewReader(first), WithCollectStats(true))
+	if decoded := decodeAll(t, d); !reflect.DeepEqual([]interface{}{en, ew}, decoded) {
+		t.Errorf("\nExpected: %#v\nActual:   %#v", []interface{}{en, ew}, decoded)
+	}
+	dataDecoders := d.dataDecoders
+
+	if err := d.Reset(bytes.NewReader(second)); err != nil {
+		t.Fatal(err)
+	}
+	if d.Progress() != (Progress{}) || d.Headers() != nil {
+		t.Errorf("expected progress and headers to start over, got %+v and %v", d.Progress(), d.Headers())
+	}
+	if decoded := decodeAll(t, d); !reflect.DeepEqual([]interface{}{ew, er}, decoded) {
+		t.Errorf("\nExpected: %#v\nActual:   %#v", []interface{}{ew, er}, decoded)
+	}
+	if d.dataDecoders[0] != dataDecoders[0] {
+		t.Error("expected data decoders to be reused")
+	}
+	if s := d.Stats(); s.Nodes != 0 || s.Ways != 1 || s.Relations != 1 {
+		t.Errorf("expected statistics of the second input stream, got %+v", s)
+	}
+
+	// reset in the middle of decoding, context of the previous input stream is not used
+	ctx, cancel := context.WithCancel(context.Background())
+	d.Reset(bytes.NewReader(encodeNodesWays(t, maxBlockEntities*3, 10).Bytes()))
+	if err := d.StartWithContext(ctx, 2); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.Decode(); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Reset(bytes.NewReader(first)); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	if decoded := decodeAll(t, d); !reflect.DeepEqual([]interface{}{en, ew}, decoded) {
+		t.Errorf("\nExpected: %#v\nActual:   %#v", []interface{}{en, ew}, decoded)
+	}
+
+	// reset after Close
+	d.Close()
+	if err := d.Reset(bytes.NewReader(second)); err != nil {
+		t.Fatal(err)
+	}
+	if decoded := decodeAll(t, d); !reflect.DeepEqual([]interface{}{ew, er}, decoded) {
+		t.Errorf("\nExpected: %#v\nActual:   %#v", []interface{}{ew, er}, decoded)
+	}
+}
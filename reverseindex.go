@@ -0,0 +1,351 @@
+package osmpbf
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// ReverseIndex stores which objects reference other objects: ways referencing their nodes, and
+// relations referencing their members, so tools answering "which ways use this node" do not read
+// the input stream for each query. It is filled by BuildReverseIndex.
+type ReverseIndex interface {
+	// Add stores that object parent references object child. It must not be called concurrently
+	// with other methods.
+	Add(child, parent ElementID) error
+
+	// Flush makes references added since the last Flush available to Parents. It must not be called
+	// concurrently with other methods.
+	Flush() error
+
+	// Parents returns objects referencing e, ordered by ElementID. It is safe for concurrent use.
+	Parents(e ElementID) []ElementID
+
+	// Close releases resources of index, it must not be used after Close.
+	Close() error
+}
+
+// BuildReverseIndex reads objects from started decoder until the end of input stream, adds
+// references of ways and relations to idx and flushes it.
+func BuildReverseIndex(dec *Decoder, idx ReverseIndex) error {
+	for {
+		v, err := dec.Decode()
+		if err == io.EOF {
+			return idx.Flush()
+		} else if err != nil {
+			return err
+		}
+		o := v.(Object)
+		err = addReverseRefs(idx, o)
+		o.Release()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Adds references of way or relation o to idx.
+func addReverseRefs(idx ReverseIndex, o Object) error {
+	switch o := o.(type) {
+	case *Way:
+		parent := NewElementID(WayKind, o.ID)
+		for _, id := range o.NodeIDs {
+			if err := idx.Add(NewElementID(NodeKind, id), parent); err != nil {
+				return err
+			}
+		}
+	case *Relation:
+		parent := NewElementID(RelationKind, o.ID)
+		for _, m := range o.Members {
+			if err := idx.Add(NewElementID(m.Type.Kind(), m.ID), parent); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Returns sorted ids without duplicates, reusing their array.
+func sortUnique(ids []ElementID) []ElementID {
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	unique := ids[:0]
+	for i, id := range ids {
+		if i == 0 || id != ids[i-1] {
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
+
+// Reverse index in a map, flushing sorts lists of parents.
+type memoryReverseIndex struct {
+	parents map[ElementID][]ElementID
+}
+
+// NewMemoryReverseIndex returns a reverse index kept in memory. It is suitable for extracts, it
+// takes about 50 bytes for each referenced object and 8 bytes for each reference.
+func NewMemoryReverseIndex() ReverseIndex {
+	return &memoryReverseIndex{parents: make(map[ElementID][]ElementID)}
+}
+
+func (idx *memoryReverseIndex) Add(child, parent ElementID) error {
+	idx.parents[child] = append(idx.parents[child], parent)
+	return nil
+}
+
+func (idx *memoryReverseIndex) Flush() error {
+	for child, parents := range idx.parents {
+		idx.parents[child] = sortUnique(parents)
+	}
+	return nil
+}
+
+func (idx *memoryReverseIndex) Parents(e ElementID) []ElementID {
+	return idx.parents[e]
+}
+
+func (idx *memoryReverseIndex) Close() error {
+	idx.parents = nil
+	return nil
+}
+
+// Number of references sorted in memory by file reverse index, variable for tests.
+var reverseChunkSize = 1 << 20
+
+// Size of reference record in file of reverse index.
+const reverseRefSize = 16
+
+// Reference from parent to child object.
+type reverseRef struct {
+	child, parent ElementID
+}
+
+func (r reverseRef) less(other reverseRef) bool {
+	return r.child < other.child || r.child == other.child && r.parent < other.parent
+}
+
+// Reverse index in a file of references sorted by child, then by parent, mapped into memory.
+// Added references are sorted in chunks written to temporary files, which are merged with the
+// file by Flush.
+type fileReverseIndex struct {
+	path   string
+	f      *os.File // nil if file does not exist
+	data   []byte
+	chunk  []reverseRef
+	chunks []*os.File
+}
+
+// OpenFileReverseIndex returns a reverse index kept in a file at path, mapped into memory, so
+// the OS pages it in and out as needed. It takes 16 bytes for each reference, it is suitable for
+// planet files. References of existing file are kept and new ones are merged into it, so the file
+// can be used by later processes. References added after the last Flush are dropped by Close.
+func OpenFileReverseIndex(path string) (ReverseIndex, error) {
+	idx := &fileReverseIndex{path: path}
+	if err := idx.open(); err != nil {
+		return nil, err
+	}
+	return idx, nil
+}
+
+// Maps file of index, if it exists.
+func (idx *fileReverseIndex) open() error {
+	f, err := os.Open(idx.path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	if fi.Size()%reverseRefSize != 0 {
+		f.Close()
+		return errors.New("invalid size of reverse index file")
+	}
+	if fi.Size() > 0 {
+		if idx.data, err = mmap(f, fi.Size()); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	idx.f = f
+	return nil
+}
+
+func (idx *fileReverseIndex) Add(child, parent ElementID) error {
+	idx.chunk = append(idx.chunk, reverseRef{child, parent})
+	if len(idx.chunk) >= reverseChunkSize {
+		return idx.writeChunk()
+	}
+	return nil
+}
+
+// Sorts added references and writes them to a temporary file next to the index file.
+func (idx *fileReverseIndex) writeChunk() error {
+	sort.Slice(idx.chunk, func(i, j int) bool { return idx.chunk[i].less(idx.chunk[j]) })
+	f, err := os.CreateTemp(filepath.Dir(idx.path), filepath.Base(idx.path)+"-*")
+	if err != nil {
+		return err
+	}
+	idx.chunks = append(idx.chunks, f)
+
+	w := bufio.NewWriter(f)
+	for _, r := range idx.chunk {
+		if err := writeReverseRef(w, r); err != nil {
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	idx.chunk = idx.chunk[:0]
+	_, err = f.Seek(0, io.SeekStart)
+	return err
+}
+
+func (idx *fileReverseIndex) Flush() error {
+	if len(idx.chunk) > 0 {
+		if err := idx.writeChunk(); err != nil {
+			return err
+		}
+	}
+	if len(idx.chunks) == 0 {
+		return nil
+	}
+
+	out, err := os.CreateTemp(filepath.Dir(idx.path), filepath.Base(idx.path)+"-*")
+	if err != nil {
+		return err
+	}
+	inputs := []io.Reader{bytes.NewReader(idx.data)}
+	for _, f := range idx.chunks {
+		inputs = append(inputs, bufio.NewReader(f))
+	}
+	err = mergeReverseRefs(out, inputs)
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = idx.closeFiles()
+	}
+	if err == nil {
+		err = os.Rename(out.Name(), idx.path)
+	}
+	if err != nil {
+		os.Remove(out.Name())
+		return err
+	}
+	return idx.open()
+}
+
+// Writes sorted references of inputs to w, without duplicates.
+func mergeReverseRefs(w io.Writer, inputs []io.Reader) error {
+	bw := bufio.NewWriter(w)
+	heads := make([]reverseRef, len(inputs))
+	for i := 0; i < len(inputs); i++ {
+		r, err := readReverseRef(inputs[i])
+		if err == io.EOF {
+			inputs = append(inputs[:i], inputs[i+1:]...)
+			heads = heads[:len(inputs)]
+			i--
+			continue
+		} else if err != nil {
+			return err
+		}
+		heads[i] = r
+	}
+
+	var last reverseRef
+	written := false
+	for len(inputs) > 0 {
+		first := 0
+		for i := range heads {
+			if heads[i].less(heads[first]) {
+				first = i
+			}
+		}
+		if !written || heads[first] != last {
+			if err := writeReverseRef(bw, heads[first]); err != nil {
+				return err
+			}
+			last, written = heads[first], true
+		}
+
+		r, err := readReverseRef(inputs[first])
+		if err == io.EOF {
+			inputs = append(inputs[:first], inputs[first+1:]...)
+			heads = append(heads[:first], heads[first+1:]...)
+		} else if err != nil {
+			return err
+		} else {
+			heads[first] = r
+		}
+	}
+	return bw.Flush()
+}
+
+func readReverseRef(r io.Reader) (reverseRef, error) {
+	var b [reverseRefSize]byte
+	if _, err := io.ReadFull(r, b[:]); err == io.ErrUnexpectedEOF {
+		return reverseRef{}, errors.New("truncated reverse index file")
+	} else if err != nil {
+		return reverseRef{}, err
+	}
+	return reverseRef{
+		ElementID(binary.LittleEndian.Uint64(b[:8])),
+		ElementID(binary.LittleEndian.Uint64(b[8:])),
+	}, nil
+}
+
+func writeReverseRef(w io.Writer, r reverseRef) error {
+	var b [reverseRefSize]byte
+	binary.LittleEndian.PutUint64(b[:8], uint64(r.child))
+	binary.LittleEndian.PutUint64(b[8:], uint64(r.parent))
+	_, err := w.Write(b[:])
+	return err
+}
+
+func (idx *fileReverseIndex) Parents(e ElementID) []ElementID {
+	n := len(idx.data) / reverseRefSize
+	child := func(i int) ElementID {
+		return ElementID(binary.LittleEndian.Uint64(idx.data[i*reverseRefSize:]))
+	}
+	var parents []ElementID
+	for i := sort.Search(n, func(i int) bool { return child(i) >= e }); i < n && child(i) == e; i++ {
+		parents = append(parents, ElementID(binary.LittleEndian.Uint64(idx.data[i*reverseRefSize+8:])))
+	}
+	return parents
+}
+
+// Unmaps and closes index file and removes chunk files.
+func (idx *fileReverseIndex) closeFiles() error {
+	var err error
+	if idx.data != nil {
+		err = munmap(idx.data)
+		idx.data = nil
+	}
+	if idx.f != nil {
+		if cerr := idx.f.Close(); err == nil {
+			err = cerr
+		}
+		idx.f = nil
+	}
+	for _, f := range idx.chunks {
+		f.Close()
+		os.Remove(f.Name())
+	}
+	idx.chunks = nil
+	return err
+}
+
+func (idx *fileReverseIndex) Close() error {
+	idx.chunk = nil
+	return idx.closeFiles()
+}
@@ -0,0 +1,85 @@
+package osmpbf
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func testReverseIndex(t *testing.T, idx ReverseIndex) {
+	t.Helper()
+	buf := encodeObjects(t,
+		&Node{ID: 1}, &Node{ID: 2}, &Node{ID: 3},
+		&Way{ID: 10, NodeIDs: []int64{1, 2, 1}},
+		&Way{ID: 11, NodeIDs: []int64{2, 3}},
+		&Relation{ID: 20, Members: []Member{{ID: 3, Type: NodeType}, {ID: 10, Type: WayType}}},
+		&Relation{ID: 21, Members: []Member{{ID: 20, Type: RelationType}, {ID: 2, Type: NodeType}}},
+	)
+	d := NewDecoder(buf)
+	if err := d.Start(2); err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	if err := BuildReverseIndex(d, idx); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		e        ElementID
+		expected []ElementID
+	}{
+		{NewElementID(NodeKind, 1), []ElementID{NewElementID(WayKind, 10)}},
+		{NewElementID(NodeKind, 2), []ElementID{NewElementID(WayKind, 10), NewElementID(WayKind, 11), NewElementID(RelationKind, 21)}},
+		{NewElementID(NodeKind, 3), []ElementID{NewElementID(WayKind, 11), NewElementID(RelationKind, 20)}},
+		{NewElementID(WayKind, 10), []ElementID{NewElementID(RelationKind, 20)}},
+		{NewElementID(RelationKind, 20), []ElementID{NewElementID(RelationKind, 21)}},
+		{NewElementID(WayKind, 11), nil},
+		{NewElementID(NodeKind, 4), nil},
+	}
+	for _, test := range tests {
+		if parents := idx.Parents(test.e); !reflect.DeepEqual(parents, test.expected) {
+			t.Errorf("%v: expected %v, got %v", test.e, test.expected, parents)
+		}
+	}
+}
+
+func TestMemoryReverseIndex(t *testing.T) {
+	idx := NewMemoryReverseIndex()
+	defer idx.Close()
+	testReverseIndex(t, idx)
+}
+
+func TestFileReverseIndex(t *testing.T) {
+	defer func(size int) { reverseChunkSize = size }(reverseChunkSize)
+	reverseChunkSize = 3
+
+	path := filepath.Join(t.TempDir(), "reverse.idx")
+	idx, err := OpenFileReverseIndex(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testReverseIndex(t, idx)
+	if err := idx.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// references are kept, new ones are merged
+	idx, err = OpenFileReverseIndex(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer idx.Close()
+	if err := idx.Add(NewElementID(NodeKind, 1), NewElementID(WayKind, 5)); err != nil {
+		t.Fatal(err)
+	}
+	if err := idx.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	expected := []ElementID{NewElementID(WayKind, 5), NewElementID(WayKind, 10)}
+	if parents := idx.Parents(NewElementID(NodeKind, 1)); !reflect.DeepEqual(parents, expected) {
+		t.Errorf("expected %v, got %v", expected, parents)
+	}
+	if files, _ := filepath.Glob(path + "-*"); len(files) != 0 {
+		t.Errorf("expected no temporary files, got %v", files)
+	}
+}
@@ -0,0 +1,52 @@
+package osmpbf
+
+import (
+	"io"
+)
+
+// A Scanner provides a convenient interface for reading objects from Decoder, similar to bufio.Scanner.
+// Successive calls to Scan method step through decoded objects. Scanning stops at the end of
+// the input stream or at the first error; after Scan returns false, Err method returns that error,
+// or nil if it was io.EOF.
+type Scanner struct {
+	dec  *Decoder
+	obj  Object
+	err  error
+	done bool
+}
+
+// NewScanner returns a new Scanner reading objects from d. Decoder d must be started.
+func NewScanner(d *Decoder) *Scanner {
+	return &Scanner{dec: d}
+}
+
+// Scan advances the Scanner to the next object, which will then be available through Object method.
+// It returns false when the scan stops, either by reaching the end of the input stream or an error.
+func (s *Scanner) Scan() bool {
+	if s.done {
+		return false
+	}
+
+	v, err := s.dec.Decode()
+	if err != nil {
+		s.obj = nil
+		s.done = true
+		if err != io.EOF {
+			s.err = err
+		}
+		return false
+	}
+
+	s.obj = v.(Object)
+	return true
+}
+
+// Object returns the most recent object decoded by a call to Scan.
+func (s *Scanner) Object() Object {
+	return s.obj
+}
+
+// Err returns the first non-EOF error that was encountered by the Scanner.
+func (s *Scanner) Err() error {
+	return s.err
+}
@@ -0,0 +1,47 @@
+package osmpbf
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestScanner(t *testing.T) {
+	d := NewDecoder(encodeObjects(t, ew, er))
+	if err := d.Start(1); err != nil {
+		t.Fatal(err)
+	}
+
+	var objects []Object
+	s := NewScanner(d)
+	for s.Scan() {
+		objects = append(objects, s.Object())
+	}
+	if err := s.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if s.Scan() || s.Object() != nil {
+		t.Error("expected Scan to return false after the end of the stream")
+	}
+
+	if expected := []Object{ew, er}; !reflect.DeepEqual(expected, objects) {
+		t.Errorf("\nExpected: %#v\nActual:   %#v", expected, objects)
+	}
+}
+
+func TestScannerError(t *testing.T) {
+	buf := encodeObjects(t)
+	buf.Write([]byte{0xff, 0xff, 0xff, 0xff}) // invalid BlobHeader size
+
+	d := NewDecoder(buf)
+	if err := d.Start(1); err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewScanner(d)
+	for s.Scan() {
+		t.Errorf("unexpected object %#v", s.Object())
+	}
+	if err := s.Err(); err == nil {
+		t.Errorf("expected error, got %v", err)
+	}
+}
@@ -0,0 +1,197 @@
+package osmpbf
+
+import (
+	"io"
+	"os"
+	"sort"
+)
+
+// Number of objects sorted in memory by Sort, variable for tests.
+var sortChunkSize = 1 << 20
+
+// Maximum number of chunks merged at once by Sort, variable for tests. Each merged chunk is read
+// by its own Decoder, so more chunks are merged in several passes through temporary files.
+var sortFanIn = 16
+
+// Number of decoded objects buffered by Decoder of each merged chunk.
+const sortMergeBuffer = 1024
+
+// Sort reads PBF data from r and writes it to w sorted by type, then by ID (Sort.Type_then_ID),
+// which is required by many tools. Objects with the same type and ID, like versions in history
+// files, keep their input order. Input which does not fit in memory is sorted by external merge
+// sort: chunks of objects are sorted and written to temporary files, which are then merged, so
+// free disk space of about input size is needed. At most 16 chunks are merged at once, larger
+// inputs are merged in several passes. Objects are written to new PrimitiveBlocks.
+// OSMHeader of input is kept, with Sort.Type_then_ID optional feature added.
+func Sort(r io.Reader, w io.Writer) error {
+	dec := NewDecoder(r)
+	if err := dec.Start(0); err != nil {
+		return err
+	}
+	defer dec.Close()
+	h := *dec.Header()
+
+	var chunks []*os.File
+	defer func() {
+		removeChunks(chunks)
+	}()
+
+	chunk := make([]Object, 0, sortChunkSize)
+	var writeErr error
+	err := readAll(dec, func(o Object) {
+		if writeErr != nil {
+			return
+		}
+		chunk = append(chunk, o)
+		if len(chunk) == sortChunkSize {
+			var f *os.File
+			f, writeErr = writeSortedChunk(chunk, &h)
+			if f != nil {
+				chunks = append(chunks, f)
+			}
+			chunk = chunk[:0]
+		}
+	})
+	if err != nil {
+		return err
+	}
+	if writeErr != nil {
+		return writeErr
+	}
+
+	sorted := false
+	for _, feature := range h.OptionalFeatures {
+		sorted = sorted || feature == sortedFeature
+	}
+	if !sorted {
+		h.OptionalFeatures = append(append([]string{}, h.OptionalFeatures...), sortedFeature)
+	}
+	enc := NewEncoder(w)
+	enc.SetHeader(&h)
+
+	if len(chunks) == 0 {
+		// sorted in memory
+		sortObjects(chunk)
+		for _, o := range chunk {
+			if err := enc.Encode(o); err != nil {
+				return err
+			}
+		}
+		return enc.Close()
+	}
+
+	if len(chunk) > 0 {
+		f, err := writeSortedChunk(chunk, &h)
+		if f != nil {
+			chunks = append(chunks, f)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	for len(chunks) > sortFanIn {
+		if chunks, err = mergeChunks(chunks, &h); err != nil {
+			return err
+		}
+	}
+	return mergeSorted(chunks, enc)
+}
+
+// Sorts objects by type, then by ID, keeping order of equal ones.
+func sortObjects(objects []Object) {
+	sort.SliceStable(objects, func(i, j int) bool {
+		return precedes(objects[i], objects[j])
+	})
+}
+
+// Sorts objects and writes them to a new temporary file, which is returned rewound.
+func writeSortedChunk(objects []Object, h *Header) (*os.File, error) {
+	sortObjects(objects)
+	f, err := os.CreateTemp("", "osmpbf-sort")
+	if err != nil {
+		return nil, err
+	}
+	enc := newChunkEncoder(f, h)
+	for _, o := range objects {
+		if err := enc.Encode(o); err != nil {
+			return f, err
+		}
+	}
+	if err := enc.Close(); err != nil {
+		return f, err
+	}
+	for i := range objects {
+		objects[i].Release()
+		objects[i] = nil
+	}
+	_, err = f.Seek(0, io.SeekStart)
+	return f, err
+}
+
+// Returns Encoder of temporary file with chunk of objects.
+func newChunkEncoder(f *os.File, h *Header) *Encoder {
+	enc := NewEncoder(f)
+	enc.SetCompression(Zstd)
+	enc.SetHeader(h)
+	return enc
+}
+
+// Merges chunks by groups of sortFanIn into new temporary files, which are returned rewound.
+// Merged chunks are removed; returned ones must be removed by caller, also on error.
+func mergeChunks(chunks []*os.File, h *Header) ([]*os.File, error) {
+	var merged []*os.File
+	for len(chunks) > 0 {
+		n := len(chunks)
+		if n > sortFanIn {
+			n = sortFanIn
+		}
+		f, err := os.CreateTemp("", "osmpbf-sort")
+		if err == nil {
+			merged = append(merged, f)
+			err = mergeSorted(chunks[:n], newChunkEncoder(f, h))
+		}
+		if err == nil {
+			_, err = f.Seek(0, io.SeekStart)
+		}
+		removeChunks(chunks[:n])
+		chunks = chunks[n:]
+		if err != nil {
+			removeChunks(chunks)
+			return merged, err
+		}
+	}
+	return merged, nil
+}
+
+// Merges sorted chunks and writes objects to enc, which is closed at the end. Many chunks are
+// open at once, so each of them is decoded by one goroutine with small output buffer.
+func mergeSorted(chunks []*os.File, enc *Encoder) error {
+	readers := make([]io.Reader, len(chunks))
+	for i, f := range chunks {
+		readers[i] = f
+	}
+	md := NewMultiDecoder(readers, MergeSorted, WithOutputBuffer(sortMergeBuffer))
+	defer md.Close()
+	if err := md.Start(1); err != nil {
+		return err
+	}
+	for {
+		v, err := md.Decode()
+		if err == io.EOF {
+			return enc.Close()
+		} else if err != nil {
+			return err
+		}
+		if err := enc.Encode(v); err != nil {
+			return err
+		}
+	}
+}
+
+// Closes and removes temporary files.
+func removeChunks(chunks []*os.File) {
+	for _, f := range chunks {
+		f.Close()
+		os.Remove(f.Name())
+	}
+}
@@ -0,0 +1,45 @@
+package osmpbf
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestSort(t *testing.T) {
+	input := encodeObjects(t,
+		&Relation{ID: 2}, &Node{ID: 5}, &Way{ID: 3, NodeIDs: []int64{1, 5}},
+		&Node{ID: 1}, &Relation{ID: 1}, &Node{ID: 3}, &Way{ID: 1, NodeIDs: []int64{3}},
+	).Bytes()
+	expected := []string{"n1", "n3", "n5", "w1", "w3", "r1", "r2"}
+
+	for _, c := range []struct{ chunkSize, fanIn int }{{100, 16}, {2, 16}, {2, 2}, {1, 3}} {
+		chunkSize := c.chunkSize
+		sortChunkSize, sortFanIn = chunkSize, c.fanIn
+		tmp := t.TempDir()
+		t.Setenv("TMPDIR", tmp)
+		var buf bytes.Buffer
+		err := Sort(bytes.NewReader(input), &buf)
+		sortChunkSize, sortFanIn = 1<<20, 16
+		if err != nil {
+			t.Fatal(err)
+		}
+		if files, _ := os.ReadDir(tmp); len(files) != 0 {
+			t.Errorf("chunk size %d, fan-in %d: temporary files not removed: %v", chunkSize, c.fanIn, files)
+		}
+
+		d := NewDecoder(&buf)
+		d.SetStrict(true)
+		objects := decodeAll(t, d)
+		if ids := objectIDs(objects); !reflect.DeepEqual(expected, ids) {
+			t.Errorf("chunk size %d:\nExpected: %v\nActual:   %v", chunkSize, expected, ids)
+		}
+		if w := objects[4].(*Way); !reflect.DeepEqual(w.NodeIDs, []int64{1, 5}) {
+			t.Errorf("unexpected way %v", w)
+		}
+		if features := d.Header().OptionalFeatures; len(features) != 1 || features[0] != sortedFeature {
+			t.Errorf("expected sorted feature, got %v", features)
+		}
+	}
+}
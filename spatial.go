@@ -0,0 +1,127 @@
+package osmpbf
+
+// LatLon is a location in degrees.
+type LatLon struct {
+	Lat float64
+	Lon float64
+}
+
+// Region is an area used for spatial filtering. Implementations must be safe for concurrent use.
+type Region interface {
+	// Contains returns true if point is inside area.
+	Contains(lat, lon float64) bool
+}
+
+// BBox is a bounding box in degrees.
+type BBox struct {
+	Left   float64
+	Right  float64
+	Top    float64
+	Bottom float64
+}
+
+// Contains returns true if point is inside bounding box or on its border.
+func (b BBox) Contains(lat, lon float64) bool {
+	return lat >= b.Bottom && lat <= b.Top && lon >= b.Left && lon <= b.Right
+}
+
+// Intersects returns true if bounding boxes b and other have a common point.
+func (b BBox) Intersects(other BBox) bool {
+	return b.Left <= other.Right && other.Left <= b.Right && b.Bottom <= other.Top && other.Bottom <= b.Top
+}
+
+// SpatialMode controls which objects are dropped by spatial filter.
+type SpatialMode int
+
+const (
+	// SpatialNodes drops only nodes outside of the area; ways and relations are not filtered.
+	SpatialNodes SpatialMode = iota
+
+	// SpatialWays also drops ways without any node inside of the area.
+	// Nodes must precede ways in the input stream, as they do in sorted files.
+	SpatialWays
+
+	// SpatialRelations also drops relations without any returned member.
+	// Only members preceding relation in the input stream are considered.
+	SpatialRelations
+)
+
+// SetRegion is the same as WithRegion option. Must be called before Start.
+func (dec *Decoder) SetRegion(r Region, mode SpatialMode) {
+	WithRegion(r, mode)(dec)
+}
+
+// SetBBox is the same as WithBBox option. Must be called before Start.
+func (dec *Decoder) SetBBox(b BBox, mode SpatialMode) {
+	WithBBox(b, mode)(dec)
+}
+
+// Drops ways and relations without returned members. Objects must be passed in input stream order,
+// so it is used by serializer goroutine.
+type spatialFilter struct {
+	mode      SpatialMode
+	nodes     map[int64]struct{}
+	ways      map[int64]struct{}
+	relations map[int64]struct{}
+}
+
+func newSpatialFilter(mode SpatialMode) *spatialFilter {
+	f := &spatialFilter{mode: mode}
+	if mode >= SpatialWays {
+		f.nodes = make(map[int64]struct{})
+	}
+	if mode >= SpatialRelations {
+		f.ways = make(map[int64]struct{})
+		f.relations = make(map[int64]struct{})
+	}
+	return f
+}
+
+// keep returns false if object should be dropped. Nodes are already filtered by data decoders.
+func (f *spatialFilter) keep(o interface{}) bool {
+	switch o := o.(type) {
+	case *Node:
+		if f.nodes != nil {
+			f.nodes[o.ID] = struct{}{}
+		}
+		return true
+
+	case *Way:
+		if f.mode < SpatialWays {
+			return true
+		}
+		for _, id := range o.NodeIDs {
+			if _, ok := f.nodes[id]; ok {
+				if f.ways != nil {
+					f.ways[o.ID] = struct{}{}
+				}
+				return true
+			}
+		}
+		return false
+
+	case *Relation:
+		if f.mode < SpatialRelations {
+			return true
+		}
+		for _, m := range o.Members {
+			var ids map[int64]struct{}
+			switch m.Type {
+			case NodeType:
+				ids = f.nodes
+			case WayType:
+				ids = f.ways
+			case RelationType:
+				ids = f.relations
+			}
+			if _, ok := ids[m.ID]; ok {
+				f.relations[o.ID] = struct{}{}
+				return true
+			}
+		}
+		return false
+
+	default:
+		return true
+	}
+}
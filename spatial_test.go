@@ -0,0 +1,52 @@
+package osmpbf
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestBBox(t *testing.T) {
+	objects := []Object{
+		&Node{ID: 1, Lat: 51.5, Lon: -0.1, Tags: map[string]string{"name": "Tagged inside"}},
+		&Node{ID: 2, Lat: 51.6, Lon: -0.2},
+		&Node{ID: 3, Lat: 48.8, Lon: 2.3, Tags: map[string]string{"name": "Tagged outside"}},
+		&Node{ID: 4, Lat: 48.9, Lon: 2.4},
+		&Way{ID: 10, NodeIDs: []int64{1, 3}},
+		&Way{ID: 11, NodeIDs: []int64{3, 4}},
+		&Relation{ID: 20, Members: []Member{{ID: 11, Type: WayType}, {ID: 2, Type: NodeType}}},
+		&Relation{ID: 21, Members: []Member{{ID: 11, Type: WayType}, {ID: 4, Type: NodeType}}},
+		&Relation{ID: 22, Members: []Member{{ID: 21, Type: RelationType}, {ID: 20, Type: RelationType}}},
+	}
+	london := BBox{Left: -0.5, Right: 0.3, Top: 51.7, Bottom: 51.3}
+
+	for mode, expected := range map[SpatialMode][]string{
+		SpatialNodes:     {"node/1", "node/2", "way/10", "way/11", "relation/20", "relation/21", "relation/22"},
+		SpatialWays:      {"node/1", "node/2", "way/10", "relation/20", "relation/21", "relation/22"},
+		SpatialRelations: {"node/1", "node/2", "way/10", "relation/20", "relation/22"},
+	} {
+		d := NewDecoder(encodeObjects(t, objects...))
+		d.SetBBox(london, mode)
+
+		var ids []string
+		for _, o := range decodeAll(t, d) {
+			ids = append(ids, objectID(o.(Object)))
+		}
+		if !reflect.DeepEqual(expected, ids) {
+			t.Errorf("mode %d:\nExpected: %v\nGot:      %v", mode, expected, ids)
+		}
+	}
+}
+
+func objectID(o Object) string {
+	var id int64
+	switch o := o.(type) {
+	case *Node:
+		id = o.ID
+	case *Way:
+		id = o.ID
+	case *Relation:
+		id = o.ID
+	}
+	return o.Kind().String() + "/" + strconv.FormatInt(id, 10)
+}
@@ -0,0 +1,155 @@
+package osmpbf
+
+import (
+	"errors"
+	"io"
+	"math"
+)
+
+// SplitByKind reads objects from started decoder until the end of input stream and writes nodes,
+// ways and relations as PBF data to separate writers, so they can be processed in parallel. Nil
+// writer drops objects of its kind. OSMHeader of input is written to all outputs.
+func SplitByKind(dec *Decoder, nodes, ways, relations io.Writer) error {
+	var encoders [3]*Encoder
+	for k, w := range []io.Writer{nodes, ways, relations} {
+		if w != nil {
+			encoders[k] = NewEncoder(w)
+			encoders[k].SetHeader(dec.Header())
+		}
+	}
+
+	var encodeErr error
+	err := readAll(dec, func(o Object) {
+		k := o.Kind()
+		if k > RelationKind || encoders[k] == nil || encodeErr != nil {
+			o.Release()
+			return
+		}
+		encodeErr = encoders[k].Encode(o)
+	})
+	if err != nil {
+		return err
+	}
+	if encodeErr != nil {
+		return encodeErr
+	}
+	for _, enc := range encoders {
+		if enc != nil {
+			if err := enc.Close(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// GridCell is a cell of grid of SplitByGrid: column from longitude -180 and row from latitude -90.
+type GridCell struct {
+	Col, Row int
+}
+
+// NoCell is cell of objects without any known location.
+var NoCell = GridCell{-1, -1}
+
+// Returns cell of size degrees containing location.
+func gridCell(l LatLon, size float64) GridCell {
+	return GridCell{int(math.Floor((l.Lon + 180) / size)), int(math.Floor((l.Lat + 90) / size))}
+}
+
+// SplitByGrid reads objects from started decoder until the end of input stream and partitions
+// them by grid of square cells of size degrees, writing each cell as PBF data to writer returned
+// by create, which is called when the first object of cell is found. Nodes belong to cell of their
+// location, ways to cell of their first node with known location, and relations to cell of their
+// first node or way member with known cell. Objects without any known location belong to NoCell.
+// Input must be sorted by type, so nodes are read before ways. Node locations are put into store s,
+// which may be nil to use NewSparseLocationStore. OSMHeader of input is written to all outputs.
+func SplitByGrid(dec *Decoder, s NodeLocationStore, size float64, create func(cell GridCell) (io.Writer, error)) error {
+	if size <= 0 {
+		return errors.New("grid cell size must be positive")
+	}
+	if s == nil {
+		s = NewSparseLocationStore()
+		defer s.Close()
+	}
+	encoders := make(map[GridCell]*Encoder)
+	ways := make(map[int64]GridCell)
+	relations := make(map[int64]GridCell)
+
+	cell := func(o Object) GridCell {
+		switch o := o.(type) {
+		case *Node:
+			return gridCell(LatLon{o.Lat, o.Lon}, size)
+		case *Way:
+			for i, id := range o.NodeIDs {
+				if len(o.NodeLocations) == len(o.NodeIDs) {
+					return gridCell(o.NodeLocations[i], size)
+				}
+				if l, ok := s.Location(id); ok {
+					return gridCell(l, size)
+				}
+			}
+		case *Relation:
+			for _, m := range o.Members {
+				switch m.Type {
+				case NodeType:
+					if l, ok := s.Location(m.ID); ok {
+						return gridCell(l, size)
+					}
+				case WayType:
+					if c, ok := ways[m.ID]; ok && c != NoCell {
+						return c
+					}
+				case RelationType:
+					if c, ok := relations[m.ID]; ok && c != NoCell {
+						return c
+					}
+				}
+			}
+		}
+		return NoCell
+	}
+
+	var encodeErr error
+	err := readAll(dec, func(o Object) {
+		if encodeErr != nil || o.Kind() > RelationKind {
+			o.Release()
+			return
+		}
+		c := cell(o)
+		switch o := o.(type) {
+		case *Node:
+			encodeErr = s.Set(o.ID, LatLon{o.Lat, o.Lon})
+		case *Way:
+			ways[o.ID] = c
+		case *Relation:
+			relations[o.ID] = c
+		}
+		if encodeErr != nil {
+			return
+		}
+
+		enc := encoders[c]
+		if enc == nil {
+			var w io.Writer
+			if w, encodeErr = create(c); encodeErr != nil {
+				return
+			}
+			enc = NewEncoder(w)
+			enc.SetHeader(dec.Header())
+			encoders[c] = enc
+		}
+		encodeErr = enc.Encode(o)
+	})
+	if err != nil {
+		return err
+	}
+	if encodeErr != nil {
+		return encodeErr
+	}
+	for _, enc := range encoders {
+		if err := enc.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package osmpbf
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestSplitByKind(t *testing.T) {
+	d := NewDecoder(encodeObjects(t, &Node{ID: 1}, &Node{ID: 2}, &Way{ID: 1}, &Relation{ID: 1}))
+	if err := d.Start(2); err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	var nodes, relations bytes.Buffer
+	if err := SplitByKind(d, &nodes, nil, &relations); err != nil {
+		t.Fatal(err)
+	}
+	if ids := objectIDs(decodeAll(t, NewDecoder(&nodes))); !reflect.DeepEqual([]string{"n1", "n2"}, ids) {
+		t.Errorf("unexpected nodes %v", ids)
+	}
+	if ids := objectIDs(decodeAll(t, NewDecoder(&relations))); !reflect.DeepEqual([]string{"r1"}, ids) {
+		t.Errorf("unexpected relations %v", ids)
+	}
+}
+
+func TestSplitByGrid(t *testing.T) {
+	d := NewDecoder(encodeObjects(t,
+		&Node{ID: 1, Lat: 0.5, Lon: 0.5},
+		&Node{ID: 2, Lat: 1.5, Lon: 0.5},
+		&Node{ID: 3, Lat: -0.5, Lon: -179.5},
+		&Way{ID: 1, NodeIDs: []int64{5, 2, 1}},
+		&Way{ID: 2, NodeIDs: []int64{5}},
+		&Relation{ID: 1, Members: []Member{{ID: 2, Type: WayType}, {ID: 1, Type: WayType}}},
+		&Relation{ID: 2, Members: []Member{{ID: 1, Type: RelationType}}},
+	))
+	if err := d.Start(2); err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+
+	buffers := make(map[GridCell]*bytes.Buffer)
+	err := SplitByGrid(d, nil, 1, func(cell GridCell) (io.Writer, error) {
+		buffers[cell] = new(bytes.Buffer)
+		return buffers[cell], nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[GridCell][]string{
+		{180, 90}: {"n1"},
+		{180, 91}: {"n2", "w1", "r1", "r2"},
+		{0, 89}:   {"n3"},
+		NoCell:    {"w2"},
+	}
+	actual := make(map[GridCell][]string)
+	for cell, buf := range buffers {
+		actual[cell] = objectIDs(decodeAll(t, NewDecoder(buf)))
+	}
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("\nExpected: %v\nActual:   %v", expected, actual)
+	}
+
+	if err := SplitByGrid(d, nil, 0, nil); err == nil {
+		t.Error("expected error for zero cell size")
+	}
+}
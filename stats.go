@@ -0,0 +1,76 @@
+package osmpbf
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Stats contains decoding statistics, collected if enabled by WithCollectStats.
+// Timings are summed over all goroutines, so they may exceed wall time.
+type Stats struct {
+	// decoded objects, including ones dropped later by spatial filter
+	Nodes     int64
+	Ways      int64
+	Relations int64
+
+	// Blobs is the number of read OSMData blobs.
+	Blobs int64
+
+	// UncompressedBytes is the total size of uncompressed OSMData blobs.
+	UncompressedBytes int64
+
+	ReadTime      time.Duration // reading fileblocks from input stream
+	InflateTime   time.Duration // uncompressing blobs
+	UnmarshalTime time.Duration // unmarshalling PrimitiveBlocks
+	BuildTime     time.Duration // making objects from PrimitiveBlocks
+}
+
+// SetCollectStats is the same as WithCollectStats option. Must be called before Start.
+func (dec *Decoder) SetCollectStats(collect bool) {
+	WithCollectStats(collect)(dec)
+}
+
+// Stats returns decoding statistics collected so far. It is safe to call concurrently with Decode.
+// Zero value is returned if statistics are not collected.
+func (dec *Decoder) Stats() Stats {
+	s := dec.opts.stats
+	if s == nil {
+		return Stats{}
+	}
+	return Stats{
+		Nodes:             atomic.LoadInt64(&s.Nodes),
+		Ways:              atomic.LoadInt64(&s.Ways),
+		Relations:         atomic.LoadInt64(&s.Relations),
+		Blobs:             atomic.LoadInt64(&s.Blobs),
+		UncompressedBytes: atomic.LoadInt64(&s.UncompressedBytes),
+		ReadTime:          time.Duration(atomic.LoadInt64((*int64)(&s.ReadTime))),
+		InflateTime:       time.Duration(atomic.LoadInt64((*int64)(&s.InflateTime))),
+		UnmarshalTime:     time.Duration(atomic.LoadInt64((*int64)(&s.UnmarshalTime))),
+		BuildTime:         time.Duration(atomic.LoadInt64((*int64)(&s.BuildTime))),
+	}
+}
+
+// Counts decoded objects by kind.
+func (s *Stats) count(objects []interface{}) {
+	var nodes, ways, relations int64
+	for _, o := range objects {
+		switch o.(type) {
+		case *Node:
+			nodes++
+		case *Way:
+			ways++
+		case *Relation:
+			relations++
+		}
+	}
+	atomic.AddInt64(&s.Nodes, nodes)
+	atomic.AddInt64(&s.Ways, ways)
+	atomic.AddInt64(&s.Relations, relations)
+}
+
+// Adds time passed since start to d atomically, returns current time.
+func addSince(d *time.Duration, start time.Time) time.Time {
+	now := time.Now()
+	atomic.AddInt64((*int64)(d), int64(now.Sub(start)))
+	return now
+}
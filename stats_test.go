@@ -0,0 +1,28 @@
+package osmpbf
+
+import (
+	"testing"
+)
+
+func TestStats(t *testing.T) {
+	d := NewDecoder(encodeNodesWays(t, maxBlockEntities*2+1, 10))
+	d.SetCollectStats(true)
+	decodeAll(t, d)
+
+	s := d.Stats()
+	if s.Nodes != maxBlockEntities*2+1 || s.Ways != 10 || s.Relations != 0 {
+		t.Errorf("unexpected object counts %d, %d, %d", s.Nodes, s.Ways, s.Relations)
+	}
+	if s.Blobs != 4 {
+		t.Errorf("expected 4 blobs, got %d", s.Blobs)
+	}
+	if s.UncompressedBytes == 0 || s.InflateTime == 0 || s.UnmarshalTime == 0 || s.BuildTime == 0 {
+		t.Errorf("expected non-zero stats, got %#v", s)
+	}
+
+	d = NewDecoder(encodeObjects(t, en))
+	decodeAll(t, d)
+	if s := d.Stats(); s != (Stats{}) {
+		t.Errorf("expected zero stats, got %#v", s)
+	}
+}
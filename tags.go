@@ -0,0 +1,152 @@
+package osmpbf
+
+import (
+	"strings"
+)
+
+// Tag is a key-value pair of OSM object tags.
+type Tag struct {
+	Key   string
+	Value string
+}
+
+// TagList is an alternative to tags map: for objects with few tags a slice takes less memory
+// and allocations, and linear search is fast enough.
+type TagList []Tag
+
+// SetTagList is the same as WithTagList option. Must be called before Start.
+func (dec *Decoder) SetTagList(tagList bool) {
+	WithTagList(tagList)(dec)
+}
+
+// Get returns value of tag with given key and whether it is present.
+func (l TagList) Get(key string) (string, bool) {
+	for _, tag := range l {
+		if tag.Key == key {
+			return tag.Value, true
+		}
+	}
+	return "", false
+}
+
+// Value returns value of tag with given key, or empty string if it is absent.
+func (l TagList) Value(key string) string {
+	v, _ := l.Get(key)
+	return v
+}
+
+// Map returns tags as a new map.
+func (l TagList) Map() map[string]string {
+	tags := make(map[string]string, len(l))
+	for _, tag := range l {
+		tags[tag.Key] = tag.Value
+	}
+	return tags
+}
+
+// Tags is a tags map with helper methods. Tags fields of objects can be converted to it, for example
+// Tags(n.Tags).Has("name"), or Tags(n.TagList.Map()) in TagList mode.
+type Tags map[string]string
+
+// Has returns true if tag with given key is present.
+func (t Tags) Has(key string) bool {
+	_, ok := t[key]
+	return ok
+}
+
+// Get returns value of tag with given key and whether it is present.
+func (t Tags) Get(key string) (string, bool) {
+	v, ok := t[key]
+	return v, ok
+}
+
+// GetBool returns boolean value of tag with given key: true for yes, true and 1, false for no,
+// false and 0. False ok is returned if tag is absent or has other value.
+func (t Tags) GetBool(key string) (value, ok bool) {
+	switch t[key] {
+	case "yes", "true", "1":
+		return true, true
+	case "no", "false", "0":
+		return false, true
+	}
+	return false, false
+}
+
+// AnyOf returns true if tag with any of given keys is present.
+func (t Tags) AnyOf(keys ...string) bool {
+	for _, key := range keys {
+		if t.Has(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// Match returns true if tags match expression similar to osmium tags-filter: "key" or "key=*" match
+// tag with the key, "!key" matches absence of the key, "key=a,b" matches tag with one of the values
+// and "key!=a,b" matches tag with other values.
+func (t Tags) Match(expr string) bool {
+	if strings.HasPrefix(expr, "!") && !strings.Contains(expr, "=") {
+		return !t.Has(expr[1:])
+	}
+	i := strings.IndexByte(expr, '=')
+	if i < 0 || expr[i+1:] == "*" {
+		return t.Has(strings.TrimSuffix(expr, "=*"))
+	}
+	key, values := expr[:i], expr[i+1:]
+	negated := strings.HasSuffix(key, "!")
+	key = strings.TrimSuffix(key, "!")
+	v, ok := t[key]
+	if !ok {
+		return false
+	}
+	for _, value := range strings.Split(values, ",") {
+		if v == value {
+			return !negated
+		}
+	}
+	return negated
+}
+
+// Keys of tags which make closed ways areas, with values which do not.
+var areaKeys = map[string]map[string]bool{
+	"amenity":  nil,
+	"building": nil,
+	"landuse":  nil,
+	"leisure":  nil,
+	"natural":  {"coastline": true, "cliff": true, "ridge": true, "arete": true, "tree_row": true},
+	"place":    nil,
+	"shop":     nil,
+	"tourism":  nil,
+}
+
+// IsArea returns true if closed way with these tags is an area: if it has area=yes tag, or one of
+// amenity, building, landuse, leisure, natural, place, shop and tourism tags, except area=no and
+// linear natural features like natural=coastline.
+func (t Tags) IsArea() bool {
+	switch t["area"] {
+	case "yes":
+		return true
+	case "no":
+		return false
+	}
+	for key, value := range t {
+		if except, ok := areaKeys[key]; ok && !except[value] {
+			return true
+		}
+	}
+	return false
+}
+
+// IsClosedWayArea returns true if way is closed, with at least 3 distinct nodes, and its tags make
+// it an area, see Tags.IsArea.
+func IsClosedWayArea(w *Way) bool {
+	ids := w.NodeIDs
+	if len(ids) < 4 || ids[0] != ids[len(ids)-1] {
+		return false
+	}
+	if w.Tags == nil && w.TagList != nil {
+		return Tags(w.TagList.Map()).IsArea()
+	}
+	return Tags(w.Tags).IsArea()
+}
@@ -0,0 +1,125 @@
+package osmpbf
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTagList(t *testing.T) {
+	d := NewDecoder(encodeObjects(t, en, ew, er))
+	d.SetTagList(true)
+	objects := decodeAll(t, d)
+	if len(objects) != 3 {
+		t.Fatalf("expected 3 objects, got %d", len(objects))
+	}
+
+	n := objects[0].(*Node)
+	if n.Tags != nil {
+		t.Errorf("expected nil Tags, got %v", n.Tags)
+	}
+	if !reflect.DeepEqual(en.Tags, n.TagList.Map()) {
+		t.Errorf("\nExpected: %v\nActual:   %v", en.Tags, n.TagList)
+	}
+	if v, ok := n.TagList.Get("amenity"); !ok || v != "pub" {
+		t.Errorf("expected pub, got %q", v)
+	}
+	if _, ok := n.TagList.Get("shop"); ok {
+		t.Error("expected no shop tag")
+	}
+	if v := objects[1].(*Way).TagList.Value("highway"); v != "pedestrian" {
+		t.Errorf("expected pedestrian, got %q", v)
+	}
+	if !reflect.DeepEqual(er.Tags, objects[2].(*Relation).TagList.Map()) {
+		t.Errorf("\nExpected: %v\nActual:   %v", er.Tags, objects[2].(*Relation).TagList)
+	}
+
+	// filter gets map
+	d = NewDecoder(encodeObjects(t, en, ew, er))
+	d.SetTagList(true)
+	d.SetFilter(func(kind Kind, tags map[string]string) bool {
+		return tags["highway"] == "pedestrian"
+	})
+	if objects := decodeAll(t, d); len(objects) != 1 || objects[0].(*Way).ID != ew.ID {
+		t.Errorf("expected only way, got %#v", objects)
+	}
+}
+
+func TestEncodeTagList(t *testing.T) {
+	w := &Way{ID: 1, TagList: TagList{{"name", "Main Street"}, {"highway", "primary"}}, NodeIDs: []int64{1, 2}}
+	decoded := decodeAll(t, NewDecoder(encodeObjects(t, &Node{ID: 1, TagList: TagList{{"barrier", "gate"}}}, w)))
+	if len(decoded) != 2 {
+		t.Fatalf("expected 2 objects, got %d", len(decoded))
+	}
+	if expected := map[string]string{"barrier": "gate"}; !reflect.DeepEqual(expected, decoded[0].(*Node).Tags) {
+		t.Errorf("expected %v, got %v", expected, decoded[0].(*Node).Tags)
+	}
+	if !reflect.DeepEqual(w.TagList.Map(), decoded[1].(*Way).Tags) {
+		t.Errorf("expected %v, got %v", w.TagList, decoded[1].(*Way).Tags)
+	}
+}
+
+func TestTags(t *testing.T) {
+	tags := Tags{"highway": "primary", "oneway": "yes", "lit": "no", "layer": "1", "name": "Main"}
+	if !tags.Has("name") || tags.Has("ref") {
+		t.Error("unexpected Has result")
+	}
+	if v, ok := tags.Get("highway"); !ok || v != "primary" {
+		t.Errorf("expected primary, got %q", v)
+	}
+	for _, c := range []struct {
+		key       string
+		value, ok bool
+	}{
+		{"oneway", true, true}, {"lit", false, true}, {"name", false, false}, {"ref", false, false},
+	} {
+		if value, ok := tags.GetBool(c.key); value != c.value || ok != c.ok {
+			t.Errorf("%s: expected %v, %v, got %v, %v", c.key, c.value, c.ok, value, ok)
+		}
+	}
+	if !tags.AnyOf("ref", "name") || tags.AnyOf("ref", "building") {
+		t.Error("unexpected AnyOf result")
+	}
+
+	for expr, expected := range map[string]bool{
+		"highway":                     true,
+		"highway=*":                   true,
+		"building":                    false,
+		"!building":                   true,
+		"!highway":                    false,
+		"highway=primary":             true,
+		"highway=secondary,primary":   true,
+		"highway=secondary":           false,
+		"highway!=secondary,tertiary": true,
+		"highway!=primary":            false,
+		"building!=yes":               false,
+	} {
+		if tags.Match(expr) != expected {
+			t.Errorf("expected %v for %q", expected, expr)
+		}
+	}
+}
+
+func TestIsArea(t *testing.T) {
+	for _, c := range []struct {
+		tags     Tags
+		expected bool
+	}{
+		{Tags{"building": "yes"}, true},
+		{Tags{"building": "yes", "area": "no"}, false},
+		{Tags{"highway": "pedestrian", "area": "yes"}, true},
+		{Tags{"highway": "primary"}, false},
+		{Tags{"natural": "coastline"}, false},
+		{Tags{"natural": "water"}, true},
+	} {
+		if c.tags.IsArea() != c.expected {
+			t.Errorf("expected %v for %v", c.expected, c.tags)
+		}
+	}
+
+	if !IsClosedWayArea(&Way{NodeIDs: []int64{1, 2, 3, 1}, TagList: TagList{{"landuse", "forest"}}}) {
+		t.Error("expected closed way to be area")
+	}
+	if IsClosedWayArea(&Way{NodeIDs: []int64{1, 2, 3}, Tags: map[string]string{"landuse": "forest"}}) {
+		t.Error("expected open way not to be area")
+	}
+}
@@ -0,0 +1,176 @@
+package osmpbf
+
+import (
+	"sort"
+	"sync"
+)
+
+// TagCount is number of objects with tag key, or with key=value if Value is set.
+type TagCount struct {
+	Key   string
+	Value string
+	Count int64
+}
+
+// TagStats collects frequencies of tag keys and key=value pairs per object kind, like taginfo.
+// It implements Handler, so a whole file can be analyzed by Decoder.Handle; objects may be also
+// added by Add. TagStats is safe for concurrent use.
+type TagStats struct {
+	values bool
+
+	mu      sync.Mutex
+	objects [3]int64
+	keys    [3]map[string]*keyStats
+}
+
+// Counts of one key.
+type keyStats struct {
+	count  int64
+	values map[string]int64
+}
+
+// NewTagStats returns empty statistics. If values is set, key=value pairs are counted too, which
+// takes memory for all distinct values, for example all names in the file.
+func NewTagStats(values bool) *TagStats {
+	s := &TagStats{values: values}
+	for i := range s.keys {
+		s.keys[i] = make(map[string]*keyStats)
+	}
+	return s
+}
+
+// HandleNode adds tags of node.
+func (s *TagStats) HandleNode(n *Node) { s.add(NodeKind, n.Tags, n.TagList) }
+
+// HandleWay adds tags of way.
+func (s *TagStats) HandleWay(w *Way) { s.add(WayKind, w.Tags, w.TagList) }
+
+// HandleRelation adds tags of relation.
+func (s *TagStats) HandleRelation(r *Relation) { s.add(RelationKind, r.Tags, r.TagList) }
+
+// Add adds tags of object, other objects than Node, Way and Relation are ignored.
+func (s *TagStats) Add(o Object) {
+	switch o := o.(type) {
+	case *Node:
+		s.HandleNode(o)
+	case *Way:
+		s.HandleWay(o)
+	case *Relation:
+		s.HandleRelation(o)
+	}
+}
+
+func (s *TagStats) add(kind Kind, tags map[string]string, tagList TagList) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.objects[kind]++
+	for k, v := range tags {
+		s.addTag(kind, k, v)
+	}
+	for _, tag := range tagList {
+		s.addTag(kind, tag.Key, tag.Value)
+	}
+}
+
+func (s *TagStats) addTag(kind Kind, key, value string) {
+	ks := s.keys[kind][key]
+	if ks == nil {
+		ks = &keyStats{}
+		if s.values {
+			ks.values = make(map[string]int64)
+		}
+		s.keys[kind][key] = ks
+	}
+	ks.count++
+	if s.values {
+		ks.values[value]++
+	}
+}
+
+// Objects returns number of added objects of kind k, with or without tags.
+func (s *TagStats) Objects(k Kind) int64 {
+	if k > RelationKind {
+		return 0
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.objects[k]
+}
+
+// Keys returns number of distinct keys of objects of kind k.
+func (s *TagStats) Keys(k Kind) int {
+	if k > RelationKind {
+		return 0
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.keys[k])
+}
+
+// TopKeys returns n most frequent keys of objects of kind k, ordered by descending count and key.
+// All keys are returned if n is not positive.
+func (s *TagStats) TopKeys(k Kind, n int) []TagCount {
+	if k > RelationKind {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	counts := make([]TagCount, 0, len(s.keys[k]))
+	for key, ks := range s.keys[k] {
+		counts = append(counts, TagCount{Key: key, Count: ks.count})
+	}
+	return topCounts(counts, n)
+}
+
+// TopValues returns n most frequent key=value pairs of objects of kind k, ordered by descending
+// count, key and value. If key is not empty, only values of that key are returned. All pairs are
+// returned if n is not positive. Nil is returned if values are not counted.
+func (s *TagStats) TopValues(k Kind, key string, n int) []TagCount {
+	if k > RelationKind || !s.values {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var counts []TagCount
+	for kk, ks := range s.keys[k] {
+		if key != "" && kk != key {
+			continue
+		}
+		for value, count := range ks.values {
+			counts = append(counts, TagCount{Key: kk, Value: value, Count: count})
+		}
+	}
+	return topCounts(counts, n)
+}
+
+// Cardinality returns number of distinct values of key of objects of kind k,
+// or 0 if values are not counted.
+func (s *TagStats) Cardinality(k Kind, key string) int {
+	if k > RelationKind {
+		return 0
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if ks := s.keys[k][key]; ks != nil {
+		return len(ks.values)
+	}
+	return 0
+}
+
+// Sorts counts and returns first n of them, or all if n is not positive.
+func topCounts(counts []TagCount, n int) []TagCount {
+	sort.Slice(counts, func(i, j int) bool {
+		a, b := counts[i], counts[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		if a.Key != b.Key {
+			return a.Key < b.Key
+		}
+		return a.Value < b.Value
+	})
+	if n > 0 && len(counts) > n {
+		counts = counts[:n]
+	}
+	return counts
+}
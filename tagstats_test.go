@@ -0,0 +1,55 @@
+package osmpbf
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTagStats(t *testing.T) {
+	d := NewDecoder(encodeObjects(t,
+		&Node{ID: 1, Tags: map[string]string{"amenity": "cafe", "name": "A"}},
+		&Node{ID: 2, Tags: map[string]string{"amenity": "cafe"}},
+		&Node{ID: 3, Tags: map[string]string{"amenity": "bar", "name": "B"}},
+		&Node{ID: 4},
+		&Way{ID: 10, Tags: map[string]string{"highway": "primary"}, NodeIDs: []int64{1, 2}},
+		&Relation{ID: 20, Tags: map[string]string{"type": "route"}},
+	))
+	d.SetTagList(true)
+	s := NewTagStats(true)
+	if err := d.Handle(s, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	if s.Objects(NodeKind) != 4 || s.Objects(WayKind) != 1 || s.Objects(RelationKind) != 1 {
+		t.Errorf("unexpected object counts %d, %d, %d",
+			s.Objects(NodeKind), s.Objects(WayKind), s.Objects(RelationKind))
+	}
+	if s.Keys(NodeKind) != 2 || s.Keys(WayKind) != 1 {
+		t.Errorf("unexpected key counts %d, %d", s.Keys(NodeKind), s.Keys(WayKind))
+	}
+
+	expected := []TagCount{{Key: "amenity", Count: 3}, {Key: "name", Count: 2}}
+	if keys := s.TopKeys(NodeKind, 0); !reflect.DeepEqual(expected, keys) {
+		t.Errorf("\nExpected: %v\nActual:   %v", expected, keys)
+	}
+	expected = []TagCount{{"amenity", "cafe", 2}, {"amenity", "bar", 1}}
+	if values := s.TopValues(NodeKind, "", 2); !reflect.DeepEqual(expected, values) {
+		t.Errorf("\nExpected: %v\nActual:   %v", expected, values)
+	}
+	expected = []TagCount{{"name", "A", 1}, {"name", "B", 1}}
+	if values := s.TopValues(NodeKind, "name", 0); !reflect.DeepEqual(expected, values) {
+		t.Errorf("\nExpected: %v\nActual:   %v", expected, values)
+	}
+	if c := s.Cardinality(NodeKind, "amenity"); c != 2 {
+		t.Errorf("expected 2 values of amenity, got %d", c)
+	}
+
+	s = NewTagStats(false)
+	s.Add(&Node{ID: 1, Tags: map[string]string{"amenity": "cafe"}})
+	if s.TopValues(NodeKind, "", 0) != nil || s.Cardinality(NodeKind, "amenity") != 0 {
+		t.Error("expected no values to be counted")
+	}
+	if keys := s.TopKeys(NodeKind, 1); len(keys) != 1 || keys[0].Count != 1 {
+		t.Errorf("unexpected keys %v", keys)
+	}
+}
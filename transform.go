@@ -0,0 +1,118 @@
+package osmpbf
+
+import (
+	"sort"
+	"strings"
+)
+
+// Transform rewrites object before it is returned by Decoder or written by Encoder, see
+// WithTransforms and Encoder.SetTransforms. It returns the object, possibly modified in place,
+// a replacement, or nil to drop it. Bounds are not transformed.
+type Transform func(o Object) Object
+
+// SetTransforms is the same as WithTransforms option. Must be called before Start.
+func (dec *Decoder) SetTransforms(fns ...Transform) {
+	WithTransforms(fns...)(dec)
+}
+
+// SetTransforms sets transforms applied in order to objects passed to Encode, objects dropped by
+// a transform are not written.
+func (enc *Encoder) SetTransforms(fns ...Transform) {
+	enc.transforms = fns
+}
+
+// Returns object transformed by fns, or nil if it is dropped. Dropped object is released if
+// release is set.
+func applyTransforms(fns []Transform, o Object, release bool) Object {
+	for _, fn := range fns {
+		t := fn(o)
+		if t == nil {
+			if release {
+				o.Release()
+			}
+			return nil
+		}
+		o = t
+	}
+	return o
+}
+
+// LowercaseKeys returns transform converting tag keys to lower case, like "Name" to "name".
+// If the lowercase key is already present, its tag is kept and the other one is dropped.
+func LowercaseKeys() Transform {
+	return func(o Object) Object {
+		renameKeys(o, strings.ToLower)
+		return o
+	}
+}
+
+// RenameKeys returns transform renaming tag keys by renames, which maps old keys to new ones, for
+// example to merge deprecated tags. If the new key is already present, its tag is kept and the
+// old one is dropped.
+func RenameKeys(renames map[string]string) Transform {
+	return func(o Object) Object {
+		renameKeys(o, func(key string) string {
+			if k, ok := renames[key]; ok {
+				return k
+			}
+			return key
+		})
+		return o
+	}
+}
+
+// Renames tag keys of node, way or relation by rename. Tags with unchanged keys win conflicts,
+// then renamed tags in order of their keys.
+func renameKeys(o Object, rename func(key string) string) {
+	var tags *map[string]string
+	var list *TagList
+	switch o := o.(type) {
+	case *Node:
+		tags, list = &o.Tags, &o.TagList
+	case *Way:
+		tags, list = &o.Tags, &o.TagList
+	case *Relation:
+		tags, list = &o.Tags, &o.TagList
+	default:
+		return
+	}
+
+	var renamed []string
+	for k := range *tags {
+		if rename(k) != k {
+			renamed = append(renamed, k)
+		}
+	}
+	if len(renamed) > 0 {
+		sort.Strings(renamed)
+		values := make(map[string]string, len(renamed))
+		for _, k := range renamed {
+			values[k] = (*tags)[k]
+			delete(*tags, k)
+		}
+		for _, k := range renamed {
+			if _, ok := (*tags)[rename(k)]; !ok {
+				(*tags)[rename(k)] = values[k]
+			}
+		}
+	}
+
+	present := make(map[string]bool, len(*list))
+	for _, tag := range *list {
+		if rename(tag.Key) == tag.Key {
+			present[tag.Key] = true
+		}
+	}
+	l := (*list)[:0]
+	for _, tag := range *list {
+		if k := rename(tag.Key); k != tag.Key {
+			if present[k] {
+				continue
+			}
+			present[k] = true
+			tag.Key = k
+		}
+		l = append(l, tag)
+	}
+	*list = l
+}
@@ -0,0 +1,76 @@
+package osmpbf
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestTransforms(t *testing.T) {
+	buf := encodeObjects(t,
+		&Node{ID: 1, Tags: map[string]string{"Name": "a", "name": "b", "Amenity": "cafe"}},
+		&Node{ID: 2, Tags: map[string]string{"fixme": "x"}},
+		&Way{ID: 10, Tags: map[string]string{"highway": "road", "Highway": "primary", "postal_code": "1000"}},
+		&Relation{ID: 20, Tags: map[string]string{"Type": "route"}},
+	)
+	dropFixme := func(o Object) Object {
+		if n, ok := o.(*Node); ok && Tags(n.Tags).Has("fixme") {
+			return nil
+		}
+		return o
+	}
+	d := NewDecoder(buf, WithTransforms(dropFixme, LowercaseKeys(), RenameKeys(map[string]string{"postal_code": "addr:postcode"})))
+	objects := decodeAll(t, d)
+
+	if ids := objectIDs(objects); !reflect.DeepEqual(ids, []string{"n1", "w10", "r20"}) {
+		t.Fatalf("unexpected objects %v", ids)
+	}
+	if tags := objects[0].(*Node).Tags; !reflect.DeepEqual(tags, map[string]string{"name": "b", "amenity": "cafe"}) {
+		t.Errorf("unexpected node tags %v", tags)
+	}
+	if tags := objects[1].(*Way).Tags; !reflect.DeepEqual(tags, map[string]string{"highway": "road", "addr:postcode": "1000"}) {
+		t.Errorf("unexpected way tags %v", tags)
+	}
+	if tags := objects[2].(*Relation).Tags; !reflect.DeepEqual(tags, map[string]string{"type": "route"}) {
+		t.Errorf("unexpected relation tags %v", tags)
+	}
+}
+
+func TestRenameKeysTagList(t *testing.T) {
+	n := &Node{ID: 1, TagList: TagList{{"Name", "a"}, {"NAME", "b"}, {"amenity", "cafe"}, {"Amenity", "bar"}}}
+	LowercaseKeys()(n)
+	if expected := (TagList{{"name", "a"}, {"amenity", "cafe"}}); !reflect.DeepEqual(n.TagList, expected) {
+		t.Errorf("unexpected tags %v", n.TagList)
+	}
+}
+
+func TestEncoderTransforms(t *testing.T) {
+	var buf bytes.Buffer
+	e := NewEncoder(&buf)
+	e.SetTransforms(func(o Object) Object {
+		if n, ok := o.(*Node); ok && n.ID == 2 {
+			return nil
+		}
+		return o
+	}, RenameKeys(map[string]string{"old": "new"}))
+	for _, o := range []Object{
+		&Node{ID: 1, Tags: map[string]string{"old": "v"}},
+		&Node{ID: 2},
+		&Way{ID: 10},
+	} {
+		if err := e.Encode(o); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := e.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	objects := decodeAll(t, NewDecoder(&buf))
+	if ids := objectIDs(objects); !reflect.DeepEqual(ids, []string{"n1", "w10"}) {
+		t.Fatalf("unexpected objects %v", ids)
+	}
+	if tags := objects[0].(*Node).Tags; !reflect.DeepEqual(tags, map[string]string{"new": "v"}) {
+		t.Errorf("unexpected tags %v", tags)
+	}
+}
@@ -0,0 +1,303 @@
+package osmpbf
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/brechtbm/osmpbf/OSMPBF"
+)
+
+// sortedFeature is OSMHeader optional feature declaring objects sorted by type, then by ID.
+const sortedFeature = "Sort.Type_then_ID"
+
+// Returns true if OSMHeader declares objects sorted by type, then by ID.
+func isSorted(h *Header) bool {
+	if h == nil {
+		return false
+	}
+	for _, feature := range h.OptionalFeatures {
+		if feature == sortedFeature {
+			return true
+		}
+	}
+	return false
+}
+
+// Checks that objects are sorted by type, then by ID. IDs may repeat, as in history files.
+type sortOrder struct {
+	kind    Kind
+	id      int64
+	started bool
+}
+
+func (s *sortOrder) next(kind Kind, id int64) error {
+	if s.started && (kind < s.kind || kind == s.kind && id < s.id) {
+		return fmt.Errorf("%s %d after %s %d breaks %s order", kind, id, s.kind, s.id, sortedFeature)
+	}
+	*s = sortOrder{kind, id, true}
+	return nil
+}
+
+// Returns kind and ID of decoded object.
+func kindID(o Object) (Kind, int64) {
+	switch o := o.(type) {
+	case *Node:
+		return NodeKind, o.ID
+	case *Way:
+		return WayKind, o.ID
+	case *Relation:
+		return RelationKind, o.ID
+	}
+	return o.Kind(), 0
+}
+
+// Checks size of uncompressed Blob data, which is not checked for raw data when decoding.
+func validateBlob(blob *OSMPBF.Blob, data []byte) error {
+	if blob.RawSize != nil && int(blob.GetRawSize()) != len(data) {
+		return fmt.Errorf("raw blob data size %d but expected %d", len(data), blob.GetRawSize())
+	}
+	return nil
+}
+
+// Validator of PrimitiveBlock, checks invariants of PBF format which are not checked when decoding.
+type blockValidator struct {
+	st          []string
+	granularity int64
+	latOffset   int64
+	lonOffset   int64
+}
+
+// Checks PrimitiveBlock for violations of PBF format specification. Sort order is checked
+// by serializer, across fileblocks and concatenated input streams.
+func validateBlock(pb *OSMPBF.PrimitiveBlock) error {
+	if pb.GetGranularity() <= 0 {
+		return fmt.Errorf("granularity %d is not positive", pb.GetGranularity())
+	}
+	if pb.GetDateGranularity() <= 0 {
+		return fmt.Errorf("date granularity %d is not positive", pb.GetDateGranularity())
+	}
+
+	v := &blockValidator{
+		st:          pb.GetStringtable().GetS(),
+		granularity: int64(pb.GetGranularity()),
+		latOffset:   pb.GetLatOffset(),
+		lonOffset:   pb.GetLonOffset(),
+	}
+	for i, pg := range pb.GetPrimitivegroup() {
+		if err := v.group(pg); err != nil {
+			return fmt.Errorf("PrimitiveGroup %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func (v *blockValidator) group(pg *OSMPBF.PrimitiveGroup) error {
+	var types int
+	for _, n := range []int{len(pg.GetNodes()), len(pg.GetDense().GetId()), len(pg.GetWays()), len(pg.GetRelations())} {
+		if n > 0 {
+			types++
+		}
+	}
+	if types > 1 {
+		return errors.New("objects of different types")
+	}
+
+	for _, node := range pg.GetNodes() {
+		if err := v.node(node); err != nil {
+			return fmt.Errorf("node %d: %w", node.GetId(), err)
+		}
+	}
+	if err := v.denseNodes(pg.GetDense()); err != nil {
+		return err
+	}
+	for _, way := range pg.GetWays() {
+		if err := v.way(way); err != nil {
+			return fmt.Errorf("way %d: %w", way.GetId(), err)
+		}
+	}
+	for _, rel := range pg.GetRelations() {
+		if err := v.relation(rel); err != nil {
+			return fmt.Errorf("relation %d: %w", rel.GetId(), err)
+		}
+	}
+	return nil
+}
+
+func (v *blockValidator) node(node *OSMPBF.Node) error {
+	if err := v.tags(node.GetKeys(), node.GetVals()); err != nil {
+		return err
+	}
+	if err := v.info(node.GetInfo()); err != nil {
+		return err
+	}
+	return v.location(node.GetLat(), node.GetLon())
+}
+
+func (v *blockValidator) denseNodes(dn *OSMPBF.DenseNodes) error {
+	ids := dn.GetId()
+	if len(dn.GetLat()) != len(ids) || len(dn.GetLon()) != len(ids) {
+		return fmt.Errorf("dense nodes have %d IDs, %d lats and %d lons", len(ids), len(dn.GetLat()), len(dn.GetLon()))
+	}
+
+	di := dn.GetDenseinfo()
+	if di != nil {
+		for _, field := range []struct {
+			name string
+			n    int
+		}{
+			{"version", len(di.GetVersion())},
+			{"timestamp", len(di.GetTimestamp())},
+			{"changeset", len(di.GetChangeset())},
+			{"uid", len(di.GetUid())},
+			{"user_sid", len(di.GetUserSid())},
+			{"visible", len(di.GetVisible())},
+		} {
+			if field.n > 0 && field.n != len(ids) {
+				return fmt.Errorf("dense info has %d %s values for %d nodes", field.n, field.name, len(ids))
+			}
+		}
+	}
+
+	keysVals := dn.GetKeysVals()
+	var index int
+	var id, lat, lon int64
+	var userSid int32
+	for i := range ids {
+		// delta encoding
+		id += ids[i]
+		lat += dn.GetLat()[i]
+		lon += dn.GetLon()[i]
+
+		if err := v.location(lat, lon); err != nil {
+			return fmt.Errorf("node %d: %w", id, err)
+		}
+
+		if len(keysVals) > 0 {
+			// keys and values of node are terminated by 0
+			for {
+				if index >= len(keysVals) {
+					return fmt.Errorf("keys_vals are not terminated: node %d", id)
+				}
+				keyID := keysVals[index]
+				index++
+				if keyID == 0 {
+					break
+				}
+				if index >= len(keysVals) {
+					return fmt.Errorf("key without value in keys_vals: node %d", id)
+				}
+				if err := v.sid(int64(keyID)); err != nil {
+					return fmt.Errorf("node %d: %w", id, err)
+				}
+				if err := v.sid(int64(keysVals[index])); err != nil {
+					return fmt.Errorf("node %d: %w", id, err)
+				}
+				index++
+			}
+		}
+
+		if userSids := di.GetUserSid(); len(userSids) > 0 {
+			userSid += userSids[i]
+			if err := v.sid(int64(userSid)); err != nil {
+				return fmt.Errorf("user of node %d: %w", id, err)
+			}
+		}
+	}
+	if index < len(keysVals) {
+		return fmt.Errorf("keys_vals have %d values after last node", len(keysVals)-index)
+	}
+	return nil
+}
+
+func (v *blockValidator) way(way *OSMPBF.Way) error {
+	if err := v.tags(way.GetKeys(), way.GetVals()); err != nil {
+		return err
+	}
+	if err := v.info(way.GetInfo()); err != nil {
+		return err
+	}
+
+	// LocationsOnWays
+	refs, lats, lons := way.GetRefs(), way.GetLat(), way.GetLon()
+	if len(lats) == 0 && len(lons) == 0 {
+		return nil
+	}
+	if len(lats) != len(refs) || len(lons) != len(refs) {
+		return fmt.Errorf("way has %d refs, %d lats and %d lons", len(refs), len(lats), len(lons))
+	}
+	var lat, lon int64
+	for i := range refs {
+		lat += lats[i] // delta encoding
+		lon += lons[i]
+		if err := v.location(lat, lon); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (v *blockValidator) relation(rel *OSMPBF.Relation) error {
+	if err := v.tags(rel.GetKeys(), rel.GetVals()); err != nil {
+		return err
+	}
+	if err := v.info(rel.GetInfo()); err != nil {
+		return err
+	}
+
+	memIDs, types, roleIDs := rel.GetMemids(), rel.GetTypes(), rel.GetRolesSid()
+	if len(types) != len(memIDs) || len(roleIDs) != len(memIDs) {
+		return fmt.Errorf("relation has %d memids, %d types and %d roles_sid", len(memIDs), len(types), len(roleIDs))
+	}
+	for i := range memIDs {
+		if _, ok := OSMPBF.Relation_MemberType_name[int32(types[i])]; !ok {
+			return fmt.Errorf("unknown member type %d", types[i])
+		}
+		if err := v.sid(int64(roleIDs[i])); err != nil {
+			return fmt.Errorf("role of member %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func (v *blockValidator) tags(keyIDs, valueIDs []uint32) error {
+	if len(keyIDs) != len(valueIDs) {
+		return fmt.Errorf("%d keys but %d values", len(keyIDs), len(valueIDs))
+	}
+	for i := range keyIDs {
+		if err := v.sid(int64(keyIDs[i])); err != nil {
+			return err
+		}
+		if err := v.sid(int64(valueIDs[i])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (v *blockValidator) info(info *OSMPBF.Info) error {
+	if info == nil {
+		return nil
+	}
+	if err := v.sid(int64(info.GetUserSid())); err != nil {
+		return fmt.Errorf("user: %w", err)
+	}
+	return nil
+}
+
+// Checks string table index.
+func (v *blockValidator) sid(id int64) error {
+	if id < 0 || id >= int64(len(v.st)) {
+		return fmt.Errorf("string table index %d out of range [0, %d)", id, len(v.st))
+	}
+	return nil
+}
+
+// Checks that location in units of granularity is valid.
+func (v *blockValidator) location(lat, lon int64) error {
+	latitude := 1e-9 * float64(v.latOffset+v.granularity*lat)
+	longitude := 1e-9 * float64(v.lonOffset+v.granularity*lon)
+	if latitude < -90 || latitude > 90 || longitude < -180 || longitude > 180 {
+		return fmt.Errorf("location %g, %g out of range", latitude, longitude)
+	}
+	return nil
+}
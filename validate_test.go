@@ -0,0 +1,117 @@
+package osmpbf
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/brechtbm/osmpbf/OSMPBF"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestValidateBlock(t *testing.T) {
+	st := &OSMPBF.StringTable{S: []string{"", "name", "value"}}
+	for _, c := range []struct {
+		name     string
+		pg       *OSMPBF.PrimitiveGroup
+		expected string // error substring, empty if valid
+	}{
+		{"valid", &OSMPBF.PrimitiveGroup{Dense: &OSMPBF.DenseNodes{
+			Id: []int64{1, 1}, Lat: []int64{0, 1}, Lon: []int64{0, 1}, KeysVals: []int32{1, 2, 0, 0},
+		}}, ""},
+		{"unterminated", &OSMPBF.PrimitiveGroup{Dense: &OSMPBF.DenseNodes{
+			Id: []int64{1, 1}, Lat: []int64{0, 1}, Lon: []int64{0, 1}, KeysVals: []int32{1, 2, 0},
+		}}, "not terminated"},
+		{"string index", &OSMPBF.PrimitiveGroup{Dense: &OSMPBF.DenseNodes{
+			Id: []int64{1}, Lat: []int64{0}, Lon: []int64{0}, KeysVals: []int32{1, 3, 0},
+		}}, "string table index 3"},
+		{"lengths", &OSMPBF.PrimitiveGroup{Dense: &OSMPBF.DenseNodes{
+			Id: []int64{1, 1}, Lat: []int64{0}, Lon: []int64{0, 1},
+		}}, "2 IDs, 1 lats"},
+		{"location", &OSMPBF.PrimitiveGroup{Dense: &OSMPBF.DenseNodes{
+			Id: []int64{1}, Lat: []int64{1e9}, Lon: []int64{0},
+		}}, "out of range"},
+		{"way tags", &OSMPBF.PrimitiveGroup{Ways: []*OSMPBF.Way{
+			{Id: proto.Int64(1), Keys: []uint32{1}},
+		}}, "1 keys but 0 values"},
+		{"members", &OSMPBF.PrimitiveGroup{Relations: []*OSMPBF.Relation{
+			{Id: proto.Int64(1), Memids: []int64{1}, RolesSid: []int32{0}},
+		}}, "1 memids, 0 types"},
+		{"mixed types", &OSMPBF.PrimitiveGroup{
+			Nodes: []*OSMPBF.Node{{Id: proto.Int64(1), Lat: proto.Int64(0), Lon: proto.Int64(0)}},
+			Ways:  []*OSMPBF.Way{{Id: proto.Int64(1)}},
+		}, "different types"},
+	} {
+		pb := &OSMPBF.PrimitiveBlock{Stringtable: st, Primitivegroup: []*OSMPBF.PrimitiveGroup{c.pg}}
+		err := validateBlock(pb)
+		if c.expected == "" && err != nil {
+			t.Errorf("%s: unexpected error %v", c.name, err)
+		}
+		if c.expected != "" && (err == nil || !strings.Contains(err.Error(), c.expected)) {
+			t.Errorf("%s: expected error containing %q, got %v", c.name, c.expected, err)
+		}
+	}
+
+	pb := &OSMPBF.PrimitiveBlock{Stringtable: st, Granularity: proto.Int32(0)}
+	if err := validateBlock(pb); err == nil {
+		t.Error("expected granularity error")
+	}
+}
+
+func TestStrict(t *testing.T) {
+	encode := func(ids ...int64) *bytes.Buffer {
+		var buf bytes.Buffer
+		e := NewEncoder(&buf)
+		e.SetHeader(&Header{OptionalFeatures: []string{sortedFeature}})
+		for _, id := range ids {
+			if err := e.Encode(&Node{ID: id}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := e.Close(); err != nil {
+			t.Fatal(err)
+		}
+		return &buf
+	}
+
+	d := NewDecoder(encodeNodesWays(t, maxBlockEntities+1, 10), WithStrict(true))
+	if objects := decodeAll(t, d); len(objects) != maxBlockEntities+11 {
+		t.Errorf("expected %d objects, got %d", maxBlockEntities+11, len(objects))
+	}
+
+	// unsorted within fileblock and across fileblocks
+	ids := make([]int64, maxBlockEntities+1)
+	for i := range ids {
+		ids[i] = int64(i + 2)
+	}
+	ids[maxBlockEntities] = 1
+	for _, data := range [][]byte{encode(2, 1).Bytes(), encode(ids...).Bytes()} {
+		d := NewDecoder(bytes.NewReader(data), WithStrict(true))
+		if err := d.Start(2); err != nil {
+			t.Fatal(err)
+		}
+		var err error
+		for err == nil {
+			_, err = d.Decode()
+		}
+		var ve *ValidationError
+		if !errors.As(err, &ve) {
+			t.Errorf("expected ValidationError, got %v", err)
+		}
+		d.Close()
+
+		d = NewDecoder(bytes.NewReader(data))
+		if err := d.Start(2); err != nil {
+			t.Fatal(err)
+		}
+		for err = nil; err == nil; {
+			_, err = d.Decode()
+		}
+		if err != io.EOF {
+			t.Errorf("expected io.EOF without strict mode, got %v", err)
+		}
+		d.Close()
+	}
+}